@@ -9,7 +9,9 @@ import (
 	"github.com/igor-kupczynski/alpha-monday/internal/api"
 	"github.com/igor-kupczynski/alpha-monday/internal/config"
 	"github.com/igor-kupczynski/alpha-monday/internal/db"
-	"github.com/jackc/pgx/v5/pgxpool"
+	appworker "github.com/igor-kupczynski/alpha-monday/internal/worker"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"log/slog"
 )
 
@@ -23,18 +25,92 @@ func main() {
 	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: cfg.LogLevel}))
 
 	ctx := context.Background()
-	pool, err := pgxpool.New(ctx, cfg.DatabaseURL)
+	cfg.DBPool.QueryLog.Logger = logger
+	pool, err := db.NewPool(ctx, cfg.DatabaseURL, cfg.DBPool)
 	if err != nil {
 		logger.Error("db pool init failed", "error", err)
 		os.Exit(1)
 	}
 	defer pool.Close()
 
-	store := db.NewStore(pool)
-	handler := api.NewRouter(store, logger, cfg.CORSAllowOrigins)
+	queryMetrics, err := db.NewQueryMetrics(prometheus.DefaultRegisterer)
+	if err != nil {
+		logger.Error("db metrics init failed", "error", err)
+		os.Exit(1)
+	}
+	panicMetrics, err := api.NewPanicMetrics(prometheus.DefaultRegisterer)
+	if err != nil {
+		logger.Error("api metrics init failed", "error", err)
+		os.Exit(1)
+	}
+	store := db.NewStore(pool, db.WithQueryMetrics(queryMetrics), db.WithQueryLog(cfg.DBPool.QueryLog))
+	if err := store.CheckSchema(ctx, cfg.SchemaCheck, logger); err != nil {
+		logger.Error("schema check failed", "error", err)
+		os.Exit(1)
+	}
+	routerOpts := []api.Option{
+		api.WithAdminToken(cfg.AdminToken),
+		api.WithAdminKeys(cfg.AdminKeys),
+		api.WithAdminAllowedCIDRs(cfg.AdminAllowedCIDRs),
+		api.WithTrustedProxies(cfg.TrustedProxyCIDRs),
+		api.WithURLSigningKey(cfg.URLSigningKey),
+		api.WithAPIKeysRequired(cfg.APIKeysRequired),
+		api.WithOpenAPIValidation(cfg.OpenAPIValidate),
+		api.WithSlowRequestThreshold(cfg.SlowRequestThreshold),
+		api.WithRequestLogSampleRate(cfg.RequestLogSampleRate),
+		api.WithPanicMetrics(panicMetrics),
+		api.WithPanicAlerts(cfg.PanicAlerts),
+		api.WithSecurity(cfg.Security),
+		api.WithCompression(cfg.CompressionLevel),
+		api.WithDefaultPrecision(cfg.DefaultPrecision),
+		api.WithCORS(cfg.CORS),
+		api.WithRateLimit(cfg.RateLimit),
+		api.WithLatestCacheTTL(cfg.LatestCacheTTL),
+		api.WithCacheControl(cfg.CacheControl),
+		api.WithSchemaCheck(cfg.SchemaCheck),
+	}
+	if cfg.MetricsEnabled {
+		requestMetrics, err := api.NewRequestMetrics(prometheus.DefaultRegisterer)
+		if err != nil {
+			logger.Error("api metrics init failed", "error", err)
+			os.Exit(1)
+		}
+		if err := db.RegisterPoolMetrics(prometheus.DefaultRegisterer, pool); err != nil {
+			logger.Error("db pool metrics init failed", "error", err)
+			os.Exit(1)
+		}
+		routerOpts = append(routerOpts, api.WithRequestMetrics(requestMetrics))
+		if cfg.MetricsAddr == "" {
+			routerOpts = append(routerOpts, api.WithMetricsHandler(promhttp.Handler()))
+		} else {
+			go serveMetrics(logger, cfg.MetricsAddr, cfg.MaxHeaderBytes)
+		}
+	}
+	if cfg.HatchetClientToken != "" {
+		client, err := appworker.NewHatchetClient(cfg.HatchetClientToken, cfg.HatchetClientHostPort)
+		if err != nil {
+			logger.Error("hatchet client init failed", "error", err)
+			os.Exit(1)
+		}
+		routerOpts = append(routerOpts, api.WithWorkflowRunner(appworker.NewHatchetRunner(client)))
+	}
+	handler := api.NewRouter(store, logger, cfg.CORSAllowOrigins, routerOpts...)
 
 	addr := fmt.Sprintf(":%d", cfg.Port)
-	server := api.NewHTTPServer(addr, handler)
+	server := api.NewHTTPServer(addr, handler, cfg.MaxHeaderBytes)
+
+	if cfg.TLS.Enabled() {
+		redirect := api.ConfigureTLS(server, cfg.TLS)
+		if cfg.TLSHTTPAddr != "" {
+			go serveHTTPRedirect(logger, cfg.TLSHTTPAddr, redirect, cfg.MaxHeaderBytes)
+		}
+		logger.Info("api listening", "addr", addr, "tls", true, "autocert", len(cfg.TLS.AutocertDomains) > 0)
+		if err := server.ListenAndServeTLS(cfg.TLS.CertFile, cfg.TLS.KeyFile); err != nil && err != http.ErrServerClosed {
+			logger.Error("server error", "error", err)
+			os.Exit(1)
+		}
+		return
+	}
 
 	logger.Info("api listening", "addr", addr)
 	if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
@@ -42,3 +118,29 @@ func main() {
 		os.Exit(1)
 	}
 }
+
+// serveHTTPRedirect serves the plain HTTP listener of a TLS deployment,
+// which redirects to HTTPS and answers ACME challenges.
+func serveHTTPRedirect(logger *slog.Logger, addr string, handler http.Handler, maxHeaderBytes int) {
+	server := api.NewHTTPServer(addr, handler, maxHeaderBytes)
+
+	logger.Info("http redirect listening", "addr", addr)
+	if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		logger.Error("http redirect server error", "error", err)
+		os.Exit(1)
+	}
+}
+
+// serveMetrics serves /metrics on its own listener, so it can be bound to an
+// internal port that is not exposed with the API.
+func serveMetrics(logger *slog.Logger, addr string, maxHeaderBytes int) {
+	mux := http.NewServeMux()
+	mux.Handle("GET /metrics", promhttp.Handler())
+	server := api.NewHTTPServer(addr, mux, maxHeaderBytes)
+
+	logger.Info("metrics listening", "addr", addr)
+	if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		logger.Error("metrics server error", "error", err)
+		os.Exit(1)
+	}
+}
@@ -3,21 +3,24 @@ package main
 import (
 	"context"
 	"fmt"
-	"net"
+	"net/http"
 	"os"
 	"os/signal"
-	"strconv"
+	"sync"
 	"syscall"
+	"time"
 
-	hatchetclient "github.com/hatchet-dev/hatchet/pkg/client"
-	hatchet "github.com/hatchet-dev/hatchet/sdks/go"
 	"github.com/igor-kupczynski/alpha-monday/internal/db"
 	"github.com/igor-kupczynski/alpha-monday/internal/integrations/alphavantage"
 	"github.com/igor-kupczynski/alpha-monday/internal/integrations/openai"
+	"github.com/igor-kupczynski/alpha-monday/internal/integrations/vcr"
+	"github.com/igor-kupczynski/alpha-monday/internal/outbox"
 	appworker "github.com/igor-kupczynski/alpha-monday/internal/worker"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	temporalclient "go.temporal.io/sdk/client"
+	temporallog "go.temporal.io/sdk/log"
 	"log/slog"
-
-	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 func main() {
@@ -29,58 +32,101 @@ func main() {
 
 	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: cfg.LogLevel}))
 
-	clientOpts := []hatchetclient.ClientOpt{
-		hatchetclient.WithToken(cfg.HatchetClientToken),
+	cfg.DBPool.QueryLog.Logger = logger
+	pool, err := db.NewPool(context.Background(), cfg.DatabaseURL, cfg.DBPool)
+	if err != nil {
+		logger.Error("db pool init failed", "error", err)
+		os.Exit(1)
 	}
-	if cfg.HatchetClientHostPort != "" {
-		host, portStr, err := net.SplitHostPort(cfg.HatchetClientHostPort)
-		if err != nil {
-			logger.Error("invalid HATCHET_CLIENT_HOST_PORT", "error", err)
+	defer pool.Close()
+
+	queryMetrics, err := db.NewQueryMetrics(prometheus.DefaultRegisterer)
+	if err != nil {
+		logger.Error("db metrics init failed", "error", err)
+		os.Exit(1)
+	}
+	if cfg.MetricsAddr != "" {
+		if err := db.RegisterPoolMetrics(prometheus.DefaultRegisterer, pool); err != nil {
+			logger.Error("db pool metrics init failed", "error", err)
 			os.Exit(1)
 		}
-		port, err := strconv.Atoi(portStr)
+		go serveMetrics(logger, cfg.MetricsAddr)
+	}
+	store := db.NewStore(pool, db.WithQueryMetrics(queryMetrics), db.WithQueryLog(cfg.DBPool.QueryLog))
+	if err := store.CheckSchema(context.Background(), cfg.SchemaCheck, logger); err != nil {
+		logger.Error("schema check failed", "error", err)
+		os.Exit(1)
+	}
+	openAIOpts := []openai.Option{
+		openai.WithModel(cfg.OpenAIModel),
+		openai.WithLanguage(cfg.ReasoningLanguage),
+		openai.WithBlocklist(cfg.TickerBlocklist),
+		openai.WithMinSectors(cfg.MinPickSectors),
+	}
+	var alphaOpts []alphavantage.Option
+	if cfg.VCR.Mode != vcr.ModeReplay {
+		alphaOpts = append(alphaOpts, alphavantage.WithRequestsPerMinute(cfg.RateLimits.PerMinute))
+	}
+	if cfg.VCR.Mode != vcr.ModeOff {
+		transport, err := vcr.NewTransport(cfg.VCR.Mode, cfg.VCR.Dir, http.DefaultTransport)
 		if err != nil {
-			logger.Error("invalid HATCHET_CLIENT_HOST_PORT port", "error", err)
+			logger.Error("vcr transport init failed", "error", err)
 			os.Exit(1)
 		}
-		clientOpts = append(clientOpts, hatchetclient.WithHostPort(host, port))
+		integrationClient := &http.Client{Transport: transport}
+		openAIOpts = append(openAIOpts, openai.WithHTTPClient(integrationClient))
+		alphaOpts = append(alphaOpts, alphavantage.WithHTTPClient(integrationClient))
+		logger.Info("integration vcr enabled", "mode", cfg.VCR.Mode, "dir", cfg.VCR.Dir)
 	}
+	openAIClient := openai.NewClient(cfg.OpenAIAPIKey, openAIOpts...)
+	alphaClient := alphavantage.NewClient(cfg.AlphaVantageAPIKey, alphaOpts...)
+	steps := appworker.NewSteps(store, openAIClient, alphaClient, logger, appworker.WithRecentPickWindow(cfg.RecentPickWeeks),
+		appworker.WithQuoteFanout(cfg.QuoteFanout, cfg.QuoteConcurrency))
 
-	client, err := hatchet.NewClient(clientOpts...)
-	if err != nil {
-		logger.Error("hatchet client init failed", "error", err)
-		os.Exit(1)
-	}
-	if err := appworker.ConfigureRateLimits(client, logger); err != nil {
-		logger.Error("hatchet rate limit configuration failed", "error", err)
-		os.Exit(1)
+	if cfg.Preflight != appworker.PreflightOff {
+		if err := runPreflight(cfg, openAIClient, alphaClient, logger); err != nil && cfg.Preflight == appworker.PreflightRequire {
+			logger.Error("worker preflight failed", "error", err)
+			os.Exit(1)
+		}
 	}
 
-	pool, err := pgxpool.New(context.Background(), cfg.DatabaseURL)
-	if err != nil {
-		logger.Error("db pool init failed", "error", err)
-		os.Exit(1)
+	if cfg.StaleBatchAfter > 0 {
+		sweepCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		if _, err := appworker.SweepStaleBatches(sweepCtx, store, time.Now(), cfg.StaleBatchAfter, logger); err != nil {
+			logger.Error("stale batch sweep failed", "error", err)
+		}
+		cancel()
 	}
-	defer pool.Close()
-
-	store := db.NewStore(pool)
-	openAIClient := openai.NewClient(cfg.OpenAIAPIKey, openai.WithModel(cfg.OpenAIModel))
-	alphaClient := alphavantage.NewClient(cfg.AlphaVantageAPIKey)
-	steps := appworker.NewSteps(store, openAIClient, alphaClient, logger)
 
-	workflows, err := appworker.BuildWorkflows(client, logger, steps)
-	if err != nil {
-		logger.Error("workflow build failed", "error", err)
-		os.Exit(1)
+	var engine appworker.Engine
+	switch cfg.Engine {
+	case appworker.EngineStandalone:
+		engine = appworker.NewStandaloneEngine(store, cfg.RateLimits, cfg.Standalone, logger)
+	case appworker.EngineTemporal:
+		client, err := temporalclient.Dial(temporalclient.Options{
+			HostPort:  cfg.Temporal.HostPort,
+			Namespace: cfg.Temporal.Namespace,
+			Logger:    temporallog.NewStructuredLogger(logger),
+		})
+		if err != nil {
+			logger.Error("temporal client init failed", "error", err)
+			os.Exit(1)
+		}
+		engine = appworker.NewTemporalEngine(client, cfg.Temporal, cfg.RateLimits, logger)
+	default:
+		client, err := appworker.NewHatchetClient(cfg.HatchetClientToken, cfg.HatchetClientHostPort)
+		if err != nil {
+			logger.Error("hatchet client init failed", "error", err)
+			os.Exit(1)
+		}
+		engine = appworker.NewHatchetEngine(client, cfg.WorkerName, cfg.RateLimits, logger)
 	}
-
-	w, err := client.NewWorker(cfg.WorkerName, hatchet.WithWorkflows(workflows...))
-	if err != nil {
-		logger.Error("hatchet worker init failed", "error", err)
+	if err := engine.Register(steps); err != nil {
+		logger.Error("workflow registration failed", "error", err)
 		os.Exit(1)
 	}
 
-	cleanup, err := w.Start()
+	cleanup, err := engine.Start()
 	if err != nil {
 		logger.Error("worker start failed", "error", err)
 		os.Exit(1)
@@ -91,11 +137,73 @@ func main() {
 		}
 	}()
 
-	logger.Info("worker started", "name", cfg.WorkerName)
+	logger.Info("worker started", "name", cfg.WorkerName, "engine", cfg.Engine)
 
 	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 	defer stop()
 
+	dispatcher := outbox.NewDispatcher(store, outboxSinks(cfg.Outbox, store), outbox.Config{
+		PollInterval: cfg.Outbox.PollInterval,
+		MaxAttempts:  cfg.Outbox.MaxAttempts,
+	}, logger)
+	var wg sync.WaitGroup
+	wg.Go(func() { dispatcher.Run(ctx) })
+
 	<-ctx.Done()
+	stop()
 	logger.Info("worker shutdown requested")
+	// Let the dispatcher finish its current pass before the engine and pool
+	// are torn down by the deferred cleanups.
+	wg.Wait()
+	logger.Info("outbox dispatcher stopped")
+}
+
+// serveMetrics serves the default registry at /metrics, which holds the store
+// query, pool and preflight metrics.
+func serveMetrics(logger *slog.Logger, addr string) {
+	mux := http.NewServeMux()
+	mux.Handle("GET /metrics", promhttp.Handler())
+	server := &http.Server{Addr: addr, Handler: mux, ReadHeaderTimeout: 5 * time.Second}
+	logger.Info("metrics listening", "addr", addr)
+	if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		logger.Error("metrics server failed", "error", err)
+		os.Exit(1)
+	}
+}
+
+// runPreflight probes the provider credentials. Recorded or replayed runs skip
+// it, since the probes are not part of the fixtures.
+func runPreflight(cfg appworker.Config, openAIClient *openai.Client, alphaClient *alphavantage.Client, logger *slog.Logger) error {
+	if cfg.VCR.Mode != vcr.ModeOff {
+		logger.Info("provider preflight skipped", "reason", "integration vcr enabled")
+		return nil
+	}
+	metrics, err := appworker.NewPreflightMetrics(prometheus.DefaultRegisterer)
+	if err != nil {
+		return err
+	}
+	probes := []appworker.ProviderProbe{
+		{Name: "openai", Probe: openAIClient.Probe},
+		{Name: "alphavantage", Probe: func(ctx context.Context) error {
+			return alphaClient.Probe(ctx, appworker.PreflightSymbol)
+		}},
+	}
+	return appworker.RunPreflight(context.Background(), probes, metrics, logger)
+}
+
+// outboxSinks returns the configured sinks plus the webhook subscriptions
+// sink, which is always on since subscriptions are registered at runtime.
+func outboxSinks(cfg appworker.OutboxConfig, store *db.Store) []outbox.Sink {
+	httpClient := &http.Client{Timeout: 10 * time.Second}
+	sinks := []outbox.Sink{outbox.NewSubscriptionSink(store, httpClient)}
+	if cfg.SlackWebhookURL != "" {
+		sinks = append(sinks, outbox.NewSlackSink(cfg.SlackWebhookURL, cfg.APIBaseURL, httpClient))
+	}
+	if cfg.WebhookURL != "" {
+		sinks = append(sinks, outbox.NewWebhookSink(cfg.WebhookURL, httpClient))
+	}
+	if cfg.SMTPAddr != "" {
+		sinks = append(sinks, outbox.NewEmailSink(cfg.SMTPAddr, cfg.SMTPUsername, cfg.SMTPPassword, cfg.EmailFrom, cfg.EmailTo, cfg.APIBaseURL))
+	}
+	return sinks
 }
@@ -0,0 +1,94 @@
+package api
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// minAdminKeyLen matches the minimum length of the URL signing key.
+const minAdminKeyLen = 32
+
+// AdminKey is a named credential for the /admin routes, sent as X-API-Key.
+// Unlike the shared admin token, each key identifies its holder, so audit
+// entries record the key name as the actor.
+type AdminKey struct {
+	Name string
+	Key  string
+}
+
+// ParseAdminKeys parses API_ADMIN_KEYS entries of the form name:key. Names
+// must be unique and keys at least 32 characters long.
+func ParseAdminKeys(entries []string) ([]AdminKey, error) {
+	keys := make([]AdminKey, 0, len(entries))
+	seen := make(map[string]bool, len(entries))
+	for _, entry := range entries {
+		name, key, ok := strings.Cut(entry, ":")
+		name = strings.TrimSpace(name)
+		key = strings.TrimSpace(key)
+		if !ok || name == "" {
+			return nil, fmt.Errorf("entry %q must be name:key", name)
+		}
+		if len(key) < minAdminKeyLen {
+			return nil, fmt.Errorf("key %q must be at least %d characters", name, minAdminKeyLen)
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("duplicate key name %q", name)
+		}
+		seen[name] = true
+		keys = append(keys, AdminKey{Name: name, Key: key})
+	}
+	return keys, nil
+}
+
+type adminKeyContextKey struct{}
+
+// requireAdmin guards admin routes. A request is admitted with the static
+// admin bearer token (when set) or with one of the named admin keys in
+// X-API-Key; the matched key's name is kept in the request context for the
+// audit log.
+func requireAdmin(token string, keys []AdminKey) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if provided := strings.TrimSpace(r.Header.Get(apiKeyHeader)); provided != "" {
+				name, ok := matchAdminKey(keys, provided)
+				if !ok {
+					writeError(w, http.StatusUnauthorized, "unauthenticated", "invalid admin api key")
+					return
+				}
+				next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), adminKeyContextKey{}, name)))
+				return
+			}
+
+			provided := bearerToken(r)
+			if token == "" || provided == "" || subtle.ConstantTimeCompare([]byte(provided), []byte(token)) != 1 {
+				writeError(w, http.StatusUnauthorized, "unauthenticated", "missing or invalid admin token")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// matchAdminKey compares provided with every key, so the time taken does not
+// reveal which key (if any) matched.
+func matchAdminKey(keys []AdminKey, provided string) (string, bool) {
+	var name string
+	matched := false
+	for _, key := range keys {
+		if subtle.ConstantTimeCompare([]byte(provided), []byte(key.Key)) == 1 {
+			name = key.Name
+			matched = true
+		}
+	}
+	return name, matched
+}
+
+// adminKeyName returns the name of the admin key that authenticated r, or ""
+// when it used the admin token.
+func adminKeyName(r *http.Request) string {
+	name, _ := r.Context().Value(adminKeyContextKey{}).(string)
+	return name
+}
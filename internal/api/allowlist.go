@@ -0,0 +1,111 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// ParseCIDRs parses CIDR ranges; a bare address is treated as a single-host
+// range.
+func ParseCIDRs(values []string) ([]netip.Prefix, error) {
+	prefixes := make([]netip.Prefix, 0, len(values))
+	for _, value := range values {
+		value = strings.TrimSpace(value)
+		if !strings.Contains(value, "/") {
+			addr, err := netip.ParseAddr(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid address %q", value)
+			}
+			prefixes = append(prefixes, netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()))
+			continue
+		}
+		prefix, err := netip.ParsePrefix(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CIDR %q", value)
+		}
+		prefixes = append(prefixes, prefix.Masked())
+	}
+	return prefixes, nil
+}
+
+// forwardedClient replaces RemoteAddr with the client address a trusted
+// proxy forwarded in True-Client-IP, X-Real-IP or X-Forwarded-For. The
+// headers are only read when the direct peer is in trusted, since any client
+// can set them; X-Forwarded-For is read from the right, skipping the trusted
+// proxies that appended to it. RemoteAddr is left as it is otherwise.
+func forwardedClient(trusted []netip.Prefix) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if peer, ok := clientAddr(r.RemoteAddr); ok && containsAddr(trusted, peer) {
+				if addr, ok := forwardedAddr(r.Header, trusted); ok {
+					r.RemoteAddr = addr.String()
+				}
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func forwardedAddr(header http.Header, trusted []netip.Prefix) (netip.Addr, bool) {
+	for _, name := range []string{"True-Client-IP", "X-Real-IP"} {
+		if value := header.Get(name); value != "" {
+			addr, err := netip.ParseAddr(strings.TrimSpace(value))
+			return addr.Unmap(), err == nil
+		}
+	}
+	var hops []string
+	for _, value := range header.Values("X-Forwarded-For") {
+		hops = append(hops, strings.Split(value, ",")...)
+	}
+	var client netip.Addr
+	for i := len(hops) - 1; i >= 0; i-- {
+		addr, err := netip.ParseAddr(strings.TrimSpace(hops[i]))
+		if err != nil {
+			break
+		}
+		client = addr.Unmap()
+		if !containsAddr(trusted, client) {
+			break
+		}
+	}
+	return client, client.IsValid()
+}
+
+// requireAllowedIP rejects requests whose client address is outside allowed.
+// It reads RemoteAddr, which forwardedClient has already replaced with the
+// forwarded address when the request came through a trusted proxy.
+func requireAllowedIP(allowed []netip.Prefix) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			addr, ok := clientAddr(r.RemoteAddr)
+			if !ok || !containsAddr(allowed, addr) {
+				writeError(w, http.StatusForbidden, "permission_denied", "access from this address is not allowed")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// clientAddr parses RemoteAddr, which is host:port from net/http or a bare
+// address after forwardedClient.
+func clientAddr(remoteAddr string) (netip.Addr, bool) {
+	if addrPort, err := netip.ParseAddrPort(remoteAddr); err == nil {
+		return addrPort.Addr().Unmap(), true
+	}
+	if addr, err := netip.ParseAddr(remoteAddr); err == nil {
+		return addr.Unmap(), true
+	}
+	return netip.Addr{}, false
+}
+
+func containsAddr(prefixes []netip.Prefix, addr netip.Addr) bool {
+	for _, prefix := range prefixes {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
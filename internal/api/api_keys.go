@@ -0,0 +1,145 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+const apiKeyHeader = "X-API-Key"
+
+type createAPIKeyRequest struct {
+	Name         string `json:"name"`
+	DailyQuota   int    `json:"daily_quota"`
+	MonthlyQuota int    `json:"monthly_quota"`
+}
+
+type apiKeyResponse struct {
+	ID           string `json:"id"`
+	Name         string `json:"name"`
+	Key          string `json:"key"`
+	DailyQuota   int    `json:"daily_quota"`
+	MonthlyQuota int    `json:"monthly_quota"`
+}
+
+// requireAPIKeyQuota meters requests carrying an X-API-Key header against the
+// key's daily and monthly quotas, which are tracked in the database. This is
+// separate from burst rate limiting: it caps the total a third party can use.
+// Requests without a key pass through unless required is set.
+func (s *Server) requireAPIKeyQuota(required bool) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := strings.TrimSpace(r.Header.Get(apiKeyHeader))
+			if key == "" {
+				if required {
+					writeError(w, http.StatusUnauthorized, "unauthenticated", "api key required")
+					return
+				}
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			ctx, cancel := context.WithTimeout(r.Context(), 2*time.Second)
+			defer cancel()
+
+			now := time.Now().UTC()
+			usage, err := s.store.ConsumeAPIKeyQuota(ctx, key, now)
+			if err != nil {
+				s.logger.Error("api key quota check failed", "error", err)
+				writeError(w, http.StatusInternalServerError, "internal", "unexpected error")
+				return
+			}
+			if usage == nil {
+				writeError(w, http.StatusUnauthorized, "unauthenticated", "invalid or revoked api key")
+				return
+			}
+
+			header := w.Header()
+			header.Set("X-Quota-Daily-Limit", strconv.Itoa(usage.DailyLimit))
+			header.Set("X-Quota-Daily-Remaining", strconv.Itoa(max(usage.DailyLimit-usage.DailyUsed, 0)))
+			header.Set("X-Quota-Monthly-Limit", strconv.Itoa(usage.MonthlyLimit))
+			header.Set("X-Quota-Monthly-Remaining", strconv.Itoa(max(usage.MonthlyLimit-usage.MonthlyUsed, 0)))
+			if !usage.Allowed {
+				header.Set("Retry-After", strconv.Itoa(quotaRetryAfter(now, usage.MonthlyUsed >= usage.MonthlyLimit)))
+				writeError(w, http.StatusTooManyRequests, "resource_exhausted", "api key quota exceeded")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// quotaRetryAfter returns the seconds until the exhausted quota resets: the
+// next UTC midnight, or the first of next month when the monthly quota is
+// spent.
+func quotaRetryAfter(now time.Time, monthly bool) int {
+	reset := time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, time.UTC)
+	if monthly {
+		reset = time.Date(now.Year(), now.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+	}
+	return int(reset.Sub(now).Round(time.Second).Seconds())
+}
+
+func (s *Server) handleCreateAPIKey(w http.ResponseWriter, r *http.Request) {
+	var req createAPIKeyRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 4<<10)).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_argument", "invalid request body")
+		return
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		writeError(w, http.StatusBadRequest, "invalid_argument", "name is required")
+		return
+	}
+	if req.DailyQuota <= 0 || req.MonthlyQuota <= 0 {
+		writeError(w, http.StatusBadRequest, "invalid_argument", "daily_quota and monthly_quota must be positive")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	created, key, err := s.store.CreateAPIKey(ctx, req.Name, req.DailyQuota, req.MonthlyQuota)
+	if err != nil {
+		s.logger.Error("create api key failed", "error", err)
+		writeError(w, http.StatusInternalServerError, "internal", "unexpected error")
+		return
+	}
+
+	writeJSON(w, r, http.StatusCreated, apiKeyResponse{
+		ID:           created.ID,
+		Name:         created.Name,
+		Key:          key,
+		DailyQuota:   created.DailyQuota,
+		MonthlyQuota: created.MonthlyQuota,
+	})
+}
+
+func (s *Server) handleRevokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	keyID := chi.URLParam(r, "keyID")
+	if _, err := uuid.Parse(keyID); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_argument", "invalid key id")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	revoked, err := s.store.RevokeAPIKey(ctx, keyID)
+	if err != nil {
+		s.logger.Error("revoke api key failed", "error", err)
+		writeError(w, http.StatusInternalServerError, "internal", "unexpected error")
+		return
+	}
+	if !revoked {
+		writeError(w, http.StatusNotFound, "not_found", "api key not found")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,200 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/google/uuid"
+	"github.com/igor-kupczynski/alpha-monday/internal/db"
+)
+
+const (
+	adminActorHeader  = "X-Admin-Actor"
+	defaultAdminActor = "admin-token"
+	maxAdminActorLen  = 100
+	// adminKeyActorPrefix marks actors authenticated by a named admin key
+	// rather than self-declared via X-Admin-Actor.
+	adminKeyActorPrefix = "api-key:"
+)
+
+type auditEntryResponse struct {
+	ID         string          `json:"id"`
+	CreatedAt  string          `json:"created_at"`
+	Actor      string          `json:"actor"`
+	Action     string          `json:"action"`
+	Target     *string         `json:"target"`
+	Params     json.RawMessage `json:"params"`
+	RemoteAddr *string         `json:"remote_addr"`
+	RequestID  *string         `json:"request_id"`
+}
+
+type auditResponse struct {
+	Entries    []auditEntryResponse `json:"entries"`
+	NextCursor *string              `json:"next_cursor"`
+}
+
+// auditParams are the recorded inputs of an admin mutation. Responses are
+// never recorded: they can carry freshly issued secrets.
+type auditParams struct {
+	Path    map[string]string `json:"path,omitempty"`
+	Body    json.RawMessage   `json:"body,omitempty"`
+	Details map[string]any    `json:"details,omitempty"`
+}
+
+type auditDetailsKey struct{}
+
+// setAuditDetail adds context the request alone does not carry, such as the
+// value a mutation replaced, to the request's audit entry.
+func setAuditDetail(r *http.Request, key string, value any) {
+	if details, ok := r.Context().Value(auditDetailsKey{}).(map[string]any); ok {
+		details[key] = value
+	}
+}
+
+// auditAdminMutations records every successful non-GET admin request in the
+// audit log with its actor, route and inputs. The action is the method and
+// route pattern, so new admin endpoints are audited without extra wiring.
+// The actor is the admin key's name, or X-Admin-Actor for the admin token,
+// since the token itself is shared.
+func (s *Server) auditAdminMutations(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet || r.Method == http.MethodHead || r.Method == http.MethodOptions {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		var body []byte
+		if r.Body != nil {
+			var err error
+			body, err = io.ReadAll(r.Body)
+			if err != nil {
+				var tooLarge *http.MaxBytesError
+				if errors.As(err, &tooLarge) {
+					writeError(w, http.StatusRequestEntityTooLarge, "invalid_argument", "request body too large")
+					return
+				}
+				writeError(w, http.StatusBadRequest, "invalid_argument", "invalid request body")
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+		}
+
+		details := map[string]any{}
+		r = r.WithContext(context.WithValue(r.Context(), auditDetailsKey{}, details))
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		next.ServeHTTP(ww, r)
+		if ww.Status() >= http.StatusBadRequest {
+			return
+		}
+
+		params := auditParams{Path: map[string]string{}, Details: details}
+		var target *string
+		if rctx := chi.RouteContext(r.Context()); rctx != nil {
+			for i, key := range rctx.URLParams.Keys {
+				if key == "*" {
+					continue
+				}
+				params.Path[key] = rctx.URLParams.Values[i]
+				if target == nil {
+					value := rctx.URLParams.Values[i]
+					target = &value
+				}
+			}
+		}
+		if json.Valid(body) {
+			params.Body = body
+		}
+		encoded, err := json.Marshal(params)
+		if err != nil {
+			s.logger.Error("encode audit params failed", "error", err)
+			return
+		}
+
+		entry := db.AdminAuditEntry{
+			Actor:      adminActor(r),
+			Action:     r.Method + " " + routePattern(r),
+			Target:     target,
+			Params:     encoded,
+			RemoteAddr: optionalString(r.RemoteAddr),
+			RequestID:  optionalString(middleware.GetReqID(r.Context())),
+		}
+
+		// The mutation has already happened; record it even if the client
+		// went away.
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(r.Context()), 5*time.Second)
+		defer cancel()
+		if err := s.store.RecordAdminAction(ctx, entry); err != nil {
+			s.logger.Error("record admin action failed", "action", entry.Action, "error", err)
+		}
+	})
+}
+
+func adminActor(r *http.Request) string {
+	if name := adminKeyName(r); name != "" {
+		return adminKeyActorPrefix + name
+	}
+	actor := strings.TrimSpace(r.Header.Get(adminActorHeader))
+	if actor == "" {
+		return defaultAdminActor
+	}
+	if len(actor) > maxAdminActorLen {
+		actor = actor[:maxAdminActorLen]
+	}
+	return actor
+}
+
+func optionalString(value string) *string {
+	if value == "" {
+		return nil
+	}
+	return &value
+}
+
+func (s *Server) handleAdminAudit(w http.ResponseWriter, r *http.Request) {
+	limit, err := parseLimit(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_argument", err.Error())
+		return
+	}
+	var cursor *string
+	if value := r.URL.Query().Get("cursor"); value != "" {
+		if _, err := uuid.Parse(value); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid_argument", "cursor must be an audit entry id")
+			return
+		}
+		cursor = &value
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	page, err := s.store.ListAdminAudit(ctx, limit, cursor)
+	if err != nil {
+		s.logger.Error("admin audit query failed", "error", err)
+		writeError(w, http.StatusInternalServerError, "internal", "unexpected error")
+		return
+	}
+
+	entries := make([]auditEntryResponse, 0, len(page.Entries))
+	for _, entry := range page.Entries {
+		entries = append(entries, auditEntryResponse{
+			ID:         entry.ID,
+			CreatedAt:  entry.CreatedAt.UTC().Format(time.RFC3339),
+			Actor:      entry.Actor,
+			Action:     entry.Action,
+			Target:     entry.Target,
+			Params:     entry.Params,
+			RemoteAddr: entry.RemoteAddr,
+			RequestID:  entry.RequestID,
+		})
+	}
+	writeJSON(w, r, http.StatusOK, auditResponse{Entries: entries, NextCursor: page.NextCursor})
+}
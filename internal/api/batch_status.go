@@ -0,0 +1,93 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/igor-kupczynski/alpha-monday/internal/db"
+)
+
+type updateBatchRequest struct {
+	Status string `json:"status"`
+}
+
+// handleUpdateBatch lets an admin force a batch's status, e.g. complete or
+// cancel a stuck batch. Only active batches change status; the previous
+// status is added to the audit entry.
+func (s *Server) handleUpdateBatch(w http.ResponseWriter, r *http.Request) {
+	batchID := chi.URLParam(r, "id")
+	if _, err := uuid.Parse(batchID); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_argument", "invalid batch id")
+		return
+	}
+
+	var req updateBatchRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 4<<10)).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_argument", "invalid request body")
+		return
+	}
+	if !db.ValidBatchStatus(req.Status) {
+		writeError(w, http.StatusBadRequest, "invalid_argument", "status must be one of "+strings.Join(db.BatchStatuses, ", "))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	batch, previous, err := s.store.SetBatchStatus(ctx, batchID, req.Status)
+	if err != nil {
+		if errors.Is(err, db.ErrBatchStatusTransition) {
+			writeError(w, http.StatusConflict, "failed_precondition", "only active batches can change status")
+			return
+		}
+		s.logger.Error("set batch status failed", "error", err)
+		writeError(w, http.StatusInternalServerError, "internal", "unexpected error")
+		return
+	}
+	if batch == nil {
+		writeError(w, http.StatusNotFound, "not_found", "batch not found")
+		return
+	}
+
+	if s.latest != nil {
+		s.latest.invalidate()
+	}
+
+	setAuditDetail(r, "previous_status", previous)
+	writeJSON(w, r, http.StatusOK, toBatchResponse(*batch))
+}
+
+// handleDeleteBatch soft-deletes a batch, e.g. one created by mistake. It
+// disappears from every read endpoint but stays in the database.
+func (s *Server) handleDeleteBatch(w http.ResponseWriter, r *http.Request) {
+	batchID := chi.URLParam(r, "id")
+	if _, err := uuid.Parse(batchID); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_argument", "invalid batch id")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	deleted, err := s.store.DeleteBatch(ctx, batchID)
+	if err != nil {
+		s.logger.Error("delete batch failed", "error", err)
+		writeError(w, http.StatusInternalServerError, "internal", "unexpected error")
+		return
+	}
+	if !deleted {
+		writeError(w, http.StatusNotFound, "not_found", "batch not found")
+		return
+	}
+
+	if s.latest != nil {
+		s.latest.invalidate()
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,53 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/igor-kupczynski/alpha-monday/internal/db"
+)
+
+// CacheConfig sets the Cache-Control max-age of successful read responses.
+// A zero duration sends no-cache, so clients always revalidate with
+// If-Modified-Since.
+type CacheConfig struct {
+	// Latest covers /latest and the listings that change with every new
+	// batch or checkpoint: /batches, /feed.atom, /picks/{ticker} and /summary.
+	Latest time.Duration
+	// ActiveBatch covers the /batches/{id} routes of an active batch, which
+	// gains a checkpoint each trading day.
+	ActiveBatch time.Duration
+	// FinishedBatch covers the /batches/{id} routes of a batch in any other
+	// status; only an admin recompute changes those.
+	FinishedBatch time.Duration
+}
+
+// DefaultCacheConfig returns the max-ages used when none are configured.
+func DefaultCacheConfig() CacheConfig {
+	return CacheConfig{Latest: time.Minute, ActiveBatch: 5 * time.Minute, FinishedBatch: 24 * time.Hour}
+}
+
+// setCacheControl marks a response cacheable for maxAge. Responses are public
+// unless API keys are required, or they come from a share-token route;
+// shared caches must not hand those to other clients.
+func (s *Server) setCacheControl(w http.ResponseWriter, r *http.Request, maxAge time.Duration) {
+	if maxAge <= 0 {
+		w.Header().Set("Cache-Control", "no-cache")
+		return
+	}
+	scope := "public"
+	if s.privateCache || strings.HasPrefix(r.URL.Path, "/shared/") {
+		scope = "private"
+	}
+	w.Header().Set("Cache-Control", scope+", max-age="+strconv.FormatInt(int64(maxAge/time.Second), 10))
+}
+
+// batchMaxAge is the max-age of a batch route given the batch's status.
+func (s *Server) batchMaxAge(status string) time.Duration {
+	if status == db.BatchStatusActive {
+		return s.cache.ActiveBatch
+	}
+	return s.cache.FinishedBatch
+}
@@ -0,0 +1,62 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/go-chi/cors"
+)
+
+// CORSConfig configures the CORS middleware, which NewRouter installs only
+// when allowed origins are given.
+type CORSConfig struct {
+	AllowedMethods []string
+	AllowedHeaders []string
+	ExposedHeaders []string
+	// AllowCredentials lets browsers send cookies and Authorization on
+	// cross-origin requests. It cannot be combined with the "*" origin.
+	AllowCredentials bool
+	// MaxAge is how long browsers may cache a preflight response.
+	MaxAge time.Duration
+}
+
+// DefaultCORSConfig returns the settings used when none are configured: GET
+// and POST (for /graphql), the request headers the API reads, the quota and
+// rate limit response headers, no credentials and a 5 minute preflight cache.
+func DefaultCORSConfig() CORSConfig {
+	return CORSConfig{
+		AllowedMethods: []string{http.MethodGet, http.MethodPost, http.MethodOptions},
+		AllowedHeaders: []string{"Accept", "Authorization", "Content-Type", "X-API-Key", "X-Timezone"},
+		ExposedHeaders: []string{"Retry-After", "X-Quota-Daily-Limit", "X-Quota-Daily-Remaining", "X-Quota-Monthly-Limit", "X-Quota-Monthly-Remaining"},
+		MaxAge:         5 * time.Minute,
+	}
+}
+
+// ParseCORSMethods upper-cases methods and checks the API serves each of them.
+func ParseCORSMethods(values []string) ([]string, error) {
+	methods := make([]string, 0, len(values))
+	for _, value := range values {
+		method := strings.ToUpper(strings.TrimSpace(value))
+		if !isAllowedMethod(method) {
+			return nil, fmt.Errorf("unsupported method %q", value)
+		}
+		if !slices.Contains(methods, method) {
+			methods = append(methods, method)
+		}
+	}
+	return methods, nil
+}
+
+func corsMiddleware(origins []string, config CORSConfig) func(http.Handler) http.Handler {
+	return cors.New(cors.Options{
+		AllowedOrigins:   origins,
+		AllowedMethods:   config.AllowedMethods,
+		AllowedHeaders:   config.AllowedHeaders,
+		ExposedHeaders:   config.ExposedHeaders,
+		AllowCredentials: config.AllowCredentials,
+		MaxAge:           int(config.MaxAge / time.Second),
+	}).Handler
+}
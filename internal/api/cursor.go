@@ -0,0 +1,59 @@
+package api
+
+import (
+	"encoding/base64"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/igor-kupczynski/alpha-monday/internal/db"
+)
+
+var errInvalidBatchCursor = &paramError{"cursor must be a next_cursor from a previous page"}
+
+// legacyCursorID pairs with a bare run_date cursor, as issued before cursors
+// carried the batch id. No id sorts below it, so such a cursor keeps its old
+// meaning: batches with an earlier run_date.
+const legacyCursorID = "00000000-0000-0000-0000-000000000000"
+
+// encodeBatchCursor renders a batch cursor as an opaque URL-safe token.
+func encodeBatchCursor(cursor *db.BatchCursor) *string {
+	if cursor == nil {
+		return nil
+	}
+	encoded := base64.RawURLEncoding.EncodeToString([]byte(cursor.RunDate + "," + cursor.ID))
+	return &encoded
+}
+
+// decodeBatchCursor reverses encodeBatchCursor. A bare YYYY-MM-DD is still
+// accepted so clients holding an old cursor can finish paging.
+func decodeBatchCursor(value string) (*db.BatchCursor, error) {
+	if _, err := time.Parse("2006-01-02", value); err == nil {
+		return &db.BatchCursor{RunDate: value, ID: legacyCursorID}, nil
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, errInvalidBatchCursor
+	}
+	runDate, id, ok := strings.Cut(string(raw), ",")
+	if !ok {
+		return nil, errInvalidBatchCursor
+	}
+	if _, err := time.Parse("2006-01-02", runDate); err != nil {
+		return nil, errInvalidBatchCursor
+	}
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, errInvalidBatchCursor
+	}
+	return &db.BatchCursor{RunDate: runDate, ID: id}, nil
+}
+
+// parseBatchCursor reads the ?cursor= of the batch-ordered list endpoints.
+func parseBatchCursor(r *http.Request) (*db.BatchCursor, error) {
+	value := r.URL.Query().Get("cursor")
+	if value == "" {
+		return nil, nil
+	}
+	return decodeBatchCursor(value)
+}
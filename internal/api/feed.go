@@ -0,0 +1,60 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/igor-kupczynski/alpha-monday/internal/report"
+)
+
+// handleFeed renders the latest batches and their picks as an Atom feed so
+// followers can subscribe in a feed reader.
+func (s *Server) handleFeed(w http.ResponseWriter, r *http.Request) {
+	limit, err := parseLimit(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_argument", err.Error())
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	entries, err := s.store.RecentBatchesWithPicks(ctx, limit)
+	if err != nil {
+		s.logger.Error("feed batches failed", "error", err)
+		writeError(w, http.StatusInternalServerError, "internal", "unexpected error")
+		return
+	}
+	s.setCacheControl(w, r, s.cache.Latest)
+	if len(entries) > 0 {
+		// Entries are immutable once published, so the newest batch dates
+		// the whole feed.
+		newest := entries[0].CreatedAt
+		for _, entry := range entries[1:] {
+			if entry.CreatedAt.After(newest) {
+				newest = entry.CreatedAt
+			}
+		}
+		if checkNotModified(w, r, newest) {
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/atom+xml; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	if err := report.WriteAtom(w, requestBaseURL(r), entries, time.Now()); err != nil {
+		s.logger.Error("write feed failed", "error", err)
+	}
+}
+
+// requestBaseURL is the scheme and host the client used to reach the API,
+// honouring X-Forwarded-Proto from a TLS-terminating proxy.
+func requestBaseURL(r *http.Request) string {
+	scheme := "http"
+	if r.TLS != nil || strings.EqualFold(r.Header.Get("X-Forwarded-Proto"), "https") {
+		scheme = "https"
+	}
+	return scheme + "://" + r.Host
+}
@@ -0,0 +1,5 @@
+// Package graph holds the GraphQL schema served at POST /graphql and the
+// gqlgen-generated executor and models. The resolvers are in internal/api.
+package graph
+
+//go:generate go tool gqlgen generate --config gqlgen.yml
@@ -0,0 +1,120 @@
+// Code generated by github.com/99designs/gqlgen, DO NOT EDIT.
+
+package graph
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// Decimal values are strings with their stored precision, as in the REST API.
+type Batch struct {
+	ID                    string      `json:"id"`
+	RunDate               string      `json:"runDate"`
+	Status                BatchStatus `json:"status"`
+	BenchmarkSymbol       string      `json:"benchmarkSymbol"`
+	BenchmarkInitialPrice string      `json:"benchmarkInitialPrice"`
+}
+
+type BatchPage struct {
+	Nodes []*Batch `json:"nodes"`
+	// Pass as after to fetch the next page; null on the last page.
+	NextCursor *string `json:"nextCursor,omitempty"`
+}
+
+type Checkpoint struct {
+	ID                 string  `json:"id"`
+	CheckpointDate     string  `json:"checkpointDate"`
+	Status             string  `json:"status"`
+	BenchmarkPrice     *string `json:"benchmarkPrice,omitempty"`
+	BenchmarkReturnPct *string `json:"benchmarkReturnPct,omitempty"`
+	// RFC 3339, UTC.
+	CreatedAt string        `json:"createdAt"`
+	Metrics   []*PickMetric `json:"metrics"`
+}
+
+type Pick struct {
+	ID             string  `json:"id"`
+	Ticker         string  `json:"ticker"`
+	Action         string  `json:"action"`
+	Reasoning      string  `json:"reasoning"`
+	InitialPrice   string  `json:"initialPrice"`
+	TargetPrice    *string `json:"targetPrice,omitempty"`
+	TargetErrorPct *string `json:"targetErrorPct,omitempty"`
+}
+
+type PickMetric struct {
+	ID                string   `json:"id"`
+	Pick              *Pick    `json:"pick"`
+	CurrentPrice      string   `json:"currentPrice"`
+	AbsoluteReturnPct string   `json:"absoluteReturnPct"`
+	VsBenchmarkPct    string   `json:"vsBenchmarkPct"`
+	OpenPrice         *string  `json:"openPrice,omitempty"`
+	HighPrice         *string  `json:"highPrice,omitempty"`
+	LowPrice          *string  `json:"lowPrice,omitempty"`
+	Volume            *float64 `json:"volume,omitempty"`
+}
+
+type Query struct {
+}
+
+type BatchStatus string
+
+const (
+	BatchStatusActive    BatchStatus = "active"
+	BatchStatusCompleted BatchStatus = "completed"
+	BatchStatusFailed    BatchStatus = "failed"
+	BatchStatusCancelled BatchStatus = "cancelled"
+)
+
+var AllBatchStatus = []BatchStatus{
+	BatchStatusActive,
+	BatchStatusCompleted,
+	BatchStatusFailed,
+	BatchStatusCancelled,
+}
+
+func (e BatchStatus) IsValid() bool {
+	switch e {
+	case BatchStatusActive, BatchStatusCompleted, BatchStatusFailed, BatchStatusCancelled:
+		return true
+	}
+	return false
+}
+
+func (e BatchStatus) String() string {
+	return string(e)
+}
+
+func (e *BatchStatus) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = BatchStatus(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid BatchStatus", str)
+	}
+	return nil
+}
+
+func (e BatchStatus) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+func (e *BatchStatus) UnmarshalJSON(b []byte) error {
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return err
+	}
+	return e.UnmarshalGQL(s)
+}
+
+func (e BatchStatus) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	e.MarshalGQL(&buf)
+	return buf.Bytes(), nil
+}
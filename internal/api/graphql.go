@@ -0,0 +1,419 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/executor"
+	"github.com/99designs/gqlgen/graphql/handler/extension"
+	"github.com/google/uuid"
+	"github.com/igor-kupczynski/alpha-monday/internal/api/graph"
+	"github.com/igor-kupczynski/alpha-monday/internal/db"
+	"github.com/igor-kupczynski/alpha-monday/internal/decimal"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+const (
+	// graphQLMaxDepth allows batches > nodes > checkpoints > metrics > pick
+	// with room for fragments; deeper queries are rejected before execution.
+	graphQLMaxDepth       = 8
+	graphQLMaxQueryLength = 16 << 10
+)
+
+// errGraphQLInternal is returned to clients in place of store errors and
+// resolver panics, which are logged instead.
+var errGraphQLInternal = errors.New("unexpected error")
+
+type graphQLRequest struct {
+	Query         string         `json:"query"`
+	OperationName string         `json:"operationName"`
+	Variables     map[string]any `json:"variables"`
+}
+
+// newGraphQLExecutor builds the executor for the read-only schema in
+// internal/api/graph. The gqlgen-generated code checks the resolvers below
+// against schema.graphql at compile time.
+func newGraphQLExecutor(store *db.Store, logger *slog.Logger) *executor.Executor {
+	exec := executor.New(graph.NewExecutableSchema(graph.Config{
+		Resolvers: &graphQLResolver{store: store, logger: logger},
+	}))
+	exec.Use(extension.Introspection{})
+	exec.Use(graphQLLimits{maxDepth: graphQLMaxDepth, maxQueryLength: graphQLMaxQueryLength})
+	exec.SetRecoverFunc(func(ctx context.Context, value any) error {
+		logger.Error("graphql panic recovered", "panic", fmt.Sprint(value))
+		return errGraphQLInternal
+	})
+	return exec
+}
+
+// handleGraphQL executes a query against the read-only schema. As usual for
+// GraphQL, query and resolver errors are reported in the body with status 200;
+// only a request that is not a GraphQL request gets a 400.
+func (s *Server) handleGraphQL(w http.ResponseWriter, r *http.Request) {
+	var req graphQLRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 64<<10)).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_argument", "invalid request body")
+		return
+	}
+	if req.Query == "" {
+		writeError(w, http.StatusBadRequest, "invalid_argument", "query is required")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+	ctx = context.WithValue(graphql.StartOperationTrace(ctx), batchDetailsCacheKey{}, &batchDetailsCache{})
+
+	opCtx, errs := s.graphQL.CreateOperationContext(ctx, &graphql.RawParams{
+		Query:         req.Query,
+		OperationName: req.OperationName,
+		Variables:     req.Variables,
+	})
+	if errs != nil {
+		writeJSON(w, r, http.StatusOK, s.graphQL.DispatchError(graphql.WithOperationContext(ctx, opCtx), errs))
+		return
+	}
+	responses, ctx := s.graphQL.DispatchOperation(ctx, opCtx)
+	writeJSON(w, r, http.StatusOK, responses(ctx))
+}
+
+// graphQLLimits rejects oversized and deeply nested queries before they
+// execute.
+type graphQLLimits struct {
+	maxDepth       int
+	maxQueryLength int
+}
+
+func (graphQLLimits) ExtensionName() string {
+	return "Limits"
+}
+
+func (graphQLLimits) Validate(graphql.ExecutableSchema) error {
+	return nil
+}
+
+func (l graphQLLimits) MutateOperationParameters(ctx context.Context, params *graphql.RawParams) *gqlerror.Error {
+	if len(params.Query) > l.maxQueryLength {
+		return gqlerror.Errorf("query length %d exceeds the maximum allowed query length of %d bytes", len(params.Query), l.maxQueryLength)
+	}
+	return nil
+}
+
+func (l graphQLLimits) MutateOperationContext(ctx context.Context, opCtx *graphql.OperationContext) *gqlerror.Error {
+	if depth := selectionDepth(opCtx.Operation.SelectionSet); depth > l.maxDepth {
+		return gqlerror.Errorf("query depth %d exceeds the maximum allowed depth of %d", depth, l.maxDepth)
+	}
+	return nil
+}
+
+// selectionDepth returns how many fields deep set nests, counting fields
+// inside fragments at the level they are spread. Fragment cycles are rejected
+// by validation before this runs.
+func selectionDepth(set ast.SelectionSet) int {
+	depth := 0
+	for _, selection := range set {
+		var d int
+		switch s := selection.(type) {
+		case *ast.Field:
+			d = 1 + selectionDepth(s.SelectionSet)
+		case *ast.InlineFragment:
+			d = selectionDepth(s.SelectionSet)
+		case *ast.FragmentSpread:
+			if s.Definition != nil {
+				d = selectionDepth(s.Definition.SelectionSet)
+			}
+		}
+		depth = max(depth, d)
+	}
+	return depth
+}
+
+type graphQLResolver struct {
+	store  *db.Store
+	logger *slog.Logger
+}
+
+func (r *graphQLResolver) Query() graph.QueryResolver {
+	return queryResolver{r}
+}
+
+func (r *graphQLResolver) Batch() graph.BatchResolver {
+	return batchResolver{r}
+}
+
+func (r *graphQLResolver) internal(msg string, err error) error {
+	r.logger.Error(msg, "error", err)
+	return errGraphQLInternal
+}
+
+type queryResolver struct {
+	*graphQLResolver
+}
+
+func (r queryResolver) LatestBatch(ctx context.Context) (*graph.Batch, error) {
+	latest, err := r.store.LatestBatch(ctx)
+	if err != nil {
+		return nil, r.internal("graphql latest batch failed", err)
+	}
+	if latest == nil {
+		return nil, nil
+	}
+	if entry := cachedBatchFor(ctx, latest.Batch.ID); entry != nil {
+		entry.mu.Lock()
+		entry.picks = latest.Picks
+		entry.mu.Unlock()
+	}
+	return graphQLBatch(latest.Batch), nil
+}
+
+func (r queryResolver) Batch(ctx context.Context, id string) (*graph.Batch, error) {
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, errors.New("invalid batch id")
+	}
+	detail, err := r.store.BatchDetails(ctx, id)
+	if err != nil {
+		return nil, r.internal("graphql batch failed", err)
+	}
+	if detail == nil {
+		return nil, nil
+	}
+	if entry := cachedBatchFor(ctx, id); entry != nil {
+		entry.mu.Lock()
+		entry.detail = detail
+		entry.mu.Unlock()
+	}
+	return graphQLBatch(detail.Batch), nil
+}
+
+func (r queryResolver) Batches(ctx context.Context, first *int, after *string, status *graph.BatchStatus, ticker *string) (*graph.BatchPage, error) {
+	if first == nil || *first < 1 || *first > 100 {
+		return nil, errors.New("first must be between 1 and 100")
+	}
+	var cursor *db.BatchCursor
+	if after != nil {
+		decoded, err := decodeBatchCursor(*after)
+		if err != nil {
+			return nil, err
+		}
+		cursor = decoded
+	}
+	var filter db.BatchFilter
+	if status != nil {
+		filter.Status = string(*status)
+	}
+	if ticker != nil {
+		filter.Ticker = strings.ToUpper(*ticker)
+		if !tickerPattern.MatchString(filter.Ticker) {
+			return nil, errInvalidTicker
+		}
+	}
+
+	page, err := r.store.ListBatches(ctx, *first, cursor, filter)
+	if err != nil {
+		return nil, r.internal("graphql list batches failed", err)
+	}
+	nodes := make([]*graph.Batch, 0, len(page.Batches))
+	for _, batch := range page.Batches {
+		nodes = append(nodes, graphQLBatch(batch))
+	}
+	return &graph.BatchPage{Nodes: nodes, NextCursor: encodeBatchCursor(page.NextCursor)}, nil
+}
+
+// batchResolver resolves a batch's picks and checkpoints from one
+// BatchDetails query, made on first use and shared through the request's
+// batchDetailsCache, so a query selecting only batch fields costs nothing
+// more. A page of batches selecting nested data costs one query per batch.
+type batchResolver struct {
+	*graphQLResolver
+}
+
+func (r batchResolver) details(ctx context.Context, batchID string) (*db.BatchDetails, error) {
+	entry := cachedBatchFor(ctx, batchID)
+	if entry == nil {
+		entry = &cachedBatch{}
+	}
+	entry.mu.Lock()
+	defer entry.mu.Unlock()
+	if entry.detail != nil {
+		return entry.detail, nil
+	}
+	detail, err := r.store.BatchDetails(ctx, batchID)
+	if err != nil {
+		return nil, r.internal("graphql batch details failed", err)
+	}
+	if detail == nil {
+		// Deleted between the parent query and this one.
+		detail = &db.BatchDetails{}
+	}
+	entry.detail = detail
+	return detail, nil
+}
+
+func (r batchResolver) Picks(ctx context.Context, obj *graph.Batch) ([]*graph.Pick, error) {
+	var picks []db.Pick
+	if entry := cachedBatchFor(ctx, obj.ID); entry != nil {
+		entry.mu.Lock()
+		picks = entry.picks
+		entry.mu.Unlock()
+	}
+	if picks == nil {
+		detail, err := r.details(ctx, obj.ID)
+		if err != nil {
+			return nil, err
+		}
+		picks = detail.Picks
+	}
+	nodes := make([]*graph.Pick, 0, len(picks))
+	for _, pick := range picks {
+		nodes = append(nodes, graphQLPick(pick))
+	}
+	return nodes, nil
+}
+
+func (r batchResolver) Checkpoints(ctx context.Context, obj *graph.Batch) ([]*graph.Checkpoint, error) {
+	detail, err := r.details(ctx, obj.ID)
+	if err != nil {
+		return nil, err
+	}
+	nodes := make([]*graph.Checkpoint, 0, len(detail.Checkpoints))
+	for _, checkpoint := range detail.Checkpoints {
+		nodes = append(nodes, graphQLCheckpoint(checkpoint, detail.Picks))
+	}
+	return nodes, nil
+}
+
+func (r batchResolver) Checkpoint(ctx context.Context, obj *graph.Batch, date string) (*graph.Checkpoint, error) {
+	if _, err := time.Parse("2006-01-02", date); err != nil {
+		return nil, errInvalidCheckpointDate
+	}
+	detail, err := r.details(ctx, obj.ID)
+	if err != nil {
+		return nil, err
+	}
+	i := slices.IndexFunc(detail.Checkpoints, func(checkpoint db.Checkpoint) bool {
+		return checkpoint.CheckpointDate == date
+	})
+	if i < 0 {
+		return nil, nil
+	}
+	return graphQLCheckpoint(detail.Checkpoints[i], detail.Picks), nil
+}
+
+type batchDetailsCacheKey struct{}
+
+// batchDetailsCache holds the batches loaded by one GraphQL request. gqlgen
+// resolves sibling fields concurrently, so each entry has its own lock.
+type batchDetailsCache struct {
+	mu      sync.Mutex
+	batches map[string]*cachedBatch
+}
+
+type cachedBatch struct {
+	mu sync.Mutex
+	// picks is set up front when the parent query already loaded them.
+	picks  []db.Pick
+	detail *db.BatchDetails
+}
+
+// cachedBatchFor returns the request's cache entry for batchID, or nil outside
+// handleGraphQL.
+func cachedBatchFor(ctx context.Context, batchID string) *cachedBatch {
+	cache, ok := ctx.Value(batchDetailsCacheKey{}).(*batchDetailsCache)
+	if !ok {
+		return nil
+	}
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	if cache.batches == nil {
+		cache.batches = map[string]*cachedBatch{}
+	}
+	entry, ok := cache.batches[batchID]
+	if !ok {
+		entry = &cachedBatch{}
+		cache.batches[batchID] = entry
+	}
+	return entry
+}
+
+func graphQLBatch(batch db.Batch) *graph.Batch {
+	return &graph.Batch{
+		ID:                    batch.ID,
+		RunDate:               batch.RunDate,
+		Status:                graph.BatchStatus(batch.Status),
+		BenchmarkSymbol:       batch.BenchmarkSymbol,
+		BenchmarkInitialPrice: batch.BenchmarkInitialPrice.String(),
+	}
+}
+
+func graphQLPick(pick db.Pick) *graph.Pick {
+	return &graph.Pick{
+		ID:             pick.ID,
+		Ticker:         pick.Ticker,
+		Action:         pick.Action,
+		Reasoning:      pick.Reasoning,
+		InitialPrice:   pick.InitialPrice.String(),
+		TargetPrice:    decimalString(pick.TargetPrice),
+		TargetErrorPct: decimalString(pick.TargetErrorPct),
+	}
+}
+
+// graphQLCheckpoint converts checkpoint with its metrics, resolving each
+// metric's pick from picks, the batch's picks.
+func graphQLCheckpoint(checkpoint db.Checkpoint, picks []db.Pick) *graph.Checkpoint {
+	metrics := make([]*graph.PickMetric, 0, len(checkpoint.Metrics))
+	for _, metric := range checkpoint.Metrics {
+		i := slices.IndexFunc(picks, func(pick db.Pick) bool { return pick.ID == metric.PickID })
+		if i < 0 {
+			// Metrics reference picks of the same batch; this cannot happen
+			// with the foreign keys in place.
+			continue
+		}
+		metrics = append(metrics, graphQLPickMetric(metric, picks[i]))
+	}
+	return &graph.Checkpoint{
+		ID:                 checkpoint.ID,
+		CheckpointDate:     checkpoint.CheckpointDate,
+		Status:             checkpoint.Status,
+		BenchmarkPrice:     decimalString(checkpoint.BenchmarkPrice),
+		BenchmarkReturnPct: decimalString(checkpoint.BenchmarkReturnPct),
+		CreatedAt:          checkpoint.CreatedAt.UTC().Format(time.RFC3339),
+		Metrics:            metrics,
+	}
+}
+
+func graphQLPickMetric(metric db.PickMetric, pick db.Pick) *graph.PickMetric {
+	node := &graph.PickMetric{
+		ID:                metric.ID,
+		Pick:              graphQLPick(pick),
+		CurrentPrice:      metric.CurrentPrice.String(),
+		AbsoluteReturnPct: metric.AbsoluteReturnPct.String(),
+		VsBenchmarkPct:    metric.VsBenchmarkPct.String(),
+		OpenPrice:         decimalString(metric.OpenPrice),
+		HighPrice:         decimalString(metric.HighPrice),
+		LowPrice:          decimalString(metric.LowPrice),
+	}
+	// Volume is a Float because GraphQL's Int is 32-bit.
+	if metric.Volume != nil {
+		volume := float64(*metric.Volume)
+		node.Volume = &volume
+	}
+	return node
+}
+
+func decimalString(value *decimal.Decimal) *string {
+	if value == nil {
+		return nil
+	}
+	s := value.String()
+	return &s
+}
@@ -3,14 +3,42 @@ package api
 import (
 	"encoding/json"
 	"net/http"
+	"time"
 )
 
-func writeJSON(w http.ResponseWriter, status int, payload any) {
+// writeJSON encodes payload with its decimals in the request's number format
+// (?numbers=, ?precision=).
+func writeJSON(w http.ResponseWriter, r *http.Request, status int, payload any) {
+	encodeJSON(w, status, formatNumbers(payload, numberFormatFrom(r.Context())))
+}
+
+func encodeJSON(w http.ResponseWriter, status int, payload any) {
 	w.Header().Set("Content-Type", "application/json; charset=utf-8")
 	w.WriteHeader(status)
 	_ = json.NewEncoder(w).Encode(payload)
 }
 
 func writeError(w http.ResponseWriter, status int, code, message string) {
-	writeJSON(w, status, errorResponse{Error: apiError{Code: code, Message: message}})
+	encodeJSON(w, status, errorResponse{Error: apiError{Code: code, Message: message}})
+}
+
+// checkNotModified sets Last-Modified and reports whether the request's
+// If-Modified-Since is at or after it, in which case a 304 has been written.
+func checkNotModified(w http.ResponseWriter, r *http.Request, lastModified time.Time) bool {
+	lastModified = lastModified.UTC().Truncate(time.Second)
+	w.Header().Set("Last-Modified", lastModified.Format(http.TimeFormat))
+
+	value := r.Header.Get("If-Modified-Since")
+	if value == "" {
+		return false
+	}
+	since, err := http.ParseTime(value)
+	if err != nil {
+		return false
+	}
+	if lastModified.After(since) {
+		return false
+	}
+	w.WriteHeader(http.StatusNotModified)
+	return true
 }
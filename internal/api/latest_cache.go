@@ -0,0 +1,53 @@
+package api
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/igor-kupczynski/alpha-monday/internal/db"
+)
+
+// latestCache keeps the last LatestBatch result for ttl. /latest is polled
+// constantly but only changes when the worker creates a batch or computes a
+// checkpoint, at most a few times a day. The worker writes from its own
+// process, so the TTL bounds how stale /latest can be; writes made through
+// this API (admin status changes) invalidate it right away.
+type latestCache struct {
+	store *db.Store
+	ttl   time.Duration
+	now   func() time.Time
+
+	// mu is held across the query, so concurrent misses share one query.
+	mu      sync.Mutex
+	value   *db.LatestBatchResult
+	expires time.Time
+}
+
+func newLatestCache(store *db.Store, ttl time.Duration) *latestCache {
+	return &latestCache{store: store, ttl: ttl, now: time.Now}
+}
+
+// get returns the cached result, querying the store once it has expired.
+// Errors are not cached. A nil result (no batches yet) is.
+func (c *latestCache) get(ctx context.Context) (*db.LatestBatchResult, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.now().Before(c.expires) {
+		return c.value, nil
+	}
+	value, err := c.store.LatestBatch(ctx)
+	if err != nil {
+		return nil, err
+	}
+	c.value = value
+	c.expires = c.now().Add(c.ttl)
+	return value, nil
+}
+
+func (c *latestCache) invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.value = nil
+	c.expires = time.Time{}
+}
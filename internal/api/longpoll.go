@@ -0,0 +1,111 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/igor-kupczynski/alpha-monday/internal/db"
+)
+
+// maxLatestWait bounds ?wait= on /latest, keeping long polls under common
+// proxy idle timeouts.
+const maxLatestWait = 60 * time.Second
+
+// latestPollInterval is how often a long poll re-reads the latest batch. The
+// worker writes from its own process, so changes are found by polling; with
+// the latest cache enabled the polls are served from it.
+var latestPollInterval = time.Second
+
+var errInvalidWait = &paramError{fmt.Sprintf("wait must be a duration between 1s and %s, e.g. 30s", maxLatestWait)}
+
+// parseLatestWait reads ?wait=, how long /latest may block for new data. It
+// returns 0 when the parameter is absent.
+func parseLatestWait(r *http.Request) (time.Duration, error) {
+	value := r.URL.Query().Get("wait")
+	if value == "" {
+		return 0, nil
+	}
+	wait, err := time.ParseDuration(value)
+	if err != nil || wait < time.Second || wait > maxLatestWait {
+		return 0, errInvalidWait
+	}
+	return wait, nil
+}
+
+// requestTimeout is middleware.Timeout, extended by the wait of a /latest
+// long poll so it can block past timeout. The server's write deadline is
+// pushed out to match.
+func requestTimeout(timeout time.Duration) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		regular := middleware.Timeout(timeout)(next)
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			wait, err := parseLatestWait(r)
+			if err != nil || wait == 0 || r.Method != http.MethodGet || r.URL.Path != "/latest" {
+				regular.ServeHTTP(w, r)
+				return
+			}
+			_ = http.NewResponseController(w).SetWriteDeadline(time.Now().Add(writeTimeout + wait))
+			middleware.Timeout(timeout+wait)(next).ServeHTTP(w, r)
+		})
+	}
+}
+
+// latestETag identifies the /latest data: the batch, its status and its
+// newest write. It is weak, as the bytes also depend on tz, numbers and
+// compression.
+func latestETag(latest *db.LatestBatchResult) string {
+	if latest == nil {
+		return `W/"empty"`
+	}
+	h := fnv.New64a()
+	fmt.Fprintf(h, "%s|%s|%d", latest.Batch.ID, latest.Batch.Status, latest.LastModified.UnixNano())
+	return fmt.Sprintf(`W/"%016x"`, h.Sum64())
+}
+
+// etagMatches reports whether an If-None-Match header lists etag, comparing
+// weakly.
+func etagMatches(header, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
+
+// waitForLatest re-reads the latest batch every latestPollInterval until its
+// ETag differs from latest's or wait elapses, and returns the last result.
+func (s *Server) waitForLatest(ctx context.Context, latest *db.LatestBatchResult, wait time.Duration) (*db.LatestBatchResult, error) {
+	etag := latestETag(latest)
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	ticker := time.NewTicker(latestPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-timer.C:
+			return latest, nil
+		case <-ticker.C:
+		}
+
+		queryCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		next, err := s.latestBatch(queryCtx)
+		cancel()
+		if err != nil {
+			return nil, err
+		}
+		latest = next
+		if latestETag(latest) != etag {
+			return latest, nil
+		}
+	}
+}
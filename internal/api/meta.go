@@ -0,0 +1,88 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// APIVersion is info.version of openapi.yaml; TestOpenAPISpec keeps them
+// equal.
+const APIVersion = "1"
+
+const metaParam = "meta"
+
+type responseMeta struct {
+	GeneratedAt time.Time `json:"generated_at"`
+	APIVersion  string    `json:"api_version"`
+	// DataAsOf is when the newest checkpoint was written; null before the
+	// first one.
+	DataAsOf *time.Time `json:"data_as_of"`
+}
+
+// responseMetadata adds a "meta" object to successful JSON object responses
+// when the request carries ?meta=true, so dashboards and caches can show how
+// fresh the data is. The handler's body is otherwise passed through as is.
+func (s *Server) responseMetadata(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		enabled := false
+		if value := r.URL.Query().Get(metaParam); value != "" {
+			parsed, err := strconv.ParseBool(value)
+			if err != nil {
+				writeError(w, http.StatusBadRequest, "invalid_argument", "meta must be true or false")
+				return
+			}
+			enabled = parsed
+		}
+		if !enabled {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		buf := &bufferedResponseWriter{header: w.Header(), status: http.StatusOK}
+		next.ServeHTTP(buf, r)
+
+		body := buf.body.Bytes()
+		trimmed := bytes.TrimSpace(body)
+		if buf.status == http.StatusOK && strings.HasPrefix(w.Header().Get("Content-Type"), "application/json") && len(trimmed) >= 2 && trimmed[0] == '{' {
+			if meta, err := json.Marshal(s.responseMeta(r.Context())); err == nil {
+				var out bytes.Buffer
+				out.WriteString(`{"meta":`)
+				out.Write(meta)
+				if rest := bytes.TrimSpace(trimmed[1:]); rest[0] != '}' {
+					out.WriteByte(',')
+				}
+				out.Write(trimmed[1:])
+				out.WriteByte('\n')
+				body = out.Bytes()
+			}
+		}
+		w.Header().Del("Content-Length")
+		w.WriteHeader(buf.status)
+		_, _ = w.Write(body)
+	})
+}
+
+// responseMeta leaves data_as_of null when the store cannot be reached; the
+// response it decorates has already been served.
+func (s *Server) responseMeta(ctx context.Context) responseMeta {
+	meta := responseMeta{GeneratedAt: time.Now().UTC(), APIVersion: APIVersion}
+
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	dataAsOf, err := s.store.LatestCheckpointAt(ctx)
+	if err != nil {
+		s.logger.Error("latest checkpoint time failed", "error", err)
+		return meta
+	}
+	if dataAsOf != nil {
+		utc := dataAsOf.UTC()
+		meta.DataAsOf = &utc
+	}
+	return meta
+}
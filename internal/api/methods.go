@@ -0,0 +1,126 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// routeMethodCandidates are the methods probed when listing what a path
+// serves, in the order they appear in Allow headers.
+var routeMethodCandidates = []string{http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPatch, http.MethodDelete}
+
+// routeMethods returns the methods the router serves for the request's path,
+// with HEAD wherever GET is served and OPTIONS always last. It returns nil for
+// paths no route matches.
+func routeMethods(r *http.Request) []string {
+	rctx := chi.RouteContext(r.Context())
+	if rctx == nil || rctx.Routes == nil {
+		return nil
+	}
+	path := routePath(r)
+	var methods []string
+	for _, method := range routeMethodCandidates {
+		served := rctx.Routes.Match(chi.NewRouteContext(), method, path)
+		if method == http.MethodHead {
+			served = served || len(methods) > 0 && methods[0] == http.MethodGet
+		}
+		if served {
+			methods = append(methods, method)
+		}
+	}
+	if len(methods) == 0 {
+		return nil
+	}
+	return append(methods, http.MethodOptions)
+}
+
+// routePath is the path chi routes on.
+func routePath(r *http.Request) string {
+	if r.URL.RawPath != "" {
+		return r.URL.RawPath
+	}
+	return r.URL.Path
+}
+
+// answerOptions replies to OPTIONS on any known path with 204 and an Allow
+// header. CORS preflights are answered by the cors middleware when it is
+// configured, before they get here.
+func answerOptions(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodOptions {
+			next.ServeHTTP(w, r)
+			return
+		}
+		methods := routeMethods(r)
+		if methods == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+		w.Header().Set("Allow", strings.Join(methods, ", "))
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+// headAsGet serves HEAD on routes without a HEAD handler of their own by
+// running the GET handler and discarding the body. The body is counted rather
+// than sent, so Content-Length matches what GET would return, compressed or
+// not.
+func headAsGet(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodHead {
+			next.ServeHTTP(w, r)
+			return
+		}
+		rctx := chi.RouteContext(r.Context())
+		if rctx.Routes.Match(chi.NewRouteContext(), http.MethodHead, routePath(r)) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		rctx.RouteMethod = http.MethodGet
+		hw := &headResponseWriter{w: w}
+		next.ServeHTTP(hw, r)
+
+		status := hw.status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		if bodyAllowed(status) && w.Header().Get("Content-Length") == "" {
+			w.Header().Set("Content-Length", strconv.FormatInt(hw.size, 10))
+		}
+		w.WriteHeader(status)
+	})
+}
+
+// headResponseWriter holds back the status until the handler is done and
+// counts, but drops, the body.
+type headResponseWriter struct {
+	w      http.ResponseWriter
+	status int
+	size   int64
+}
+
+func (h *headResponseWriter) Header() http.Header {
+	return h.w.Header()
+}
+
+func (h *headResponseWriter) WriteHeader(status int) {
+	if h.status == 0 {
+		h.status = status
+	}
+}
+
+func (h *headResponseWriter) Write(p []byte) (int, error) {
+	if h.status == 0 {
+		h.status = http.StatusOK
+	}
+	h.size += int64(len(p))
+	return len(p), nil
+}
+
+func bodyAllowed(status int) bool {
+	return status >= http.StatusOK && status != http.StatusNoContent && status != http.StatusNotModified
+}
@@ -0,0 +1,64 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// RequestMetrics counts API requests and their latency by route pattern.
+type RequestMetrics struct {
+	requests *prometheus.CounterVec
+	duration *prometheus.HistogramVec
+}
+
+// NewRequestMetrics creates the request collectors and registers them with
+// registerer.
+func NewRequestMetrics(registerer prometheus.Registerer) (*RequestMetrics, error) {
+	metrics := &RequestMetrics{
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "alpha_monday",
+			Subsystem: "api",
+			Name:      "requests_total",
+			Help:      "API requests by route pattern, method and status code.",
+		}, []string{"route", "method", "status"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: "alpha_monday",
+			Subsystem: "api",
+			Name:      "request_duration_seconds",
+			Help:      "API request latency in seconds by route pattern and method.",
+			Buckets:   []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10},
+		}, []string{"route", "method"}),
+	}
+	if registerer != nil {
+		if err := registerer.Register(metrics.requests); err != nil {
+			return nil, err
+		}
+		if err := registerer.Register(metrics.duration); err != nil {
+			return nil, err
+		}
+	}
+	return metrics, nil
+}
+
+// middleware records every request once routing and the handler are done, so
+// the route label is the matched pattern rather than the raw path.
+func (m *RequestMetrics) middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+
+		next.ServeHTTP(ww, r)
+
+		status := ww.Status()
+		if status == 0 {
+			status = http.StatusOK
+		}
+		route := routePattern(r)
+		m.requests.WithLabelValues(route, r.Method, strconv.Itoa(status)).Inc()
+		m.duration.WithLabelValues(route, r.Method).Observe(time.Since(start).Seconds())
+	})
+}
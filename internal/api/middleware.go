@@ -1,6 +1,8 @@
 package api
 
 import (
+	"bytes"
+	"math/rand/v2"
 	"net/http"
 	"time"
 
@@ -8,7 +10,15 @@ import (
 	"log/slog"
 )
 
-func requestLogger(logger *slog.Logger) func(http.Handler) http.Handler {
+// requestLogConfig controls request logging. Requests that fail (4xx/5xx) or
+// take at least slowThreshold are always logged; other requests are logged
+// with probability sampleRate.
+type requestLogConfig struct {
+	slowThreshold time.Duration
+	sampleRate    float64
+}
+
+func requestLogger(logger *slog.Logger, config requestLogConfig) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			start := time.Now()
@@ -16,13 +26,61 @@ func requestLogger(logger *slog.Logger) func(http.Handler) http.Handler {
 
 			next.ServeHTTP(ww, r)
 
-			logger.Info("request",
+			duration := time.Since(start)
+			slow := config.slowThreshold > 0 && duration >= config.slowThreshold
+			failed := ww.Status() >= http.StatusBadRequest
+			if !slow && !failed && !sampled(config.sampleRate) {
+				return
+			}
+
+			attrs := []any{
 				"method", r.Method,
 				"path", r.URL.Path,
 				"status", ww.Status(),
+				"bytes_in", max(r.ContentLength, 0),
 				"bytes", ww.BytesWritten(),
-				"duration_ms", time.Since(start).Milliseconds(),
+				"duration_ms", duration.Milliseconds(),
+			}
+			if !slow {
+				if !failed && config.sampleRate < 1 {
+					attrs = append(attrs, "sample_rate", config.sampleRate)
+				}
+				logger.Info("request", attrs...)
+				return
+			}
+			attrs = append(attrs,
+				"query", r.URL.RawQuery,
+				"request_id", middleware.GetReqID(r.Context()),
+				"remote_addr", r.RemoteAddr,
+				"user_agent", r.UserAgent(),
+				"slow_threshold_ms", config.slowThreshold.Milliseconds(),
 			)
+			logger.Warn("slow request", attrs...)
 		})
 	}
 }
+
+func sampled(rate float64) bool {
+	if rate >= 1 {
+		return true
+	}
+	return rate > 0 && rand.Float64() < rate
+}
+
+type bufferedResponseWriter struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (b *bufferedResponseWriter) Header() http.Header {
+	return b.header
+}
+
+func (b *bufferedResponseWriter) WriteHeader(status int) {
+	b.status = status
+}
+
+func (b *bufferedResponseWriter) Write(p []byte) (int, error) {
+	return b.body.Write(p)
+}
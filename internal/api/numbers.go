@@ -0,0 +1,174 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"strconv"
+
+	"github.com/igor-kupczynski/alpha-monday/internal/decimal"
+)
+
+const (
+	numbersParam  = "numbers"
+	numbersString = "string"
+	numbersJSON   = "json"
+
+	precisionParam = "precision"
+	// MaxPrecision is the largest ?precision=; stored values carry at most 8
+	// decimals.
+	MaxPrecision = 8
+)
+
+// numberFormat is how writeJSON renders the decimals of a response: rounded
+// to precision decimals unless it is negative, and as JSON numbers when
+// asNumbers is set.
+type numberFormat struct {
+	precision int
+	asNumbers bool
+}
+
+// storedNumbers keeps every decimal as stored, as a JSON string.
+var storedNumbers = numberFormat{precision: -1}
+
+type numberFormatKey struct{}
+
+// numberFormatting reads the request's number format: ?precision= decimals
+// (defaultPrecision when absent; negative keeps the stored precision), and
+// JSON numbers with ?numbers=json, for clients that cannot parse string
+// numerics. Invalid values are rejected with 400.
+func numberFormatting(defaultPrecision int) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			query := r.URL.Query()
+			format := numberFormat{precision: defaultPrecision}
+			switch query.Get(numbersParam) {
+			case "", numbersString:
+			case numbersJSON:
+				format.asNumbers = true
+			default:
+				writeError(w, http.StatusBadRequest, "invalid_argument", "numbers must be string or json")
+				return
+			}
+			if raw := query.Get(precisionParam); raw != "" {
+				precision, err := strconv.Atoi(raw)
+				if err != nil || precision < 0 || precision > MaxPrecision {
+					writeError(w, http.StatusBadRequest, "invalid_argument", "precision must be between 0 and 8")
+					return
+				}
+				format.precision = precision
+			}
+			if format != storedNumbers {
+				r = r.WithContext(context.WithValue(r.Context(), numberFormatKey{}, format))
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// numberFormatFrom returns the number format numberFormatting stored on ctx.
+func numberFormatFrom(ctx context.Context) numberFormat {
+	if format, ok := ctx.Value(numberFormatKey{}).(numberFormat); ok {
+		return format
+	}
+	return storedNumbers
+}
+
+// apply renders d in the format. Values already at or below the precision
+// keep their scale.
+func (f numberFormat) apply(d decimal.Decimal) decimal.Decimal {
+	d = d.RoundMax(int32(f.precision))
+	if f.asNumbers {
+		d = d.AsJSONNumber()
+	}
+	return d
+}
+
+var (
+	decimalType   = reflect.TypeFor[decimal.Decimal]()
+	marshalerType = reflect.TypeFor[json.Marshaler]()
+)
+
+// formatNumbers returns a copy of payload with every decimal.Decimal in it
+// rendered in format. Response values share decimals (through pointers and
+// slices) with store results and the /latest cache, so they are copied
+// rather than changed in place.
+func formatNumbers(payload any, format numberFormat) any {
+	if payload == nil || format == storedNumbers {
+		return payload
+	}
+	value := reflect.ValueOf(payload)
+	formatted := reflect.New(value.Type()).Elem()
+	formatted.Set(value)
+	formatValue(formatted, format)
+	return formatted.Interface()
+}
+
+// formatValue rewrites the decimals reachable from the settable value v,
+// replacing pointers, slices and maps on the way with formatted copies.
+func formatValue(v reflect.Value, format numberFormat) {
+	if v.Type() == decimalType {
+		if v.CanSet() {
+			v.Set(reflect.ValueOf(format.apply(v.Interface().(decimal.Decimal))))
+		}
+		return
+	}
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+	default:
+		// Other types with their own encoding (time.Time, json.RawMessage)
+		// are left as they are.
+		if v.Type().Implements(marshalerType) {
+			return
+		}
+	}
+
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() || !v.CanSet() {
+			return
+		}
+		elem := reflect.New(v.Elem().Type()).Elem()
+		elem.Set(v.Elem())
+		formatValue(elem, format)
+		if v.Kind() == reflect.Pointer {
+			v.Set(elem.Addr())
+		} else {
+			v.Set(elem)
+		}
+	case reflect.Slice:
+		if v.IsNil() || !v.CanSet() {
+			return
+		}
+		items := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		reflect.Copy(items, v)
+		for i := range items.Len() {
+			formatValue(items.Index(i), format)
+		}
+		v.Set(items)
+	case reflect.Array:
+		for i := range v.Len() {
+			formatValue(v.Index(i), format)
+		}
+	case reflect.Map:
+		if v.IsNil() || !v.CanSet() {
+			return
+		}
+		entries := reflect.MakeMapWithSize(v.Type(), v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			entry := reflect.New(v.Type().Elem()).Elem()
+			entry.Set(iter.Value())
+			formatValue(entry, format)
+			entries.SetMapIndex(iter.Key(), entry)
+		}
+		v.Set(entries)
+	case reflect.Struct:
+		// Exported fields of embedded unexported structs stay settable, so
+		// promoted fields are formatted like the struct's own.
+		for i := range v.NumField() {
+			formatValue(v.Field(i), format)
+		}
+	}
+}
@@ -0,0 +1,270 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/igor-kupczynski/alpha-monday/internal/db"
+	"github.com/igor-kupczynski/alpha-monday/internal/decimal"
+	"github.com/igor-kupczynski/alpha-monday/internal/report"
+)
+
+// tickerPattern matches the tickers the model is allowed to pick.
+var tickerPattern = regexp.MustCompile(`^[A-Z]{1,5}$`)
+
+var errInvalidTicker = &paramError{"ticker must be 1 to 5 letters"}
+
+// defaultSparklinePoints is how many recent returns a sparkline has unless
+// ?points= asks for another count.
+const defaultSparklinePoints = 20
+
+var errInvalidSparklinePoints = &paramError{"points must be between 1 and 100"}
+
+// maxSearchQueryLength bounds ?q= on /search.
+const maxSearchQueryLength = 200
+
+var errInvalidSearchQuery = &paramError{"q is required and must be at most 200 characters"}
+
+type pickHistoryEntryResponse struct {
+	BatchID         string       `json:"batch_id"`
+	RunDate         string       `json:"run_date"`
+	BatchStatus     string       `json:"batch_status"`
+	BenchmarkSymbol string       `json:"benchmark_symbol"`
+	Pick            pickResponse `json:"pick"`
+
+	LatestCheckpointDate *string             `json:"latest_checkpoint_date"`
+	LatestMetric         *pickMetricResponse `json:"latest_metric"`
+	// BeatBenchmark is null until the pick has a metric.
+	BeatBenchmark *bool `json:"beat_benchmark"`
+}
+
+type pickHistoryResponse struct {
+	Ticker string                     `json:"ticker"`
+	Picks  []pickHistoryEntryResponse `json:"picks"`
+}
+
+type pickSearchResultResponse struct {
+	BatchID         string       `json:"batch_id"`
+	RunDate         string       `json:"run_date"`
+	BatchStatus     string       `json:"batch_status"`
+	BenchmarkSymbol string       `json:"benchmark_symbol"`
+	Pick            pickResponse `json:"pick"`
+}
+
+type pickSearchResponse struct {
+	Query   string                     `json:"query"`
+	Results []pickSearchResultResponse `json:"results"`
+}
+
+type pickCheckpointMetricResponse struct {
+	CheckpointDate string `json:"checkpoint_date"`
+	pickMetricResponse
+}
+
+type dayChangeResponse struct {
+	Date      string          `json:"date"`
+	ChangePct decimal.Decimal `json:"change_pct"`
+}
+
+type pickStatsResponse struct {
+	MaxDrawdownPct *decimal.Decimal   `json:"max_drawdown_pct"`
+	BestDay        *dayChangeResponse `json:"best_day"`
+}
+
+type pickSparklineResponse struct {
+	PickID  string            `json:"pick_id"`
+	BatchID string            `json:"batch_id"`
+	Ticker  string            `json:"ticker"`
+	Returns []decimal.Decimal `json:"returns"`
+}
+
+type pickDetailResponse struct {
+	Batch   batchResponse                  `json:"batch"`
+	Pick    pickResponse                   `json:"pick"`
+	Metrics []pickCheckpointMetricResponse `json:"metrics"`
+	Stats   pickStatsResponse              `json:"stats"`
+}
+
+// handlePickDetail returns one pick of a batch with its metric at every
+// checkpoint and summary statistics of its price path.
+func (s *Server) handlePickDetail(w http.ResponseWriter, r *http.Request) {
+	batchID := chi.URLParam(r, "id")
+	if _, err := uuid.Parse(batchID); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_argument", "invalid batch id")
+		return
+	}
+	pickID := chi.URLParam(r, "pickID")
+	if _, err := uuid.Parse(pickID); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_argument", "invalid pick id")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	detail, err := s.store.PickDetail(ctx, batchID, pickID)
+	if err != nil {
+		s.logger.Error("pick detail failed", "error", err)
+		writeError(w, http.StatusInternalServerError, "internal", "unexpected error")
+		return
+	}
+	if detail == nil {
+		writeError(w, http.StatusNotFound, "not_found", "pick not found")
+		return
+	}
+
+	metrics := make([]pickCheckpointMetricResponse, 0, len(detail.Metrics))
+	for _, entry := range detail.Metrics {
+		metrics = append(metrics, pickCheckpointMetricResponse{
+			CheckpointDate:     entry.CheckpointDate,
+			pickMetricResponse: toMetricResponses([]db.PickMetric{entry.Metric})[0],
+		})
+	}
+	stats := report.BuildPickStats(detail.Pick.InitialPrice, detail.Metrics)
+	resp := pickDetailResponse{
+		Batch:   toBatchResponse(detail.Batch),
+		Pick:    toPickResponses([]db.Pick{detail.Pick})[0],
+		Metrics: metrics,
+		Stats:   pickStatsResponse{MaxDrawdownPct: stats.MaxDrawdownPct},
+	}
+	if stats.BestDay != nil {
+		resp.Stats.BestDay = &dayChangeResponse{Date: stats.BestDay.Date, ChangePct: stats.BestDay.ChangePct}
+	}
+
+	s.setCacheControl(w, r, s.batchMaxAge(detail.Batch.Status))
+	writeJSON(w, r, http.StatusOK, resp)
+}
+
+// handlePickSparkline returns a pick's recent absolute returns as a bare
+// array, oldest first, for list views that draw an inline chart per pick.
+func (s *Server) handlePickSparkline(w http.ResponseWriter, r *http.Request) {
+	pickID := chi.URLParam(r, "pickID")
+	if _, err := uuid.Parse(pickID); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_argument", "invalid pick id")
+		return
+	}
+	points := defaultSparklinePoints
+	if raw := r.URL.Query().Get("points"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 1 || parsed > 100 {
+			writeError(w, http.StatusBadRequest, "invalid_argument", errInvalidSparklinePoints.Error())
+			return
+		}
+		points = parsed
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	sparkline, err := s.store.PickSparkline(ctx, pickID, points)
+	if err != nil {
+		s.logger.Error("pick sparkline failed", "error", err)
+		writeError(w, http.StatusInternalServerError, "internal", "unexpected error")
+		return
+	}
+	if sparkline == nil {
+		writeError(w, http.StatusNotFound, "not_found", "pick not found")
+		return
+	}
+
+	s.setCacheControl(w, r, s.batchMaxAge(sparkline.BatchStatus))
+	writeJSON(w, r, http.StatusOK, pickSparklineResponse{
+		PickID:  sparkline.PickID,
+		BatchID: sparkline.BatchID,
+		Ticker:  sparkline.Ticker,
+		Returns: sparkline.Returns,
+	})
+}
+
+// handlePickHistory lists every pick of a ticker across batches, newest batch
+// first, with the pick's latest metric.
+func (s *Server) handlePickHistory(w http.ResponseWriter, r *http.Request) {
+	ticker := strings.ToUpper(chi.URLParam(r, "ticker"))
+	if !tickerPattern.MatchString(ticker) {
+		writeError(w, http.StatusBadRequest, "invalid_argument", errInvalidTicker.Error())
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	entries, err := s.store.PickHistory(ctx, ticker)
+	if err != nil {
+		s.logger.Error("pick history failed", "error", err)
+		writeError(w, http.StatusInternalServerError, "internal", "unexpected error")
+		return
+	}
+
+	s.setCacheControl(w, r, s.cache.Latest)
+	writeJSON(w, r, http.StatusOK, pickHistoryResponse{
+		Ticker: ticker,
+		Picks:  toPickHistoryResponses(entries),
+	})
+}
+
+// handleSearch full-text searches pick tickers and reasoning, best matches
+// first.
+func (s *Server) handleSearch(w http.ResponseWriter, r *http.Request) {
+	query := strings.TrimSpace(r.URL.Query().Get("q"))
+	if query == "" || len(query) > maxSearchQueryLength {
+		writeError(w, http.StatusBadRequest, "invalid_argument", errInvalidSearchQuery.Error())
+		return
+	}
+	limit, err := parseLimit(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_argument", err.Error())
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	results, err := s.store.SearchPicks(ctx, query, limit)
+	if err != nil {
+		s.logger.Error("search picks failed", "error", err)
+		writeError(w, http.StatusInternalServerError, "internal", "unexpected error")
+		return
+	}
+
+	resp := pickSearchResponse{Query: query, Results: make([]pickSearchResultResponse, 0, len(results))}
+	for _, result := range results {
+		resp.Results = append(resp.Results, pickSearchResultResponse{
+			BatchID:         result.Batch.ID,
+			RunDate:         result.Batch.RunDate,
+			BatchStatus:     result.Batch.Status,
+			BenchmarkSymbol: result.Batch.BenchmarkSymbol,
+			Pick:            toPickResponses([]db.Pick{result.Pick})[0],
+		})
+	}
+
+	s.setCacheControl(w, r, s.cache.Latest)
+	writeJSON(w, r, http.StatusOK, resp)
+}
+
+func toPickHistoryResponses(entries []db.PickHistoryEntry) []pickHistoryEntryResponse {
+	result := make([]pickHistoryEntryResponse, 0, len(entries))
+	for _, entry := range entries {
+		item := pickHistoryEntryResponse{
+			BatchID:              entry.Batch.ID,
+			RunDate:              entry.Batch.RunDate,
+			BatchStatus:          entry.Batch.Status,
+			BenchmarkSymbol:      entry.Batch.BenchmarkSymbol,
+			Pick:                 toPickResponses([]db.Pick{entry.Pick})[0],
+			LatestCheckpointDate: entry.LatestCheckpointDate,
+		}
+		if entry.LatestMetric != nil {
+			metric := toMetricResponses([]db.PickMetric{*entry.LatestMetric})[0]
+			beat := entry.LatestMetric.VsBenchmarkPct.Sign() > 0
+			item.LatestMetric = &metric
+			item.BeatBenchmark = &beat
+		}
+		result = append(result, item)
+	}
+	return result
+}
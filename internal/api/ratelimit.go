@@ -0,0 +1,87 @@
+package api
+
+import (
+	"math"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// rateLimitIdle is how long a client's bucket is kept after its last request.
+// A bucket idle that long has refilled, so dropping it loses nothing.
+const rateLimitIdle = 10 * time.Minute
+
+// RateLimitConfig is the per-client token bucket: RPS requests per second on
+// average with bursts of up to Burst. A zero RPS disables rate limiting.
+type RateLimitConfig struct {
+	RPS   float64
+	Burst int
+}
+
+type clientBucket struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+// ipRateLimiter keeps a token bucket per client address. IPv6 clients are
+// grouped by /64, since a single host usually holds a whole /64.
+type ipRateLimiter struct {
+	config    RateLimitConfig
+	now       func() time.Time
+	mu        sync.Mutex
+	buckets   map[netip.Addr]*clientBucket
+	lastPrune time.Time
+}
+
+func newIPRateLimiter(config RateLimitConfig) *ipRateLimiter {
+	return &ipRateLimiter{config: config, now: time.Now, buckets: map[netip.Addr]*clientBucket{}}
+}
+
+// allow takes a token from addr's bucket and reports whether one was left.
+func (l *ipRateLimiter) allow(addr netip.Addr) bool {
+	if addr.Is6() {
+		addr = netip.PrefixFrom(addr, 64).Masked().Addr()
+	}
+	now := l.now()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if now.Sub(l.lastPrune) >= time.Minute {
+		for key, bucket := range l.buckets {
+			if now.Sub(bucket.lastSeen) >= rateLimitIdle {
+				delete(l.buckets, key)
+			}
+		}
+		l.lastPrune = now
+	}
+	bucket, ok := l.buckets[addr]
+	if !ok {
+		bucket = &clientBucket{limiter: rate.NewLimiter(rate.Limit(l.config.RPS), l.config.Burst)}
+		l.buckets[addr] = bucket
+	}
+	bucket.lastSeen = now
+	return bucket.limiter.AllowN(now, 1)
+}
+
+// rateLimit rejects clients that exceed their token bucket with 429. The
+// client address is RemoteAddr as resolved by forwardedClient, so behind a
+// proxy that is not trusted, or does not forward the address, every client
+// shares the proxy's bucket. Requests without a parseable address are not
+// limited.
+func rateLimit(limiter *ipRateLimiter) func(http.Handler) http.Handler {
+	retryAfter := strconv.Itoa(int(math.Max(1, math.Ceil(1/limiter.config.RPS))))
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if addr, ok := clientAddr(r.RemoteAddr); ok && !limiter.allow(addr) {
+				w.Header().Set("Retry-After", retryAfter)
+				writeError(w, http.StatusTooManyRequests, "resource_exhausted", "rate limit exceeded")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
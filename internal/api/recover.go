@@ -0,0 +1,140 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/igor-kupczynski/alpha-monday/internal/db"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// panicAlertInterval limits alerts to one per route in this window, so a
+	// crashing endpoint under load does not flood the outbox.
+	panicAlertInterval = time.Minute
+	maxPanicStackBytes = 8 << 10
+)
+
+// PanicMetrics counts handler panics recovered by the API.
+type PanicMetrics struct {
+	panics *prometheus.CounterVec
+}
+
+// NewPanicMetrics creates the panic counter and registers it with registerer.
+func NewPanicMetrics(registerer prometheus.Registerer) (*PanicMetrics, error) {
+	metrics := &PanicMetrics{
+		panics: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "alpha_monday",
+			Subsystem: "api",
+			Name:      "panics_total",
+			Help:      "Handler panics recovered by the API, by route pattern.",
+		}, []string{"route"}),
+	}
+	if registerer != nil {
+		if err := registerer.Register(metrics.panics); err != nil {
+			return nil, err
+		}
+	}
+	return metrics, nil
+}
+
+func (m *PanicMetrics) inc(route string) {
+	if m == nil {
+		return
+	}
+	m.panics.WithLabelValues(route).Inc()
+}
+
+// panicAlerter enqueues api_panic outbox events, throttled per route.
+type panicAlerter struct {
+	store *db.Store
+
+	mu   sync.Mutex
+	last map[string]time.Time
+}
+
+func (a *panicAlerter) allow(route string, now time.Time) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if last, ok := a.last[route]; ok && now.Sub(last) < panicAlertInterval {
+		return false
+	}
+	a.last[route] = now
+	return true
+}
+
+// recoverer replaces chi's Recoverer: it logs the panic and stack through
+// slog, counts it, optionally alerts through the outbox and responds with the
+// standard error body carrying the request ID.
+func (s *Server) recoverer(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			rec := recover()
+			if rec == nil {
+				return
+			}
+			if rec == http.ErrAbortHandler {
+				// Deliberate abort of the response; let net/http handle it.
+				panic(rec)
+			}
+
+			requestID := middleware.GetReqID(r.Context())
+			route := routePattern(r)
+			stack := debug.Stack()
+			s.logger.Error("panic recovered",
+				"method", r.Method,
+				"path", r.URL.Path,
+				"route", route,
+				"request_id", requestID,
+				"panic", fmt.Sprint(rec),
+				"stack", string(stack),
+			)
+			s.panicMetrics.inc(route)
+			if s.panicAlerts != nil && s.panicAlerts.allow(route, time.Now()) {
+				go s.alertPanic(db.APIPanicPayload{
+					RequestID: requestID,
+					Method:    r.Method,
+					Route:     route,
+					Error:     fmt.Sprint(rec),
+					Stack:     string(stack[:min(len(stack), maxPanicStackBytes)]),
+				})
+			}
+
+			if r.Header.Get("Connection") != "Upgrade" {
+				encodeJSON(w, http.StatusInternalServerError, errorResponse{Error: apiError{
+					Code:      "internal",
+					Message:   "unexpected error",
+					RequestID: requestID,
+				}})
+			}
+		}()
+		next.ServeHTTP(w, r)
+	})
+}
+
+// alertPanic runs detached from the request, whose context may already be
+// done.
+func (s *Server) alertPanic(payload db.APIPanicPayload) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := s.panicAlerts.store.RecordAPIPanic(ctx, payload); err != nil {
+		s.logger.Error("panic alert failed", "error", err)
+	}
+}
+
+// routePattern returns the matched chi route pattern, which keeps the metric
+// label bounded, or "unmatched" when routing has not matched a route.
+func routePattern(r *http.Request) string {
+	if rctx := chi.RouteContext(r.Context()); rctx != nil {
+		if pattern := rctx.RoutePattern(); pattern != "" {
+			return pattern
+		}
+	}
+	return "unmatched"
+}
@@ -1,43 +1,79 @@
 package api
 
-import "github.com/igor-kupczynski/alpha-monday/internal/db"
+import (
+	"time"
 
-type healthResponse struct {
-	Ok   bool `json:"ok"`
-	DBOk bool `json:"db_ok"`
+	"github.com/igor-kupczynski/alpha-monday/internal/db"
+	"github.com/igor-kupczynski/alpha-monday/internal/decimal"
+	"github.com/igor-kupczynski/alpha-monday/internal/report"
+)
+
+// displayPrecision is the number of decimals used by the *_display fields.
+const displayPrecision = 2
+
+// tradingTimezone is the zone checkpoint_date is expressed in: checkpoints are
+// keyed by the US trading day, not by the caller's local date.
+const tradingTimezone = "America/New_York"
+
+type livenessResponse struct {
+	Ok bool `json:"ok"`
+}
+
+type readinessResponse struct {
+	Ok                    bool `json:"ok"`
+	DBOk                  bool `json:"db_ok"`
+	SchemaOk              bool `json:"schema_ok"`
+	SchemaVersion         *int `json:"schema_version"`
+	ExpectedSchemaVersion int  `json:"expected_schema_version"`
 }
 
 type batchResponse struct {
-	ID                    string `json:"id"`
-	RunDate               string `json:"run_date"`
-	Status                string `json:"status"`
-	BenchmarkSymbol       string `json:"benchmark_symbol"`
-	BenchmarkInitialPrice string `json:"benchmark_initial_price"`
+	ID                    string          `json:"id"`
+	RunDate               string          `json:"run_date"`
+	Status                string          `json:"status"`
+	BenchmarkSymbol       string          `json:"benchmark_symbol"`
+	BenchmarkInitialPrice decimal.Decimal `json:"benchmark_initial_price"`
+	ConfigHash            *string         `json:"config_hash"`
 }
 
 type pickResponse struct {
-	ID           string `json:"id"`
-	Ticker       string `json:"ticker"`
-	Action       string `json:"action"`
-	Reasoning    string `json:"reasoning"`
-	InitialPrice string `json:"initial_price"`
+	ID             string           `json:"id"`
+	Ticker         string           `json:"ticker"`
+	Action         string           `json:"action"`
+	Reasoning      string           `json:"reasoning"`
+	InitialPrice   decimal.Decimal  `json:"initial_price"`
+	TargetPrice    *decimal.Decimal `json:"target_price"`
+	TargetErrorPct *decimal.Decimal `json:"target_error_pct"`
 }
 
 type pickMetricResponse struct {
-	ID                string `json:"id"`
-	PickID            string `json:"pick_id"`
-	CurrentPrice      string `json:"current_price"`
-	AbsoluteReturnPct string `json:"absolute_return_pct"`
-	VsBenchmarkPct    string `json:"vs_benchmark_pct"`
+	ID                string          `json:"id"`
+	PickID            string          `json:"pick_id"`
+	CurrentPrice      decimal.Decimal `json:"current_price"`
+	AbsoluteReturnPct decimal.Decimal `json:"absolute_return_pct"`
+	VsBenchmarkPct    decimal.Decimal `json:"vs_benchmark_pct"`
+
+	OpenPrice *decimal.Decimal `json:"open_price"`
+	HighPrice *decimal.Decimal `json:"high_price"`
+	LowPrice  *decimal.Decimal `json:"low_price"`
+	Volume    *int64           `json:"volume"`
+
+	AbsoluteReturnPctDisplay string `json:"absolute_return_pct_display"`
+	VsBenchmarkPctDisplay    string `json:"vs_benchmark_pct_display"`
 }
 
 type checkpointResponse struct {
 	ID                 string               `json:"id"`
 	CheckpointDate     string               `json:"checkpoint_date"`
 	Status             string               `json:"status"`
-	BenchmarkPrice     *string              `json:"benchmark_price"`
-	BenchmarkReturnPct *string              `json:"benchmark_return_pct"`
-	Metrics            []pickMetricResponse `json:"metrics"`
+	BenchmarkPrice     *decimal.Decimal     `json:"benchmark_price"`
+	BenchmarkReturnPct *decimal.Decimal     `json:"benchmark_return_pct"`
+	Metrics            []pickMetricResponse `json:"metrics,omitzero"`
+
+	BenchmarkReturnPctDisplay *string `json:"benchmark_return_pct_display"`
+
+	TradingTimezone string `json:"trading_timezone"`
+	CreatedAt       string `json:"created_at"`
 }
 
 type latestResponse struct {
@@ -47,14 +83,80 @@ type latestResponse struct {
 }
 
 type batchesResponse struct {
-	Batches    []batchResponse `json:"batches"`
-	NextCursor *string         `json:"next_cursor"`
+	Batches    []batchListItemResponse `json:"batches"`
+	NextCursor *string                 `json:"next_cursor"`
+	TotalCount *int                    `json:"total_count,omitempty"`
+}
+
+type batchListItemResponse struct {
+	batchResponse
+	// Performance is only set with ?include=performance.
+	Performance *batchPerformanceResponse `json:"performance,omitempty"`
+}
+
+type batchPerformanceResponse struct {
+	LatestCheckpointDate  *string          `json:"latest_checkpoint_date"`
+	AverageVsBenchmarkPct *decimal.Decimal `json:"average_vs_benchmark_pct"`
 }
 
 type batchDetailResponse struct {
 	Batch       batchResponse        `json:"batch"`
 	Picks       []pickResponse       `json:"picks"`
+	Checkpoints []checkpointResponse `json:"checkpoints,omitzero"`
+	// CheckpointsNextCursor is only set when checkpoint_limit cut the
+	// checkpoints short.
+	CheckpointsNextCursor *string `json:"checkpoints_next_cursor,omitempty"`
+}
+
+type checkpointsResponse struct {
 	Checkpoints []checkpointResponse `json:"checkpoints"`
+	NextCursor  *string              `json:"next_cursor"`
+	TotalCount  *int                 `json:"total_count,omitempty"`
+}
+
+type checkpointDetailResponse struct {
+	Checkpoint *checkpointResponse `json:"checkpoint"`
+}
+
+type seriesResponse struct {
+	BatchID         string               `json:"batch_id"`
+	Dates           []string             `json:"dates"`
+	BenchmarkReturn []*decimal.Decimal   `json:"benchmark_return"`
+	PortfolioReturn []*decimal.Decimal   `json:"portfolio_return"`
+	Picks           []pickSeriesResponse `json:"picks"`
+}
+
+type pickSeriesResponse struct {
+	PickID  string             `json:"pick_id"`
+	Ticker  string             `json:"ticker"`
+	Action  string             `json:"action"`
+	Returns []*decimal.Decimal `json:"returns"`
+}
+
+type timeseriesResponse struct {
+	BatchID   string                   `json:"batch_id"`
+	Benchmark timeseriesLineResponse   `json:"benchmark"`
+	Picks     []timeseriesLineResponse `json:"picks"`
+}
+
+type timeseriesLineResponse struct {
+	PickID string                    `json:"pick_id,omitempty"`
+	Ticker string                    `json:"ticker"`
+	Action string                    `json:"action,omitempty"`
+	Points []timeseriesPointResponse `json:"points"`
+}
+
+type timeseriesPointResponse struct {
+	Date      string          `json:"date"`
+	Price     decimal.Decimal `json:"price"`
+	ReturnPct decimal.Decimal `json:"return_pct"`
+}
+
+type benchmarkSeriesResponse struct {
+	BatchID      string                    `json:"batch_id"`
+	Ticker       string                    `json:"ticker"`
+	InitialPrice decimal.Decimal           `json:"initial_price"`
+	Points       []timeseriesPointResponse `json:"points"`
 }
 
 type errorResponse struct {
@@ -62,8 +164,9 @@ type errorResponse struct {
 }
 
 type apiError struct {
-	Code    string `json:"code"`
-	Message string `json:"message"`
+	Code      string `json:"code"`
+	Message   string `json:"message"`
+	RequestID string `json:"request_id,omitempty"`
 }
 
 func toBatchResponse(batch db.Batch) batchResponse {
@@ -73,6 +176,7 @@ func toBatchResponse(batch db.Batch) batchResponse {
 		Status:                batch.Status,
 		BenchmarkSymbol:       batch.BenchmarkSymbol,
 		BenchmarkInitialPrice: batch.BenchmarkInitialPrice,
+		ConfigHash:            batch.ConfigHash,
 	}
 }
 
@@ -81,13 +185,20 @@ func toBatchResponsePtr(batch db.Batch) *batchResponse {
 	return &resp
 }
 
-func toBatchResponses(batches []db.Batch) []batchResponse {
-	if len(batches) == 0 {
-		return []batchResponse{}
-	}
-	result := make([]batchResponse, 0, len(batches))
-	for _, batch := range batches {
-		result = append(result, toBatchResponse(batch))
+// toBatchListResponses converts a page's batches, with their performance
+// when the page carries it.
+func toBatchListResponses(page db.BatchesPage) []batchListItemResponse {
+	result := make([]batchListItemResponse, 0, len(page.Batches))
+	for i, batch := range page.Batches {
+		item := batchListItemResponse{batchResponse: toBatchResponse(batch)}
+		if page.Performance != nil {
+			perf := page.Performance[i]
+			item.Performance = &batchPerformanceResponse{
+				LatestCheckpointDate:  perf.LatestCheckpointDate,
+				AverageVsBenchmarkPct: perf.AverageVsBenchmarkPct,
+			}
+		}
+		result = append(result, item)
 	}
 	return result
 }
@@ -99,45 +210,48 @@ func toPickResponses(picks []db.Pick) []pickResponse {
 	result := make([]pickResponse, 0, len(picks))
 	for _, pick := range picks {
 		result = append(result, pickResponse{
-			ID:           pick.ID,
-			Ticker:       pick.Ticker,
-			Action:       pick.Action,
-			Reasoning:    pick.Reasoning,
-			InitialPrice: pick.InitialPrice,
+			ID:             pick.ID,
+			Ticker:         pick.Ticker,
+			Action:         pick.Action,
+			Reasoning:      pick.Reasoning,
+			InitialPrice:   pick.InitialPrice,
+			TargetPrice:    pick.TargetPrice,
+			TargetErrorPct: pick.TargetErrorPct,
 		})
 	}
 	return result
 }
 
-func toCheckpointResponse(checkpoint *db.Checkpoint) *checkpointResponse {
+func toCheckpointResponse(checkpoint *db.Checkpoint, loc *time.Location) *checkpointResponse {
 	if checkpoint == nil {
 		return nil
 	}
-	resp := checkpointResponse{
-		ID:                 checkpoint.ID,
-		CheckpointDate:     checkpoint.CheckpointDate,
-		Status:             checkpoint.Status,
-		BenchmarkPrice:     checkpoint.BenchmarkPrice,
-		BenchmarkReturnPct: checkpoint.BenchmarkReturnPct,
-		Metrics:            toMetricResponses(checkpoint.Metrics),
-	}
+	resp := toCheckpointResponseValue(*checkpoint, loc)
 	return &resp
 }
 
-func toCheckpointResponses(checkpoints []db.Checkpoint) []checkpointResponse {
+// toCheckpointResponseValue renders created_at in loc with an explicit offset.
+func toCheckpointResponseValue(checkpoint db.Checkpoint, loc *time.Location) checkpointResponse {
+	return checkpointResponse{
+		ID:                        checkpoint.ID,
+		CheckpointDate:            checkpoint.CheckpointDate,
+		Status:                    checkpoint.Status,
+		BenchmarkPrice:            checkpoint.BenchmarkPrice,
+		BenchmarkReturnPct:        checkpoint.BenchmarkReturnPct,
+		Metrics:                   toMetricResponses(checkpoint.Metrics),
+		BenchmarkReturnPctDisplay: displayDecimalPtr(checkpoint.BenchmarkReturnPct),
+		TradingTimezone:           tradingTimezone,
+		CreatedAt:                 checkpoint.CreatedAt.In(loc).Format(time.RFC3339),
+	}
+}
+
+func toCheckpointResponses(checkpoints []db.Checkpoint, loc *time.Location) []checkpointResponse {
 	if len(checkpoints) == 0 {
 		return []checkpointResponse{}
 	}
 	result := make([]checkpointResponse, 0, len(checkpoints))
 	for _, checkpoint := range checkpoints {
-		result = append(result, checkpointResponse{
-			ID:                 checkpoint.ID,
-			CheckpointDate:     checkpoint.CheckpointDate,
-			Status:             checkpoint.Status,
-			BenchmarkPrice:     checkpoint.BenchmarkPrice,
-			BenchmarkReturnPct: checkpoint.BenchmarkReturnPct,
-			Metrics:            toMetricResponses(checkpoint.Metrics),
-		})
+		result = append(result, toCheckpointResponseValue(checkpoint, loc))
 	}
 	return result
 }
@@ -154,7 +268,72 @@ func toMetricResponses(metrics []db.PickMetric) []pickMetricResponse {
 			CurrentPrice:      metric.CurrentPrice,
 			AbsoluteReturnPct: metric.AbsoluteReturnPct,
 			VsBenchmarkPct:    metric.VsBenchmarkPct,
+
+			OpenPrice: metric.OpenPrice,
+			HighPrice: metric.HighPrice,
+			LowPrice:  metric.LowPrice,
+			Volume:    metric.Volume,
+
+			AbsoluteReturnPctDisplay: displayDecimal(metric.AbsoluteReturnPct),
+			VsBenchmarkPctDisplay:    displayDecimal(metric.VsBenchmarkPct),
 		})
 	}
 	return result
 }
+
+func toSeriesResponse(batchID string, series report.Series) seriesResponse {
+	picks := make([]pickSeriesResponse, 0, len(series.Picks))
+	for _, pick := range series.Picks {
+		picks = append(picks, pickSeriesResponse{
+			PickID:  pick.PickID,
+			Ticker:  pick.Ticker,
+			Action:  pick.Action,
+			Returns: pick.Returns,
+		})
+	}
+	return seriesResponse{
+		BatchID:         batchID,
+		Dates:           series.Dates,
+		BenchmarkReturn: series.Benchmark,
+		PortfolioReturn: series.Portfolio,
+		Picks:           picks,
+	}
+}
+
+func toTimeseriesResponse(batchID string, series report.Timeseries) timeseriesResponse {
+	picks := make([]timeseriesLineResponse, 0, len(series.Picks))
+	for _, line := range series.Picks {
+		picks = append(picks, toTimeseriesLineResponse(line))
+	}
+	return timeseriesResponse{
+		BatchID:   batchID,
+		Benchmark: toTimeseriesLineResponse(series.Benchmark),
+		Picks:     picks,
+	}
+}
+
+func toTimeseriesLineResponse(line report.Line) timeseriesLineResponse {
+	points := make([]timeseriesPointResponse, 0, len(line.Points))
+	for _, point := range line.Points {
+		points = append(points, timeseriesPointResponse(point))
+	}
+	return timeseriesLineResponse{
+		PickID: line.PickID,
+		Ticker: line.Ticker,
+		Action: line.Action,
+		Points: points,
+	}
+}
+
+// displayDecimal renders value rounded to displayPrecision decimals.
+func displayDecimal(value decimal.Decimal) string {
+	return value.StringFixed(displayPrecision)
+}
+
+func displayDecimalPtr(value *decimal.Decimal) *string {
+	if value == nil {
+		return nil
+	}
+	display := displayDecimal(*value)
+	return &display
+}
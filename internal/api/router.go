@@ -2,11 +2,11 @@ package api
 
 import (
 	"net/http"
+	"net/netip"
 	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
-	"github.com/go-chi/cors"
 	"github.com/igor-kupczynski/alpha-monday/internal/db"
 	"log/slog"
 )
@@ -17,43 +17,384 @@ const (
 	idleTimeout  = 60 * time.Second
 )
 
-func NewRouter(store *db.Store, logger *slog.Logger, corsOrigins []string) http.Handler {
+// DefaultCompressionLevel is the gzip level used for compressible responses.
+const DefaultCompressionLevel = 5
+
+// compressibleTypes are the response types worth compressing; PDFs and PNGs
+// are already compressed.
+var compressibleTypes = []string{"application/json", "application/yaml", "application/atom+xml", "text/csv", "text/plain"}
+
+// Option configures optional router features.
+type Option func(*routerOptions)
+
+type routerOptions struct {
+	adminToken      string
+	adminKeys       []AdminKey
+	urlSigningKey   string
+	apiKeysRequired bool
+	openAPIValidate bool
+	requestLog      requestLogConfig
+	panicMetrics    *PanicMetrics
+	panicAlerts     bool
+	security        SecurityConfig
+	adminCIDRs      []netip.Prefix
+	trustedProxies  []netip.Prefix
+	compression     int
+	runner          WorkflowRunner
+	rateLimit       RateLimitConfig
+	requestMetrics  *RequestMetrics
+	metricsHandler  http.Handler
+	schemaCheck     string
+	latestCacheTTL  time.Duration
+	cache           CacheConfig
+	precision       int
+	cors            CORSConfig
+}
+
+// WithAdminToken enables the /admin routes, authenticated with token as a
+// bearer token. Admin routes are not mounted when token is empty.
+func WithAdminToken(token string) Option {
+	return func(o *routerOptions) {
+		o.adminToken = token
+	}
+}
+
+// WithAdminKeys also enables the /admin routes, authenticated with any of
+// keys as an X-API-Key header. The routes are mounted when either an admin
+// token or at least one key is set.
+func WithAdminKeys(keys []AdminKey) Option {
+	return func(o *routerOptions) {
+		o.adminKeys = keys
+	}
+}
+
+// WithWorkflowRunner backs POST /admin/batches and the checkpoint recompute
+// route, which enqueue workflow runs through runner. Without it both routes
+// return 501.
+func WithWorkflowRunner(runner WorkflowRunner) Option {
+	return func(o *routerOptions) {
+		o.runner = runner
+	}
+}
+
+// WithAdminAllowedCIDRs restricts the /admin routes to clients in the given
+// ranges, in addition to the admin token. An empty list allows any address.
+func WithAdminAllowedCIDRs(prefixes []netip.Prefix) Option {
+	return func(o *routerOptions) {
+		o.adminCIDRs = prefixes
+	}
+}
+
+// WithTrustedProxies trusts the forwarded client address headers
+// (True-Client-IP, X-Real-IP, X-Forwarded-For) on requests whose direct peer
+// is in the given ranges. The headers are ignored when the list is empty.
+func WithTrustedProxies(prefixes []netip.Prefix) Option {
+	return func(o *routerOptions) {
+		o.trustedProxies = prefixes
+	}
+}
+
+// WithURLSigningKey enables HMAC-signed share URLs for /shared routes.
+func WithURLSigningKey(key string) Option {
+	return func(o *routerOptions) {
+		o.urlSigningKey = key
+	}
+}
+
+// WithAPIKeysRequired rejects data requests that carry no X-API-Key header.
+// Without it, only requests that present a key are metered.
+func WithAPIKeysRequired(required bool) Option {
+	return func(o *routerOptions) {
+		o.apiKeysRequired = required
+	}
+}
+
+// WithOpenAPIValidation checks requests and JSON responses against the served
+// OpenAPI schema and logs violations. Meant for dev and staging: it buffers
+// every response.
+func WithOpenAPIValidation(enabled bool) Option {
+	return func(o *routerOptions) {
+		o.openAPIValidate = enabled
+	}
+}
+
+// WithSlowRequestThreshold logs requests taking at least threshold at warn
+// level with full detail (query, request ID, client), regardless of sampling.
+// Zero disables slow-request logging.
+func WithSlowRequestThreshold(threshold time.Duration) Option {
+	return func(o *routerOptions) {
+		o.requestLog.slowThreshold = threshold
+	}
+}
+
+// WithRequestLogSampleRate logs only this fraction (0..1) of successful,
+// fast requests. Failed and slow requests are always logged.
+func WithRequestLogSampleRate(rate float64) Option {
+	return func(o *routerOptions) {
+		o.requestLog.sampleRate = rate
+	}
+}
+
+// WithPanicMetrics counts recovered handler panics.
+func WithPanicMetrics(metrics *PanicMetrics) Option {
+	return func(o *routerOptions) {
+		o.panicMetrics = metrics
+	}
+}
+
+// WithSchemaCheck sets the SCHEMA_CHECK mode; with db.SchemaCheckOff,
+// /readyz does not compare the schema version.
+func WithSchemaCheck(mode string) Option {
+	return func(o *routerOptions) {
+		o.schemaCheck = mode
+	}
+}
+
+// WithRequestMetrics counts requests and their latency per route pattern.
+func WithRequestMetrics(metrics *RequestMetrics) Option {
+	return func(o *routerOptions) {
+		o.requestMetrics = metrics
+	}
+}
+
+// WithMetricsHandler serves handler (typically promhttp) at GET /metrics.
+// Leave it unset when metrics are exposed on a separate internal listener.
+func WithMetricsHandler(handler http.Handler) Option {
+	return func(o *routerOptions) {
+		o.metricsHandler = handler
+	}
+}
+
+// WithPanicAlerts enqueues an api_panic outbox event for recovered panics (at
+// most one per route per minute), which the worker's outbox dispatcher
+// delivers to its sinks.
+func WithPanicAlerts(enabled bool) Option {
+	return func(o *routerOptions) {
+		o.panicAlerts = enabled
+	}
+}
+
+// WithCompression sets the gzip level (1-9) for JSON, YAML, CSV and text
+// responses when the client accepts it. Zero disables compression.
+func WithCompression(level int) Option {
+	return func(o *routerOptions) {
+		o.compression = level
+	}
+}
+
+// WithRateLimit limits each client address on the data and shared routes to
+// a token bucket; see RateLimitConfig. Health probes and /admin are not
+// limited.
+func WithRateLimit(config RateLimitConfig) Option {
+	return func(o *routerOptions) {
+		o.rateLimit = config
+	}
+}
+
+// WithLatestCacheTTL caches the /latest query for ttl; zero disables the
+// cache.
+func WithLatestCacheTTL(ttl time.Duration) Option {
+	return func(o *routerOptions) {
+		o.latestCacheTTL = ttl
+	}
+}
+
+// WithCacheControl overrides the Cache-Control max-ages; see
+// DefaultCacheConfig for the defaults.
+func WithCacheControl(config CacheConfig) Option {
+	return func(o *routerOptions) {
+		o.cache = config
+	}
+}
+
+// WithDefaultPrecision rounds numeric response fields to places decimals
+// unless the request asks for another ?precision=. Negative keeps the stored
+// precision, which is the default.
+func WithDefaultPrecision(places int) Option {
+	return func(o *routerOptions) {
+		o.precision = places
+	}
+}
+
+// WithCORS overrides the CORS methods, headers, credentials and preflight
+// max-age; see DefaultCORSConfig for the defaults. It has no effect without
+// allowed origins.
+func WithCORS(config CORSConfig) Option {
+	return func(o *routerOptions) {
+		o.cors = config
+	}
+}
+
+// WithSecurity overrides the security headers and request limits; see
+// DefaultSecurityConfig for the defaults.
+func WithSecurity(config SecurityConfig) Option {
+	return func(o *routerOptions) {
+		o.security = config
+	}
+}
+
+func NewRouter(store *db.Store, logger *slog.Logger, corsOrigins []string, opts ...Option) http.Handler {
 	if logger == nil {
 		logger = slog.Default()
 	}
+	options := routerOptions{requestLog: requestLogConfig{sampleRate: 1}, security: DefaultSecurityConfig(), cache: DefaultCacheConfig(), compression: DefaultCompressionLevel, precision: -1, cors: DefaultCORSConfig()}
+	for _, opt := range opts {
+		opt(&options)
+	}
 
-	server := &Server{store: store, logger: logger}
+	server := &Server{store: store, logger: logger, signer: urlSigner{key: []byte(options.urlSigningKey)}, panicMetrics: options.panicMetrics, runner: options.runner, schemaCheck: options.schemaCheck, cache: options.cache, privateCache: options.apiKeysRequired}
+	server.graphQL = newGraphQLExecutor(store, logger)
+	if options.latestCacheTTL > 0 {
+		server.latest = newLatestCache(store, options.latestCacheTTL)
+	}
+	if options.panicAlerts {
+		server.panicAlerts = &panicAlerter{store: store, last: map[string]time.Time{}}
+	}
 
 	r := chi.NewRouter()
-	r.Use(middleware.RealIP)
+	r.MethodNotAllowed(methodNotAllowed)
+	r.Use(forwardedClient(options.trustedProxies))
 	r.Use(middleware.RequestID)
-	r.Use(middleware.Recoverer)
-	r.Use(middleware.Timeout(10 * time.Second))
-	r.Use(requestLogger(logger))
+	// Outside the recoverer, so recovered panics are counted as 500s.
+	if options.requestMetrics != nil {
+		r.Use(options.requestMetrics.middleware)
+	}
+	r.Use(server.recoverer)
+	r.Use(securityMiddleware(options.security))
+	r.Use(requestTimeout(10 * time.Second))
+	r.Use(requestLogger(logger, options.requestLog))
 
 	if len(corsOrigins) > 0 {
-		r.Use(cors.New(cors.Options{
-			AllowedOrigins: corsOrigins,
-			AllowedMethods: []string{"GET", "OPTIONS"},
-			AllowedHeaders: []string{"Accept", "Content-Type"},
-			MaxAge:         300,
-		}).Handler)
+		// Admin routes still need the admin token, whatever methods CORS
+		// allows.
+		r.Use(corsMiddleware(corsOrigins, options.cors))
+	}
+
+	// Before compression, so HEAD's Content-Length is the compressed size GET
+	// would send.
+	r.Use(answerOptions)
+	r.Use(headAsGet)
+
+	// Compression wraps the response metadata splicing below so it, and the
+	// OpenAPI validator, see the uncompressed response.
+	if options.compression > 0 {
+		r.Use(middleware.Compress(options.compression, compressibleTypes...))
+	}
+
+	r.Use(numberFormatting(options.precision))
+	r.Use(server.responseMetadata)
+
+	if options.openAPIValidate {
+		validator, err := newOpenAPIValidator(logger)
+		if err != nil {
+			logger.Error("openapi validation disabled", "error", err)
+		} else {
+			r.Use(validator.middleware)
+		}
+	}
+
+	// One limiter shared by the data and shared routes, so a client's budget
+	// covers both.
+	var limitClients func(http.Handler) http.Handler
+	if options.rateLimit.RPS > 0 {
+		limitClients = rateLimit(newIPRateLimiter(options.rateLimit))
 	}
 
-	r.Get("/health", server.handleHealth)
-	r.Get("/latest", server.handleLatest)
-	r.Get("/batches", server.handleBatches)
-	r.Get("/batches/{id}", server.handleBatchDetails)
+	r.Get("/healthz", server.handleLiveness)
+	r.Get("/readyz", server.handleReadiness)
+	if options.metricsHandler != nil {
+		r.Method(http.MethodGet, "/metrics", options.metricsHandler)
+	}
+	r.Get("/openapi.yaml", server.handleOpenAPI)
+	r.Get("/openapi.json", server.handleOpenAPIJSON)
+
+	// Data routes are metered against per-key quotas when a client presents
+	// an API key.
+	r.Group(func(r chi.Router) {
+		if limitClients != nil {
+			r.Use(limitClients)
+		}
+		r.Use(server.requireAPIKeyQuota(options.apiKeysRequired))
+		r.Get("/latest", server.handleLatest)
+		r.Post("/graphql", server.handleGraphQL)
+		r.Get("/batches", server.handleBatches)
+		r.Get("/batches/{id}", server.handleBatchDetails)
+		r.Head("/batches/{id}", server.handleBatchDetailsHead)
+		r.Get("/batches/{id}/checkpoints", server.handleBatchCheckpoints)
+		r.Get("/batches/{id}/checkpoints/{date}", server.handleBatchCheckpoint)
+		r.Get("/batches/{id}/report.pdf", server.handleBatchReport)
+		r.Get("/batches/{id}/export.csv", server.handleBatchExport)
+		r.Get("/batches/{id}/export.xlsx", server.handleBatchExportXLSX)
+		r.Get("/batches/{id}/chart.png", server.handleBatchChart)
+		r.Get("/batches/{id}/series", server.handleBatchSeries)
+		r.Get("/batches/{id}/timeseries", server.handleBatchTimeseries)
+		r.Get("/batches/{id}/benchmark", server.handleBatchBenchmark)
+		r.Get("/batches/{id}/picks/{pickID}", server.handlePickDetail)
+		r.Get("/picks/{ticker}", server.handlePickHistory)
+		r.Get("/picks/{pickID}/sparkline", server.handlePickSparkline)
+		r.Get("/search", server.handleSearch)
+		r.Get("/feed.atom", server.handleFeed)
+		r.Get("/stats/runs", server.handleRunStats)
+		r.Get("/stats/system", server.handleSystemStats)
+		r.Get("/stats/tickers", server.handleTickerStats)
+		r.Get("/summary", server.handleSummary)
+	})
+
+	// Share-token scoped, read-only copies of the batch routes. These can be
+	// exposed publicly while the rest of the API stays private.
+	r.Route("/shared/batches/{id}", func(r chi.Router) {
+		if limitClients != nil {
+			r.Use(limitClients)
+		}
+		r.Use(server.requireShareToken)
+		r.Get("/", server.handleBatchDetails)
+		r.Get("/checkpoints", server.handleBatchCheckpoints)
+		r.Get("/checkpoints/{date}", server.handleBatchCheckpoint)
+		r.Get("/report.pdf", server.handleBatchReport)
+		r.Get("/export.csv", server.handleBatchExport)
+		r.Get("/export.xlsx", server.handleBatchExportXLSX)
+		r.Get("/chart.png", server.handleBatchChart)
+		r.Get("/series", server.handleBatchSeries)
+		r.Get("/timeseries", server.handleBatchTimeseries)
+		r.Get("/benchmark", server.handleBatchBenchmark)
+		r.Get("/picks/{pickID}", server.handlePickDetail)
+	})
+
+	if options.adminToken != "" || len(options.adminKeys) > 0 {
+		r.Route("/admin", func(r chi.Router) {
+			if len(options.adminCIDRs) > 0 {
+				r.Use(requireAllowedIP(options.adminCIDRs))
+			}
+			r.Use(requireAdmin(options.adminToken, options.adminKeys))
+			r.Use(server.auditAdminMutations)
+			r.Get("/audit", server.handleAdminAudit)
+			r.Patch("/batches/{id}", server.handleUpdateBatch)
+			r.Delete("/batches/{id}", server.handleDeleteBatch)
+			r.Post("/batches/{id}/share-tokens", server.handleCreateShareToken)
+			r.Delete("/share-tokens/{tokenID}", server.handleRevokeShareToken)
+			r.Post("/api-keys", server.handleCreateAPIKey)
+			r.Delete("/api-keys/{keyID}", server.handleRevokeAPIKey)
+			r.Get("/webhooks", server.handleListWebhooks)
+			r.Post("/webhooks", server.handleCreateWebhook)
+			r.Delete("/webhooks/{webhookID}", server.handleRevokeWebhook)
+			if options.urlSigningKey != "" {
+				r.Post("/batches/{id}/signed-urls", server.handleCreateSignedURL)
+			}
+			r.Post("/batches", server.requireWorkflowRunner(server.handleTriggerBatch))
+			r.Post("/batches/{id}/checkpoints/{date}/recompute", server.requireWorkflowRunner(server.handleRecomputeCheckpoint))
+		})
+	}
 
 	return r
 }
 
-func NewHTTPServer(addr string, handler http.Handler) *http.Server {
+func NewHTTPServer(addr string, handler http.Handler, maxHeaderBytes int) *http.Server {
 	return &http.Server{
-		Addr:         addr,
-		Handler:      handler,
-		ReadTimeout:  readTimeout,
-		WriteTimeout: writeTimeout,
-		IdleTimeout:  idleTimeout,
+		Addr:           addr,
+		Handler:        handler,
+		MaxHeaderBytes: maxHeaderBytes,
+		ReadTimeout:    readTimeout,
+		WriteTimeout:   writeTimeout,
+		IdleTimeout:    idleTimeout,
 	}
 }
@@ -1,53 +1,128 @@
 package api
 
 import (
+	"bytes"
 	"context"
+	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
+	"github.com/99designs/gqlgen/graphql/executor"
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
 	"github.com/igor-kupczynski/alpha-monday/internal/db"
+	"github.com/igor-kupczynski/alpha-monday/internal/report"
+	"golang.org/x/sync/errgroup"
 	"log/slog"
 )
 
 type Server struct {
-	store  *db.Store
-	logger *slog.Logger
+	store        *db.Store
+	logger       *slog.Logger
+	signer       urlSigner
+	panicMetrics *PanicMetrics
+	panicAlerts  *panicAlerter
+	runner       WorkflowRunner
+	schemaCheck  string
+	graphQL      *executor.Executor
+	// latest is nil when /latest is not cached.
+	latest       *latestCache
+	cache        CacheConfig
+	privateCache bool
 }
 
-func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
+// handleLiveness only reports that the process is serving requests. It does
+// not touch the database, so an outage does not get the container restarted.
+func (s *Server) handleLiveness(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, r, http.StatusOK, livenessResponse{Ok: true})
+}
+
+// handleReadiness reports whether the instance can serve traffic: the
+// database answers and its schema is not behind this build. A schema ahead of
+// the build is ready, as at startup; with SCHEMA_CHECK=off the schema is not
+// checked.
+func (s *Server) handleReadiness(w http.ResponseWriter, r *http.Request) {
 	ctx, cancel := context.WithTimeout(r.Context(), 2*time.Second)
 	defer cancel()
 
-	dbOK := true
+	resp := readinessResponse{ExpectedSchemaVersion: db.SchemaVersion}
 	if err := s.store.Ping(ctx); err != nil {
-		dbOK = false
-		s.logger.Warn("health check failed", "error", err)
+		s.logger.Warn("readiness check failed", "error", err)
+		writeJSON(w, r, http.StatusServiceUnavailable, resp)
+		return
+	}
+	resp.DBOk = true
+
+	if s.schemaCheck == db.SchemaCheckOff {
+		resp.SchemaOk = true
+	} else if status, err := s.store.SchemaStatus(ctx); err != nil {
+		s.logger.Warn("readiness schema check failed", "error", err)
+	} else {
+		resp.SchemaVersion = &status.Current
+		resp.SchemaOk = !status.Behind()
 	}
 
+	resp.Ok = resp.DBOk && resp.SchemaOk
 	status := http.StatusOK
-	if !dbOK {
+	if !resp.Ok {
 		status = http.StatusServiceUnavailable
 	}
-
-	writeJSON(w, status, healthResponse{Ok: dbOK, DBOk: dbOK})
+	writeJSON(w, r, status, resp)
 }
 
+// handleLatest serves the newest batch. With ?wait= and an If-None-Match
+// naming the current ETag it long-polls: the response is held until the data
+// changes or the wait elapses, which ends in a 304.
 func (s *Server) handleLatest(w http.ResponseWriter, r *http.Request) {
+	loc, err := parseTimezone(w, r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_argument", err.Error())
+		return
+	}
+	wait, err := parseLatestWait(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_argument", err.Error())
+		return
+	}
+
 	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
 	defer cancel()
 
-	latest, err := s.store.LatestBatch(ctx)
+	latest, err := s.latestBatch(ctx)
 	if err != nil {
 		s.logger.Error("latest batch query failed", "error", err)
 		writeError(w, http.StatusInternalServerError, "internal", "unexpected error")
 		return
 	}
 
+	ifNoneMatch := r.Header.Get("If-None-Match")
+	if wait > 0 && ifNoneMatch != "" && etagMatches(ifNoneMatch, latestETag(latest)) {
+		latest, err = s.waitForLatest(r.Context(), latest, wait)
+		if err != nil {
+			if r.Context().Err() != nil {
+				// The client went away.
+				return
+			}
+			s.logger.Error("latest batch query failed", "error", err)
+			writeError(w, http.StatusInternalServerError, "internal", "unexpected error")
+			return
+		}
+	}
+
+	s.setCacheControl(w, r, s.cache.Latest)
+	etag := latestETag(latest)
+	w.Header().Set("ETag", etag)
+	if ifNoneMatch != "" && etagMatches(ifNoneMatch, etag) {
+		if latest != nil {
+			w.Header().Set("Last-Modified", latest.LastModified.UTC().Truncate(time.Second).Format(http.TimeFormat))
+		}
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
 	if latest == nil {
-		writeJSON(w, http.StatusOK, latestResponse{
+		writeJSON(w, r, http.StatusOK, latestResponse{
 			Batch:            nil,
 			Picks:            []pickResponse{},
 			LatestCheckpoint: nil,
@@ -55,13 +130,24 @@ func (s *Server) handleLatest(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if checkNotModified(w, r, latest.LastModified) {
+		return
+	}
+
 	resp := latestResponse{
 		Batch:            toBatchResponsePtr(latest.Batch),
 		Picks:            toPickResponses(latest.Picks),
-		LatestCheckpoint: toCheckpointResponse(latest.LatestCheckpoint),
+		LatestCheckpoint: toCheckpointResponse(latest.LatestCheckpoint, loc),
 	}
 
-	writeJSON(w, http.StatusOK, resp)
+	writeJSON(w, r, http.StatusOK, resp)
+}
+
+func (s *Server) latestBatch(ctx context.Context) (*db.LatestBatchResult, error) {
+	if s.latest != nil {
+		return s.latest.get(ctx)
+	}
+	return s.store.LatestBatch(ctx)
 }
 
 func (s *Server) handleBatches(w http.ResponseWriter, r *http.Request) {
@@ -71,7 +157,31 @@ func (s *Server) handleBatches(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	cursor, err := parseCursor(r)
+	cursor, err := parseBatchCursor(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_argument", err.Error())
+		return
+	}
+
+	filter := db.BatchFilter{Status: r.URL.Query().Get("status")}
+	if filter.Status != "" && !db.ValidBatchStatus(filter.Status) {
+		writeError(w, http.StatusBadRequest, "invalid_argument", errInvalidBatchStatus.Error())
+		return
+	}
+	if ticker := r.URL.Query().Get("ticker"); ticker != "" {
+		filter.Ticker = strings.ToUpper(ticker)
+		if !tickerPattern.MatchString(filter.Ticker) {
+			writeError(w, http.StatusBadRequest, "invalid_argument", errInvalidTicker.Error())
+			return
+		}
+	}
+
+	includeTotal, err := parseIncludeTotal(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_argument", err.Error())
+		return
+	}
+	includePerformance, err := parseBatchesInclude(r)
 	if err != nil {
 		writeError(w, http.StatusBadRequest, "invalid_argument", err.Error())
 		return
@@ -80,32 +190,73 @@ func (s *Server) handleBatches(w http.ResponseWriter, r *http.Request) {
 	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
 	defer cancel()
 
-	page, err := s.store.ListBatches(ctx, limit, cursor)
-	if err != nil {
+	listBatches := s.store.ListBatches
+	if includePerformance {
+		listBatches = s.store.ListBatchesWithPerformance
+	}
+	// The page and the total are independent, so the count runs alongside.
+	var page db.BatchesPage
+	var total *int
+	group, groupCtx := errgroup.WithContext(ctx)
+	group.Go(func() error {
+		var err error
+		if page, err = listBatches(groupCtx, limit, cursor, filter); err != nil {
+			return fmt.Errorf("list batches: %w", err)
+		}
+		return nil
+	})
+	if includeTotal {
+		group.Go(func() error {
+			count, err := s.store.CountBatches(groupCtx, filter)
+			if err != nil {
+				return fmt.Errorf("count batches: %w", err)
+			}
+			total = &count
+			return nil
+		})
+	}
+	if err := group.Wait(); err != nil {
 		s.logger.Error("list batches failed", "error", err)
 		writeError(w, http.StatusInternalServerError, "internal", "unexpected error")
 		return
 	}
 
 	resp := batchesResponse{
-		Batches:    toBatchResponses(page.Batches),
-		NextCursor: page.NextCursor,
+		Batches:    toBatchListResponses(page),
+		NextCursor: encodeBatchCursor(page.NextCursor),
+		TotalCount: total,
 	}
 
-	writeJSON(w, http.StatusOK, resp)
+	s.setCacheControl(w, r, s.cache.Latest)
+	writeJSON(w, r, http.StatusOK, resp)
 }
 
 func (s *Server) handleBatchDetails(w http.ResponseWriter, r *http.Request) {
-	batchID := chi.URLParam(r, "id")
-	if _, err := uuid.Parse(batchID); err != nil {
-		writeError(w, http.StatusBadRequest, "invalid_argument", "invalid batch id")
+	batchID, ok := parseBatchID(w, r)
+	if !ok {
+		return
+	}
+
+	loc, err := parseTimezone(w, r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_argument", err.Error())
+		return
+	}
+
+	scope, err := parseBatchInclude(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_argument", err.Error())
+		return
+	}
+	if err := parseCheckpointPage(r, &scope); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_argument", err.Error())
 		return
 	}
 
 	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
 	defer cancel()
 
-	detail, err := s.store.BatchDetails(ctx, batchID)
+	detail, err := s.store.BatchDetailsFor(ctx, batchID, scope)
 	if err != nil {
 		s.logger.Error("batch detail failed", "error", err)
 		writeError(w, http.StatusInternalServerError, "internal", "unexpected error")
@@ -115,14 +266,350 @@ func (s *Server) handleBatchDetails(w http.ResponseWriter, r *http.Request) {
 		writeError(w, http.StatusNotFound, "not_found", "batch not found")
 		return
 	}
+	s.setCacheControl(w, r, s.batchMaxAge(detail.Batch.Status))
+	if checkNotModified(w, r, detail.LastModified) {
+		return
+	}
 
 	resp := batchDetailResponse{
-		Batch:       toBatchResponse(detail.Batch),
-		Picks:       toPickResponses(detail.Picks),
-		Checkpoints: toCheckpointResponses(detail.Checkpoints),
+		Batch: toBatchResponse(detail.Batch),
+		Picks: toPickResponses(detail.Picks),
+	}
+	// Checkpoints and metrics left nil are omitted from the JSON (omitzero).
+	if scope.Checkpoints {
+		resp.Checkpoints = toCheckpointResponses(detail.Checkpoints, loc)
+		resp.CheckpointsNextCursor = detail.CheckpointsNextCursor
+		if !scope.Metrics {
+			for i := range resp.Checkpoints {
+				resp.Checkpoints[i].Metrics = nil
+			}
+		}
+	}
+
+	writeJSON(w, r, http.StatusOK, resp)
+}
+
+// handleBatchDetailsHead answers HEAD /batches/{id} from the batch row alone,
+// so caches can revalidate without loading picks, checkpoints and metrics. The
+// status and headers match GET's.
+func (s *Server) handleBatchDetailsHead(w http.ResponseWriter, r *http.Request) {
+	batchID, ok := parseBatchID(w, r)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	freshness, err := s.store.BatchFreshness(ctx, batchID)
+	if err != nil {
+		s.logger.Error("batch freshness failed", "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	if freshness == nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	s.setCacheControl(w, r, s.batchMaxAge(freshness.Status))
+	if checkNotModified(w, r, freshness.LastModified) {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+}
+
+// parseBatchID returns the {id} URL parameter, answering 400 when it is not a
+// UUID.
+func parseBatchID(w http.ResponseWriter, r *http.Request) (string, bool) {
+	batchID := chi.URLParam(r, "id")
+	if _, err := uuid.Parse(batchID); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_argument", "invalid batch id")
+		return "", false
+	}
+	return batchID, true
+}
+
+// loadBatchDetails loads the full details of the {id} batch. When it returns
+// false the error response (400, 404 or 500) has been written.
+func (s *Server) loadBatchDetails(w http.ResponseWriter, r *http.Request) (*db.BatchDetails, bool) {
+	return s.loadBatchDetailsFor(w, r, db.FullBatchDetails)
+}
+
+// loadBatchDetailsFor is loadBatchDetails limited to scope.
+func (s *Server) loadBatchDetailsFor(w http.ResponseWriter, r *http.Request, scope db.BatchDetailsScope) (*db.BatchDetails, bool) {
+	batchID, ok := parseBatchID(w, r)
+	if !ok {
+		return nil, false
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	detail, err := s.store.BatchDetailsFor(ctx, batchID, scope)
+	if err != nil {
+		s.logger.Error("batch detail failed", "error", err)
+		writeError(w, http.StatusInternalServerError, "internal", "unexpected error")
+		return nil, false
+	}
+	if detail == nil {
+		writeError(w, http.StatusNotFound, "not_found", "batch not found")
+		return nil, false
+	}
+	return detail, true
+}
+
+// handleBatchReport renders a PDF report for a completed batch.
+func (s *Server) handleBatchReport(w http.ResponseWriter, r *http.Request) {
+	detail, ok := s.loadBatchDetails(w, r)
+	if !ok {
+		return
+	}
+	if detail.Batch.Status != "completed" {
+		writeError(w, http.StatusConflict, "failed_precondition", "report is available once the batch is completed")
+		return
+	}
+	s.setCacheControl(w, r, s.batchMaxAge(detail.Batch.Status))
+	if checkNotModified(w, r, detail.LastModified) {
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := report.RenderPDF(&buf, *detail); err != nil {
+		s.logger.Error("render batch report failed", "error", err)
+		writeError(w, http.StatusInternalServerError, "internal", "unexpected error")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/pdf")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="alpha-monday-%s.pdf"`, detail.Batch.RunDate))
+	w.Header().Set("Content-Length", strconv.Itoa(buf.Len()))
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(buf.Bytes())
+}
+
+// handleBatchExport streams a batch's picks x checkpoints x metrics as CSV.
+func (s *Server) handleBatchExport(w http.ResponseWriter, r *http.Request) {
+	detail, ok := s.loadBatchDetails(w, r)
+	if !ok {
+		return
+	}
+	s.setCacheControl(w, r, s.batchMaxAge(detail.Batch.Status))
+	if checkNotModified(w, r, detail.LastModified) {
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="alpha-monday-%s.csv"`, detail.Batch.RunDate))
+	w.WriteHeader(http.StatusOK)
+	if err := report.WriteCSV(w, *detail); err != nil {
+		// The status is already sent; the client sees a truncated file.
+		s.logger.Error("write batch export failed", "error", err)
+	}
+}
+
+// handleBatchExportXLSX returns a batch as an Excel workbook with Picks and
+// Checkpoints sheets.
+func (s *Server) handleBatchExportXLSX(w http.ResponseWriter, r *http.Request) {
+	detail, ok := s.loadBatchDetails(w, r)
+	if !ok {
+		return
+	}
+	s.setCacheControl(w, r, s.batchMaxAge(detail.Batch.Status))
+	if checkNotModified(w, r, detail.LastModified) {
+		return
 	}
 
-	writeJSON(w, http.StatusOK, resp)
+	var buf bytes.Buffer
+	if err := report.WriteXLSX(&buf, *detail); err != nil {
+		s.logger.Error("write batch workbook failed", "error", err)
+		writeError(w, http.StatusInternalServerError, "internal", "unexpected error")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="alpha-monday-%s.xlsx"`, detail.Batch.RunDate))
+	w.Header().Set("Content-Length", strconv.Itoa(buf.Len()))
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(buf.Bytes())
+}
+
+// handleBatchSeries returns checkpoint returns as date-aligned arrays so
+// charting libraries can plot them without joining checkpoints and metrics.
+func (s *Server) handleBatchSeries(w http.ResponseWriter, r *http.Request) {
+	detail, ok := s.loadBatchDetails(w, r)
+	if !ok {
+		return
+	}
+	s.setCacheControl(w, r, s.batchMaxAge(detail.Batch.Status))
+	if checkNotModified(w, r, detail.LastModified) {
+		return
+	}
+
+	writeJSON(w, r, http.StatusOK, toSeriesResponse(detail.Batch.ID, report.BuildSeries(detail.Picks, detail.Checkpoints)))
+}
+
+// handleBatchTimeseries returns one ordered list of {date, price, return_pct}
+// points for the benchmark and for each pick.
+func (s *Server) handleBatchTimeseries(w http.ResponseWriter, r *http.Request) {
+	detail, ok := s.loadBatchDetails(w, r)
+	if !ok {
+		return
+	}
+	s.setCacheControl(w, r, s.batchMaxAge(detail.Batch.Status))
+	if checkNotModified(w, r, detail.LastModified) {
+		return
+	}
+
+	writeJSON(w, r, http.StatusOK, toTimeseriesResponse(detail.Batch.ID, report.BuildTimeseries(detail.Batch, detail.Picks, detail.Checkpoints)))
+}
+
+// handleBatchBenchmark returns only the benchmark's price and return points,
+// so charts can overlay the benchmark without loading pick metrics.
+func (s *Server) handleBatchBenchmark(w http.ResponseWriter, r *http.Request) {
+	detail, ok := s.loadBatchDetailsFor(w, r, db.BatchDetailsScope{Checkpoints: true})
+	if !ok {
+		return
+	}
+	s.setCacheControl(w, r, s.batchMaxAge(detail.Batch.Status))
+	if checkNotModified(w, r, detail.LastModified) {
+		return
+	}
+
+	line := report.BuildTimeseries(detail.Batch, nil, detail.Checkpoints).Benchmark
+	writeJSON(w, r, http.StatusOK, benchmarkSeriesResponse{
+		BatchID:      detail.Batch.ID,
+		Ticker:       line.Ticker,
+		InitialPrice: detail.Batch.BenchmarkInitialPrice,
+		Points:       toTimeseriesLineResponse(line).Points,
+	})
+}
+
+// handleBatchChart renders a PNG line chart of portfolio vs benchmark returns,
+// suitable for embedding in notifications.
+func (s *Server) handleBatchChart(w http.ResponseWriter, r *http.Request) {
+	detail, ok := s.loadBatchDetails(w, r)
+	if !ok {
+		return
+	}
+	s.setCacheControl(w, r, s.batchMaxAge(detail.Batch.Status))
+	if checkNotModified(w, r, detail.LastModified) {
+		return
+	}
+
+	title := fmt.Sprintf("Alpha Monday %s: portfolio vs %s", detail.Batch.RunDate, detail.Batch.BenchmarkSymbol)
+	var buf bytes.Buffer
+	if err := report.RenderChartPNG(&buf, title, report.BuildSeries(detail.Picks, detail.Checkpoints)); err != nil {
+		s.logger.Error("render batch chart failed", "error", err)
+		writeError(w, http.StatusInternalServerError, "internal", "unexpected error")
+		return
+	}
+
+	w.Header().Set("Content-Type", "image/png")
+	w.Header().Set("Content-Length", strconv.Itoa(buf.Len()))
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(buf.Bytes())
+}
+
+func (s *Server) handleBatchCheckpoints(w http.ResponseWriter, r *http.Request) {
+	batchID, ok := parseBatchID(w, r)
+	if !ok {
+		return
+	}
+
+	limit, err := parseLimit(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_argument", err.Error())
+		return
+	}
+
+	cursor, err := parseCursor(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_argument", err.Error())
+		return
+	}
+
+	loc, err := parseTimezone(w, r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_argument", err.Error())
+		return
+	}
+
+	includeTotal, err := parseIncludeTotal(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_argument", err.Error())
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	page, err := s.store.ListCheckpoints(ctx, batchID, limit, cursor)
+	if err != nil {
+		s.logger.Error("list checkpoints failed", "error", err)
+		writeError(w, http.StatusInternalServerError, "internal", "unexpected error")
+		return
+	}
+	if page == nil {
+		writeError(w, http.StatusNotFound, "not_found", "batch not found")
+		return
+	}
+
+	resp := checkpointsResponse{
+		Checkpoints: toCheckpointResponses(page.Checkpoints, loc),
+		NextCursor:  page.NextCursor,
+	}
+	if includeTotal {
+		total, err := s.store.CountCheckpoints(ctx, batchID)
+		if err != nil {
+			s.logger.Error("count checkpoints failed", "error", err)
+			writeError(w, http.StatusInternalServerError, "internal", "unexpected error")
+			return
+		}
+		resp.TotalCount = &total
+	}
+
+	s.setCacheControl(w, r, s.cache.ActiveBatch)
+	writeJSON(w, r, http.StatusOK, resp)
+}
+
+// handleBatchCheckpoint returns one checkpoint of a batch by its
+// checkpoint_date, without loading the rest of the batch.
+func (s *Server) handleBatchCheckpoint(w http.ResponseWriter, r *http.Request) {
+	batchID, ok := parseBatchID(w, r)
+	if !ok {
+		return
+	}
+
+	checkpointDate := chi.URLParam(r, "date")
+	if _, err := time.Parse("2006-01-02", checkpointDate); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_argument", errInvalidCheckpointDate.Error())
+		return
+	}
+
+	loc, err := parseTimezone(w, r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_argument", err.Error())
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	checkpoint, err := s.store.GetCheckpointByDate(ctx, batchID, checkpointDate)
+	if err != nil {
+		s.logger.Error("get checkpoint failed", "error", err)
+		writeError(w, http.StatusInternalServerError, "internal", "unexpected error")
+		return
+	}
+	if checkpoint == nil {
+		writeError(w, http.StatusNotFound, "not_found", "checkpoint not found")
+		return
+	}
+
+	s.setCacheControl(w, r, s.cache.ActiveBatch)
+	writeJSON(w, r, http.StatusOK, checkpointDetailResponse{Checkpoint: toCheckpointResponse(checkpoint, loc)})
 }
 
 func parseLimit(r *http.Request) (int, error) {
@@ -151,9 +638,122 @@ func parseCursor(r *http.Request) (*string, error) {
 	return &value, nil
 }
 
+// parseIncludeTotal reads ?include_total=, which asks paginated endpoints for
+// a total_count across all pages. It costs an extra COUNT query.
+func parseIncludeTotal(r *http.Request) (bool, error) {
+	value := r.URL.Query().Get("include_total")
+	if value == "" {
+		return false, nil
+	}
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, errInvalidIncludeTotal
+	}
+	return parsed, nil
+}
+
+// parseBatchesInclude reads ?include= on /batches, where performance is the
+// only optional data.
+func parseBatchesInclude(r *http.Request) (bool, error) {
+	performance := false
+	for _, value := range r.URL.Query()["include"] {
+		for _, part := range strings.Split(value, ",") {
+			switch strings.TrimSpace(part) {
+			case "":
+			case "performance":
+				performance = true
+			default:
+				return false, errInvalidBatchesInclude
+			}
+		}
+	}
+	return performance, nil
+}
+
+// parseBatchInclude reads ?include=, a comma-separated list of the nested data
+// /batches/{id} returns: checkpoints and metrics (which implies checkpoints,
+// where metrics are nested). Without the parameter both are included; an
+// empty value returns only the batch and its picks.
+func parseBatchInclude(r *http.Request) (db.BatchDetailsScope, error) {
+	values, ok := r.URL.Query()["include"]
+	if !ok {
+		return db.FullBatchDetails, nil
+	}
+	var scope db.BatchDetailsScope
+	for _, value := range values {
+		for _, part := range strings.Split(value, ",") {
+			switch strings.TrimSpace(part) {
+			case "":
+			case "checkpoints":
+				scope.Checkpoints = true
+			case "metrics":
+				scope.Checkpoints = true
+				scope.Metrics = true
+			default:
+				return db.BatchDetailsScope{}, errInvalidInclude
+			}
+		}
+	}
+	return scope, nil
+}
+
+// parseCheckpointPage reads ?checkpoint_limit= (1-100) and
+// ?checkpoint_cursor= (a checkpoint_date), which page the checkpoints nested
+// in /batches/{id} like /batches/{id}/checkpoints pages them. Without a limit
+// every checkpoint after the cursor is returned.
+func parseCheckpointPage(r *http.Request, scope *db.BatchDetailsScope) error {
+	if value := r.URL.Query().Get("checkpoint_limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 1 || limit > 100 {
+			return errInvalidCheckpointLimit
+		}
+		scope.CheckpointLimit = limit
+	}
+	if value := r.URL.Query().Get("checkpoint_cursor"); value != "" {
+		if _, err := time.Parse("2006-01-02", value); err != nil {
+			return errInvalidCheckpointCursor
+		}
+		scope.CheckpointCursor = &value
+	}
+	return nil
+}
+
+// parseTimezone reads the zone for rendering timestamps from ?tz= or the
+// X-Timezone header (IANA name, e.g. Europe/Warsaw). It defaults to UTC.
+//
+// The header changes the body of a cacheable response, so it is added to Vary
+// along with Accept-Encoding (the compressor only adds that when it
+// compresses).
+func parseTimezone(w http.ResponseWriter, r *http.Request) (*time.Location, error) {
+	w.Header().Add("Vary", "Accept-Encoding, X-Timezone")
+	value := r.URL.Query().Get("tz")
+	if value == "" {
+		value = r.Header.Get("X-Timezone")
+	}
+	if value == "" {
+		return time.UTC, nil
+	}
+	loc, err := time.LoadLocation(value)
+	if err != nil || value == "Local" {
+		return nil, errInvalidTimezone
+	}
+	return loc, nil
+}
+
 var (
-	errInvalidLimit  = &paramError{"limit must be between 1 and 100"}
-	errInvalidCursor = &paramError{"cursor must be YYYY-MM-DD"}
+	errInvalidLimit          = &paramError{"limit must be between 1 and 100"}
+	errInvalidCursor         = &paramError{"cursor must be YYYY-MM-DD"}
+	errInvalidTimezone       = &paramError{"tz must be an IANA time zone name"}
+	errInvalidIncludeTotal   = &paramError{"include_total must be true or false"}
+	errInvalidInclude        = &paramError{"include must be a comma-separated list of checkpoints, metrics"}
+	errInvalidCheckpointDate = &paramError{"date must be YYYY-MM-DD"}
+
+	errInvalidBatchStatus = &paramError{"status must be " + strings.Join(db.BatchStatuses, ", ")}
+
+	errInvalidCheckpointLimit  = &paramError{"checkpoint_limit must be between 1 and 100"}
+	errInvalidCheckpointCursor = &paramError{"checkpoint_cursor must be YYYY-MM-DD"}
+
+	errInvalidBatchesInclude = &paramError{"include must be performance"}
 )
 
 type paramError struct {
@@ -0,0 +1,164 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+const (
+	defaultShareTokenTTL = 7 * 24 * time.Hour
+	maxShareTokenTTL     = 90 * 24 * time.Hour
+)
+
+type createShareTokenRequest struct {
+	TTLHours *int `json:"ttl_hours"`
+}
+
+type shareTokenResponse struct {
+	ID        string `json:"id"`
+	BatchID   string `json:"batch_id"`
+	Token     string `json:"token"`
+	ExpiresAt string `json:"expires_at"`
+	Path      string `json:"path"`
+}
+
+// requireShareToken admits requests for the {id} batch that carry either a
+// valid HMAC-signed URL (?expires=&signature=) or a share token from ?token=
+// or the Authorization header.
+func (s *Server) requireShareToken(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Has("signature") {
+			if !s.signer.verify(chi.URLParam(r, "id"), r.URL.Query(), time.Now()) {
+				writeError(w, http.StatusUnauthorized, "unauthenticated", "invalid or expired signed url")
+				return
+			}
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		token := r.URL.Query().Get("token")
+		if token == "" {
+			token = bearerToken(r)
+		}
+		if token == "" {
+			writeError(w, http.StatusUnauthorized, "unauthenticated", "share token required")
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), 2*time.Second)
+		defer cancel()
+
+		shareToken, err := s.store.LookupShareToken(ctx, token)
+		if err != nil {
+			s.logger.Error("share token lookup failed", "error", err)
+			writeError(w, http.StatusInternalServerError, "internal", "unexpected error")
+			return
+		}
+		if shareToken == nil {
+			writeError(w, http.StatusUnauthorized, "unauthenticated", "invalid or expired share token")
+			return
+		}
+		if shareToken.BatchID != chi.URLParam(r, "id") {
+			writeError(w, http.StatusForbidden, "permission_denied", "share token is not valid for this batch")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (s *Server) handleCreateShareToken(w http.ResponseWriter, r *http.Request) {
+	batchID := chi.URLParam(r, "id")
+	if _, err := uuid.Parse(batchID); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_argument", "invalid batch id")
+		return
+	}
+
+	ttl, err := parseShareTTL(w, r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_argument", err.Error())
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	shareToken, token, err := s.store.CreateShareToken(ctx, batchID, time.Now().Add(ttl))
+	if err != nil {
+		s.logger.Error("create share token failed", "error", err)
+		writeError(w, http.StatusInternalServerError, "internal", "unexpected error")
+		return
+	}
+	if shareToken == nil {
+		writeError(w, http.StatusNotFound, "not_found", "batch not found")
+		return
+	}
+
+	writeJSON(w, r, http.StatusCreated, shareTokenResponse{
+		ID:        shareToken.ID,
+		BatchID:   shareToken.BatchID,
+		Token:     token,
+		ExpiresAt: shareToken.ExpiresAt.UTC().Format(time.RFC3339),
+		Path:      "/shared/batches/" + shareToken.BatchID + "?token=" + token,
+	})
+}
+
+func (s *Server) handleRevokeShareToken(w http.ResponseWriter, r *http.Request) {
+	tokenID := chi.URLParam(r, "tokenID")
+	if _, err := uuid.Parse(tokenID); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_argument", "invalid token id")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	revoked, err := s.store.RevokeShareToken(ctx, tokenID)
+	if err != nil {
+		s.logger.Error("revoke share token failed", "error", err)
+		writeError(w, http.StatusInternalServerError, "internal", "unexpected error")
+		return
+	}
+	if !revoked {
+		writeError(w, http.StatusNotFound, "not_found", "share token not found")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// parseShareTTL reads the optional {"ttl_hours": n} request body.
+func parseShareTTL(w http.ResponseWriter, r *http.Request) (time.Duration, error) {
+	if r.ContentLength == 0 {
+		return defaultShareTokenTTL, nil
+	}
+	var req createShareTokenRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 4<<10)).Decode(&req); err != nil {
+		return 0, errInvalidShareBody
+	}
+	if req.TTLHours == nil {
+		return defaultShareTokenTTL, nil
+	}
+	ttl := time.Duration(*req.TTLHours) * time.Hour
+	if ttl <= 0 || ttl > maxShareTokenTTL {
+		return 0, errInvalidShareTTL
+	}
+	return ttl, nil
+}
+
+var (
+	errInvalidShareBody = &paramError{"invalid request body"}
+	errInvalidShareTTL  = &paramError{"ttl_hours must be between 1 and 2160"}
+)
+
+func bearerToken(r *http.Request) string {
+	value := r.Header.Get("Authorization")
+	if len(value) > 7 && strings.EqualFold(value[:7], "bearer ") {
+		return strings.TrimSpace(value[7:])
+	}
+	return ""
+}
@@ -0,0 +1,94 @@
+package api
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// urlSigner signs batch share URLs with HMAC-SHA256 over the batch ID and the
+// expiry, so links can be shared without storing anything.
+type urlSigner struct {
+	key []byte
+}
+
+type signedURLResponse struct {
+	BatchID   string `json:"batch_id"`
+	ExpiresAt string `json:"expires_at"`
+	Path      string `json:"path"`
+}
+
+func (s urlSigner) signature(batchID string, expires int64) string {
+	mac := hmac.New(sha256.New, s.key)
+	mac.Write([]byte(batchID + "\n" + strconv.FormatInt(expires, 10)))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// path returns the shared batch detail path signed until expiresAt.
+func (s urlSigner) path(batchID string, expiresAt time.Time) string {
+	expires := expiresAt.Unix()
+	query := url.Values{}
+	query.Set("expires", strconv.FormatInt(expires, 10))
+	query.Set("signature", s.signature(batchID, expires))
+	return "/shared/batches/" + batchID + "?" + query.Encode()
+}
+
+// verify checks the expires/signature query parameters against batchID.
+func (s urlSigner) verify(batchID string, query url.Values, now time.Time) bool {
+	if len(s.key) == 0 {
+		return false
+	}
+	expires, err := strconv.ParseInt(query.Get("expires"), 10, 64)
+	if err != nil || now.Unix() >= expires {
+		return false
+	}
+	provided, err := hex.DecodeString(query.Get("signature"))
+	if err != nil {
+		return false
+	}
+	expected, _ := hex.DecodeString(s.signature(batchID, expires))
+	return hmac.Equal(provided, expected)
+}
+
+func (s *Server) handleCreateSignedURL(w http.ResponseWriter, r *http.Request) {
+	batchID := chi.URLParam(r, "id")
+	if _, err := uuid.Parse(batchID); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_argument", "invalid batch id")
+		return
+	}
+
+	ttl, err := parseShareTTL(w, r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_argument", err.Error())
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	lastModified, err := s.store.BatchLastModified(ctx, batchID)
+	if err != nil {
+		s.logger.Error("batch last modified failed", "error", err)
+		writeError(w, http.StatusInternalServerError, "internal", "unexpected error")
+		return
+	}
+	if lastModified == nil {
+		writeError(w, http.StatusNotFound, "not_found", "batch not found")
+		return
+	}
+
+	expiresAt := time.Now().Add(ttl).Truncate(time.Second)
+	writeJSON(w, r, http.StatusCreated, signedURLResponse{
+		BatchID:   batchID,
+		ExpiresAt: expiresAt.UTC().Format(time.RFC3339),
+		Path:      s.signer.path(batchID, expiresAt),
+	})
+}
@@ -0,0 +1,216 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/igor-kupczynski/alpha-monday/internal/db"
+	"github.com/igor-kupczynski/alpha-monday/internal/decimal"
+)
+
+type runCountsResponse struct {
+	Succeeded int `json:"succeeded"`
+	Skipped   int `json:"skipped"`
+	Failed    int `json:"failed"`
+}
+
+type runStepResponse struct {
+	Step string `json:"step"`
+	runCountsResponse
+	UpdatedAt string `json:"updated_at"`
+}
+
+type runStatsResponse struct {
+	BatchID string            `json:"batch_id"`
+	RunDate string            `json:"run_date"`
+	Status  string            `json:"status"`
+	Steps   []runStepResponse `json:"steps"`
+	Totals  runCountsResponse `json:"totals"`
+}
+
+type runStatsPageResponse struct {
+	Runs       []runStatsResponse `json:"runs"`
+	NextCursor *string            `json:"next_cursor"`
+	TotalCount *int               `json:"total_count,omitempty"`
+}
+
+type systemStatsResponse struct {
+	Batches            int              `json:"batches"`
+	Picks              int              `json:"picks"`
+	Checkpoints        int              `json:"checkpoints"`
+	SkippedCheckpoints int              `json:"skipped_checkpoints"`
+	SkippedRatio       *decimal.Decimal `json:"skipped_checkpoint_ratio"`
+	OldestRunDate      *string          `json:"oldest_run_date"`
+	NewestRunDate      *string          `json:"newest_run_date"`
+	ScoredTargets      int              `json:"scored_targets"`
+	TargetMeanAbsError *decimal.Decimal `json:"target_mean_abs_error_pct"`
+}
+
+type tickerStatsResponse struct {
+	Ticker                string           `json:"ticker"`
+	Picks                 int              `json:"picks"`
+	ScoredPicks           int              `json:"scored_picks"`
+	AverageVsBenchmarkPct *decimal.Decimal `json:"average_vs_benchmark_pct"`
+	WinRate               *decimal.Decimal `json:"win_rate"`
+}
+
+type tickerStatsListResponse struct {
+	Tickers []tickerStatsResponse `json:"tickers"`
+}
+
+type summaryResponse struct {
+	TotalBatches             int              `json:"total_batches"`
+	CompletedBatches         int              `json:"completed_batches"`
+	ScoredPicks              int              `json:"scored_picks"`
+	AverageAbsoluteReturnPct *decimal.Decimal `json:"average_absolute_return_pct"`
+	AverageVsBenchmarkPct    *decimal.Decimal `json:"average_vs_benchmark_pct"`
+	WinRate                  *decimal.Decimal `json:"win_rate"`
+}
+
+// handleSummary reports aggregate pick performance across completed batches.
+func (s *Server) handleSummary(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	summary, err := s.store.PerformanceSummary(ctx)
+	if err != nil {
+		s.logger.Error("performance summary failed", "error", err)
+		writeError(w, http.StatusInternalServerError, "internal", "unexpected error")
+		return
+	}
+
+	s.setCacheControl(w, r, s.cache.Latest)
+	writeJSON(w, r, http.StatusOK, summaryResponse{
+		TotalBatches:             summary.TotalBatches,
+		CompletedBatches:         summary.CompletedBatches,
+		ScoredPicks:              summary.ScoredPicks,
+		AverageAbsoluteReturnPct: summary.AverageAbsoluteReturnPct,
+		AverageVsBenchmarkPct:    summary.AverageVsBenchmarkPct,
+		WinRate:                  summary.WinRate,
+	})
+}
+
+// handleSystemStats returns table-wide counts for dashboards.
+func (s *Server) handleSystemStats(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	stats, err := s.store.SystemStats(ctx)
+	if err != nil {
+		s.logger.Error("system stats failed", "error", err)
+		writeError(w, http.StatusInternalServerError, "internal", "unexpected error")
+		return
+	}
+
+	writeJSON(w, r, http.StatusOK, systemStatsResponse{
+		Batches:            stats.Batches,
+		Picks:              stats.Picks,
+		Checkpoints:        stats.Checkpoints,
+		SkippedCheckpoints: stats.SkippedCheckpoints,
+		SkippedRatio:       stats.SkippedRatio,
+		OldestRunDate:      stats.OldestRunDate,
+		NewestRunDate:      stats.NewestRunDate,
+		ScoredTargets:      stats.ScoredTargets,
+		TargetMeanAbsError: stats.TargetMeanAbsErrorPct,
+	})
+}
+
+// handleTickerStats reports the hit rate of every ticker ever picked.
+func (s *Server) handleTickerStats(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	stats, err := s.store.TickerStats(ctx)
+	if err != nil {
+		s.logger.Error("ticker stats failed", "error", err)
+		writeError(w, http.StatusInternalServerError, "internal", "unexpected error")
+		return
+	}
+
+	tickers := make([]tickerStatsResponse, 0, len(stats))
+	for _, entry := range stats {
+		tickers = append(tickers, tickerStatsResponse{
+			Ticker:                entry.Ticker,
+			Picks:                 entry.Picks,
+			ScoredPicks:           entry.ScoredPicks,
+			AverageVsBenchmarkPct: entry.AverageVsBenchmarkPct,
+			WinRate:               entry.WinRate,
+		})
+	}
+	writeJSON(w, r, http.StatusOK, tickerStatsListResponse{Tickers: tickers})
+}
+
+// handleRunStats lists per-batch workflow outcome counters, newest batch
+// first, paginated like /batches.
+func (s *Server) handleRunStats(w http.ResponseWriter, r *http.Request) {
+	limit, err := parseLimit(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_argument", err.Error())
+		return
+	}
+
+	cursor, err := parseBatchCursor(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_argument", err.Error())
+		return
+	}
+
+	includeTotal, err := parseIncludeTotal(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_argument", err.Error())
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	page, err := s.store.ListRunStats(ctx, limit, cursor)
+	if err != nil {
+		s.logger.Error("list run stats failed", "error", err)
+		writeError(w, http.StatusInternalServerError, "internal", "unexpected error")
+		return
+	}
+
+	resp := runStatsPageResponse{
+		Runs:       toRunStatsResponses(page.Runs),
+		NextCursor: encodeBatchCursor(page.NextCursor),
+	}
+	if includeTotal {
+		// Run stats page through every batch, like an unfiltered /batches.
+		total, err := s.store.CountBatches(ctx, db.BatchFilter{})
+		if err != nil {
+			s.logger.Error("count batches failed", "error", err)
+			writeError(w, http.StatusInternalServerError, "internal", "unexpected error")
+			return
+		}
+		resp.TotalCount = &total
+	}
+
+	writeJSON(w, r, http.StatusOK, resp)
+}
+
+func toRunStatsResponses(runs []db.BatchRunStats) []runStatsResponse {
+	resp := make([]runStatsResponse, 0, len(runs))
+	for _, run := range runs {
+		item := runStatsResponse{
+			BatchID: run.Batch.ID,
+			RunDate: run.Batch.RunDate,
+			Status:  run.Batch.Status,
+			Steps:   make([]runStepResponse, 0, len(run.Steps)),
+		}
+		for _, step := range run.Steps {
+			counts := runCountsResponse{Succeeded: step.Succeeded, Skipped: step.Skipped, Failed: step.Failed}
+			item.Steps = append(item.Steps, runStepResponse{
+				Step:              step.Step,
+				runCountsResponse: counts,
+				UpdatedAt:         step.UpdatedAt.UTC().Format(time.RFC3339),
+			})
+			item.Totals.Succeeded += counts.Succeeded
+			item.Totals.Skipped += counts.Skipped
+			item.Totals.Failed += counts.Failed
+		}
+		resp = append(resp, item)
+	}
+	return resp
+}
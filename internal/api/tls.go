@@ -0,0 +1,70 @@
+package api
+
+import (
+	"crypto/tls"
+	"net"
+	"net/http"
+
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// TLSConfig lets the API terminate HTTPS itself, for deployments without a
+// reverse proxy. Set either CertFile and KeyFile or AutocertDomains; the zero
+// value serves plain HTTP.
+type TLSConfig struct {
+	CertFile string
+	KeyFile  string
+	// AutocertDomains obtains and renews certificates for these hosts from
+	// Let's Encrypt. AutocertCacheDir keeps them across restarts, which
+	// Let's Encrypt's rate limits make mandatory.
+	AutocertDomains  []string
+	AutocertCacheDir string
+	AutocertEmail    string
+}
+
+// Enabled reports whether the API should serve HTTPS.
+func (c TLSConfig) Enabled() bool {
+	return c.CertFile != "" || len(c.AutocertDomains) > 0
+}
+
+// ConfigureTLS prepares server for ListenAndServeTLS(config.CertFile,
+// config.KeyFile); with autocert both are empty and certificates come from
+// the ACME manager, which also answers tls-alpn-01 challenges on the HTTPS
+// port. It returns the handler for an optional plain HTTP listener: it
+// redirects to HTTPS and, with autocert, answers http-01 challenges.
+func ConfigureTLS(server *http.Server, config TLSConfig) http.Handler {
+	redirect := httpsRedirect(server.Addr)
+	if len(config.AutocertDomains) == 0 {
+		server.TLSConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+		return redirect
+	}
+
+	manager := &autocert.Manager{
+		Prompt:     autocert.AcceptTOS,
+		HostPolicy: autocert.HostWhitelist(config.AutocertDomains...),
+		Cache:      autocert.DirCache(config.AutocertCacheDir),
+		Email:      config.AutocertEmail,
+	}
+	server.TLSConfig = manager.TLSConfig()
+	server.TLSConfig.MinVersion = tls.VersionTLS12
+	return manager.HTTPHandler(redirect)
+}
+
+// httpsRedirect permanently redirects to the same host and path on the HTTPS
+// listener at addr, keeping its port unless it is 443.
+func httpsRedirect(addr string) http.Handler {
+	_, port, err := net.SplitHostPort(addr)
+	if err != nil || port == "443" {
+		port = ""
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host := r.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		if port != "" {
+			host = net.JoinHostPort(host, port)
+		}
+		http.Redirect(w, r, "https://"+host+r.URL.RequestURI(), http.StatusMovedPermanently)
+	})
+}
@@ -0,0 +1,206 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/igor-kupczynski/alpha-monday/internal/decimal"
+)
+
+// The workflow IDs mirror worker.WeeklyPickWorkflowID and
+// worker.DailyCheckpointWorkflowID; the API does not import the worker
+// package.
+const (
+	weeklyPickWorkflowID      = "weekly_pick_v1"
+	dailyCheckpointWorkflowID = "daily_checkpoint_v1"
+)
+
+// WorkflowRunner enqueues workflow runs on the worker's engine and returns the
+// run id. worker.HatchetRunner implements it.
+type WorkflowRunner interface {
+	RunWorkflow(ctx context.Context, workflowID string, input any) (string, error)
+}
+
+// requireWorkflowRunner answers 501 when the API has no workflow runner. Only
+// the Hatchet engine is reachable from the API: standalone and Temporal
+// workers schedule their own runs.
+func (s *Server) requireWorkflowRunner(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if s.runner == nil {
+			writeError(w, http.StatusNotImplemented, "unimplemented", "workflow triggers need the hatchet engine; set HATCHET_CLIENT_TOKEN on the api")
+			return
+		}
+		next(w, r)
+	}
+}
+
+type triggerBatchRequest struct {
+	RunDate string `json:"run_date"`
+}
+
+// weeklyPickInput is the weekly_pick_v1 input (worker.WeeklyPickInput).
+type weeklyPickInput struct {
+	RunDate string `json:"run_date"`
+}
+
+// dailyCheckpointInput is the daily_checkpoint_v1 input
+// (worker.DailyCheckpointInput) for a recompute.
+type dailyCheckpointInput struct {
+	BatchID               string          `json:"batch_id"`
+	BenchmarkSymbol       string          `json:"benchmark_symbol"`
+	BenchmarkInitialPrice decimal.Decimal `json:"benchmark_initial_price"`
+	Picks                 []pickState     `json:"picks"`
+	ScheduledAt           string          `json:"scheduled_at"`
+	CheckpointDate        string          `json:"checkpoint_date"`
+}
+
+// pickState is worker.PickState.
+type pickState struct {
+	PickID       string          `json:"pick_id"`
+	Ticker       string          `json:"ticker"`
+	Action       string          `json:"action"`
+	Reasoning    string          `json:"reasoning"`
+	InitialPrice decimal.Decimal `json:"initial_price"`
+}
+
+type recomputeCheckpointResponse struct {
+	Workflow       string `json:"workflow"`
+	RunID          string `json:"run_id"`
+	BatchID        string `json:"batch_id"`
+	CheckpointDate string `json:"checkpoint_date"`
+}
+
+type triggerBatchResponse struct {
+	Workflow string `json:"workflow"`
+	RunID    string `json:"run_id"`
+	RunDate  string `json:"run_date"`
+}
+
+// handleTriggerBatch enqueues an on-demand weekly pick run. The run date
+// defaults to today (UTC); it may be in the past, but not the future, and must
+// not already have a batch.
+func (s *Server) handleTriggerBatch(w http.ResponseWriter, r *http.Request) {
+	var req triggerBatchRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 4<<10)).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		writeError(w, http.StatusBadRequest, "invalid_argument", "invalid request body")
+		return
+	}
+
+	today := time.Now().UTC().Format("2006-01-02")
+	runDate := today
+	if req.RunDate != "" {
+		if _, err := time.Parse("2006-01-02", req.RunDate); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid_argument", "run_date must be YYYY-MM-DD")
+			return
+		}
+		if req.RunDate > today {
+			writeError(w, http.StatusBadRequest, "invalid_argument", "run_date must not be in the future")
+			return
+		}
+		runDate = req.RunDate
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	existing, err := s.store.BatchIDForRunDate(ctx, runDate)
+	if err != nil {
+		s.logger.Error("batch lookup failed", "error", err)
+		writeError(w, http.StatusInternalServerError, "internal", "unexpected error")
+		return
+	}
+	if existing != nil {
+		writeError(w, http.StatusConflict, "already_exists", "batch "+*existing+" already exists for run_date "+runDate)
+		return
+	}
+
+	runID, err := s.runner.RunWorkflow(ctx, weeklyPickWorkflowID, weeklyPickInput{RunDate: runDate})
+	if err != nil {
+		s.logger.Error("trigger weekly pick failed", "error", err)
+		writeError(w, http.StatusServiceUnavailable, "unavailable", "failed to enqueue workflow")
+		return
+	}
+	s.logger.Info("weekly pick triggered", "run_id", runID, "run_date", runDate)
+	writeJSON(w, r, http.StatusAccepted, triggerBatchResponse{Workflow: weeklyPickWorkflowID, RunID: runID, RunDate: runDate})
+}
+
+// handleRecomputeCheckpoint enqueues a daily_checkpoint_v1 run that
+// recomputes a skipped checkpoint from the closes of its date.
+func (s *Server) handleRecomputeCheckpoint(w http.ResponseWriter, r *http.Request) {
+	batchID := chi.URLParam(r, "id")
+	if _, err := uuid.Parse(batchID); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_argument", "invalid batch id")
+		return
+	}
+	checkpointDate := chi.URLParam(r, "date")
+	if _, err := time.Parse("2006-01-02", checkpointDate); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_argument", errInvalidCheckpointDate.Error())
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	details, err := s.store.BatchDetails(ctx, batchID)
+	if err != nil {
+		s.logger.Error("batch details query failed", "error", err)
+		writeError(w, http.StatusInternalServerError, "internal", "unexpected error")
+		return
+	}
+	if details == nil {
+		writeError(w, http.StatusNotFound, "not_found", "batch not found")
+		return
+	}
+	checkpoint, err := s.store.GetCheckpointByDate(ctx, batchID, checkpointDate)
+	if err != nil {
+		s.logger.Error("get checkpoint failed", "error", err)
+		writeError(w, http.StatusInternalServerError, "internal", "unexpected error")
+		return
+	}
+	if checkpoint == nil {
+		writeError(w, http.StatusNotFound, "not_found", "checkpoint not found")
+		return
+	}
+	if checkpoint.Status != "skipped" {
+		writeError(w, http.StatusConflict, "failed_precondition", "only skipped checkpoints can be recomputed")
+		return
+	}
+
+	input := dailyCheckpointInput{
+		BatchID:               batchID,
+		BenchmarkSymbol:       details.Batch.BenchmarkSymbol,
+		BenchmarkInitialPrice: details.Batch.BenchmarkInitialPrice,
+		Picks:                 make([]pickState, 0, len(details.Picks)),
+		ScheduledAt:           time.Now().UTC().Format(time.RFC3339),
+		CheckpointDate:        checkpointDate,
+	}
+	for _, pick := range details.Picks {
+		input.Picks = append(input.Picks, pickState{
+			PickID:       pick.ID,
+			Ticker:       pick.Ticker,
+			Action:       pick.Action,
+			Reasoning:    pick.Reasoning,
+			InitialPrice: pick.InitialPrice,
+		})
+	}
+
+	runID, err := s.runner.RunWorkflow(ctx, dailyCheckpointWorkflowID, input)
+	if err != nil {
+		s.logger.Error("trigger checkpoint recompute failed", "error", err)
+		writeError(w, http.StatusServiceUnavailable, "unavailable", "failed to enqueue workflow")
+		return
+	}
+	s.logger.Info("checkpoint recompute triggered", "run_id", runID, "batch_id", batchID, "checkpoint_date", checkpointDate)
+	writeJSON(w, r, http.StatusAccepted, recomputeCheckpointResponse{
+		Workflow:       dailyCheckpointWorkflowID,
+		RunID:          runID,
+		BatchID:        batchID,
+		CheckpointDate: checkpointDate,
+	})
+}
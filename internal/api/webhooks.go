@@ -0,0 +1,141 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/igor-kupczynski/alpha-monday/internal/db"
+)
+
+type createWebhookRequest struct {
+	URL        string   `json:"url"`
+	EventTypes []string `json:"event_types"`
+}
+
+type webhookResponse struct {
+	ID         string    `json:"id"`
+	URL        string    `json:"url"`
+	EventTypes []string  `json:"event_types"`
+	CreatedAt  time.Time `json:"created_at"`
+	// Secret is only set in the creation response.
+	Secret string `json:"secret,omitempty"`
+}
+
+type webhooksResponse struct {
+	Items []webhookResponse `json:"items"`
+}
+
+func toWebhookResponse(subscription db.WebhookSubscription) webhookResponse {
+	return webhookResponse{
+		ID:         subscription.ID,
+		URL:        subscription.URL,
+		EventTypes: subscription.EventTypes,
+		CreatedAt:  subscription.CreatedAt.UTC(),
+	}
+}
+
+// parseWebhookEventTypes validates the requested event types; none means all
+// supported types. Duplicates are dropped.
+func parseWebhookEventTypes(requested []string) ([]string, bool) {
+	if len(requested) == 0 {
+		return slices.Clone(db.WebhookEventTypes), true
+	}
+	eventTypes := make([]string, 0, len(requested))
+	for _, eventType := range requested {
+		if !slices.Contains(db.WebhookEventTypes, eventType) {
+			return nil, false
+		}
+		if !slices.Contains(eventTypes, eventType) {
+			eventTypes = append(eventTypes, eventType)
+		}
+	}
+	return eventTypes, true
+}
+
+func validWebhookURL(raw string) bool {
+	parsed, err := url.Parse(raw)
+	if err != nil || parsed.Host == "" {
+		return false
+	}
+	return parsed.Scheme == "https" || parsed.Scheme == "http"
+}
+
+func (s *Server) handleCreateWebhook(w http.ResponseWriter, r *http.Request) {
+	var req createWebhookRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 4<<10)).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_argument", "invalid request body")
+		return
+	}
+	req.URL = strings.TrimSpace(req.URL)
+	if !validWebhookURL(req.URL) {
+		writeError(w, http.StatusBadRequest, "invalid_argument", "url must be an absolute http or https URL")
+		return
+	}
+	eventTypes, ok := parseWebhookEventTypes(req.EventTypes)
+	if !ok {
+		writeError(w, http.StatusBadRequest, "invalid_argument", "event_types must be any of "+strings.Join(db.WebhookEventTypes, ", "))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	created, err := s.store.CreateWebhookSubscription(ctx, req.URL, eventTypes)
+	if err != nil {
+		s.logger.Error("create webhook failed", "error", err)
+		writeError(w, http.StatusInternalServerError, "internal", "unexpected error")
+		return
+	}
+
+	resp := toWebhookResponse(*created)
+	resp.Secret = created.Secret
+	writeJSON(w, r, http.StatusCreated, resp)
+}
+
+func (s *Server) handleListWebhooks(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	subscriptions, err := s.store.ListWebhookSubscriptions(ctx)
+	if err != nil {
+		s.logger.Error("list webhooks failed", "error", err)
+		writeError(w, http.StatusInternalServerError, "internal", "unexpected error")
+		return
+	}
+
+	items := make([]webhookResponse, 0, len(subscriptions))
+	for _, subscription := range subscriptions {
+		items = append(items, toWebhookResponse(subscription))
+	}
+	writeJSON(w, r, http.StatusOK, webhooksResponse{Items: items})
+}
+
+func (s *Server) handleRevokeWebhook(w http.ResponseWriter, r *http.Request) {
+	webhookID := chi.URLParam(r, "webhookID")
+	if _, err := uuid.Parse(webhookID); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_argument", "invalid webhook id")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	revoked, err := s.store.RevokeWebhookSubscription(ctx, webhookID)
+	if err != nil {
+		s.logger.Error("revoke webhook failed", "error", err)
+		writeError(w, http.StatusInternalServerError, "internal", "unexpected error")
+		return
+	}
+	if !revoked {
+		writeError(w, http.StatusNotFound, "not_found", "webhook not found")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
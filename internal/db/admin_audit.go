@@ -0,0 +1,96 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// AdminAuditEntry records one administrative mutation.
+type AdminAuditEntry struct {
+	ID         string
+	CreatedAt  time.Time
+	Actor      string
+	Action     string
+	Target     *string
+	Params     json.RawMessage
+	RemoteAddr *string
+	RequestID  *string
+}
+
+type AdminAuditPage struct {
+	Entries    []AdminAuditEntry
+	NextCursor *string
+}
+
+// RecordAdminAction appends entry to the audit log. ID and CreatedAt are
+// assigned by the store; a nil Params is stored as an empty object.
+func (s *Store) RecordAdminAction(ctx context.Context, entry AdminAuditEntry) (err error) {
+	defer s.observe("RecordAdminAction", time.Now(), &err)
+
+	params := entry.Params
+	if len(params) == 0 {
+		params = json.RawMessage(`{}`)
+	}
+	return s.withWriteRetry(ctx, func(ctx context.Context) error {
+		_, err := s.pool.Exec(ctx, `
+            INSERT INTO admin_audit (id, actor, action, target, params, remote_addr, request_id)
+            VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+			uuid.New(), entry.Actor, entry.Action, entry.Target, params, entry.RemoteAddr, entry.RequestID,
+		)
+		return err
+	})
+}
+
+// ListAdminAudit returns audit entries newest first. cursor is the ID of the
+// last entry of the previous page.
+func (s *Store) ListAdminAudit(ctx context.Context, limit int, cursor *string) (_ AdminAuditPage, err error) {
+	defer s.observe("ListAdminAudit", time.Now(), &err)
+
+	const listSQL = `
+        SELECT id::text, created_at, actor, action, target, params, remote_addr, request_id
+        FROM admin_audit
+        ORDER BY created_at DESC, id DESC
+        LIMIT $1`
+	const listCursorSQL = `
+        SELECT a.id::text, a.created_at, a.actor, a.action, a.target, a.params, a.remote_addr, a.request_id
+        FROM admin_audit a, admin_audit c
+        WHERE c.id = $1 AND (a.created_at, a.id) < (c.created_at, c.id)
+        ORDER BY a.created_at DESC, a.id DESC
+        LIMIT $2`
+
+	queryLimit := limit + 1
+	var rows pgx.Rows
+	if cursor != nil {
+		rows, err = s.pool.Query(ctx, listCursorSQL, *cursor, queryLimit)
+	} else {
+		rows, err = s.pool.Query(ctx, listSQL, queryLimit)
+	}
+	if err != nil {
+		return AdminAuditPage{}, err
+	}
+	defer rows.Close()
+
+	entries := make([]AdminAuditEntry, 0, limit)
+	for rows.Next() {
+		var entry AdminAuditEntry
+		if err := rows.Scan(&entry.ID, &entry.CreatedAt, &entry.Actor, &entry.Action, &entry.Target, &entry.Params, &entry.RemoteAddr, &entry.RequestID); err != nil {
+			return AdminAuditPage{}, err
+		}
+		entries = append(entries, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return AdminAuditPage{}, err
+	}
+
+	var nextCursor *string
+	if len(entries) > limit {
+		last := entries[limit-1].ID
+		nextCursor = &last
+		entries = entries[:limit]
+	}
+	return AdminAuditPage{Entries: entries, NextCursor: nextCursor}, nil
+}
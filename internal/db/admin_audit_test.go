@@ -0,0 +1,58 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestAdminAuditLog(t *testing.T) {
+	truncateTables(t)
+
+	store := NewStore(testPool)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	target := "aaaaaaaa-aaaa-aaaa-aaaa-aaaaaaaaaaaa"
+	for _, action := range []string{"create_share_token", "revoke_share_token", "create_api_key"} {
+		err := store.RecordAdminAction(ctx, AdminAuditEntry{
+			Actor:  "ops",
+			Action: action,
+			Target: &target,
+			Params: json.RawMessage(`{"ttl_hours":24}`),
+		})
+		if err != nil {
+			t.Fatalf("record %s: %v", action, err)
+		}
+	}
+	if err := store.RecordAdminAction(ctx, AdminAuditEntry{Actor: "ops", Action: "revoke_api_key"}); err != nil {
+		t.Fatalf("record without params: %v", err)
+	}
+
+	first, err := store.ListAdminAudit(ctx, 3, nil)
+	if err != nil {
+		t.Fatalf("list audit: %v", err)
+	}
+	if len(first.Entries) != 3 || first.NextCursor == nil {
+		t.Fatalf("expected a full first page with a cursor, got %d entries", len(first.Entries))
+	}
+	if first.Entries[0].Action != "revoke_api_key" || string(first.Entries[0].Params) != "{}" {
+		t.Fatalf("expected newest entry first with empty params, got %+v", first.Entries[0])
+	}
+
+	second, err := store.ListAdminAudit(ctx, 3, first.NextCursor)
+	if err != nil {
+		t.Fatalf("list audit page 2: %v", err)
+	}
+	if len(second.Entries) != 1 || second.NextCursor != nil || second.Entries[0].Action != "create_share_token" {
+		t.Fatalf("unexpected second page: %+v", second)
+	}
+	var params map[string]int
+	if err := json.Unmarshal(second.Entries[0].Params, &params); err != nil || params["ttl_hours"] != 24 {
+		t.Fatalf("unexpected params %s (%v)", second.Entries[0].Params, err)
+	}
+	if second.Entries[0].Target == nil || *second.Entries[0].Target != target {
+		t.Fatalf("expected target %s, got %v", target, second.Entries[0].Target)
+	}
+}
@@ -0,0 +1,144 @@
+package db
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// apiKeyPrefix marks API keys so they are recognisable in logs and secret
+// scanners.
+const apiKeyPrefix = "amak_"
+
+// APIKey identifies a third-party API client and its request quotas. Only a
+// hash of the key is stored; the plaintext is returned once, on creation.
+type APIKey struct {
+	ID           string
+	Name         string
+	CreatedAt    time.Time
+	DailyQuota   int
+	MonthlyQuota int
+}
+
+// QuotaUsage is the state of a key's quotas after a request was counted (or
+// rejected). Days and months are UTC.
+type QuotaUsage struct {
+	KeyID        string
+	DailyLimit   int
+	DailyUsed    int
+	MonthlyLimit int
+	MonthlyUsed  int
+	Allowed      bool
+}
+
+// CreateAPIKey issues a key with the given daily and monthly request quotas.
+func (s *Store) CreateAPIKey(ctx context.Context, name string, dailyQuota, monthlyQuota int) (_ *APIKey, _ string, err error) {
+	defer s.observe("CreateAPIKey", time.Now(), &err)
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, "", err
+	}
+	key := apiKeyPrefix + base64.RawURLEncoding.EncodeToString(secret)
+
+	var created APIKey
+	err = s.withWriteRetry(ctx, func(ctx context.Context) error {
+		return s.pool.QueryRow(ctx, `
+            INSERT INTO api_keys (id, name, key_hash, daily_quota, monthly_quota)
+            VALUES ($1, $2, $3, $4, $5)
+            RETURNING id::text, name, created_at, daily_quota, monthly_quota`,
+			uuid.New(), name, hashShareToken(key), dailyQuota, monthlyQuota,
+		).Scan(&created.ID, &created.Name, &created.CreatedAt, &created.DailyQuota, &created.MonthlyQuota)
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	return &created, key, nil
+}
+
+// RevokeAPIKey revokes a key by ID and reports whether an active key was
+// revoked.
+func (s *Store) RevokeAPIKey(ctx context.Context, id string) (_ bool, err error) {
+	defer s.observe("RevokeAPIKey", time.Now(), &err)
+
+	var revoked bool
+	err = s.withWriteRetry(ctx, func(ctx context.Context) error {
+		tag, err := s.pool.Exec(ctx, `
+            UPDATE api_keys
+            SET revoked_at = now()
+            WHERE id = $1 AND revoked_at IS NULL`, id)
+		if err != nil {
+			return err
+		}
+		revoked = tag.RowsAffected() > 0
+		return nil
+	})
+	return revoked, err
+}
+
+// ConsumeAPIKeyQuota counts one request against key at now. The request is
+// counted only when both the daily and monthly quotas have room; otherwise
+// the returned usage has Allowed false. It returns nil for an unknown or
+// revoked key. Requests for the same key are serialized on the key row, so
+// concurrent requests cannot overshoot a quota.
+func (s *Store) ConsumeAPIKeyQuota(ctx context.Context, key string, now time.Time) (_ *QuotaUsage, err error) {
+	defer s.observe("ConsumeAPIKeyQuota", time.Now(), &err)
+
+	now = now.UTC()
+	day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+
+	var usage *QuotaUsage
+	err = s.withWriteRetry(ctx, func(ctx context.Context) error {
+		usage = nil
+		return pgx.BeginFunc(ctx, s.pool, func(tx pgx.Tx) error {
+			var row QuotaUsage
+			err := tx.QueryRow(ctx, `
+                SELECT id::text, daily_quota, monthly_quota
+                FROM api_keys
+                WHERE key_hash = $1 AND revoked_at IS NULL
+                FOR UPDATE`, hashShareToken(key),
+			).Scan(&row.KeyID, &row.DailyLimit, &row.MonthlyLimit)
+			if err == pgx.ErrNoRows {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+
+			err = tx.QueryRow(ctx, `
+                SELECT
+                  COALESCE(SUM(request_count) FILTER (WHERE usage_date = $2), 0)::int,
+                  COALESCE(SUM(request_count), 0)::int
+                FROM api_key_usage
+                WHERE api_key_id = $1 AND usage_date BETWEEN $3 AND $2`,
+				row.KeyID, day, monthStart,
+			).Scan(&row.DailyUsed, &row.MonthlyUsed)
+			if err != nil {
+				return err
+			}
+
+			row.Allowed = row.DailyUsed < row.DailyLimit && row.MonthlyUsed < row.MonthlyLimit
+			if row.Allowed {
+				_, err = tx.Exec(ctx, `
+                    INSERT INTO api_key_usage (api_key_id, usage_date, request_count)
+                    VALUES ($1, $2, 1)
+                    ON CONFLICT (api_key_id, usage_date)
+                    DO UPDATE SET request_count = api_key_usage.request_count + 1`,
+					row.KeyID, day)
+				if err != nil {
+					return err
+				}
+				row.DailyUsed++
+				row.MonthlyUsed++
+			}
+			usage = &row
+			return nil
+		})
+	})
+	return usage, err
+}
@@ -0,0 +1,72 @@
+package db
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAPIKeyQuota(t *testing.T) {
+	truncateTables(t)
+
+	store := NewStore(testPool)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	created, key, err := store.CreateAPIKey(ctx, "partner", 2, 3)
+	if err != nil {
+		t.Fatalf("create key: %v", err)
+	}
+	if !strings.HasPrefix(key, apiKeyPrefix) || created.DailyQuota != 2 || created.MonthlyQuota != 3 {
+		t.Fatalf("unexpected key %q (%+v)", key, created)
+	}
+
+	day1 := time.Date(2026, 3, 30, 12, 0, 0, 0, time.UTC)
+	day2 := day1.AddDate(0, 0, 1)
+	nextMonth := day1.AddDate(0, 0, 2)
+
+	steps := []struct {
+		now         time.Time
+		allowed     bool
+		dailyUsed   int
+		monthlyUsed int
+	}{
+		{now: day1, allowed: true, dailyUsed: 1, monthlyUsed: 1},
+		{now: day1, allowed: true, dailyUsed: 2, monthlyUsed: 2},
+		{now: day1, allowed: false, dailyUsed: 2, monthlyUsed: 2},
+		{now: day2, allowed: true, dailyUsed: 1, monthlyUsed: 3},
+		{now: day2, allowed: false, dailyUsed: 1, monthlyUsed: 3},
+		{now: nextMonth, allowed: true, dailyUsed: 1, monthlyUsed: 1},
+	}
+	for i, step := range steps {
+		usage, err := store.ConsumeAPIKeyQuota(ctx, key, step.now)
+		if err != nil {
+			t.Fatalf("step %d: consume: %v", i, err)
+		}
+		if usage == nil || usage.KeyID != created.ID {
+			t.Fatalf("step %d: unexpected usage %+v", i, usage)
+		}
+		if usage.Allowed != step.allowed || usage.DailyUsed != step.dailyUsed || usage.MonthlyUsed != step.monthlyUsed {
+			t.Fatalf("step %d: expected allowed=%v daily=%d monthly=%d, got %+v", i, step.allowed, step.dailyUsed, step.monthlyUsed, usage)
+		}
+	}
+
+	unknown, err := store.ConsumeAPIKeyQuota(ctx, apiKeyPrefix+"unknown", day1)
+	if err != nil || unknown != nil {
+		t.Fatalf("expected nil usage for unknown key, got %+v (%v)", unknown, err)
+	}
+
+	revoked, err := store.RevokeAPIKey(ctx, created.ID)
+	if err != nil || !revoked {
+		t.Fatalf("revoke key: %v (revoked=%v)", err, revoked)
+	}
+	usage, err := store.ConsumeAPIKeyQuota(ctx, key, nextMonth)
+	if err != nil || usage != nil {
+		t.Fatalf("expected nil usage for revoked key, got %+v (%v)", usage, err)
+	}
+	revoked, err = store.RevokeAPIKey(ctx, created.ID)
+	if err != nil || revoked {
+		t.Fatalf("expected second revoke to be a no-op: %v (revoked=%v)", err, revoked)
+	}
+}
@@ -0,0 +1,31 @@
+package db
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// EventAPIPanic is enqueued when the API recovers from a handler panic, so the
+// outbox sinks can alert on it.
+const EventAPIPanic = "api_panic"
+
+type APIPanicPayload struct {
+	RequestID string `json:"request_id,omitempty"`
+	Method    string `json:"method"`
+	Route     string `json:"route"`
+	Error     string `json:"error"`
+	Stack     string `json:"stack,omitempty"`
+}
+
+// RecordAPIPanic enqueues an api_panic outbox event.
+func (s *Store) RecordAPIPanic(ctx context.Context, payload APIPanicPayload) (err error) {
+	defer s.observe("RecordAPIPanic", time.Now(), &err)
+
+	return s.withWriteRetry(ctx, func(ctx context.Context) error {
+		return pgx.BeginFunc(ctx, s.pool, func(tx pgx.Tx) error {
+			return enqueueOutboxEvent(ctx, tx, EventAPIPanic, "", payload)
+		})
+	})
+}
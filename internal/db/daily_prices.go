@@ -0,0 +1,105 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/igor-kupczynski/alpha-monday/internal/decimal"
+	"github.com/jackc/pgx/v5"
+)
+
+// DailyPrice is one trading day of a ticker's price history. Open, High, Low
+// and Volume are nil when the provider did not report them.
+type DailyPrice struct {
+	Ticker     string
+	TradingDay time.Time
+	Close      decimal.Decimal
+	Open       *decimal.Decimal
+	High       *decimal.Decimal
+	Low        *decimal.Decimal
+	Volume     *int64
+}
+
+// UpsertDailyPrices stores prices, replacing any existing row for the same
+// ticker and trading day.
+func (s *Store) UpsertDailyPrices(ctx context.Context, prices []DailyPrice) (err error) {
+	defer s.observe("UpsertDailyPrices", time.Now(), &err)
+
+	if len(prices) == 0 {
+		return nil
+	}
+	return s.withWriteRetry(ctx, func(ctx context.Context) error {
+		return pgx.BeginFunc(ctx, s.pool, func(tx pgx.Tx) error {
+			return upsertDailyPrices(ctx, tx, prices)
+		})
+	})
+}
+
+func upsertDailyPrices(ctx context.Context, tx pgx.Tx, prices []DailyPrice) error {
+	for _, price := range prices {
+		_, err := tx.Exec(ctx, `
+            INSERT INTO daily_prices (ticker, trading_day, close_price, open_price, high_price, low_price, volume)
+            VALUES ($1, $2, $3, $4, $5, $6, $7)
+            ON CONFLICT (ticker, trading_day) DO UPDATE
+            SET close_price = EXCLUDED.close_price,
+                open_price = EXCLUDED.open_price,
+                high_price = EXCLUDED.high_price,
+                low_price = EXCLUDED.low_price,
+                volume = EXCLUDED.volume,
+                updated_at = now()`,
+			price.Ticker,
+			price.TradingDay,
+			price.Close,
+			price.Open,
+			price.High,
+			price.Low,
+			price.Volume,
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DailyPrices returns the stored history of ticker between from and to
+// (inclusive), oldest first.
+func (s *Store) DailyPrices(ctx context.Context, ticker string, from, to time.Time) (_ []DailyPrice, err error) {
+	defer s.observe("DailyPrices", time.Now(), &err)
+
+	rows, err := s.pool.Query(ctx, `
+        SELECT ticker, trading_day, close_price::text, open_price::text, high_price::text, low_price::text, volume
+        FROM daily_prices
+        WHERE ticker = $1 AND trading_day BETWEEN $2 AND $3
+        ORDER BY trading_day`,
+		ticker, from, to,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	prices := []DailyPrice{}
+	for rows.Next() {
+		var price DailyPrice
+		var open, high, low sql.NullString
+		if err := rows.Scan(&price.Ticker, &price.TradingDay, &price.Close, &open, &high, &low, &price.Volume); err != nil {
+			return nil, err
+		}
+		if price.Open, err = nullDecimalPtr(open); err != nil {
+			return nil, err
+		}
+		if price.High, err = nullDecimalPtr(high); err != nil {
+			return nil, err
+		}
+		if price.Low, err = nullDecimalPtr(low); err != nil {
+			return nil, err
+		}
+		prices = append(prices, price)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return prices, nil
+}
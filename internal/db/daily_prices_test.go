@@ -0,0 +1,48 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/igor-kupczynski/alpha-monday/internal/decimal"
+)
+
+func TestUpsertDailyPrices(t *testing.T) {
+	truncateTables(t)
+
+	store := NewStore(testPool)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	open := decimal.MustParse("190.10")
+	volume := int64(51234567)
+	day := time.Date(2026, 1, 30, 0, 0, 0, 0, time.UTC)
+	if err := store.UpsertDailyPrices(ctx, []DailyPrice{
+		{Ticker: "AAPL", TradingDay: day.AddDate(0, 0, -1), Close: decimal.MustParse("189.90")},
+		{Ticker: "AAPL", TradingDay: day, Close: decimal.MustParse("190.00")},
+		{Ticker: "SPY", TradingDay: day, Close: decimal.MustParse("401.25")},
+	}); err != nil {
+		t.Fatalf("upsert prices: %v", err)
+	}
+	if err := store.UpsertDailyPrices(ctx, []DailyPrice{
+		{Ticker: "AAPL", TradingDay: day, Close: decimal.MustParse("191.00"), Open: &open, Volume: &volume},
+	}); err != nil {
+		t.Fatalf("upsert prices: %v", err)
+	}
+
+	prices, err := store.DailyPrices(ctx, "AAPL", day.AddDate(0, 0, -7), day)
+	if err != nil {
+		t.Fatalf("daily prices: %v", err)
+	}
+	if len(prices) != 2 {
+		t.Fatalf("expected 2 prices, got %d", len(prices))
+	}
+	if !prices[0].Close.Equal(decimal.MustParse("189.90")) || prices[0].Open != nil || prices[0].Volume != nil {
+		t.Fatalf("unexpected first price: %+v", prices[0])
+	}
+	latest := prices[1]
+	if !latest.TradingDay.Equal(day) || !latest.Close.Equal(decimal.MustParse("191.00")) || latest.Open == nil || !latest.Open.Equal(open) || latest.Volume == nil || *latest.Volume != volume {
+		t.Fatalf("unexpected replaced price: %+v", latest)
+	}
+}
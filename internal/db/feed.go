@@ -0,0 +1,55 @@
+package db
+
+import (
+	"context"
+	"time"
+)
+
+// FeedEntry is a batch with its picks, as published in the Atom feed.
+type FeedEntry struct {
+	Batch     Batch
+	Picks     []Pick
+	CreatedAt time.Time
+}
+
+// RecentBatchesWithPicks returns the newest limit batches with their picks,
+// newest run_date first.
+func (s *Store) RecentBatchesWithPicks(ctx context.Context, limit int) (_ []FeedEntry, err error) {
+	defer s.observe("RecentBatchesWithPicks", time.Now(), &err)
+
+	rows, err := s.pool.Query(ctx, `
+        SELECT b.id::text, b.run_date::text, b.status, b.benchmark_symbol, b.benchmark_initial_price::text, b.config_hash,
+               b.created_at,
+               COALESCE((
+                   SELECT json_agg(`+pickJSONSQL+` ORDER BY p.ticker)
+                   FROM picks p
+                   WHERE p.batch_id = b.id
+               ), '[]'::json)
+        FROM batches b
+        WHERE b.deleted_at IS NULL
+        ORDER BY b.run_date DESC, b.id DESC
+        LIMIT $1`, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []FeedEntry{}
+	for rows.Next() {
+		var entry FeedEntry
+		var picksJSON []byte
+		if err := rows.Scan(&entry.Batch.ID, &entry.Batch.RunDate, &entry.Batch.Status, &entry.Batch.BenchmarkSymbol, &entry.Batch.BenchmarkInitialPrice, &entry.Batch.ConfigHash, &entry.CreatedAt, &picksJSON); err != nil {
+			return nil, err
+		}
+		picks, err := decodePicksJSON(picksJSON)
+		if err != nil {
+			return nil, err
+		}
+		entry.Picks = picks
+		entries = append(entries, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
@@ -0,0 +1,141 @@
+package db
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/igor-kupczynski/alpha-monday/internal/decimal"
+)
+
+// The JSON row types mirror the json_build_object shapes produced by the
+// aggregate queries; numerics are cast to text in SQL to keep full precision.
+
+// pickJSONSQL builds one pick object from alias p.
+const pickJSONSQL = `json_build_object(
+        'id', p.id::text,
+        'ticker', p.ticker,
+        'action', p.action,
+        'reasoning', p.reasoning,
+        'initial_price', p.initial_price::text,
+        'target_price', p.target_price::text,
+        'target_error_pct', p.target_error_pct::text
+    )`
+
+// metricJSONSQL builds one pick metric object from alias m.
+const metricJSONSQL = `json_build_object(
+        'id', m.id::text,
+        'pick_id', m.pick_id::text,
+        'current_price', m.current_price::text,
+        'absolute_return_pct', m.absolute_return_pct::text,
+        'vs_benchmark_pct', m.vs_benchmark_pct::text,
+        'open_price', m.open_price::text,
+        'high_price', m.high_price::text,
+        'low_price', m.low_price::text,
+        'volume', m.volume
+    )`
+
+// checkpointJSONSQL builds one checkpoint object, with its metrics ordered by pick, from alias c.
+const checkpointJSONSQL = `json_build_object(
+        'id', c.id::text,
+        'checkpoint_date', c.checkpoint_date::text,
+        'status', c.status,
+        'benchmark_price', c.benchmark_price::text,
+        'benchmark_return_pct', c.benchmark_return_pct::text,
+        'created_at', c.created_at,
+        'metrics', COALESCE((
+            SELECT json_agg(` + metricJSONSQL + ` ORDER BY m.pick_id)
+            FROM pick_checkpoint_metrics m
+            WHERE m.checkpoint_id = c.id
+        ), '[]'::json)
+    )`
+
+// checkpointSummaryJSONSQL is checkpointJSONSQL without the metrics.
+const checkpointSummaryJSONSQL = `json_build_object(
+        'id', c.id::text,
+        'checkpoint_date', c.checkpoint_date::text,
+        'status', c.status,
+        'benchmark_price', c.benchmark_price::text,
+        'benchmark_return_pct', c.benchmark_return_pct::text,
+        'created_at', c.created_at
+    )`
+
+type pickJSON struct {
+	ID             string           `json:"id"`
+	Ticker         string           `json:"ticker"`
+	Action         string           `json:"action"`
+	Reasoning      string           `json:"reasoning"`
+	InitialPrice   decimal.Decimal  `json:"initial_price"`
+	TargetPrice    *decimal.Decimal `json:"target_price"`
+	TargetErrorPct *decimal.Decimal `json:"target_error_pct"`
+}
+
+type metricJSON struct {
+	ID                string           `json:"id"`
+	PickID            string           `json:"pick_id"`
+	CurrentPrice      decimal.Decimal  `json:"current_price"`
+	AbsoluteReturnPct decimal.Decimal  `json:"absolute_return_pct"`
+	VsBenchmarkPct    decimal.Decimal  `json:"vs_benchmark_pct"`
+	OpenPrice         *decimal.Decimal `json:"open_price"`
+	HighPrice         *decimal.Decimal `json:"high_price"`
+	LowPrice          *decimal.Decimal `json:"low_price"`
+	Volume            *int64           `json:"volume"`
+}
+
+type checkpointJSON struct {
+	ID                 string           `json:"id"`
+	CheckpointDate     string           `json:"checkpoint_date"`
+	Status             string           `json:"status"`
+	BenchmarkPrice     *decimal.Decimal `json:"benchmark_price"`
+	BenchmarkReturnPct *decimal.Decimal `json:"benchmark_return_pct"`
+	Metrics            []metricJSON     `json:"metrics"`
+	CreatedAt          time.Time        `json:"created_at"`
+}
+
+func decodePicksJSON(data []byte) ([]Pick, error) {
+	var rows []pickJSON
+	if err := json.Unmarshal(data, &rows); err != nil {
+		return nil, fmt.Errorf("decode picks: %w", err)
+	}
+	picks := make([]Pick, 0, len(rows))
+	for _, row := range rows {
+		picks = append(picks, Pick(row))
+	}
+	return picks, nil
+}
+
+func decodeCheckpointsJSON(data []byte) ([]Checkpoint, error) {
+	var rows []checkpointJSON
+	if err := json.Unmarshal(data, &rows); err != nil {
+		return nil, fmt.Errorf("decode checkpoints: %w", err)
+	}
+	checkpoints := make([]Checkpoint, 0, len(rows))
+	for _, row := range rows {
+		checkpoints = append(checkpoints, row.toCheckpoint())
+	}
+	return checkpoints, nil
+}
+
+func decodeCheckpointJSON(data []byte) (Checkpoint, error) {
+	var row checkpointJSON
+	if err := json.Unmarshal(data, &row); err != nil {
+		return Checkpoint{}, fmt.Errorf("decode checkpoint: %w", err)
+	}
+	return row.toCheckpoint(), nil
+}
+
+func (row checkpointJSON) toCheckpoint() Checkpoint {
+	metrics := make([]PickMetric, 0, len(row.Metrics))
+	for _, metric := range row.Metrics {
+		metrics = append(metrics, PickMetric(metric))
+	}
+	return Checkpoint{
+		ID:                 row.ID,
+		CheckpointDate:     row.CheckpointDate,
+		Status:             row.Status,
+		BenchmarkPrice:     row.BenchmarkPrice,
+		BenchmarkReturnPct: row.BenchmarkReturnPct,
+		Metrics:            metrics,
+		CreatedAt:          row.CreatedAt,
+	}
+}
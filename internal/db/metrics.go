@@ -0,0 +1,118 @@
+package db
+
+import (
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// QueryMetrics records per-method Store query counts, outcomes and durations so
+// database regressions can be attributed to a specific access path.
+type QueryMetrics struct {
+	queries  *prometheus.CounterVec
+	duration *prometheus.HistogramVec
+}
+
+// NewQueryMetrics creates the Store collectors and registers them with registerer.
+func NewQueryMetrics(registerer prometheus.Registerer) (*QueryMetrics, error) {
+	metrics := &QueryMetrics{
+		queries: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "alpha_monday",
+			Subsystem: "db",
+			Name:      "queries_total",
+			Help:      "Store method calls by method and outcome (ok, error).",
+		}, []string{"method", "outcome"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: "alpha_monday",
+			Subsystem: "db",
+			Name:      "query_duration_seconds",
+			Help:      "Store method latency in seconds.",
+			Buckets:   []float64{0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5},
+		}, []string{"method"}),
+	}
+	if registerer != nil {
+		if err := registerer.Register(metrics.queries); err != nil {
+			return nil, err
+		}
+		if err := registerer.Register(metrics.duration); err != nil {
+			return nil, err
+		}
+	}
+	return metrics, nil
+}
+
+func (m *QueryMetrics) observe(method string, duration time.Duration, outcome string) {
+	if m == nil {
+		return
+	}
+	m.queries.WithLabelValues(method, outcome).Inc()
+	m.duration.WithLabelValues(method).Observe(duration.Seconds())
+}
+
+// poolCollector exports pgxpool statistics, read from the pool at scrape time.
+type poolCollector struct {
+	pool *pgxpool.Pool
+
+	acquiredConns       *prometheus.Desc
+	idleConns           *prometheus.Desc
+	constructingConns   *prometheus.Desc
+	totalConns          *prometheus.Desc
+	maxConns            *prometheus.Desc
+	acquires            *prometheus.Desc
+	emptyAcquires       *prometheus.Desc
+	canceledAcquires    *prometheus.Desc
+	acquireDuration     *prometheus.Desc
+	newConns            *prometheus.Desc
+	maxLifetimeDestroys *prometheus.Desc
+	maxIdleTimeDestroys *prometheus.Desc
+}
+
+// RegisterPoolMetrics registers collectors for pool's connection and acquire
+// statistics (alpha_monday_db_pool_*) with registerer.
+func RegisterPoolMetrics(registerer prometheus.Registerer, pool *pgxpool.Pool) error {
+	desc := func(name, help string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName("alpha_monday", "db_pool", name), help, nil, nil)
+	}
+	return registerer.Register(&poolCollector{
+		pool:                pool,
+		acquiredConns:       desc("acquired_conns", "Connections currently acquired from the pool."),
+		idleConns:           desc("idle_conns", "Idle connections in the pool."),
+		constructingConns:   desc("constructing_conns", "Connections being established."),
+		totalConns:          desc("total_conns", "Connections in the pool: acquired, idle and constructing."),
+		maxConns:            desc("max_conns", "Maximum size of the pool."),
+		acquires:            desc("acquires_total", "Successful connection acquires."),
+		emptyAcquires:       desc("empty_acquires_total", "Acquires that waited because the pool had no idle connection."),
+		canceledAcquires:    desc("canceled_acquires_total", "Acquires cancelled by their context."),
+		acquireDuration:     desc("acquire_duration_seconds_total", "Total time spent waiting in successful acquires."),
+		newConns:            desc("new_conns_total", "Connections opened."),
+		maxLifetimeDestroys: desc("max_lifetime_destroys_total", "Connections closed for exceeding their maximum lifetime."),
+		maxIdleTimeDestroys: desc("max_idle_destroys_total", "Connections closed for exceeding their maximum idle time."),
+	})
+}
+
+func (c *poolCollector) Describe(ch chan<- *prometheus.Desc) {
+	prometheus.DescribeByCollect(c, ch)
+}
+
+func (c *poolCollector) Collect(ch chan<- prometheus.Metric) {
+	stat := c.pool.Stat()
+	gauge := func(desc *prometheus.Desc, value float64) {
+		ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, value)
+	}
+	counter := func(desc *prometheus.Desc, value float64) {
+		ch <- prometheus.MustNewConstMetric(desc, prometheus.CounterValue, value)
+	}
+	gauge(c.acquiredConns, float64(stat.AcquiredConns()))
+	gauge(c.idleConns, float64(stat.IdleConns()))
+	gauge(c.constructingConns, float64(stat.ConstructingConns()))
+	gauge(c.totalConns, float64(stat.TotalConns()))
+	gauge(c.maxConns, float64(stat.MaxConns()))
+	counter(c.acquires, float64(stat.AcquireCount()))
+	counter(c.emptyAcquires, float64(stat.EmptyAcquireCount()))
+	counter(c.canceledAcquires, float64(stat.CanceledAcquireCount()))
+	counter(c.acquireDuration, stat.AcquireDuration().Seconds())
+	counter(c.newConns, float64(stat.NewConnsCount()))
+	counter(c.maxLifetimeDestroys, float64(stat.MaxLifetimeDestroyCount()))
+	counter(c.maxIdleTimeDestroys, float64(stat.MaxIdleDestroyCount()))
+}
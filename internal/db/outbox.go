@@ -0,0 +1,177 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/igor-kupczynski/alpha-monday/internal/decimal"
+	"github.com/jackc/pgx/v5"
+)
+
+// Outbox event types. Events are written in the same transaction as the change
+// they describe and delivered asynchronously by the outbox dispatcher.
+const (
+	EventBatchCreated       = "batch_created"
+	EventCheckpointComputed = "checkpoint_computed"
+	EventCheckpointSkipped  = "checkpoint_skipped"
+	EventBatchStatusChanged = "batch_status_changed"
+)
+
+type OutboxEvent struct {
+	ID        string
+	EventType string
+	BatchID   *string
+	Payload   json.RawMessage
+	Attempts  int
+	CreatedAt time.Time
+	// DeliveredTargets are the sink targets that accepted the event on an
+	// earlier attempt; retries skip them.
+	DeliveredTargets []string
+}
+
+type BatchCreatedPayload struct {
+	BatchID         string            `json:"batch_id"`
+	RunDate         string            `json:"run_date"`
+	BenchmarkSymbol string            `json:"benchmark_symbol"`
+	Picks           []PickPayloadItem `json:"picks"`
+}
+
+type PickPayloadItem struct {
+	Ticker       string           `json:"ticker"`
+	Action       string           `json:"action"`
+	InitialPrice decimal.Decimal  `json:"initial_price"`
+	TargetPrice  *decimal.Decimal `json:"target_price,omitempty"`
+}
+
+type CheckpointPayload struct {
+	BatchID            string           `json:"batch_id"`
+	CheckpointID       string           `json:"checkpoint_id"`
+	CheckpointDate     string           `json:"checkpoint_date"`
+	Status             string           `json:"status"`
+	BenchmarkReturnPct *decimal.Decimal `json:"benchmark_return_pct,omitempty"`
+}
+
+type BatchStatusPayload struct {
+	BatchID string `json:"batch_id"`
+	Status  string `json:"status"`
+	// TargetAccuracy scores the picks' target prices when the batch completes.
+	TargetAccuracy []TargetAccuracyItem `json:"target_accuracy,omitempty"`
+}
+
+// TargetAccuracyItem compares a pick's target price with its final price;
+// ErrorPct is (final - target) / target * 100.
+type TargetAccuracyItem struct {
+	Ticker      string          `json:"ticker"`
+	TargetPrice decimal.Decimal `json:"target_price"`
+	FinalPrice  decimal.Decimal `json:"final_price"`
+	ErrorPct    decimal.Decimal `json:"error_pct"`
+}
+
+// enqueueOutboxEvent inserts an undelivered event as part of tx. An empty
+// batchID stores an event that is not tied to a batch.
+func enqueueOutboxEvent(ctx context.Context, tx pgx.Tx, eventType string, batchID string, payload any) error {
+	encoded, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	var batch *string
+	if batchID != "" {
+		batch = &batchID
+	}
+	_, err = tx.Exec(ctx, `
+        INSERT INTO outbox_events (id, event_type, batch_id, payload)
+        VALUES ($1, $2, $3, $4)`,
+		uuid.New(),
+		eventType,
+		batch,
+		encoded,
+	)
+	return err
+}
+
+// ClaimOutboxEvents returns up to limit events that are due for delivery and
+// leases them for lease, so concurrent dispatchers never pick the same event.
+// Each claim counts as a delivery attempt.
+func (s *Store) ClaimOutboxEvents(ctx context.Context, limit int, lease time.Duration) (_ []OutboxEvent, err error) {
+	defer s.observe("ClaimOutboxEvents", time.Now(), &err)
+
+	rows, err := s.pool.Query(ctx, `
+        UPDATE outbox_events o
+        SET attempts = o.attempts + 1,
+            next_attempt_at = now() + $2::float8 * interval '1 second'
+        WHERE o.id IN (
+            SELECT id
+            FROM outbox_events
+            WHERE delivered_at IS NULL AND abandoned_at IS NULL AND next_attempt_at <= now()
+            ORDER BY next_attempt_at, created_at
+            LIMIT $1
+            FOR UPDATE SKIP LOCKED
+        )
+        RETURNING o.id::text, o.event_type, o.batch_id::text, o.payload, o.attempts, o.created_at,
+                  ARRAY(SELECT d.target FROM outbox_deliveries d WHERE d.event_id = o.id ORDER BY d.target)`,
+		limit,
+		lease.Seconds(),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := []OutboxEvent{}
+	for rows.Next() {
+		var event OutboxEvent
+		var payload []byte
+		if err := rows.Scan(&event.ID, &event.EventType, &event.BatchID, &payload, &event.Attempts, &event.CreatedAt, &event.DeliveredTargets); err != nil {
+			return nil, err
+		}
+		event.Payload = payload
+		events = append(events, event)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+func (s *Store) MarkOutboxDelivered(ctx context.Context, id string) (err error) {
+	defer s.observe("MarkOutboxDelivered", time.Now(), &err)
+
+	_, err = s.pool.Exec(ctx, `
+        UPDATE outbox_events
+        SET delivered_at = now(), last_error = NULL
+        WHERE id = $1`, id)
+	return err
+}
+
+// MarkOutboxFailed records a failed delivery. The event is retried at
+// nextAttemptAt, or abandoned when nextAttemptAt is nil. The delivered
+// targets accepted the event on this attempt and are recorded, so the retry
+// only goes to the targets that failed.
+func (s *Store) MarkOutboxFailed(ctx context.Context, id string, lastError string, nextAttemptAt *time.Time, delivered []string) (err error) {
+	defer s.observe("MarkOutboxFailed", time.Now(), &err)
+
+	return pgx.BeginFunc(ctx, s.pool, func(tx pgx.Tx) error {
+		if len(delivered) > 0 {
+			if _, err := tx.Exec(ctx, `
+                INSERT INTO outbox_deliveries (event_id, target)
+                SELECT $1, target FROM unnest($2::text[]) AS target
+                ON CONFLICT DO NOTHING`, id, delivered); err != nil {
+				return err
+			}
+		}
+		if nextAttemptAt == nil {
+			_, err := tx.Exec(ctx, `
+                UPDATE outbox_events
+                SET abandoned_at = now(), last_error = $2
+                WHERE id = $1`, id, lastError)
+			return err
+		}
+		_, err := tx.Exec(ctx, `
+            UPDATE outbox_events
+            SET next_attempt_at = $3, last_error = $2
+            WHERE id = $1`, id, lastError, *nextAttemptAt)
+		return err
+	})
+}
@@ -0,0 +1,140 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/igor-kupczynski/alpha-monday/internal/decimal"
+)
+
+func TestOutboxEventLifecycle(t *testing.T) {
+	truncateTables(t)
+
+	store := NewStore(testPool)
+	runDate := time.Date(2026, 1, 27, 0, 0, 0, 0, time.UTC)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	result, err := store.CreateBatchWithInitialCheckpoint(ctx, CreateBatchInput{
+		RunDate:               runDate,
+		BenchmarkSymbol:       "SPY",
+		BenchmarkInitialPrice: decimal.MustParse("401.25"),
+		Status:                "active",
+		Picks: []NewPick{
+			{Ticker: "AAPL", Action: "BUY", Reasoning: "ok", InitialPrice: decimal.MustParse("178.10")},
+		},
+		CheckpointDate:   runDate,
+		CheckpointStatus: "computed",
+		BenchmarkPrice:   decimal.MustParse("401.25"),
+	})
+	if err != nil {
+		t.Fatalf("create batch: %v", err)
+	}
+
+	events, err := store.ClaimOutboxEvents(ctx, 10, time.Minute)
+	if err != nil {
+		t.Fatalf("claim events: %v", err)
+	}
+	if len(events) != 1 {
+		t.Fatalf("expected 1 event, got %d", len(events))
+	}
+	event := events[0]
+	if event.EventType != EventBatchCreated {
+		t.Fatalf("expected %s event, got %s", EventBatchCreated, event.EventType)
+	}
+	if event.BatchID == nil || *event.BatchID != result.BatchID {
+		t.Fatalf("expected batch id %s, got %v", result.BatchID, event.BatchID)
+	}
+	if event.Attempts != 1 {
+		t.Fatalf("expected 1 attempt, got %d", event.Attempts)
+	}
+	var payload BatchCreatedPayload
+	if err := json.Unmarshal(event.Payload, &payload); err != nil {
+		t.Fatalf("decode payload: %v", err)
+	}
+	if payload.RunDate != "2026-01-27" || len(payload.Picks) != 1 || payload.Picks[0].Ticker != "AAPL" {
+		t.Fatalf("unexpected payload: %+v", payload)
+	}
+
+	leased, err := store.ClaimOutboxEvents(ctx, 10, time.Minute)
+	if err != nil {
+		t.Fatalf("claim leased events: %v", err)
+	}
+	if len(leased) != 0 {
+		t.Fatalf("expected leased event to be skipped, got %d", len(leased))
+	}
+
+	retryAt := time.Now().Add(-time.Second)
+	if err := store.MarkOutboxFailed(ctx, event.ID, "boom", &retryAt, []string{"slack", "subscription:s1"}); err != nil {
+		t.Fatalf("mark failed: %v", err)
+	}
+	retried, err := store.ClaimOutboxEvents(ctx, 10, time.Minute)
+	if err != nil {
+		t.Fatalf("claim retried events: %v", err)
+	}
+	if len(retried) != 1 || retried[0].Attempts != 2 {
+		t.Fatalf("expected retried event with 2 attempts, got %+v", retried)
+	}
+	if got := retried[0].DeliveredTargets; len(got) != 2 || got[0] != "slack" || got[1] != "subscription:s1" {
+		t.Fatalf("expected the delivered targets back on the retry, got %v", got)
+	}
+
+	if err := store.MarkOutboxDelivered(ctx, event.ID); err != nil {
+		t.Fatalf("mark delivered: %v", err)
+	}
+
+	if err := store.UpdateBatchStatus(ctx, result.BatchID, "completed"); err != nil {
+		t.Fatalf("update status: %v", err)
+	}
+	if err := store.UpdateBatchStatus(ctx, result.BatchID, "completed"); err != nil {
+		t.Fatalf("repeat update status: %v", err)
+	}
+	statusEvents, err := store.ClaimOutboxEvents(ctx, 10, time.Minute)
+	if err != nil {
+		t.Fatalf("claim status events: %v", err)
+	}
+	if len(statusEvents) != 1 || statusEvents[0].EventType != EventBatchStatusChanged {
+		t.Fatalf("expected a single %s event, got %+v", EventBatchStatusChanged, statusEvents)
+	}
+
+	if err := store.MarkOutboxFailed(ctx, statusEvents[0].ID, "gave up", nil, nil); err != nil {
+		t.Fatalf("abandon event: %v", err)
+	}
+	var abandoned bool
+	if err := testPool.QueryRow(ctx, "SELECT abandoned_at IS NOT NULL FROM outbox_events WHERE id = $1", statusEvents[0].ID).Scan(&abandoned); err != nil {
+		t.Fatalf("read outbox event: %v", err)
+	}
+	if !abandoned {
+		t.Fatalf("expected event to be abandoned")
+	}
+}
+
+func TestRecordAPIPanic(t *testing.T) {
+	truncateTables(t)
+
+	store := NewStore(testPool)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := store.RecordAPIPanic(ctx, APIPanicPayload{RequestID: "req-1", Method: "GET", Route: "/latest", Error: "boom"}); err != nil {
+		t.Fatalf("record panic: %v", err)
+	}
+
+	events, err := store.ClaimOutboxEvents(ctx, 10, time.Minute)
+	if err != nil {
+		t.Fatalf("claim events: %v", err)
+	}
+	if len(events) != 1 || events[0].EventType != EventAPIPanic || events[0].BatchID != nil {
+		t.Fatalf("expected one api_panic event without a batch, got %+v", events)
+	}
+	var payload APIPanicPayload
+	if err := json.Unmarshal(events[0].Payload, &payload); err != nil {
+		t.Fatalf("decode payload: %v", err)
+	}
+	if payload.Route != "/latest" || payload.Error != "boom" {
+		t.Fatalf("unexpected payload: %+v", payload)
+	}
+}
@@ -0,0 +1,98 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"golang.org/x/sync/errgroup"
+)
+
+// PickCheckpointMetric is a pick's metric at one checkpoint.
+type PickCheckpointMetric struct {
+	CheckpointDate string
+	Metric         PickMetric
+}
+
+// PickDetail is one pick with its metric at every checkpoint that has one.
+type PickDetail struct {
+	Batch Batch
+	Pick  Pick
+	// Metrics are ordered by checkpoint date, oldest first.
+	Metrics []PickCheckpointMetric
+}
+
+// PickDetail returns a pick of a batch with its metric history. It returns nil
+// when the batch has no such pick.
+func (s *Store) PickDetail(ctx context.Context, batchID, pickID string) (_ *PickDetail, err error) {
+	defer s.observe("PickDetail", time.Now(), &err)
+
+	const pickSQL = `
+        SELECT b.id::text, b.run_date::text, b.status, b.benchmark_symbol, b.benchmark_initial_price::text, b.config_hash,
+               ` + pickJSONSQL + `
+        FROM picks p
+        JOIN batches b ON b.id = p.batch_id
+        WHERE p.batch_id = $1 AND p.id = $2 AND b.deleted_at IS NULL`
+	const metricsSQL = `
+        SELECT c.checkpoint_date::text, ` + metricJSONSQL + `
+        FROM pick_checkpoint_metrics m
+        JOIN checkpoints c ON c.id = m.checkpoint_id
+        WHERE m.pick_id = $1
+        ORDER BY c.checkpoint_date`
+
+	// The metrics only depend on the pick id, so they are read alongside the
+	// pick and dropped when the pick is not in the batch.
+	var detail PickDetail
+	var found bool
+	group, groupCtx := errgroup.WithContext(ctx)
+	group.Go(func() error {
+		var pickData []byte
+		batch := &detail.Batch
+		if err := s.pool.QueryRow(groupCtx, pickSQL, batchID, pickID).Scan(
+			&batch.ID, &batch.RunDate, &batch.Status, &batch.BenchmarkSymbol, &batch.BenchmarkInitialPrice, &batch.ConfigHash, &pickData,
+		); err != nil {
+			if err == pgx.ErrNoRows {
+				return nil
+			}
+			return err
+		}
+		var pick pickJSON
+		if err := json.Unmarshal(pickData, &pick); err != nil {
+			return fmt.Errorf("decode pick: %w", err)
+		}
+		detail.Pick = Pick(pick)
+		found = true
+		return nil
+	})
+	group.Go(func() error {
+		rows, err := s.pool.Query(groupCtx, metricsSQL, pickID)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		detail.Metrics = []PickCheckpointMetric{}
+		for rows.Next() {
+			var checkpointDate string
+			var metricData []byte
+			if err := rows.Scan(&checkpointDate, &metricData); err != nil {
+				return err
+			}
+			var metric metricJSON
+			if err := json.Unmarshal(metricData, &metric); err != nil {
+				return fmt.Errorf("decode metric: %w", err)
+			}
+			detail.Metrics = append(detail.Metrics, PickCheckpointMetric{CheckpointDate: checkpointDate, Metric: PickMetric(metric)})
+		}
+		return rows.Err()
+	})
+	if err := group.Wait(); err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, nil
+	}
+	return &detail, nil
+}
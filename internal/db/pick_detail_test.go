@@ -0,0 +1,68 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestPickDetail(t *testing.T) {
+	truncateTables(t)
+
+	batchID := "11111111-1111-1111-1111-111111111111"
+	pickID := "aaaaaaaa-0000-0000-0000-000000000001"
+	if err := seedBatch(batchID, "2026-01-05", "SPY", "400.00", "active"); err != nil {
+		t.Fatalf("seed batch: %v", err)
+	}
+	if err := seedPick(pickID, batchID, "AAPL", "BUY", "ok", "100.00"); err != nil {
+		t.Fatalf("seed pick: %v", err)
+	}
+	if err := seedPick("aaaaaaaa-0000-0000-0000-000000000002", batchID, "MSFT", "BUY", "ok", "300.00"); err != nil {
+		t.Fatalf("seed pick: %v", err)
+	}
+	// Seeded out of order to check the series is sorted by checkpoint date.
+	if err := seedCheckpoint("cccccccc-0000-0000-0000-000000000002", batchID, "2026-01-07", "computed", "408.00", "2.00"); err != nil {
+		t.Fatalf("seed checkpoint: %v", err)
+	}
+	if err := seedCheckpoint("cccccccc-0000-0000-0000-000000000001", batchID, "2026-01-06", "computed", "404.00", "1.00"); err != nil {
+		t.Fatalf("seed checkpoint: %v", err)
+	}
+	if err := seedMetric("dddddddd-0000-0000-0000-000000000002", "cccccccc-0000-0000-0000-000000000002", pickID, "95.00", "-5.00", "-7.00"); err != nil {
+		t.Fatalf("seed metric: %v", err)
+	}
+	if err := seedMetric("dddddddd-0000-0000-0000-000000000001", "cccccccc-0000-0000-0000-000000000001", pickID, "104.00", "4.00", "3.00"); err != nil {
+		t.Fatalf("seed metric: %v", err)
+	}
+
+	store := NewStore(testPool)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	detail, err := store.PickDetail(ctx, batchID, pickID)
+	if err != nil {
+		t.Fatalf("pick detail: %v", err)
+	}
+	if detail == nil {
+		t.Fatalf("expected pick detail")
+	}
+	if detail.Batch.ID != batchID || detail.Pick.Ticker != "AAPL" {
+		t.Fatalf("unexpected detail: %+v", detail)
+	}
+	if len(detail.Metrics) != 2 {
+		t.Fatalf("expected 2 metrics, got %d", len(detail.Metrics))
+	}
+	if detail.Metrics[0].CheckpointDate != "2026-01-06" || detail.Metrics[0].Metric.CurrentPrice.String() != "104.00" {
+		t.Fatalf("unexpected first metric: %+v", detail.Metrics[0])
+	}
+	if detail.Metrics[1].CheckpointDate != "2026-01-07" || detail.Metrics[1].Metric.CurrentPrice.String() != "95.00" {
+		t.Fatalf("unexpected second metric: %+v", detail.Metrics[1])
+	}
+
+	other, err := store.PickDetail(ctx, "22222222-2222-2222-2222-222222222222", pickID)
+	if err != nil {
+		t.Fatalf("pick detail other batch: %v", err)
+	}
+	if other != nil {
+		t.Fatalf("expected nil for pick of another batch, got %+v", other)
+	}
+}
@@ -0,0 +1,77 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// PickHistoryEntry is one pick of a ticker together with its batch and the
+// metric of its latest checkpoint.
+type PickHistoryEntry struct {
+	Batch Batch
+	Pick  Pick
+	// LatestCheckpointDate and LatestMetric are nil until a computed
+	// checkpoint includes the pick.
+	LatestCheckpointDate *string
+	LatestMetric         *PickMetric
+}
+
+// PickHistory returns every pick of ticker across batches, newest run_date
+// first.
+func (s *Store) PickHistory(ctx context.Context, ticker string) (_ []PickHistoryEntry, err error) {
+	defer s.observe("PickHistory", time.Now(), &err)
+
+	const pickHistorySQL = `
+        SELECT b.id::text, b.run_date::text, b.status, b.benchmark_symbol, b.benchmark_initial_price::text, b.config_hash,
+               ` + pickJSONSQL + `,
+               latest.checkpoint_date::text, latest.metric
+        FROM picks p
+        JOIN batches b ON b.id = p.batch_id
+        LEFT JOIN LATERAL (
+            SELECT c.checkpoint_date, ` + metricJSONSQL + ` AS metric
+            FROM pick_checkpoint_metrics m
+            JOIN checkpoints c ON c.id = m.checkpoint_id
+            WHERE m.pick_id = p.id
+            ORDER BY c.checkpoint_date DESC
+            LIMIT 1
+        ) latest ON true
+        WHERE p.ticker = $1 AND b.deleted_at IS NULL
+        ORDER BY b.run_date DESC`
+
+	rows, err := s.pool.Query(ctx, pickHistorySQL, ticker)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []PickHistoryEntry{}
+	for rows.Next() {
+		var entry PickHistoryEntry
+		var pickData, metricData []byte
+		batch := &entry.Batch
+		if err := rows.Scan(&batch.ID, &batch.RunDate, &batch.Status, &batch.BenchmarkSymbol, &batch.BenchmarkInitialPrice, &batch.ConfigHash,
+			&pickData, &entry.LatestCheckpointDate, &metricData); err != nil {
+			return nil, err
+		}
+		var pick pickJSON
+		if err := json.Unmarshal(pickData, &pick); err != nil {
+			return nil, fmt.Errorf("decode pick: %w", err)
+		}
+		entry.Pick = Pick(pick)
+		if len(metricData) > 0 {
+			var metric metricJSON
+			if err := json.Unmarshal(metricData, &metric); err != nil {
+				return nil, fmt.Errorf("decode metric: %w", err)
+			}
+			latest := PickMetric(metric)
+			entry.LatestMetric = &latest
+		}
+		entries = append(entries, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
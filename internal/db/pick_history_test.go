@@ -0,0 +1,74 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestPickHistory(t *testing.T) {
+	truncateTables(t)
+
+	older := "11111111-1111-1111-1111-111111111111"
+	newer := "22222222-2222-2222-2222-222222222222"
+	if err := seedBatch(older, "2026-01-05", "SPY", "400.00", "completed"); err != nil {
+		t.Fatalf("seed batch: %v", err)
+	}
+	if err := seedBatch(newer, "2026-01-19", "SPY", "410.00", "active"); err != nil {
+		t.Fatalf("seed batch: %v", err)
+	}
+	if err := seedPick("aaaaaaaa-0000-0000-0000-000000000001", older, "AAPL", "BUY", "ok", "100.00"); err != nil {
+		t.Fatalf("seed pick: %v", err)
+	}
+	if err := seedPick("aaaaaaaa-0000-0000-0000-000000000002", older, "MSFT", "BUY", "ok", "300.00"); err != nil {
+		t.Fatalf("seed pick: %v", err)
+	}
+	if err := seedPick("aaaaaaaa-0000-0000-0000-000000000003", newer, "AAPL", "BUY", "ok", "110.00"); err != nil {
+		t.Fatalf("seed pick: %v", err)
+	}
+	if err := seedCheckpoint("cccccccc-0000-0000-0000-000000000001", older, "2026-01-06", "computed", "404.00", "1.00"); err != nil {
+		t.Fatalf("seed checkpoint: %v", err)
+	}
+	if err := seedCheckpoint("cccccccc-0000-0000-0000-000000000002", older, "2026-01-07", "computed", "408.00", "2.00"); err != nil {
+		t.Fatalf("seed checkpoint: %v", err)
+	}
+	if err := seedMetric("dddddddd-0000-0000-0000-000000000001", "cccccccc-0000-0000-0000-000000000001", "aaaaaaaa-0000-0000-0000-000000000001", "101.00", "1.00", "0.00"); err != nil {
+		t.Fatalf("seed metric: %v", err)
+	}
+	if err := seedMetric("dddddddd-0000-0000-0000-000000000002", "cccccccc-0000-0000-0000-000000000002", "aaaaaaaa-0000-0000-0000-000000000001", "105.00", "5.00", "3.00"); err != nil {
+		t.Fatalf("seed metric: %v", err)
+	}
+
+	store := NewStore(testPool)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	entries, err := store.PickHistory(ctx, "AAPL")
+	if err != nil {
+		t.Fatalf("pick history: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(entries))
+	}
+	if entries[0].Batch.ID != newer || entries[0].LatestMetric != nil || entries[0].LatestCheckpointDate != nil {
+		t.Fatalf("expected newest batch without metrics first, got %+v", entries[0])
+	}
+	oldest := entries[1]
+	if oldest.Batch.ID != older || oldest.Pick.InitialPrice.String() != "100.00" {
+		t.Fatalf("unexpected older entry: %+v", oldest)
+	}
+	if oldest.LatestCheckpointDate == nil || *oldest.LatestCheckpointDate != "2026-01-07" {
+		t.Fatalf("expected latest checkpoint 2026-01-07, got %v", oldest.LatestCheckpointDate)
+	}
+	if oldest.LatestMetric == nil || oldest.LatestMetric.VsBenchmarkPct.String() != "3.00" {
+		t.Fatalf("expected latest metric vs benchmark 3.00, got %+v", oldest.LatestMetric)
+	}
+
+	entries, err = store.PickHistory(ctx, "TSLA")
+	if err != nil {
+		t.Fatalf("pick history: %v", err)
+	}
+	if len(entries) != 0 {
+		t.Fatalf("expected no entries, got %d", len(entries))
+	}
+}
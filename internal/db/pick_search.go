@@ -0,0 +1,56 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// PickSearchResult is a pick matching a search, with its batch.
+type PickSearchResult struct {
+	Batch Batch
+	Pick  Pick
+}
+
+// SearchPicks full-text searches pick tickers and reasoning with web search
+// syntax ("quoted phrases", or, -excluded), best matches first and newer
+// batches first among equals. A query that is exactly a ticker also matches
+// it directly, as English stop words such as NOW drop out of the text search.
+func (s *Store) SearchPicks(ctx context.Context, query string, limit int) (_ []PickSearchResult, err error) {
+	defer s.observe("SearchPicks", time.Now(), &err)
+
+	rows, err := s.pool.Query(ctx, `
+        SELECT b.id::text, b.run_date::text, b.status, b.benchmark_symbol, b.benchmark_initial_price::text, b.config_hash,
+               `+pickJSONSQL+`
+        FROM picks p
+        JOIN batches b ON b.id = p.batch_id,
+             websearch_to_tsquery('english', $1) q
+        WHERE (p.search_vector @@ q OR p.ticker = upper($1)) AND b.deleted_at IS NULL
+        ORDER BY ts_rank(p.search_vector, q) DESC, b.run_date DESC, p.ticker
+        LIMIT $2`, query, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	results := []PickSearchResult{}
+	for rows.Next() {
+		var result PickSearchResult
+		var pickData []byte
+		batch := &result.Batch
+		if err := rows.Scan(&batch.ID, &batch.RunDate, &batch.Status, &batch.BenchmarkSymbol, &batch.BenchmarkInitialPrice, &batch.ConfigHash, &pickData); err != nil {
+			return nil, err
+		}
+		var pick pickJSON
+		if err := json.Unmarshal(pickData, &pick); err != nil {
+			return nil, fmt.Errorf("decode pick: %w", err)
+		}
+		result.Pick = Pick(pick)
+		results = append(results, result)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
@@ -0,0 +1,77 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestSearchPicks(t *testing.T) {
+	truncateTables(t)
+
+	olderID := "11111111-1111-1111-1111-111111111111"
+	newerID := "22222222-2222-2222-2222-222222222222"
+	deletedID := "33333333-3333-3333-3333-333333333333"
+	if err := seedBatch(olderID, "2026-01-05", "SPY", "400.00", "completed"); err != nil {
+		t.Fatalf("seed batch: %v", err)
+	}
+	if err := seedBatch(newerID, "2026-01-12", "SPY", "405.00", "active"); err != nil {
+		t.Fatalf("seed batch: %v", err)
+	}
+	if err := seedBatch(deletedID, "2026-01-19", "SPY", "410.00", "active"); err != nil {
+		t.Fatalf("seed batch: %v", err)
+	}
+	picks := []struct{ id, batchID, ticker, reasoning string }{
+		{"aaaaaaaa-0000-0000-0000-000000000001", olderID, "MSFT", "Cloud margins keep expanding."},
+		{"aaaaaaaa-0000-0000-0000-000000000002", olderID, "NOW", "Workflow software demand is steady."},
+		{"aaaaaaaa-0000-0000-0000-000000000003", newerID, "AMZN", "Cloud margin recovery and retail growth."},
+		{"aaaaaaaa-0000-0000-0000-000000000004", newerID, "JPM", "Rate cuts help lending."},
+		{"aaaaaaaa-0000-0000-0000-000000000005", deletedID, "ORCL", "Cloud margins."},
+	}
+	for _, p := range picks {
+		if err := seedPick(p.id, p.batchID, p.ticker, "BUY", p.reasoning, "100.00"); err != nil {
+			t.Fatalf("seed pick: %v", err)
+		}
+	}
+
+	store := NewStore(testPool)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if _, err := store.DeleteBatch(ctx, deletedID); err != nil {
+		t.Fatalf("delete batch: %v", err)
+	}
+
+	tickers := func(query string, limit int) []string {
+		t.Helper()
+		results, err := store.SearchPicks(ctx, query, limit)
+		if err != nil {
+			t.Fatalf("search %q: %v", query, err)
+		}
+		found := []string{}
+		for _, result := range results {
+			found = append(found, result.Pick.Ticker)
+		}
+		return found
+	}
+
+	// Equal ranks fall back to the newer batch first; the deleted batch is
+	// left out.
+	if got := tickers("cloud margins", 10); len(got) != 2 || got[0] != "AMZN" || got[1] != "MSFT" {
+		t.Fatalf("expected AMZN, MSFT, got %v", got)
+	}
+	if got := tickers("cloud margins", 1); len(got) != 1 {
+		t.Fatalf("expected the limit to apply, got %v", got)
+	}
+	if got := tickers(`cloud -retail`, 10); len(got) != 1 || got[0] != "MSFT" {
+		t.Fatalf("expected MSFT, got %v", got)
+	}
+	if got := tickers("jpm", 10); len(got) != 1 || got[0] != "JPM" {
+		t.Fatalf("expected a ticker match, got %v", got)
+	}
+	if got := tickers("NOW", 10); len(got) != 1 || got[0] != "NOW" {
+		t.Fatalf("expected the stop-word ticker to match, got %v", got)
+	}
+	if got := tickers("semiconductors", 10); len(got) != 0 {
+		t.Fatalf("expected no matches, got %v", got)
+	}
+}
@@ -0,0 +1,60 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/igor-kupczynski/alpha-monday/internal/decimal"
+	"github.com/jackc/pgx/v5"
+)
+
+// PickSparkline is a pick's most recent absolute returns, for inline charts.
+type PickSparkline struct {
+	PickID      string
+	BatchID     string
+	BatchStatus string
+	Ticker      string
+	// Returns are ordered by checkpoint date, oldest first.
+	Returns []decimal.Decimal
+}
+
+// PickSparkline returns the absolute returns of the pick's last points
+// scored checkpoints. It returns nil when the pick does not exist.
+func (s *Store) PickSparkline(ctx context.Context, pickID string, points int) (_ *PickSparkline, err error) {
+	defer s.observe("PickSparkline", time.Now(), &err)
+
+	sparkline := PickSparkline{PickID: pickID}
+	var returns []string
+	if err := s.pool.QueryRow(ctx, `
+        SELECT p.batch_id::text, b.status, p.ticker,
+               COALESCE((
+                   SELECT array_agg(r.absolute_return_pct::text ORDER BY r.checkpoint_date)
+                   FROM (
+                       SELECT m.absolute_return_pct, c.checkpoint_date
+                       FROM pick_checkpoint_metrics m
+                       JOIN checkpoints c ON c.id = m.checkpoint_id
+                       WHERE m.pick_id = p.id
+                       ORDER BY c.checkpoint_date DESC
+                       LIMIT $2
+                   ) r
+               ), '{}')
+        FROM picks p
+        JOIN batches b ON b.id = p.batch_id
+        WHERE p.id = $1 AND b.deleted_at IS NULL`, pickID, points).Scan(&sparkline.BatchID, &sparkline.BatchStatus, &sparkline.Ticker, &returns); err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	sparkline.Returns = make([]decimal.Decimal, 0, len(returns))
+	for _, value := range returns {
+		parsed, err := decimal.Parse(value)
+		if err != nil {
+			return nil, fmt.Errorf("decode return: %w", err)
+		}
+		sparkline.Returns = append(sparkline.Returns, parsed)
+	}
+	return &sparkline, nil
+}
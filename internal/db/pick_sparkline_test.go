@@ -0,0 +1,58 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestPickSparkline(t *testing.T) {
+	truncateTables(t)
+
+	batchID := "11111111-1111-1111-1111-111111111111"
+	pickID := "aaaaaaaa-0000-0000-0000-000000000001"
+	if err := seedBatch(batchID, "2026-01-05", "SPY", "400.00", "active"); err != nil {
+		t.Fatalf("seed batch: %v", err)
+	}
+	if err := seedPick(pickID, batchID, "AAPL", "BUY", "ok", "100.00"); err != nil {
+		t.Fatalf("seed pick: %v", err)
+	}
+	// Seeded out of order to check the returns are sorted by checkpoint date.
+	checkpoints := []struct{ id, date, metricID, price, ret string }{
+		{"cccccccc-0000-0000-0000-000000000003", "2026-01-08", "dddddddd-0000-0000-0000-000000000003", "102.00", "2.00"},
+		{"cccccccc-0000-0000-0000-000000000001", "2026-01-06", "dddddddd-0000-0000-0000-000000000001", "104.00", "4.00"},
+		{"cccccccc-0000-0000-0000-000000000002", "2026-01-07", "dddddddd-0000-0000-0000-000000000002", "95.00", "-5.00"},
+	}
+	for _, c := range checkpoints {
+		if err := seedCheckpoint(c.id, batchID, c.date, "computed", "400.00", "0.00"); err != nil {
+			t.Fatalf("seed checkpoint: %v", err)
+		}
+		if err := seedMetric(c.metricID, c.id, pickID, c.price, c.ret, c.ret); err != nil {
+			t.Fatalf("seed metric: %v", err)
+		}
+	}
+
+	store := NewStore(testPool)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	sparkline, err := store.PickSparkline(ctx, pickID, 2)
+	if err != nil {
+		t.Fatalf("pick sparkline: %v", err)
+	}
+	if sparkline == nil || sparkline.BatchID != batchID || sparkline.BatchStatus != "active" || sparkline.Ticker != "AAPL" {
+		t.Fatalf("unexpected sparkline: %+v", sparkline)
+	}
+	// The last two checkpoints, oldest first.
+	if len(sparkline.Returns) != 2 || sparkline.Returns[0].String() != "-5.00" || sparkline.Returns[1].String() != "2.00" {
+		t.Fatalf("unexpected returns: %v", sparkline.Returns)
+	}
+
+	missing, err := store.PickSparkline(ctx, "aaaaaaaa-0000-0000-0000-000000000009", 2)
+	if err != nil {
+		t.Fatalf("missing pick sparkline: %v", err)
+	}
+	if missing != nil {
+		t.Fatalf("expected nil for unknown pick, got %+v", missing)
+	}
+}
@@ -0,0 +1,67 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// PoolConfig tunes how pgx talks to Postgres. Zero values keep the pgx defaults.
+type PoolConfig struct {
+	// QueryExecMode is one of cache_statement, cache_describe, describe_exec, exec, simple_protocol.
+	// Transaction-mode poolers such as pgbouncer need a mode that does not rely on
+	// server-side prepared statements (exec or simple_protocol).
+	QueryExecMode string
+	// StatementCacheCapacity overrides the prepared statement cache size when set.
+	StatementCacheCapacity *int
+	// DescriptionCacheCapacity overrides the statement description cache size when set.
+	DescriptionCacheCapacity *int
+	// QueryLog logs every statement with its row count and duration.
+	QueryLog QueryLogConfig
+}
+
+// NewPool builds a pgx pool for databaseURL with cfg applied to every connection.
+func NewPool(ctx context.Context, databaseURL string, cfg PoolConfig) (*pgxpool.Pool, error) {
+	poolConfig, err := pgxpool.ParseConfig(databaseURL)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.QueryExecMode != "" {
+		mode, err := ParseQueryExecMode(cfg.QueryExecMode)
+		if err != nil {
+			return nil, err
+		}
+		poolConfig.ConnConfig.DefaultQueryExecMode = mode
+	}
+	if cfg.StatementCacheCapacity != nil {
+		poolConfig.ConnConfig.StatementCacheCapacity = *cfg.StatementCacheCapacity
+	}
+	if cfg.DescriptionCacheCapacity != nil {
+		poolConfig.ConnConfig.DescriptionCacheCapacity = *cfg.DescriptionCacheCapacity
+	}
+	if cfg.QueryLog.Logger != nil {
+		poolConfig.ConnConfig.Tracer = queryTracer{cfg: cfg.QueryLog}
+	}
+	return pgxpool.NewWithConfig(ctx, poolConfig)
+}
+
+// ParseQueryExecMode maps a config value to a pgx query exec mode.
+func ParseQueryExecMode(value string) (pgx.QueryExecMode, error) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "cache_statement":
+		return pgx.QueryExecModeCacheStatement, nil
+	case "cache_describe":
+		return pgx.QueryExecModeCacheDescribe, nil
+	case "describe_exec":
+		return pgx.QueryExecModeDescribeExec, nil
+	case "exec":
+		return pgx.QueryExecModeExec, nil
+	case "simple_protocol":
+		return pgx.QueryExecModeSimpleProtocol, nil
+	default:
+		return 0, fmt.Errorf("unknown query exec mode %q", value)
+	}
+}
@@ -0,0 +1,91 @@
+package db
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// DefaultSlowQueryThreshold is the duration at which statements and Store
+// calls are logged as warnings.
+const DefaultSlowQueryThreshold = 500 * time.Millisecond
+
+// QueryLogConfig controls query logging. Every statement (with the rows it
+// returned or affected) and every Store call is logged at debug level; those
+// taking at least SlowThreshold are logged as warnings. A nil Logger disables
+// logging; a zero SlowThreshold disables the warnings.
+type QueryLogConfig struct {
+	Logger        *slog.Logger
+	SlowThreshold time.Duration
+}
+
+// level returns the level to log a call of duration at, or false when nothing
+// should be logged.
+func (c QueryLogConfig) level(ctx context.Context, duration time.Duration) (slog.Level, bool) {
+	if c.Logger == nil {
+		return 0, false
+	}
+	level := slog.LevelDebug
+	if c.SlowThreshold > 0 && duration >= c.SlowThreshold {
+		level = slog.LevelWarn
+	}
+	return level, c.Logger.Enabled(ctx, level)
+}
+
+// WithQueryLog logs the duration and outcome of every Store call.
+func WithQueryLog(cfg QueryLogConfig) StoreOption {
+	return func(s *Store) {
+		s.queryLog = cfg
+	}
+}
+
+// queryTracer is a pgx.QueryTracer logging each statement.
+type queryTracer struct {
+	cfg QueryLogConfig
+}
+
+type queryTraceKey struct{}
+
+type queryTrace struct {
+	sql   string
+	start time.Time
+}
+
+func (t queryTracer) TraceQueryStart(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	return context.WithValue(ctx, queryTraceKey{}, queryTrace{sql: data.SQL, start: time.Now()})
+}
+
+func (t queryTracer) TraceQueryEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryEndData) {
+	trace, ok := ctx.Value(queryTraceKey{}).(queryTrace)
+	if !ok {
+		return
+	}
+	duration := time.Since(trace.start)
+	level, ok := t.cfg.level(ctx, duration)
+	if !ok {
+		return
+	}
+	attrs := []any{
+		"statement", statementSummary(trace.sql),
+		"rows", data.CommandTag.RowsAffected(),
+		"duration_ms", duration.Milliseconds(),
+	}
+	if data.Err != nil {
+		attrs = append(attrs, "error", data.Err)
+	}
+	t.cfg.Logger.Log(ctx, level, "db query", attrs...)
+}
+
+// statementSummary collapses whitespace and truncates sql so log lines stay
+// short; the leading clause is enough to find the statement in the code.
+func statementSummary(sql string) string {
+	const maxLen = 120
+	summary := strings.Join(strings.Fields(sql), " ")
+	if len(summary) > maxLen {
+		summary = summary[:maxLen] + "..."
+	}
+	return summary
+}
@@ -0,0 +1,38 @@
+package db
+
+import (
+	"context"
+	"time"
+)
+
+// RecentPickTickers returns the distinct tickers picked by batches with a run
+// date on or after since, in alphabetical order.
+func (s *Store) RecentPickTickers(ctx context.Context, since time.Time) (_ []string, err error) {
+	defer s.observe("RecentPickTickers", time.Now(), &err)
+
+	rows, err := s.pool.Query(ctx, `
+        SELECT DISTINCT p.ticker
+        FROM picks p
+        JOIN batches b ON b.id = p.batch_id
+        WHERE b.run_date >= $1::date AND b.deleted_at IS NULL
+        ORDER BY p.ticker`,
+		since.Format("2006-01-02"),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tickers := []string{}
+	for rows.Next() {
+		var ticker string
+		if err := rows.Scan(&ticker); err != nil {
+			return nil, err
+		}
+		tickers = append(tickers, ticker)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return tickers, nil
+}
@@ -0,0 +1,49 @@
+package db
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/igor-kupczynski/alpha-monday/internal/decimal"
+)
+
+func TestRecentPickTickers(t *testing.T) {
+	truncateTables(t)
+
+	store := NewStore(testPool)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	for runDate, tickers := range map[time.Time][]string{
+		time.Date(2026, 1, 5, 0, 0, 0, 0, time.UTC):  {"AAPL", "JNJ"},
+		time.Date(2026, 1, 19, 0, 0, 0, 0, time.UTC): {"MSFT", "NVDA"},
+		time.Date(2026, 1, 26, 0, 0, 0, 0, time.UTC): {"AAPL", "NVDA"},
+	} {
+		picks := make([]NewPick, 0, len(tickers))
+		for _, ticker := range tickers {
+			picks = append(picks, NewPick{Ticker: ticker, Action: "BUY", Reasoning: "ok", InitialPrice: decimal.MustParse("100")})
+		}
+		if _, err := store.CreateBatchWithInitialCheckpoint(ctx, CreateBatchInput{
+			RunDate:               runDate,
+			BenchmarkSymbol:       "SPY",
+			BenchmarkInitialPrice: decimal.MustParse("401.25"),
+			Status:                "active",
+			Picks:                 picks,
+			CheckpointDate:        runDate.AddDate(0, 0, -3),
+			CheckpointStatus:      "computed",
+			BenchmarkPrice:        decimal.MustParse("401.25"),
+		}); err != nil {
+			t.Fatalf("create batch: %v", err)
+		}
+	}
+
+	tickers, err := store.RecentPickTickers(ctx, time.Date(2026, 1, 19, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("recent pick tickers: %v", err)
+	}
+	if want := []string{"AAPL", "MSFT", "NVDA"}; !reflect.DeepEqual(tickers, want) {
+		t.Fatalf("expected %v, got %v", want, tickers)
+	}
+}
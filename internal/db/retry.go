@@ -0,0 +1,51 @@
+package db
+
+import (
+	"context"
+	"errors"
+
+	"github.com/igor-kupczynski/alpha-monday/internal/integrations/retry"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// WithWriteRetry overrides the retry policy applied to Store writes. A config
+// with MaxAttempts of 1 disables retries.
+func WithWriteRetry(cfg retry.Config) StoreOption {
+	return func(s *Store) {
+		s.writeRetry = cfg
+	}
+}
+
+// withWriteRetry runs a write transaction, retrying it as a whole when it
+// fails with a transient database error. fn must be safe to re-run: each
+// attempt starts a fresh transaction and nothing from a failed one survives.
+// fn must use the attempt's context so the policy's AttemptTimeout applies.
+func (s *Store) withWriteRetry(ctx context.Context, fn func(context.Context) error) error {
+	return retry.Do(ctx, s.writeRetry, isTransientDBError, fn)
+}
+
+// isTransientDBError reports whether err is worth retrying: serialization
+// failures, deadlocks, and errors pgconn marks safe to retry because nothing
+// reached the server. A dropped connection or server shutdown may have hit
+// after COMMIT was sent, so replaying the transaction could apply it twice;
+// those, constraint violations and other data errors are never retried.
+func isTransientDBError(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		switch pgErr.Code {
+		case "40001", // serialization_failure
+			"40P01": // deadlock_detected
+			return true
+		}
+		return false
+	}
+
+	return pgconn.SafeToRetry(err)
+}
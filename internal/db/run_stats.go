@@ -0,0 +1,110 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Run outcomes counted per batch and workflow step.
+const (
+	RunOutcomeSuccess = "success"
+	RunOutcomeSkip    = "skip"
+	RunOutcomeFailure = "failure"
+)
+
+// RunStepStats are the outcome counters of one workflow step for a batch.
+type RunStepStats struct {
+	Step      string
+	Succeeded int
+	Skipped   int
+	Failed    int
+	UpdatedAt time.Time
+}
+
+// BatchRunStats pairs a batch with its per-step outcome counters.
+type BatchRunStats struct {
+	Batch Batch
+	Steps []RunStepStats
+}
+
+type RunStatsPage struct {
+	Runs       []BatchRunStats
+	NextCursor *BatchCursor
+}
+
+// RecordRunOutcome increments the counter for outcome on the batch's step.
+func (s *Store) RecordRunOutcome(ctx context.Context, batchID, step, outcome string) (err error) {
+	defer s.observe("RecordRunOutcome", time.Now(), &err)
+
+	switch outcome {
+	case RunOutcomeSuccess, RunOutcomeSkip, RunOutcomeFailure:
+	default:
+		return fmt.Errorf("unknown run outcome %q", outcome)
+	}
+
+	return s.withWriteRetry(ctx, func(ctx context.Context) error {
+		_, err := s.pool.Exec(ctx, `
+            INSERT INTO batch_run_stats (batch_id, step, success_count, skip_count, failure_count)
+            VALUES ($1, $2, ($3 = 'success')::int, ($3 = 'skip')::int, ($3 = 'failure')::int)
+            ON CONFLICT (batch_id, step) DO UPDATE
+            SET success_count = batch_run_stats.success_count + EXCLUDED.success_count,
+                skip_count = batch_run_stats.skip_count + EXCLUDED.skip_count,
+                failure_count = batch_run_stats.failure_count + EXCLUDED.failure_count,
+                updated_at = now()`,
+			batchID, step, outcome,
+		)
+		return err
+	})
+}
+
+// ListRunStats returns batches newest first with their step counters,
+// paginated like ListBatches. Batches without recorded outcomes
+// have no steps.
+func (s *Store) ListRunStats(ctx context.Context, limit int, cursor *BatchCursor) (_ RunStatsPage, err error) {
+	defer s.observe("ListRunStats", time.Now(), &err)
+
+	batches, err := s.listBatches(ctx, limit, cursor, BatchFilter{}, false)
+	if err != nil {
+		return RunStatsPage{}, err
+	}
+
+	runs := make([]BatchRunStats, 0, len(batches.Batches))
+	byID := make(map[string]int, len(batches.Batches))
+	batchIDs := make([]string, 0, len(batches.Batches))
+	for i, batch := range batches.Batches {
+		runs = append(runs, BatchRunStats{Batch: batch, Steps: []RunStepStats{}})
+		byID[batch.ID] = i
+		batchIDs = append(batchIDs, batch.ID)
+	}
+	if len(batchIDs) == 0 {
+		return RunStatsPage{Runs: runs, NextCursor: batches.NextCursor}, nil
+	}
+
+	rows, err := s.pool.Query(ctx, `
+        SELECT batch_id::text, step, success_count, skip_count, failure_count, updated_at
+        FROM batch_run_stats
+        WHERE batch_id = ANY($1::uuid[])
+        ORDER BY batch_id, step`,
+		batchIDs,
+	)
+	if err != nil {
+		return RunStatsPage{}, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var batchID string
+		var stats RunStepStats
+		if err := rows.Scan(&batchID, &stats.Step, &stats.Succeeded, &stats.Skipped, &stats.Failed, &stats.UpdatedAt); err != nil {
+			return RunStatsPage{}, err
+		}
+		run := &runs[byID[batchID]]
+		run.Steps = append(run.Steps, stats)
+	}
+	if err := rows.Err(); err != nil {
+		return RunStatsPage{}, err
+	}
+
+	return RunStatsPage{Runs: runs, NextCursor: batches.NextCursor}, nil
+}
@@ -0,0 +1,79 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/igor-kupczynski/alpha-monday/internal/decimal"
+)
+
+func TestRunStats(t *testing.T) {
+	truncateTables(t)
+
+	store := NewStore(testPool)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	batchIDs := make([]string, 0, 2)
+	for _, runDate := range []time.Time{
+		time.Date(2026, 1, 19, 0, 0, 0, 0, time.UTC),
+		time.Date(2026, 1, 26, 0, 0, 0, 0, time.UTC),
+	} {
+		result, err := store.CreateBatchWithInitialCheckpoint(ctx, CreateBatchInput{
+			RunDate:               runDate,
+			BenchmarkSymbol:       "SPY",
+			BenchmarkInitialPrice: decimal.MustParse("401.25"),
+			Status:                "active",
+			Picks: []NewPick{
+				{Ticker: "AAPL", Action: "BUY", Reasoning: "ok", InitialPrice: decimal.MustParse("178.10")},
+			},
+			CheckpointDate:   runDate,
+			CheckpointStatus: "computed",
+			BenchmarkPrice:   decimal.MustParse("401.25"),
+		})
+		if err != nil {
+			t.Fatalf("create batch: %v", err)
+		}
+		batchIDs = append(batchIDs, result.BatchID)
+	}
+
+	for _, outcome := range []string{RunOutcomeSuccess, RunOutcomeSuccess, RunOutcomeSkip, RunOutcomeFailure} {
+		if err := store.RecordRunOutcome(ctx, batchIDs[1], "daily_checkpoint_v1", outcome); err != nil {
+			t.Fatalf("record outcome: %v", err)
+		}
+	}
+	if err := store.RecordRunOutcome(ctx, batchIDs[1], "persist_batch", RunOutcomeSuccess); err != nil {
+		t.Fatalf("record outcome: %v", err)
+	}
+	if err := store.RecordRunOutcome(ctx, batchIDs[1], "persist_batch", "maybe"); err == nil {
+		t.Fatalf("expected unknown outcome to be rejected")
+	}
+
+	page, err := store.ListRunStats(ctx, 1, nil)
+	if err != nil {
+		t.Fatalf("list run stats: %v", err)
+	}
+	if len(page.Runs) != 1 || page.NextCursor == nil {
+		t.Fatalf("expected one run and a cursor, got %+v", page)
+	}
+	run := page.Runs[0]
+	if run.Batch.ID != batchIDs[1] || len(run.Steps) != 2 {
+		t.Fatalf("unexpected run %+v", run)
+	}
+	daily := run.Steps[0]
+	if daily.Step != "daily_checkpoint_v1" || daily.Succeeded != 2 || daily.Skipped != 1 || daily.Failed != 1 {
+		t.Fatalf("unexpected daily counters %+v", daily)
+	}
+	if persist := run.Steps[1]; persist.Step != "persist_batch" || persist.Succeeded != 1 {
+		t.Fatalf("unexpected persist counters %+v", persist)
+	}
+
+	page, err = store.ListRunStats(ctx, 1, page.NextCursor)
+	if err != nil {
+		t.Fatalf("list run stats page 2: %v", err)
+	}
+	if len(page.Runs) != 1 || page.Runs[0].Batch.ID != batchIDs[0] || len(page.Runs[0].Steps) != 0 || page.NextCursor != nil {
+		t.Fatalf("expected the older batch without counters, got %+v", page)
+	}
+}
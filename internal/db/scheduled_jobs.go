@@ -0,0 +1,119 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ScheduledJob is a unit of work for the standalone (Hatchet-free) worker
+// mode: a task ID, its JSON input and the time it becomes due.
+type ScheduledJob struct {
+	ID        string
+	Task      string
+	DedupeKey string
+	Input     json.RawMessage
+	RunAt     time.Time
+	Attempts  int
+}
+
+// EnqueueScheduledJob schedules task with input at runAt. Jobs are unique per
+// (task, dedupeKey); it reports false when the job already exists.
+func (s *Store) EnqueueScheduledJob(ctx context.Context, task, dedupeKey string, input any, runAt time.Time) (_ bool, err error) {
+	defer s.observe("EnqueueScheduledJob", time.Now(), &err)
+
+	encoded, err := json.Marshal(input)
+	if err != nil {
+		return false, err
+	}
+
+	var inserted bool
+	err = s.withWriteRetry(ctx, func(ctx context.Context) error {
+		tag, err := s.pool.Exec(ctx, `
+            INSERT INTO scheduled_jobs (id, task, dedupe_key, input, run_at)
+            VALUES ($1, $2, $3, $4, $5)
+            ON CONFLICT ON CONSTRAINT scheduled_jobs_task_dedupe_unique DO NOTHING`,
+			uuid.New(), task, dedupeKey, encoded, runAt,
+		)
+		if err != nil {
+			return err
+		}
+		inserted = tag.RowsAffected() > 0
+		return nil
+	})
+	return inserted, err
+}
+
+// ClaimScheduledJobs returns up to limit due jobs and leases them for lease by
+// pushing run_at forward, so a crashed worker's jobs are picked up again once
+// the lease expires. Each claim counts as an attempt.
+func (s *Store) ClaimScheduledJobs(ctx context.Context, limit int, lease time.Duration) (_ []ScheduledJob, err error) {
+	defer s.observe("ClaimScheduledJobs", time.Now(), &err)
+
+	rows, err := s.pool.Query(ctx, `
+        UPDATE scheduled_jobs j
+        SET attempts = j.attempts + 1,
+            run_at = now() + $2::float8 * interval '1 second'
+        WHERE j.id IN (
+            SELECT id
+            FROM scheduled_jobs
+            WHERE completed_at IS NULL AND abandoned_at IS NULL AND run_at <= now()
+            ORDER BY run_at, created_at
+            LIMIT $1
+            FOR UPDATE SKIP LOCKED
+        )
+        RETURNING j.id::text, j.task, j.dedupe_key, j.input, j.run_at, j.attempts`,
+		limit,
+		lease.Seconds(),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	jobs := []ScheduledJob{}
+	for rows.Next() {
+		var job ScheduledJob
+		var input []byte
+		if err := rows.Scan(&job.ID, &job.Task, &job.DedupeKey, &input, &job.RunAt, &job.Attempts); err != nil {
+			return nil, err
+		}
+		job.Input = input
+		jobs = append(jobs, job)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return jobs, nil
+}
+
+func (s *Store) MarkScheduledJobCompleted(ctx context.Context, id string) (err error) {
+	defer s.observe("MarkScheduledJobCompleted", time.Now(), &err)
+
+	_, err = s.pool.Exec(ctx, `
+        UPDATE scheduled_jobs
+        SET completed_at = now(), last_error = NULL
+        WHERE id = $1`, id)
+	return err
+}
+
+// MarkScheduledJobFailed records a failed run. The job is retried at
+// nextRunAt, or abandoned when nextRunAt is nil.
+func (s *Store) MarkScheduledJobFailed(ctx context.Context, id string, lastError string, nextRunAt *time.Time) (err error) {
+	defer s.observe("MarkScheduledJobFailed", time.Now(), &err)
+
+	if nextRunAt == nil {
+		_, err = s.pool.Exec(ctx, `
+            UPDATE scheduled_jobs
+            SET abandoned_at = now(), last_error = $2
+            WHERE id = $1`, id, lastError)
+		return err
+	}
+	_, err = s.pool.Exec(ctx, `
+        UPDATE scheduled_jobs
+        SET run_at = $3, last_error = $2
+        WHERE id = $1`, id, lastError, *nextRunAt)
+	return err
+}
@@ -0,0 +1,76 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestScheduledJobLifecycle(t *testing.T) {
+	truncateTables(t)
+
+	store := NewStore(testPool)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	input := map[string]string{"batch_id": "b1"}
+	inserted, err := store.EnqueueScheduledJob(ctx, "daily_checkpoint_v1", "job-1/0", input, time.Now().Add(-time.Minute))
+	if err != nil {
+		t.Fatalf("enqueue job: %v", err)
+	}
+	if !inserted {
+		t.Fatalf("expected job to be inserted")
+	}
+	duplicate, err := store.EnqueueScheduledJob(ctx, "daily_checkpoint_v1", "job-1/0", input, time.Now())
+	if err != nil {
+		t.Fatalf("enqueue duplicate job: %v", err)
+	}
+	if duplicate {
+		t.Fatalf("expected duplicate job to be ignored")
+	}
+	if _, err := store.EnqueueScheduledJob(ctx, "daily_checkpoint_v1", "job-1/1", input, time.Now().Add(time.Hour)); err != nil {
+		t.Fatalf("enqueue future job: %v", err)
+	}
+
+	jobs, err := store.ClaimScheduledJobs(ctx, 10, time.Minute)
+	if err != nil {
+		t.Fatalf("claim jobs: %v", err)
+	}
+	if len(jobs) != 1 || jobs[0].DedupeKey != "job-1/0" || jobs[0].Attempts != 1 {
+		t.Fatalf("expected the due job with 1 attempt, got %+v", jobs)
+	}
+	if string(jobs[0].Input) != `{"batch_id": "b1"}` {
+		t.Fatalf("unexpected input %s", jobs[0].Input)
+	}
+
+	leased, err := store.ClaimScheduledJobs(ctx, 10, time.Minute)
+	if err != nil {
+		t.Fatalf("claim leased jobs: %v", err)
+	}
+	if len(leased) != 0 {
+		t.Fatalf("expected leased job to be skipped, got %d", len(leased))
+	}
+
+	retryAt := time.Now().Add(-time.Second)
+	if err := store.MarkScheduledJobFailed(ctx, jobs[0].ID, "boom", &retryAt); err != nil {
+		t.Fatalf("mark failed: %v", err)
+	}
+	retried, err := store.ClaimScheduledJobs(ctx, 10, time.Minute)
+	if err != nil {
+		t.Fatalf("claim retried jobs: %v", err)
+	}
+	if len(retried) != 1 || retried[0].Attempts != 2 {
+		t.Fatalf("expected retried job with 2 attempts, got %+v", retried)
+	}
+
+	if err := store.MarkScheduledJobCompleted(ctx, jobs[0].ID); err != nil {
+		t.Fatalf("mark completed: %v", err)
+	}
+	var completed bool
+	if err := testPool.QueryRow(ctx, "SELECT completed_at IS NOT NULL FROM scheduled_jobs WHERE id = $1", jobs[0].ID).Scan(&completed); err != nil {
+		t.Fatalf("read job: %v", err)
+	}
+	if !completed {
+		t.Fatalf("expected job to be completed")
+	}
+}
@@ -0,0 +1,97 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+)
+
+// SchemaVersion is the migration version this build expects. Bump it with
+// every new file in migrations/; the dbtests suite fails when they diverge.
+const SchemaVersion = 27
+
+// Schema check modes selectable with SCHEMA_CHECK.
+const (
+	SchemaCheckOff     = "off"
+	SchemaCheckWarn    = "warn"
+	SchemaCheckRequire = "require"
+)
+
+// ParseSchemaCheckMode validates a SCHEMA_CHECK value; empty means warn.
+func ParseSchemaCheckMode(value string) (string, error) {
+	mode := strings.ToLower(strings.TrimSpace(value))
+	switch mode {
+	case "":
+		return SchemaCheckWarn, nil
+	case SchemaCheckOff, SchemaCheckWarn, SchemaCheckRequire:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("must be %s, %s or %s", SchemaCheckOff, SchemaCheckWarn, SchemaCheckRequire)
+	}
+}
+
+// SchemaStatus is the applied migration state compared to SchemaVersion.
+type SchemaStatus struct {
+	Current  int
+	Expected int
+	Dirty    bool
+}
+
+// Behind reports a schema that this build cannot run against: older than
+// expected, or left dirty by a failed migration.
+func (s SchemaStatus) Behind() bool {
+	return s.Dirty || s.Current < s.Expected
+}
+
+// Ahead reports a schema migrated past this build. Migrations are additive,
+// so this is expected while a rollout is in progress.
+func (s SchemaStatus) Ahead() bool {
+	return !s.Dirty && s.Current > s.Expected
+}
+
+// SchemaStatus reads the golang-migrate version table.
+func (s *Store) SchemaStatus(ctx context.Context) (_ SchemaStatus, err error) {
+	defer s.observe("SchemaStatus", time.Now(), &err)
+
+	status := SchemaStatus{Expected: SchemaVersion}
+	var current int64
+	if err := s.pool.QueryRow(ctx, `SELECT version, dirty FROM schema_migrations LIMIT 1`).Scan(&current, &status.Dirty); err != nil {
+		return SchemaStatus{}, fmt.Errorf("read schema_migrations: %w", err)
+	}
+	status.Current = int(current)
+	return status, nil
+}
+
+// CheckSchema compares the applied schema with SchemaVersion at startup. A
+// schema that is behind or unreadable is logged as an error and, in require
+// mode, returned so the binary refuses to start; a schema ahead of the build
+// is only logged.
+func (s *Store) CheckSchema(ctx context.Context, mode string, logger *slog.Logger) error {
+	if mode == SchemaCheckOff {
+		return nil
+	}
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	status, err := s.SchemaStatus(ctx)
+	switch {
+	case err != nil:
+		logger.Error("schema version unknown", "expected_version", SchemaVersion, "error", err)
+	case status.Behind():
+		err = fmt.Errorf("schema version %d (dirty=%t) is behind expected %d; run migrations", status.Current, status.Dirty, status.Expected)
+		logger.Error("schema version mismatch", "current_version", status.Current, "expected_version", status.Expected, "dirty", status.Dirty)
+	case status.Ahead():
+		logger.Warn("schema version ahead of build", "current_version", status.Current, "expected_version", status.Expected)
+		return nil
+	default:
+		logger.Info("schema version ok", "version", status.Current)
+		return nil
+	}
+	if mode == SchemaCheckRequire {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+package db
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// shareTokenPrefix marks share tokens so they are recognisable in logs and
+// secret scanners.
+const shareTokenPrefix = "amst_"
+
+// ShareToken is a read-only token scoped to a single batch. Only a hash of the
+// token is stored; the plaintext is returned once, on creation.
+type ShareToken struct {
+	ID        string
+	BatchID   string
+	CreatedAt time.Time
+	ExpiresAt time.Time
+}
+
+// CreateShareToken issues a token for batchID that expires at expiresAt. It
+// returns nil when the batch does not exist.
+func (s *Store) CreateShareToken(ctx context.Context, batchID string, expiresAt time.Time) (_ *ShareToken, _ string, err error) {
+	defer s.observe("CreateShareToken", time.Now(), &err)
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, "", err
+	}
+	token := shareTokenPrefix + base64.RawURLEncoding.EncodeToString(secret)
+
+	var created *ShareToken
+	err = s.withWriteRetry(ctx, func(ctx context.Context) error {
+		created = nil
+		var row ShareToken
+		err := s.pool.QueryRow(ctx, `
+            INSERT INTO share_tokens (id, batch_id, token_hash, expires_at)
+            SELECT $1, b.id, $3, $4
+            FROM batches b
+            WHERE b.id = $2 AND b.deleted_at IS NULL
+            RETURNING id::text, batch_id::text, created_at, expires_at`,
+			uuid.New(), batchID, hashShareToken(token), expiresAt,
+		).Scan(&row.ID, &row.BatchID, &row.CreatedAt, &row.ExpiresAt)
+		if err == pgx.ErrNoRows {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		created = &row
+		return nil
+	})
+	if err != nil || created == nil {
+		return nil, "", err
+	}
+	return created, token, nil
+}
+
+// LookupShareToken returns the token's scope, or nil when the token is unknown,
+// expired or revoked.
+func (s *Store) LookupShareToken(ctx context.Context, token string) (_ *ShareToken, err error) {
+	defer s.observe("LookupShareToken", time.Now(), &err)
+
+	var row ShareToken
+	err = s.pool.QueryRow(ctx, `
+        SELECT id::text, batch_id::text, created_at, expires_at
+        FROM share_tokens
+        WHERE token_hash = $1 AND revoked_at IS NULL AND expires_at > now()`,
+		hashShareToken(token),
+	).Scan(&row.ID, &row.BatchID, &row.CreatedAt, &row.ExpiresAt)
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &row, nil
+}
+
+// RevokeShareToken revokes a token by ID and reports whether an active token
+// was revoked.
+func (s *Store) RevokeShareToken(ctx context.Context, id string) (_ bool, err error) {
+	defer s.observe("RevokeShareToken", time.Now(), &err)
+
+	var revoked bool
+	err = s.withWriteRetry(ctx, func(ctx context.Context) error {
+		tag, err := s.pool.Exec(ctx, `
+            UPDATE share_tokens
+            SET revoked_at = now()
+            WHERE id = $1 AND revoked_at IS NULL`, id)
+		if err != nil {
+			return err
+		}
+		revoked = tag.RowsAffected() > 0
+		return nil
+	})
+	return revoked, err
+}
+
+func hashShareToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
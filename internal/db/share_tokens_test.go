@@ -0,0 +1,86 @@
+package db
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/igor-kupczynski/alpha-monday/internal/decimal"
+)
+
+func TestShareTokenLifecycle(t *testing.T) {
+	truncateTables(t)
+
+	store := NewStore(testPool)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	runDate := time.Date(2026, 1, 27, 0, 0, 0, 0, time.UTC)
+	result, err := store.CreateBatchWithInitialCheckpoint(ctx, CreateBatchInput{
+		RunDate:               runDate,
+		BenchmarkSymbol:       "SPY",
+		BenchmarkInitialPrice: decimal.MustParse("401.25"),
+		Status:                "active",
+		Picks: []NewPick{
+			{Ticker: "AAPL", Action: "BUY", Reasoning: "ok", InitialPrice: decimal.MustParse("178.10")},
+		},
+		CheckpointDate:   runDate,
+		CheckpointStatus: "computed",
+		BenchmarkPrice:   decimal.MustParse("401.25"),
+	})
+	if err != nil {
+		t.Fatalf("create batch: %v", err)
+	}
+
+	missing, token, err := store.CreateShareToken(ctx, "aaaaaaaa-aaaa-aaaa-aaaa-aaaaaaaaaaaa", time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatalf("create token for missing batch: %v", err)
+	}
+	if missing != nil || token != "" {
+		t.Fatalf("expected no token for missing batch")
+	}
+
+	created, token, err := store.CreateShareToken(ctx, result.BatchID, time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatalf("create token: %v", err)
+	}
+	if created == nil || !strings.HasPrefix(token, shareTokenPrefix) {
+		t.Fatalf("unexpected token %q (%+v)", token, created)
+	}
+
+	var storedHash string
+	if err := testPool.QueryRow(ctx, "SELECT token_hash FROM share_tokens WHERE id = $1", created.ID).Scan(&storedHash); err != nil {
+		t.Fatalf("read token: %v", err)
+	}
+	if storedHash == token {
+		t.Fatalf("expected only the token hash to be stored")
+	}
+
+	found, err := store.LookupShareToken(ctx, token)
+	if err != nil {
+		t.Fatalf("lookup token: %v", err)
+	}
+	if found == nil || found.BatchID != result.BatchID {
+		t.Fatalf("expected token scoped to %s, got %+v", result.BatchID, found)
+	}
+
+	expired, expiredToken, err := store.CreateShareToken(ctx, result.BatchID, time.Now().Add(-time.Minute))
+	if err != nil || expired == nil {
+		t.Fatalf("create expired token: %v", err)
+	}
+	if found, err := store.LookupShareToken(ctx, expiredToken); err != nil || found != nil {
+		t.Fatalf("expected expired token to be rejected, got %+v (%v)", found, err)
+	}
+
+	revoked, err := store.RevokeShareToken(ctx, created.ID)
+	if err != nil || !revoked {
+		t.Fatalf("revoke token: %v (revoked=%v)", err, revoked)
+	}
+	if found, err := store.LookupShareToken(ctx, token); err != nil || found != nil {
+		t.Fatalf("expected revoked token to be rejected, got %+v (%v)", found, err)
+	}
+	if revoked, err := store.RevokeShareToken(ctx, created.ID); err != nil || revoked {
+		t.Fatalf("expected second revoke to be a no-op, got %v (%v)", revoked, err)
+	}
+}
@@ -0,0 +1,47 @@
+package db
+
+import (
+	"context"
+	"time"
+)
+
+// StaleBatch is an active batch whose run date is old enough that its
+// workflow should have finished.
+type StaleBatch struct {
+	ID                 string
+	RunDate            string
+	LastCheckpointDate *string
+}
+
+// ListStaleBatches returns active batches with a run date before
+// runDateBefore, oldest first, with the date of their newest checkpoint.
+func (s *Store) ListStaleBatches(ctx context.Context, runDateBefore time.Time) (_ []StaleBatch, err error) {
+	defer s.observe("ListStaleBatches", time.Now(), &err)
+
+	rows, err := s.pool.Query(ctx, `
+        SELECT b.id::text, b.run_date::text, max(c.checkpoint_date)::text
+        FROM batches b
+        LEFT JOIN checkpoints c ON c.batch_id = b.id
+        WHERE b.status = 'active' AND b.run_date < $1::date AND b.deleted_at IS NULL
+        GROUP BY b.id
+        ORDER BY b.run_date`,
+		runDateBefore.Format("2006-01-02"),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	batches := []StaleBatch{}
+	for rows.Next() {
+		var batch StaleBatch
+		if err := rows.Scan(&batch.ID, &batch.RunDate, &batch.LastCheckpointDate); err != nil {
+			return nil, err
+		}
+		batches = append(batches, batch)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return batches, nil
+}
@@ -0,0 +1,62 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/igor-kupczynski/alpha-monday/internal/decimal"
+)
+
+func TestListStaleBatches(t *testing.T) {
+	truncateTables(t)
+
+	store := NewStore(testPool)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	ids := map[string]string{}
+	for _, runDate := range []time.Time{
+		time.Date(2026, 1, 5, 0, 0, 0, 0, time.UTC),
+		time.Date(2026, 1, 12, 0, 0, 0, 0, time.UTC),
+		time.Date(2026, 2, 23, 0, 0, 0, 0, time.UTC),
+	} {
+		result, err := store.CreateBatchWithInitialCheckpoint(ctx, CreateBatchInput{
+			RunDate:               runDate,
+			BenchmarkSymbol:       "SPY",
+			BenchmarkInitialPrice: decimal.MustParse("401.25"),
+			Status:                "active",
+			Picks: []NewPick{
+				{Ticker: "AAPL", Action: "BUY", Reasoning: "ok", InitialPrice: decimal.MustParse("178.10")},
+			},
+			CheckpointDate:   runDate.AddDate(0, 0, -3),
+			CheckpointStatus: "computed",
+			BenchmarkPrice:   decimal.MustParse("401.25"),
+		})
+		if err != nil {
+			t.Fatalf("create batch: %v", err)
+		}
+		ids[runDate.Format("2006-01-02")] = result.BatchID
+	}
+	if _, err := store.CreateCheckpointWithMetrics(ctx, CreateCheckpointInput{
+		BatchID:        ids["2026-01-05"],
+		CheckpointDate: time.Date(2026, 1, 16, 0, 0, 0, 0, time.UTC),
+		Status:         "skipped",
+	}); err != nil {
+		t.Fatalf("create checkpoint: %v", err)
+	}
+	if err := store.UpdateBatchStatus(ctx, ids["2026-01-12"], "completed"); err != nil {
+		t.Fatalf("update status: %v", err)
+	}
+
+	stale, err := store.ListStaleBatches(ctx, time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("list stale batches: %v", err)
+	}
+	if len(stale) != 1 || stale[0].ID != ids["2026-01-05"] || stale[0].RunDate != "2026-01-05" {
+		t.Fatalf("expected only the old active batch, got %+v", stale)
+	}
+	if stale[0].LastCheckpointDate == nil || *stale[0].LastCheckpointDate != "2026-01-16" {
+		t.Fatalf("expected newest checkpoint date, got %v", stale[0].LastCheckpointDate)
+	}
+}
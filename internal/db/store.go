@@ -3,29 +3,125 @@ package db
 import (
 	"context"
 	"database/sql"
+	"time"
 
+	"github.com/igor-kupczynski/alpha-monday/internal/decimal"
+	"github.com/igor-kupczynski/alpha-monday/internal/integrations/retry"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 type Store struct {
-	pool *pgxpool.Pool
+	pool       *pgxpool.Pool
+	metrics    *QueryMetrics
+	queryLog   QueryLogConfig
+	writeRetry retry.Config
 }
 
-func NewStore(pool *pgxpool.Pool) *Store {
-	return &Store{pool: pool}
+type StoreOption func(*Store)
+
+// WithQueryMetrics records per-method query metrics on every Store call.
+func WithQueryMetrics(metrics *QueryMetrics) StoreOption {
+	return func(s *Store) {
+		s.metrics = metrics
+	}
+}
+
+func NewStore(pool *pgxpool.Pool, opts ...StoreOption) *Store {
+	store := &Store{pool: pool, writeRetry: retry.DefaultConfig()}
+	for _, opt := range opts {
+		opt(store)
+	}
+	return store
+}
+
+// observe records the duration and outcome of a Store method. Call it deferred
+// with a pointer to the method's named error result.
+func (s *Store) observe(method string, start time.Time, errp *error) {
+	if s.metrics == nil && s.queryLog.Logger == nil {
+		return
+	}
+	duration := time.Since(start)
+	outcome := "ok"
+	if errp != nil && *errp != nil {
+		outcome = "error"
+	}
+	if s.metrics != nil {
+		s.metrics.observe(method, duration, outcome)
+	}
+	if level, ok := s.queryLog.level(context.Background(), duration); ok {
+		s.queryLog.Logger.Log(context.Background(), level, "store call",
+			"method", method, "duration_ms", duration.Milliseconds(), "outcome", outcome)
+	}
 }
 
 func (s *Store) Ping(ctx context.Context) error {
 	return s.pool.Ping(ctx)
 }
 
+// querier is the read subset shared by the pool and transactions.
+type querier interface {
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+// readSnapshot runs fn in a read-only REPEATABLE READ transaction so that
+// multi-query reads observe a single consistent snapshot of the database.
+func (s *Store) readSnapshot(ctx context.Context, fn func(q querier) error) error {
+	tx, err := s.pool.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.RepeatableRead, AccessMode: pgx.ReadOnly})
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// Batch lifecycle states. A batch is active while its checkpoints run and
+// ends completed, failed (the workflow hit an unrecoverable error), expired
+// (abandoned before its horizon ended and closed by the stale-batch sweep) or
+// cancelled (stopped by an admin).
+const (
+	BatchStatusActive    = "active"
+	BatchStatusCompleted = "completed"
+	BatchStatusFailed    = "failed"
+	BatchStatusExpired   = "expired"
+	BatchStatusCancelled = "cancelled"
+)
+
+// BatchStatuses lists every valid batch status.
+var BatchStatuses = []string{BatchStatusActive, BatchStatusCompleted, BatchStatusFailed, BatchStatusExpired, BatchStatusCancelled}
+
+// ValidBatchStatus reports whether status is a known batch status.
+func ValidBatchStatus(status string) bool {
+	for _, known := range BatchStatuses {
+		if status == known {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidBatchTransition reports whether a batch may move from one status to
+// another. Only active batches change status; the others are final.
+func ValidBatchTransition(from, to string) bool {
+	return from == BatchStatusActive && to != BatchStatusActive && ValidBatchStatus(to)
+}
+
 type Batch struct {
 	ID                    string
 	RunDate               string
 	Status                string
 	BenchmarkSymbol       string
-	BenchmarkInitialPrice string
+	BenchmarkInitialPrice decimal.Decimal
+	// ConfigHash groups batches generated with the same prompt, model,
+	// parameters and universe. It is nil for batches created before hashing.
+	ConfigHash *string
 }
 
 type Pick struct {
@@ -33,87 +129,259 @@ type Pick struct {
 	Ticker       string
 	Action       string
 	Reasoning    string
-	InitialPrice string
+	InitialPrice decimal.Decimal
+	// TargetPrice is the model's optional price target for the end of the
+	// horizon. TargetErrorPct is how far the final price landed from it, in
+	// percent of the target, set when the batch completes.
+	TargetPrice    *decimal.Decimal
+	TargetErrorPct *decimal.Decimal
 }
 
 type PickMetric struct {
 	ID                string
 	PickID            string
-	CurrentPrice      string
-	AbsoluteReturnPct string
-	VsBenchmarkPct    string
+	CurrentPrice      decimal.Decimal
+	AbsoluteReturnPct decimal.Decimal
+	VsBenchmarkPct    decimal.Decimal
+	OpenPrice         *decimal.Decimal
+	HighPrice         *decimal.Decimal
+	LowPrice          *decimal.Decimal
+	Volume            *int64
 }
 
 type Checkpoint struct {
 	ID                 string
 	CheckpointDate     string
 	Status             string
-	BenchmarkPrice     *string
-	BenchmarkReturnPct *string
+	BenchmarkPrice     *decimal.Decimal
+	BenchmarkReturnPct *decimal.Decimal
 	Metrics            []PickMetric
+	CreatedAt          time.Time
 }
 
 type LatestBatchResult struct {
 	Batch            Batch
 	Picks            []Pick
 	LatestCheckpoint *Checkpoint
+	// LastModified is the batch's creation, last status change or newest
+	// checkpoint, whichever is latest, as in BatchDetails.
+	LastModified time.Time
 }
 
 type BatchesPage struct {
 	Batches    []Batch
-	NextCursor *string
+	NextCursor *BatchCursor
+	// Performance is only loaded by ListBatchesWithPerformance, one entry
+	// per batch in Batches order.
+	Performance []BatchPerformance
+}
+
+// BatchPerformance is a batch's standing at its latest computed checkpoint.
+// Both fields are nil before the first one.
+type BatchPerformance struct {
+	LatestCheckpointDate *string
+	// AverageVsBenchmarkPct is the mean vs_benchmark_pct of the picks scored
+	// at that checkpoint, rounded to 4 places like the summary averages.
+	AverageVsBenchmarkPct *decimal.Decimal
+}
+
+// BatchCursor is the position after which a batch page starts: the run_date
+// and id of the previous page's last batch. Batches are ordered by both, so
+// pages stay stable even if two batches share a run_date.
+type BatchCursor struct {
+	RunDate string
+	ID      string
 }
 
 type BatchDetails struct {
 	Batch       Batch
 	Picks       []Pick
 	Checkpoints []Checkpoint
+	// CheckpointsNextCursor is set when BatchDetailsScope.CheckpointLimit
+	// cut the checkpoints short: the checkpoint_date to continue after.
+	CheckpointsNextCursor *string
+	LastModified          time.Time
+}
+
+// BatchDetailsScope selects the nested data BatchDetailsFor loads. Metrics
+// are nested in checkpoints, so they are only loaded with them.
+//
+// CheckpointLimit and CheckpointCursor page the checkpoints like
+// ListCheckpoints: oldest first, after the cursor's checkpoint_date. A zero
+// limit loads all of them.
+type BatchDetailsScope struct {
+	Checkpoints      bool
+	Metrics          bool
+	CheckpointLimit  int
+	CheckpointCursor *string
+}
+
+// FullBatchDetails loads checkpoints with their metrics, as BatchDetails does.
+var FullBatchDetails = BatchDetailsScope{Checkpoints: true, Metrics: true}
+
+// batchLastModifiedSQL is when batch b last changed as its readers see it: its
+// creation, its last status change (updated_at), or its newest checkpoint.
+const batchLastModifiedSQL = `GREATEST(b.created_at, b.updated_at, (SELECT max(c.created_at) FROM checkpoints c WHERE c.batch_id = b.id))`
+
+type CheckpointsPage struct {
+	Checkpoints []Checkpoint
+	NextCursor  *string
 }
 
-func (s *Store) LatestBatch(ctx context.Context) (*LatestBatchResult, error) {
+func (s *Store) LatestBatch(ctx context.Context) (_ *LatestBatchResult, err error) {
+	defer s.observe("LatestBatch", time.Now(), &err)
+
 	const latestBatchSQL = `
-        SELECT id::text, run_date::text, status, benchmark_symbol, benchmark_initial_price::text
-        FROM batches
-        ORDER BY run_date DESC
-        LIMIT 1`
+        SELECT b.id::text, b.run_date::text, b.status, b.benchmark_symbol, b.benchmark_initial_price::text, b.config_hash,
+               COALESCE(p.picks, '[]'::json), c.checkpoint,
+               ` + batchLastModifiedSQL + `
+        FROM (
+            SELECT id, run_date, status, benchmark_symbol, benchmark_initial_price, config_hash, created_at, updated_at
+            FROM batches
+            WHERE deleted_at IS NULL
+            ORDER BY run_date DESC
+            LIMIT 1
+        ) b
+        LEFT JOIN LATERAL (
+            SELECT json_agg(` + pickJSONSQL + ` ORDER BY p.ticker) AS picks
+            FROM picks p
+            WHERE p.batch_id = b.id
+        ) p ON true
+        LEFT JOIN LATERAL (
+            SELECT ` + checkpointJSONSQL + ` AS checkpoint
+            FROM checkpoints c
+            WHERE c.batch_id = b.id
+            ORDER BY c.checkpoint_date DESC
+            LIMIT 1
+        ) c ON true`
 
 	var batch Batch
+	var picksJSON []byte
+	var checkpointJSON []byte
+	var lastModified time.Time
 	row := s.pool.QueryRow(ctx, latestBatchSQL)
-	if err := row.Scan(&batch.ID, &batch.RunDate, &batch.Status, &batch.BenchmarkSymbol, &batch.BenchmarkInitialPrice); err != nil {
+	if err := row.Scan(&batch.ID, &batch.RunDate, &batch.Status, &batch.BenchmarkSymbol, &batch.BenchmarkInitialPrice, &batch.ConfigHash, &picksJSON, &checkpointJSON, &lastModified); err != nil {
 		if err == pgx.ErrNoRows {
 			return nil, nil
 		}
 		return nil, err
 	}
 
-	picks, err := s.listPicks(ctx, batch.ID)
+	picks, err := decodePicksJSON(picksJSON)
 	if err != nil {
 		return nil, err
 	}
 
-	checkpoint, err := s.latestCheckpoint(ctx, batch.ID)
-	if err != nil {
-		return nil, err
+	var checkpoint *Checkpoint
+	if len(checkpointJSON) > 0 {
+		decoded, err := decodeCheckpointJSON(checkpointJSON)
+		if err != nil {
+			return nil, err
+		}
+		checkpoint = &decoded
 	}
 
 	return &LatestBatchResult{
 		Batch:            batch,
 		Picks:            picks,
 		LatestCheckpoint: checkpoint,
+		LastModified:     lastModified,
 	}, nil
 }
 
-func (s *Store) ListBatches(ctx context.Context, limit int, cursor *string) (BatchesPage, error) {
-	const listSQL = `
-        SELECT id::text, run_date::text, status, benchmark_symbol, benchmark_initial_price::text
-        FROM batches
-        ORDER BY run_date DESC
+// BatchFilter narrows ListBatches and CountBatches; empty fields match every
+// batch.
+type BatchFilter struct {
+	Status string
+	// Ticker keeps batches with a pick of that ticker.
+	Ticker string
+}
+
+// batchFilterSQL matches batches b against a BatchFilter passed as the status
+// and ticker parameters, leaving out deleted batches. The ticker is matched
+// through picks_ticker_idx.
+func batchFilterSQL(statusParam, tickerParam string) string {
+	return `b.deleted_at IS NULL
+          AND (` + statusParam + ` = '' OR b.status = ` + statusParam + `)
+          AND (` + tickerParam + ` = '' OR EXISTS (SELECT 1 FROM picks p WHERE p.ticker = ` + tickerParam + ` AND p.batch_id = b.id))`
+}
+
+// CountBatches returns the number of batches matching filter.
+func (s *Store) CountBatches(ctx context.Context, filter BatchFilter) (count int, err error) {
+	defer s.observe("CountBatches", time.Now(), &err)
+
+	err = s.pool.QueryRow(ctx, `SELECT count(*) FROM batches b WHERE `+batchFilterSQL("$1", "$2"), filter.Status, filter.Ticker).Scan(&count)
+	return count, err
+}
+
+// CountCheckpoints returns the number of checkpoints of a batch.
+func (s *Store) CountCheckpoints(ctx context.Context, batchID string) (count int, err error) {
+	defer s.observe("CountCheckpoints", time.Now(), &err)
+
+	err = s.pool.QueryRow(ctx, `SELECT count(*) FROM checkpoints WHERE batch_id = $1`, batchID).Scan(&count)
+	return count, err
+}
+
+// LatestCheckpointAt returns when the newest checkpoint was written, or nil
+// before the first one.
+func (s *Store) LatestCheckpointAt(ctx context.Context) (_ *time.Time, err error) {
+	defer s.observe("LatestCheckpointAt", time.Now(), &err)
+
+	var createdAt *time.Time
+	err = s.pool.QueryRow(ctx, `
+        SELECT max(c.created_at)
+        FROM checkpoints c
+        JOIN batches b ON b.id = c.batch_id
+        WHERE b.deleted_at IS NULL`).Scan(&createdAt)
+	return createdAt, err
+}
+
+// ListBatches pages through batches, newest run_date first (ties broken by id),
+// keeping only those matching filter.
+func (s *Store) ListBatches(ctx context.Context, limit int, cursor *BatchCursor, filter BatchFilter) (_ BatchesPage, err error) {
+	defer s.observe("ListBatches", time.Now(), &err)
+
+	return s.listBatches(ctx, limit, cursor, filter, false)
+}
+
+// ListBatchesWithPerformance is ListBatches with each batch's
+// BatchPerformance, joined into the same statement.
+func (s *Store) ListBatchesWithPerformance(ctx context.Context, limit int, cursor *BatchCursor, filter BatchFilter) (_ BatchesPage, err error) {
+	defer s.observe("ListBatchesWithPerformance", time.Now(), &err)
+
+	return s.listBatches(ctx, limit, cursor, filter, true)
+}
+
+// batchPerformanceJoinSQL joins each batch b to its latest computed
+// checkpoint and that checkpoint's average vs_benchmark_pct, as perf.
+const batchPerformanceJoinSQL = `
+        LEFT JOIN LATERAL (
+            SELECT c.checkpoint_date::text AS checkpoint_date,
+                   (SELECT round(avg(m.vs_benchmark_pct), 4)::text
+                    FROM pick_checkpoint_metrics m
+                    WHERE m.checkpoint_id = c.id) AS vs_benchmark_pct
+            FROM checkpoints c
+            WHERE c.batch_id = b.id AND c.status = 'computed'
+            ORDER BY c.checkpoint_date DESC
+            LIMIT 1
+        ) perf ON true`
+
+func (s *Store) listBatches(ctx context.Context, limit int, cursor *BatchCursor, filter BatchFilter, withPerformance bool) (BatchesPage, error) {
+	columns, join := "", ""
+	if withPerformance {
+		columns, join = ", perf.checkpoint_date, perf.vs_benchmark_pct", batchPerformanceJoinSQL
+	}
+	listSQL := `
+        SELECT b.id::text, b.run_date::text, b.status, b.benchmark_symbol, b.benchmark_initial_price::text, b.config_hash` + columns + `
+        FROM batches b` + join + `
+        WHERE ` + batchFilterSQL("$2", "$3") + `
+        ORDER BY b.run_date DESC, b.id DESC
         LIMIT $1`
-	const listCursorSQL = `
-        SELECT id::text, run_date::text, status, benchmark_symbol, benchmark_initial_price::text
-        FROM batches
-        WHERE run_date < $1::date
-        ORDER BY run_date DESC
+	listCursorSQL := `
+        SELECT b.id::text, b.run_date::text, b.status, b.benchmark_symbol, b.benchmark_initial_price::text, b.config_hash` + columns + `
+        FROM batches b` + join + `
+        WHERE (b.run_date, b.id) < ($1::date, $4::uuid) AND ` + batchFilterSQL("$3", "$5") + `
+        ORDER BY b.run_date DESC, b.id DESC
         LIMIT $2`
 
 	queryLimit := limit + 1
@@ -121,9 +389,9 @@ func (s *Store) ListBatches(ctx context.Context, limit int, cursor *string) (Bat
 	var err error
 
 	if cursor != nil {
-		rows, err = s.pool.Query(ctx, listCursorSQL, *cursor, queryLimit)
+		rows, err = s.pool.Query(ctx, listCursorSQL, cursor.RunDate, queryLimit, filter.Status, cursor.ID, filter.Ticker)
 	} else {
-		rows, err = s.pool.Query(ctx, listSQL, queryLimit)
+		rows, err = s.pool.Query(ctx, listSQL, queryLimit, filter.Status, filter.Ticker)
 	}
 	if err != nil {
 		return BatchesPage{}, err
@@ -131,230 +399,421 @@ func (s *Store) ListBatches(ctx context.Context, limit int, cursor *string) (Bat
 	defer rows.Close()
 
 	batches := make([]Batch, 0, limit)
+	var performance []BatchPerformance
+	if withPerformance {
+		performance = make([]BatchPerformance, 0, limit)
+	}
 	for rows.Next() {
 		var batch Batch
-		if err := rows.Scan(&batch.ID, &batch.RunDate, &batch.Status, &batch.BenchmarkSymbol, &batch.BenchmarkInitialPrice); err != nil {
+		dest := []any{&batch.ID, &batch.RunDate, &batch.Status, &batch.BenchmarkSymbol, &batch.BenchmarkInitialPrice, &batch.ConfigHash}
+		var perf BatchPerformance
+		var vsBenchmark sql.NullString
+		if withPerformance {
+			dest = append(dest, &perf.LatestCheckpointDate, &vsBenchmark)
+		}
+		if err := rows.Scan(dest...); err != nil {
 			return BatchesPage{}, err
 		}
 		batches = append(batches, batch)
+		if withPerformance {
+			if perf.AverageVsBenchmarkPct, err = nullDecimalPtr(vsBenchmark); err != nil {
+				return BatchesPage{}, err
+			}
+			performance = append(performance, perf)
+		}
 	}
 	if err := rows.Err(); err != nil {
 		return BatchesPage{}, err
 	}
 
-	var nextCursor *string
+	var nextCursor *BatchCursor
 	if len(batches) > limit {
-		last := batches[limit-1].RunDate
-		nextCursor = &last
+		last := batches[limit-1]
+		nextCursor = &BatchCursor{RunDate: last.RunDate, ID: last.ID}
 		batches = batches[:limit]
+		if withPerformance {
+			performance = performance[:limit]
+		}
 	}
 
-	return BatchesPage{Batches: batches, NextCursor: nextCursor}, nil
+	return BatchesPage{Batches: batches, NextCursor: nextCursor, Performance: performance}, nil
+}
+
+func (s *Store) BatchDetails(ctx context.Context, batchID string) (_ *BatchDetails, err error) {
+	defer s.observe("BatchDetails", time.Now(), &err)
+
+	return s.batchDetails(ctx, batchID, FullBatchDetails)
+}
+
+// BatchDetailsFor is BatchDetails limited to scope; checkpoints, or their
+// metrics, left out of scope are not queried and come back empty.
+func (s *Store) BatchDetailsFor(ctx context.Context, batchID string, scope BatchDetailsScope) (_ *BatchDetails, err error) {
+	defer s.observe("BatchDetailsFor", time.Now(), &err)
+
+	return s.batchDetails(ctx, batchID, scope)
 }
 
-func (s *Store) BatchDetails(ctx context.Context, batchID string) (*BatchDetails, error) {
-	const batchSQL = `
-        SELECT id::text, run_date::text, status, benchmark_symbol, benchmark_initial_price::text
-        FROM batches
-        WHERE id = $1`
+func (s *Store) batchDetails(ctx context.Context, batchID string, scope BatchDetailsScope) (*BatchDetails, error) {
+	checkpointsSQL := `'[]'::json`
+	if scope.Checkpoints {
+		checkpointSQL := checkpointSummaryJSONSQL
+		if scope.Metrics {
+			checkpointSQL = checkpointJSONSQL
+		}
+		// LIMIT NULL is no limit.
+		checkpointsSQL = `COALESCE((
+                   SELECT json_agg(` + checkpointSQL + ` ORDER BY c.checkpoint_date)
+                   FROM (
+                       SELECT *
+                       FROM checkpoints
+                       WHERE batch_id = b.id AND ($2::date IS NULL OR checkpoint_date > $2::date)
+                       ORDER BY checkpoint_date
+                       LIMIT $3
+                   ) c
+               ), '[]'::json)`
+	}
+
+	batchSQL := `
+        SELECT b.id::text, b.run_date::text, b.status, b.benchmark_symbol, b.benchmark_initial_price::text, b.config_hash,
+               ` + batchLastModifiedSQL + `,
+               COALESCE((
+                   SELECT json_agg(` + pickJSONSQL + ` ORDER BY p.ticker)
+                   FROM picks p
+                   WHERE p.batch_id = b.id
+               ), '[]'::json),
+               ` + checkpointsSQL + `
+        FROM batches b
+        WHERE b.id = $1 AND b.deleted_at IS NULL`
+
+	// One extra checkpoint tells whether another page follows.
+	var queryLimit *int
+	if scope.CheckpointLimit > 0 {
+		limit := scope.CheckpointLimit + 1
+		queryLimit = &limit
+	}
 
 	var batch Batch
-	row := s.pool.QueryRow(ctx, batchSQL, batchID)
-	if err := row.Scan(&batch.ID, &batch.RunDate, &batch.Status, &batch.BenchmarkSymbol, &batch.BenchmarkInitialPrice); err != nil {
+	var lastModified time.Time
+	var picksJSON []byte
+	var checkpointsJSON []byte
+	args := []any{batchID}
+	if scope.Checkpoints {
+		args = append(args, scope.CheckpointCursor, queryLimit)
+	}
+	row := s.pool.QueryRow(ctx, batchSQL, args...)
+	if err := row.Scan(&batch.ID, &batch.RunDate, &batch.Status, &batch.BenchmarkSymbol, &batch.BenchmarkInitialPrice, &batch.ConfigHash, &lastModified, &picksJSON, &checkpointsJSON); err != nil {
 		if err == pgx.ErrNoRows {
 			return nil, nil
 		}
 		return nil, err
 	}
 
-	picks, err := s.listPicks(ctx, batch.ID)
+	picks, err := decodePicksJSON(picksJSON)
 	if err != nil {
 		return nil, err
 	}
-
-	checkpoints, err := s.listCheckpoints(ctx, batch.ID)
+	checkpoints, err := decodeCheckpointsJSON(checkpointsJSON)
 	if err != nil {
 		return nil, err
 	}
-
-	if len(checkpoints) > 0 {
-		metrics, err := s.listMetricsForBatch(ctx, batch.ID)
-		if err != nil {
-			return nil, err
-		}
-		metricsByCheckpoint := map[string][]PickMetric{}
-		for _, metric := range metrics {
-			metricsByCheckpoint[metric.checkpointID] = append(metricsByCheckpoint[metric.checkpointID], metric.metric)
-		}
-		for i := range checkpoints {
-			checkpoints[i].Metrics = metricsByCheckpoint[checkpoints[i].ID]
-		}
+	var nextCursor *string
+	if scope.CheckpointLimit > 0 && len(checkpoints) > scope.CheckpointLimit {
+		last := checkpoints[scope.CheckpointLimit-1].CheckpointDate
+		nextCursor = &last
+		checkpoints = checkpoints[:scope.CheckpointLimit]
 	}
 
 	return &BatchDetails{
-		Batch:       batch,
-		Picks:       picks,
-		Checkpoints: checkpoints,
+		Batch:                 batch,
+		Picks:                 picks,
+		Checkpoints:           checkpoints,
+		CheckpointsNextCursor: nextCursor,
+		LastModified:          lastModified,
 	}, nil
 }
 
-type metricRow struct {
-	checkpointID string
-	metric       PickMetric
-}
+// BatchLastModified returns the time of the latest write visible in a batch's
+// detail: the newest checkpoint, the last status change, or the batch's
+// creation. It returns nil when the batch does not exist or was deleted.
+func (s *Store) BatchLastModified(ctx context.Context, batchID string) (_ *time.Time, err error) {
+	defer s.observe("BatchLastModified", time.Now(), &err)
 
-func (s *Store) listMetricsForBatch(ctx context.Context, batchID string) ([]metricRow, error) {
-	const metricsSQL = `
-        SELECT m.id::text, m.checkpoint_id::text, m.pick_id::text,
-               m.current_price::text, m.absolute_return_pct::text, m.vs_benchmark_pct::text
-        FROM pick_checkpoint_metrics m
-        JOIN checkpoints c ON c.id = m.checkpoint_id
-        WHERE c.batch_id = $1
-        ORDER BY c.checkpoint_date ASC, m.pick_id`
-
-	rows, err := s.pool.Query(ctx, metricsSQL, batchID)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
+	const lastModifiedSQL = `
+        SELECT ` + batchLastModifiedSQL + `
+        FROM batches b
+        WHERE b.id = $1 AND b.deleted_at IS NULL`
 
-	var result []metricRow
-	for rows.Next() {
-		var row metricRow
-		var metric PickMetric
-		if err := rows.Scan(&metric.ID, &row.checkpointID, &metric.PickID, &metric.CurrentPrice, &metric.AbsoluteReturnPct, &metric.VsBenchmarkPct); err != nil {
-			return nil, err
+	var lastModified time.Time
+	if err := s.pool.QueryRow(ctx, lastModifiedSQL, batchID).Scan(&lastModified); err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
 		}
-		row.metric = metric
-		result = append(result, row)
-	}
-	if err := rows.Err(); err != nil {
 		return nil, err
 	}
-	return result, nil
+	return &lastModified, nil
 }
 
-func (s *Store) listPicks(ctx context.Context, batchID string) ([]Pick, error) {
-	const picksSQL = `
-        SELECT id::text, ticker, action, reasoning, initial_price::text
-        FROM picks
-        WHERE batch_id = $1
-        ORDER BY ticker`
+// BatchFreshness is what a cache needs to revalidate a batch: its status,
+// which sets the max-age, and its Last-Modified.
+type BatchFreshness struct {
+	Status       string
+	LastModified time.Time
+}
 
-	rows, err := s.pool.Query(ctx, picksSQL, batchID)
-	if err != nil {
+// BatchFreshness returns a batch's status and BatchLastModified from one
+// query on the batch row, without loading picks or checkpoints. It returns nil
+// when the batch does not exist or was deleted.
+func (s *Store) BatchFreshness(ctx context.Context, batchID string) (_ *BatchFreshness, err error) {
+	defer s.observe("BatchFreshness", time.Now(), &err)
+
+	const freshnessSQL = `
+        SELECT b.status, ` + batchLastModifiedSQL + `
+        FROM batches b
+        WHERE b.id = $1 AND b.deleted_at IS NULL`
+
+	var freshness BatchFreshness
+	if err := s.pool.QueryRow(ctx, freshnessSQL, batchID).Scan(&freshness.Status, &freshness.LastModified); err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
 		return nil, err
 	}
-	defer rows.Close()
+	return &freshness, nil
+}
 
-	var picks []Pick
-	for rows.Next() {
-		var pick Pick
-		if err := rows.Scan(&pick.ID, &pick.Ticker, &pick.Action, &pick.Reasoning, &pick.InitialPrice); err != nil {
-			return nil, err
+// BatchStatus returns the status of a batch, or "" when it does not exist or
+// was deleted.
+func (s *Store) BatchStatus(ctx context.Context, batchID string) (_ string, err error) {
+	defer s.observe("BatchStatus", time.Now(), &err)
+
+	var status string
+	if err := s.pool.QueryRow(ctx, `SELECT status FROM batches WHERE id = $1 AND deleted_at IS NULL`, batchID).Scan(&status); err != nil {
+		if err == pgx.ErrNoRows {
+			return "", nil
 		}
-		picks = append(picks, pick)
+		return "", err
 	}
-	if err := rows.Err(); err != nil {
+	return status, nil
+}
+
+// BatchIDForRunDate returns the id of the live batch for runDate, or nil when
+// there is none. Deleted batches free their run_date, matching the partial
+// unique index.
+func (s *Store) BatchIDForRunDate(ctx context.Context, runDate string) (_ *string, err error) {
+	defer s.observe("BatchIDForRunDate", time.Now(), &err)
+
+	var batchID string
+	if err := s.pool.QueryRow(ctx, `SELECT id::text FROM batches WHERE run_date = $1 AND deleted_at IS NULL`, runDate).Scan(&batchID); err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
 		return nil, err
 	}
-	return picks, nil
+	return &batchID, nil
 }
 
-func (s *Store) listCheckpoints(ctx context.Context, batchID string) ([]Checkpoint, error) {
-	const checkpointsSQL = `
+// ListCheckpoints returns a page of checkpoints (with metrics) for a batch, oldest first.
+// It returns nil when the batch does not exist or was deleted.
+func (s *Store) ListCheckpoints(ctx context.Context, batchID string, limit int, cursor *string) (_ *CheckpointsPage, err error) {
+	defer s.observe("ListCheckpoints", time.Now(), &err)
+
+	const batchExistsSQL = `
+        SELECT EXISTS (SELECT 1 FROM batches WHERE id = $1 AND deleted_at IS NULL)`
+	const listSQL = `
         SELECT id::text, checkpoint_date::text, status,
-               benchmark_price::text, benchmark_return_pct::text
+               benchmark_price::text, benchmark_return_pct::text, created_at
         FROM checkpoints
         WHERE batch_id = $1
-        ORDER BY checkpoint_date ASC`
+        ORDER BY checkpoint_date ASC
+        LIMIT $2`
+	const listCursorSQL = `
+        SELECT id::text, checkpoint_date::text, status,
+               benchmark_price::text, benchmark_return_pct::text, created_at
+        FROM checkpoints
+        WHERE batch_id = $1 AND checkpoint_date > $2::date
+        ORDER BY checkpoint_date ASC
+        LIMIT $3`
+
+	// The existence check, the page and its metrics are separate statements;
+	// a snapshot keeps a checkpoint rewritten in between (a skipped checkpoint
+	// recomputed in place) from pairing old rows with new metrics.
+	var result *CheckpointsPage
+	err = s.readSnapshot(ctx, func(q querier) error {
+		var exists bool
+		if err := q.QueryRow(ctx, batchExistsSQL, batchID).Scan(&exists); err != nil {
+			return err
+		}
+		if !exists {
+			return nil
+		}
 
-	rows, err := s.pool.Query(ctx, checkpointsSQL, batchID)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
+		queryLimit := limit + 1
+		var rows pgx.Rows
+		var err error
+		if cursor != nil {
+			rows, err = q.Query(ctx, listCursorSQL, batchID, *cursor, queryLimit)
+		} else {
+			rows, err = q.Query(ctx, listSQL, batchID, queryLimit)
+		}
+		if err != nil {
+			return err
+		}
+		checkpoints, err := scanCheckpoints(rows)
+		if err != nil {
+			return err
+		}
 
-	var checkpoints []Checkpoint
-	for rows.Next() {
-		var checkpoint Checkpoint
-		var benchmarkPrice sql.NullString
-		var benchmarkReturn sql.NullString
-		if err := rows.Scan(&checkpoint.ID, &checkpoint.CheckpointDate, &checkpoint.Status, &benchmarkPrice, &benchmarkReturn); err != nil {
-			return nil, err
+		var nextCursor *string
+		if len(checkpoints) > limit {
+			last := checkpoints[limit-1].CheckpointDate
+			nextCursor = &last
+			checkpoints = checkpoints[:limit]
 		}
-		checkpoint.BenchmarkPrice = nullStringPtr(benchmarkPrice)
-		checkpoint.BenchmarkReturnPct = nullStringPtr(benchmarkReturn)
-		checkpoints = append(checkpoints, checkpoint)
-	}
-	if err := rows.Err(); err != nil {
+
+		if len(checkpoints) > 0 {
+			ids := make([]string, 0, len(checkpoints))
+			for _, checkpoint := range checkpoints {
+				ids = append(ids, checkpoint.ID)
+			}
+			start := time.Now()
+			metrics, err := listMetricsForCheckpoints(ctx, q, ids)
+			s.observe("listMetricsForCheckpoints", start, &err)
+			if err != nil {
+				return err
+			}
+			attachMetrics(checkpoints, metrics)
+		}
+
+		result = &CheckpointsPage{Checkpoints: checkpoints, NextCursor: nextCursor}
+		return nil
+	})
+	if err != nil {
 		return nil, err
 	}
-	return checkpoints, nil
+	return result, nil
 }
 
-func (s *Store) latestCheckpoint(ctx context.Context, batchID string) (*Checkpoint, error) {
-	const latestCheckpointSQL = `
-        SELECT id::text, checkpoint_date::text, status,
-               benchmark_price::text, benchmark_return_pct::text
-        FROM checkpoints
-        WHERE batch_id = $1
-        ORDER BY checkpoint_date DESC
-        LIMIT 1`
+// GetCheckpointByDate returns a batch's checkpoint (with metrics) for one
+// checkpoint date. It returns nil when the batch has no checkpoint on that date
+// or was deleted.
+func (s *Store) GetCheckpointByDate(ctx context.Context, batchID string, checkpointDate string) (_ *Checkpoint, err error) {
+	defer s.observe("GetCheckpointByDate", time.Now(), &err)
 
-	var checkpoint Checkpoint
-	var benchmarkPrice sql.NullString
-	var benchmarkReturn sql.NullString
+	const checkpointSQL = `
+        SELECT ` + checkpointJSONSQL + `
+        FROM checkpoints c
+        JOIN batches b ON b.id = c.batch_id
+        WHERE c.batch_id = $1 AND c.checkpoint_date = $2::date AND b.deleted_at IS NULL`
 
-	row := s.pool.QueryRow(ctx, latestCheckpointSQL, batchID)
-	if err := row.Scan(&checkpoint.ID, &checkpoint.CheckpointDate, &checkpoint.Status, &benchmarkPrice, &benchmarkReturn); err != nil {
+	var checkpointJSON []byte
+	if err := s.pool.QueryRow(ctx, checkpointSQL, batchID, checkpointDate).Scan(&checkpointJSON); err != nil {
 		if err == pgx.ErrNoRows {
 			return nil, nil
 		}
 		return nil, err
 	}
-	checkpoint.BenchmarkPrice = nullStringPtr(benchmarkPrice)
-	checkpoint.BenchmarkReturnPct = nullStringPtr(benchmarkReturn)
-
-	metrics, err := s.listMetricsForCheckpoint(ctx, checkpoint.ID)
+	checkpoint, err := decodeCheckpointJSON(checkpointJSON)
 	if err != nil {
 		return nil, err
 	}
-	checkpoint.Metrics = metrics
-
 	return &checkpoint, nil
 }
 
-func (s *Store) listMetricsForCheckpoint(ctx context.Context, checkpointID string) ([]PickMetric, error) {
+type metricRow struct {
+	checkpointID string
+	metric       PickMetric
+}
+
+func attachMetrics(checkpoints []Checkpoint, metrics []metricRow) {
+	metricsByCheckpoint := map[string][]PickMetric{}
+	for _, metric := range metrics {
+		metricsByCheckpoint[metric.checkpointID] = append(metricsByCheckpoint[metric.checkpointID], metric.metric)
+	}
+	for i := range checkpoints {
+		checkpoints[i].Metrics = metricsByCheckpoint[checkpoints[i].ID]
+	}
+}
+
+func scanMetricRows(rows pgx.Rows) ([]metricRow, error) {
+	defer rows.Close()
+
+	var result []metricRow
+	for rows.Next() {
+		var row metricRow
+		var metric PickMetric
+		var openPrice, highPrice, lowPrice sql.NullString
+		if err := rows.Scan(&metric.ID, &row.checkpointID, &metric.PickID, &metric.CurrentPrice, &metric.AbsoluteReturnPct, &metric.VsBenchmarkPct,
+			&openPrice, &highPrice, &lowPrice, &metric.Volume); err != nil {
+			return nil, err
+		}
+		var err error
+		if metric.OpenPrice, err = nullDecimalPtr(openPrice); err != nil {
+			return nil, err
+		}
+		if metric.HighPrice, err = nullDecimalPtr(highPrice); err != nil {
+			return nil, err
+		}
+		if metric.LowPrice, err = nullDecimalPtr(lowPrice); err != nil {
+			return nil, err
+		}
+		row.metric = metric
+		result = append(result, row)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func listMetricsForCheckpoints(ctx context.Context, q querier, checkpointIDs []string) ([]metricRow, error) {
 	const metricsSQL = `
-        SELECT id::text, pick_id::text, current_price::text, absolute_return_pct::text, vs_benchmark_pct::text
+        SELECT id::text, checkpoint_id::text, pick_id::text,
+               current_price::text, absolute_return_pct::text, vs_benchmark_pct::text,
+               open_price::text, high_price::text, low_price::text, volume
         FROM pick_checkpoint_metrics
-        WHERE checkpoint_id = $1
-        ORDER BY pick_id`
+        WHERE checkpoint_id = ANY($1::uuid[])
+        ORDER BY checkpoint_id, pick_id`
 
-	rows, err := s.pool.Query(ctx, metricsSQL, checkpointID)
+	rows, err := q.Query(ctx, metricsSQL, checkpointIDs)
 	if err != nil {
 		return nil, err
 	}
+	return scanMetricRows(rows)
+}
+
+func scanCheckpoints(rows pgx.Rows) ([]Checkpoint, error) {
 	defer rows.Close()
 
-	var metrics []PickMetric
+	var checkpoints []Checkpoint
 	for rows.Next() {
-		var metric PickMetric
-		if err := rows.Scan(&metric.ID, &metric.PickID, &metric.CurrentPrice, &metric.AbsoluteReturnPct, &metric.VsBenchmarkPct); err != nil {
+		var checkpoint Checkpoint
+		var benchmarkPrice sql.NullString
+		var benchmarkReturn sql.NullString
+		if err := rows.Scan(&checkpoint.ID, &checkpoint.CheckpointDate, &checkpoint.Status, &benchmarkPrice, &benchmarkReturn, &checkpoint.CreatedAt); err != nil {
 			return nil, err
 		}
-		metrics = append(metrics, metric)
+		var err error
+		if checkpoint.BenchmarkPrice, err = nullDecimalPtr(benchmarkPrice); err != nil {
+			return nil, err
+		}
+		if checkpoint.BenchmarkReturnPct, err = nullDecimalPtr(benchmarkReturn); err != nil {
+			return nil, err
+		}
+		checkpoints = append(checkpoints, checkpoint)
 	}
 	if err := rows.Err(); err != nil {
 		return nil, err
 	}
-	return metrics, nil
+	return checkpoints, nil
 }
 
-func nullStringPtr(value sql.NullString) *string {
-	if value.Valid {
-		return &value.String
+func nullDecimalPtr(value sql.NullString) (*decimal.Decimal, error) {
+	if !value.Valid {
+		return nil, nil
+	}
+	parsed, err := decimal.Parse(value.String)
+	if err != nil {
+		return nil, err
 	}
-	return nil
+	return &parsed, nil
 }
@@ -3,32 +3,41 @@ package db
 import (
 	"context"
 	"errors"
+	"fmt"
+	"sort"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/igor-kupczynski/alpha-monday/internal/decimal"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
 )
 
 var ErrRunDateConflict = errors.New("run_date already exists")
 var ErrCheckpointConflict = errors.New("checkpoint already exists")
+var ErrBatchStatusTransition = errors.New("batch status transition not allowed")
 
 type NewPick struct {
 	Ticker       string
 	Action       string
 	Reasoning    string
-	InitialPrice string
+	InitialPrice decimal.Decimal
+	TargetPrice  *decimal.Decimal
 }
 
 type CreateBatchInput struct {
 	RunDate               time.Time
 	BenchmarkSymbol       string
-	BenchmarkInitialPrice string
-	Status                string
-	Picks                 []NewPick
-	CheckpointDate        time.Time
-	CheckpointStatus      string
-	BenchmarkPrice        string
-	BenchmarkReturnPct    *string
+	BenchmarkInitialPrice decimal.Decimal
+	// ConfigHash identifies the generation configuration (prompt, model,
+	// parameters, universe). Empty stores NULL.
+	ConfigHash         string
+	Status             string
+	Picks              []NewPick
+	CheckpointDate     time.Time
+	CheckpointStatus   string
+	BenchmarkPrice     decimal.Decimal
+	BenchmarkReturnPct *decimal.Decimal
 }
 
 type CreateBatchResult struct {
@@ -39,25 +48,45 @@ type CreateBatchResult struct {
 
 type NewCheckpointMetric struct {
 	PickID            string
-	CurrentPrice      string
-	AbsoluteReturnPct string
-	VsBenchmarkPct    string
+	CurrentPrice      decimal.Decimal
+	AbsoluteReturnPct decimal.Decimal
+	VsBenchmarkPct    decimal.Decimal
+	// OpenPrice, HighPrice, LowPrice and Volume describe the quoted session
+	// when the provider reports them.
+	OpenPrice *decimal.Decimal
+	HighPrice *decimal.Decimal
+	LowPrice  *decimal.Decimal
+	Volume    *int64
 }
 
 type CreateCheckpointInput struct {
 	BatchID            string
 	CheckpointDate     time.Time
 	Status             string
-	BenchmarkPrice     *string
-	BenchmarkReturnPct *string
+	BenchmarkPrice     *decimal.Decimal
+	BenchmarkReturnPct *decimal.Decimal
 	Metrics            []NewCheckpointMetric
+	// Prices are upserted into the daily price history in the same
+	// transaction.
+	Prices []DailyPrice
 }
 
 type CreateCheckpointResult struct {
 	CheckpointID string
 }
 
-func (s *Store) CreateBatchWithInitialCheckpoint(ctx context.Context, input CreateBatchInput) (CreateBatchResult, error) {
+func (s *Store) CreateBatchWithInitialCheckpoint(ctx context.Context, input CreateBatchInput) (result CreateBatchResult, err error) {
+	defer s.observe("CreateBatchWithInitialCheckpoint", time.Now(), &err)
+
+	err = s.withWriteRetry(ctx, func(ctx context.Context) error {
+		var attemptErr error
+		result, attemptErr = s.createBatchWithInitialCheckpoint(ctx, input)
+		return attemptErr
+	})
+	return result, err
+}
+
+func (s *Store) createBatchWithInitialCheckpoint(ctx context.Context, input CreateBatchInput) (CreateBatchResult, error) {
 	tx, err := s.pool.Begin(ctx)
 	if err != nil {
 		return CreateBatchResult{}, err
@@ -68,13 +97,14 @@ func (s *Store) CreateBatchWithInitialCheckpoint(ctx context.Context, input Crea
 
 	batchID := uuid.New()
 	_, err = tx.Exec(ctx, `
-        INSERT INTO batches (id, run_date, benchmark_symbol, benchmark_initial_price, status)
-        VALUES ($1, $2, $3, $4, $5)`,
+        INSERT INTO batches (id, run_date, benchmark_symbol, benchmark_initial_price, status, config_hash)
+        VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''))`,
 		batchID,
 		input.RunDate,
 		input.BenchmarkSymbol,
 		input.BenchmarkInitialPrice,
 		input.Status,
+		input.ConfigHash,
 	)
 	if err != nil {
 		if isRunDateConflict(err) {
@@ -87,14 +117,15 @@ func (s *Store) CreateBatchWithInitialCheckpoint(ctx context.Context, input Crea
 	for _, pick := range input.Picks {
 		pickID := uuid.New()
 		_, err := tx.Exec(ctx, `
-            INSERT INTO picks (id, batch_id, ticker, action, reasoning, initial_price)
-            VALUES ($1, $2, $3, $4, $5, $6)`,
+            INSERT INTO picks (id, batch_id, ticker, action, reasoning, initial_price, target_price)
+            VALUES ($1, $2, $3, $4, $5, $6, $7)`,
 			pickID,
 			batchID,
 			pick.Ticker,
 			pick.Action,
 			pick.Reasoning,
 			pick.InitialPrice,
+			pick.TargetPrice,
 		)
 		if err != nil {
 			return CreateBatchResult{}, err
@@ -105,6 +136,7 @@ func (s *Store) CreateBatchWithInitialCheckpoint(ctx context.Context, input Crea
 			Action:       pick.Action,
 			Reasoning:    pick.Reasoning,
 			InitialPrice: pick.InitialPrice,
+			TargetPrice:  pick.TargetPrice,
 		})
 	}
 
@@ -123,6 +155,19 @@ func (s *Store) CreateBatchWithInitialCheckpoint(ctx context.Context, input Crea
 		return CreateBatchResult{}, err
 	}
 
+	payload := BatchCreatedPayload{
+		BatchID:         batchID.String(),
+		RunDate:         input.RunDate.Format("2006-01-02"),
+		BenchmarkSymbol: input.BenchmarkSymbol,
+		Picks:           make([]PickPayloadItem, 0, len(picks)),
+	}
+	for _, pick := range picks {
+		payload.Picks = append(payload.Picks, PickPayloadItem{Ticker: pick.Ticker, Action: pick.Action, InitialPrice: pick.InitialPrice, TargetPrice: pick.TargetPrice})
+	}
+	if err := enqueueOutboxEvent(ctx, tx, EventBatchCreated, batchID.String(), payload); err != nil {
+		return CreateBatchResult{}, err
+	}
+
 	if err := tx.Commit(ctx); err != nil {
 		return CreateBatchResult{}, err
 	}
@@ -134,17 +179,39 @@ func (s *Store) CreateBatchWithInitialCheckpoint(ctx context.Context, input Crea
 	}, nil
 }
 
-func (s *Store) CreateCheckpointWithMetrics(ctx context.Context, input CreateCheckpointInput) (CreateCheckpointResult, error) {
+// CreateCheckpointWithMetrics stores a checkpoint with its pick metrics. A
+// computed checkpoint replaces a skipped one for the same date, keeping its id
+// and resetting created_at so cached responses are invalidated; any other
+// existing checkpoint for the date returns ErrCheckpointConflict.
+func (s *Store) CreateCheckpointWithMetrics(ctx context.Context, input CreateCheckpointInput) (result CreateCheckpointResult, err error) {
+	defer s.observe("CreateCheckpointWithMetrics", time.Now(), &err)
+
+	if err := validateCheckpointInput(input); err != nil {
+		return CreateCheckpointResult{}, err
+	}
+
+	err = s.withWriteRetry(ctx, func(ctx context.Context) error {
+		var attemptErr error
+		result, attemptErr = s.createCheckpointWithMetrics(ctx, input)
+		return attemptErr
+	})
+	return result, err
+}
+
+func validateCheckpointInput(input CreateCheckpointInput) error {
 	if input.Status == "computed" {
 		if input.BenchmarkPrice == nil || input.BenchmarkReturnPct == nil {
-			return CreateCheckpointResult{}, errors.New("benchmark price and return are required for computed checkpoint")
+			return errors.New("benchmark price and return are required for computed checkpoint")
 		}
 	} else if input.Status == "skipped" {
 		if input.BenchmarkPrice != nil || input.BenchmarkReturnPct != nil || len(input.Metrics) > 0 {
-			return CreateCheckpointResult{}, errors.New("skipped checkpoint cannot include benchmark metrics or pick metrics")
+			return errors.New("skipped checkpoint cannot include benchmark metrics or pick metrics")
 		}
 	}
+	return nil
+}
 
+func (s *Store) createCheckpointWithMetrics(ctx context.Context, input CreateCheckpointInput) (CreateCheckpointResult, error) {
 	tx, err := s.pool.Begin(ctx)
 	if err != nil {
 		return CreateCheckpointResult{}, err
@@ -153,19 +220,26 @@ func (s *Store) CreateCheckpointWithMetrics(ctx context.Context, input CreateChe
 		_ = tx.Rollback(ctx)
 	}()
 
-	checkpointID := uuid.New()
-	_, err = tx.Exec(ctx, `
+	var checkpointID string
+	err = tx.QueryRow(ctx, `
         INSERT INTO checkpoints (id, batch_id, checkpoint_date, status, benchmark_price, benchmark_return_pct)
-        VALUES ($1, $2, $3, $4, $5, $6)`,
-		checkpointID,
+        VALUES ($1, $2, $3, $4, $5, $6)
+        ON CONFLICT ON CONSTRAINT checkpoints_batch_date_unique DO UPDATE
+        SET status = EXCLUDED.status,
+            benchmark_price = EXCLUDED.benchmark_price,
+            benchmark_return_pct = EXCLUDED.benchmark_return_pct,
+            created_at = now()
+        WHERE checkpoints.status = 'skipped' AND EXCLUDED.status = 'computed'
+        RETURNING id::text`,
+		uuid.New(),
 		input.BatchID,
 		input.CheckpointDate,
 		input.Status,
 		input.BenchmarkPrice,
 		input.BenchmarkReturnPct,
-	)
+	).Scan(&checkpointID)
 	if err != nil {
-		if isCheckpointConflict(err) {
+		if errors.Is(err, pgx.ErrNoRows) {
 			return CreateCheckpointResult{}, ErrCheckpointConflict
 		}
 		return CreateCheckpointResult{}, err
@@ -174,47 +248,219 @@ func (s *Store) CreateCheckpointWithMetrics(ctx context.Context, input CreateChe
 	for _, metric := range input.Metrics {
 		metricID := uuid.New()
 		_, err := tx.Exec(ctx, `
-            INSERT INTO pick_checkpoint_metrics (id, checkpoint_id, pick_id, current_price, absolute_return_pct, vs_benchmark_pct)
-            VALUES ($1, $2, $3, $4, $5, $6)`,
+            INSERT INTO pick_checkpoint_metrics (id, checkpoint_id, pick_id, current_price, absolute_return_pct, vs_benchmark_pct,
+                                                 open_price, high_price, low_price, volume)
+            VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`,
 			metricID,
 			checkpointID,
 			metric.PickID,
 			metric.CurrentPrice,
 			metric.AbsoluteReturnPct,
 			metric.VsBenchmarkPct,
+			metric.OpenPrice,
+			metric.HighPrice,
+			metric.LowPrice,
+			metric.Volume,
 		)
 		if err != nil {
 			return CreateCheckpointResult{}, err
 		}
 	}
 
+	if err := upsertDailyPrices(ctx, tx, input.Prices); err != nil {
+		return CreateCheckpointResult{}, err
+	}
+
+	eventType := EventCheckpointComputed
+	if input.Status == "skipped" {
+		eventType = EventCheckpointSkipped
+	}
+	if err := enqueueOutboxEvent(ctx, tx, eventType, input.BatchID, CheckpointPayload{
+		BatchID:            input.BatchID,
+		CheckpointID:       checkpointID,
+		CheckpointDate:     input.CheckpointDate.Format("2006-01-02"),
+		Status:             input.Status,
+		BenchmarkReturnPct: input.BenchmarkReturnPct,
+	}); err != nil {
+		return CreateCheckpointResult{}, err
+	}
+
 	if err := tx.Commit(ctx); err != nil {
 		return CreateCheckpointResult{}, err
 	}
 
-	return CreateCheckpointResult{CheckpointID: checkpointID.String()}, nil
+	return CreateCheckpointResult{CheckpointID: checkpointID}, nil
+}
+
+// UpdateBatchStatus moves an active batch to status. Batches in any other
+// status are left unchanged, so a workflow finishing late cannot overwrite an
+// admin's change.
+func (s *Store) UpdateBatchStatus(ctx context.Context, batchID string, status string) (err error) {
+	defer s.observe("UpdateBatchStatus", time.Now(), &err)
+
+	if !ValidBatchStatus(status) {
+		return fmt.Errorf("unknown batch status %q", status)
+	}
+
+	return s.withWriteRetry(ctx, func(ctx context.Context) error {
+		return pgx.BeginFunc(ctx, s.pool, func(tx pgx.Tx) error {
+			tag, err := tx.Exec(ctx, `UPDATE batches SET status = $2, updated_at = now() WHERE id = $1 AND status = 'active' AND status <> $2`, batchID, status)
+			if err != nil || tag.RowsAffected() == 0 {
+				return err
+			}
+			return enqueueBatchStatusChanged(ctx, tx, batchID, status)
+		})
+	})
+}
+
+// SetBatchStatus is the admin override of a batch's status. It returns the
+// updated batch and its previous status, nil when the batch does not exist,
+// or ErrBatchStatusTransition when ValidBatchTransition rejects the change.
+func (s *Store) SetBatchStatus(ctx context.Context, batchID string, status string) (_ *Batch, _ string, err error) {
+	defer s.observe("SetBatchStatus", time.Now(), &err)
+
+	if !ValidBatchStatus(status) {
+		return nil, "", fmt.Errorf("unknown batch status %q", status)
+	}
+
+	var updated *Batch
+	var previous string
+	err = s.withWriteRetry(ctx, func(ctx context.Context) error {
+		updated = nil
+		return pgx.BeginFunc(ctx, s.pool, func(tx pgx.Tx) error {
+			var batch Batch
+			err := tx.QueryRow(ctx, `
+                SELECT id::text, run_date::text, status, benchmark_symbol, benchmark_initial_price::text, config_hash
+                FROM batches
+                WHERE id = $1 AND deleted_at IS NULL
+                FOR UPDATE`, batchID,
+			).Scan(&batch.ID, &batch.RunDate, &batch.Status, &batch.BenchmarkSymbol, &batch.BenchmarkInitialPrice, &batch.ConfigHash)
+			if err != nil {
+				if errors.Is(err, pgx.ErrNoRows) {
+					return nil
+				}
+				return err
+			}
+			if !ValidBatchTransition(batch.Status, status) {
+				return fmt.Errorf("%w: %s to %s", ErrBatchStatusTransition, batch.Status, status)
+			}
+			if _, err := tx.Exec(ctx, `UPDATE batches SET status = $2, updated_at = now() WHERE id = $1`, batchID, status); err != nil {
+				return err
+			}
+			if err := enqueueBatchStatusChanged(ctx, tx, batchID, status); err != nil {
+				return err
+			}
+			previous = batch.Status
+			batch.Status = status
+			updated = &batch
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	return updated, previous, nil
+}
+
+// DeleteBatch soft-deletes a batch by setting deleted_at, and reports whether
+// a batch that was not already deleted was found. Read queries leave deleted
+// batches out; their rows are kept, so clearing deleted_at restores them.
+func (s *Store) DeleteBatch(ctx context.Context, batchID string) (_ bool, err error) {
+	defer s.observe("DeleteBatch", time.Now(), &err)
+
+	var deleted bool
+	err = s.withWriteRetry(ctx, func(ctx context.Context) error {
+		tag, err := s.pool.Exec(ctx, `
+            UPDATE batches
+            SET deleted_at = now(), updated_at = now()
+            WHERE id = $1 AND deleted_at IS NULL`, batchID)
+		if err != nil {
+			return err
+		}
+		deleted = tag.RowsAffected() > 0
+		return nil
+	})
+	return deleted, err
+}
+
+// enqueueBatchStatusChanged enqueues batch_status_changed. A completed batch
+// also records its picks' final metrics and scores its target prices in the
+// same transaction.
+func enqueueBatchStatusChanged(ctx context.Context, tx pgx.Tx, batchID, status string) error {
+	payload := BatchStatusPayload{BatchID: batchID, Status: status}
+	if status == BatchStatusCompleted {
+		if err := recordFinalMetrics(ctx, tx, batchID); err != nil {
+			return err
+		}
+		var err error
+		payload.TargetAccuracy, err = scoreTargetPrices(ctx, tx, batchID)
+		if err != nil {
+			return err
+		}
+	}
+	return enqueueOutboxEvent(ctx, tx, EventBatchStatusChanged, batchID, payload)
 }
 
-func (s *Store) UpdateBatchStatus(ctx context.Context, batchID string, status string) error {
-	_, err := s.pool.Exec(ctx, `UPDATE batches SET status = $2 WHERE id = $1`, batchID, status)
+// recordFinalMetrics copies each pick's metric at the newest computed
+// checkpoint to final_absolute_return_pct and final_vs_benchmark_pct, so
+// per-ticker statistics read them without re-deriving the final checkpoint.
+func recordFinalMetrics(ctx context.Context, tx pgx.Tx, batchID string) error {
+	_, err := tx.Exec(ctx, `
+        UPDATE picks p
+        SET final_absolute_return_pct = f.absolute_return_pct,
+            final_vs_benchmark_pct = f.vs_benchmark_pct
+        FROM (
+            SELECT DISTINCT ON (m.pick_id) m.pick_id, m.absolute_return_pct, m.vs_benchmark_pct
+            FROM pick_checkpoint_metrics m
+            JOIN checkpoints c ON c.id = m.checkpoint_id
+            WHERE c.batch_id = $1
+            ORDER BY m.pick_id, c.checkpoint_date DESC
+        ) f
+        WHERE p.id = f.pick_id AND p.batch_id = $1`,
+		batchID,
+	)
 	return err
 }
 
-func isRunDateConflict(err error) bool {
-	var pgErr *pgconn.PgError
-	if !errors.As(err, &pgErr) {
-		return false
+// scoreTargetPrices sets target_error_pct on the batch's picks that carry a
+// target price, comparing it with the pick's price at the newest computed
+// checkpoint, and returns the scored picks.
+func scoreTargetPrices(ctx context.Context, tx pgx.Tx, batchID string) ([]TargetAccuracyItem, error) {
+	rows, err := tx.Query(ctx, `
+        UPDATE picks p
+        SET target_error_pct = round((f.current_price - p.target_price) / p.target_price * 100, 8)
+        FROM (
+            SELECT DISTINCT ON (m.pick_id) m.pick_id, m.current_price
+            FROM pick_checkpoint_metrics m
+            JOIN checkpoints c ON c.id = m.checkpoint_id
+            WHERE c.batch_id = $1
+            ORDER BY m.pick_id, c.checkpoint_date DESC
+        ) f
+        WHERE p.id = f.pick_id AND p.batch_id = $1 AND p.target_price IS NOT NULL
+        RETURNING p.ticker, p.target_price::text, f.current_price::text, p.target_error_pct::text`,
+		batchID,
+	)
+	if err != nil {
+		return nil, err
 	}
-	if pgErr.Code != "23505" {
-		return false
+	defer rows.Close()
+
+	var items []TargetAccuracyItem
+	for rows.Next() {
+		var item TargetAccuracyItem
+		if err := rows.Scan(&item.Ticker, &item.TargetPrice, &item.FinalPrice, &item.ErrorPct); err != nil {
+			return nil, err
+		}
+		items = append(items, item)
 	}
-	if pgErr.ConstraintName == "batches_run_date_unique" {
-		return true
+	if err := rows.Err(); err != nil {
+		return nil, err
 	}
-	return false
+	sort.Slice(items, func(i, j int) bool { return items[i].Ticker < items[j].Ticker })
+	return items, nil
 }
 
-func isCheckpointConflict(err error) bool {
+func isRunDateConflict(err error) bool {
 	var pgErr *pgconn.PgError
 	if !errors.As(err, &pgErr) {
 		return false
@@ -222,7 +468,7 @@ func isCheckpointConflict(err error) bool {
 	if pgErr.Code != "23505" {
 		return false
 	}
-	if pgErr.ConstraintName == "checkpoints_batch_date_unique" {
+	if pgErr.ConstraintName == "batches_run_date_unique" {
 		return true
 	}
 	return false
@@ -3,9 +3,16 @@ package db
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"io"
+	"syscall"
 	"testing"
 	"time"
+
+	"github.com/igor-kupczynski/alpha-monday/internal/decimal"
+	"github.com/jackc/pgx/v5/pgconn"
 )
 
 func TestCreateBatchWithInitialCheckpoint(t *testing.T) {
@@ -17,16 +24,17 @@ func TestCreateBatchWithInitialCheckpoint(t *testing.T) {
 	input := CreateBatchInput{
 		RunDate:               runDate,
 		BenchmarkSymbol:       "SPY",
-		BenchmarkInitialPrice: "401.25",
+		BenchmarkInitialPrice: decimal.MustParse("401.25"),
+		ConfigHash:            "5f2c",
 		Status:                "active",
 		Picks: []NewPick{
-			{Ticker: "AAPL", Action: "BUY", Reasoning: "ok", InitialPrice: "178.10"},
-			{Ticker: "MSFT", Action: "SELL", Reasoning: "ok", InitialPrice: "342.55"},
-			{Ticker: "NVDA", Action: "BUY", Reasoning: "ok", InitialPrice: "610.00"},
+			{Ticker: "AAPL", Action: "BUY", Reasoning: "ok", InitialPrice: decimal.MustParse("178.10")},
+			{Ticker: "MSFT", Action: "SELL", Reasoning: "ok", InitialPrice: decimal.MustParse("342.55")},
+			{Ticker: "NVDA", Action: "BUY", Reasoning: "ok", InitialPrice: decimal.MustParse("610.00")},
 		},
 		CheckpointDate:   runDate,
 		CheckpointStatus: "computed",
-		BenchmarkPrice:   "401.25",
+		BenchmarkPrice:   decimal.MustParse("401.25"),
 	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
@@ -76,12 +84,20 @@ func TestCreateBatchWithInitialCheckpoint(t *testing.T) {
 	if err := row.Scan(&benchmarkPrice, &benchmarkReturn); err != nil {
 		t.Fatalf("read checkpoint: %v", err)
 	}
-	if benchmarkPrice != input.BenchmarkPrice {
+	if benchmarkPrice != input.BenchmarkPrice.String() {
 		t.Fatalf("expected benchmark price %s, got %s", input.BenchmarkPrice, benchmarkPrice)
 	}
 	if benchmarkReturn.Valid {
 		t.Fatalf("expected null benchmark_return_pct for initial checkpoint")
 	}
+
+	details, err := store.BatchDetails(ctx, result.BatchID)
+	if err != nil {
+		t.Fatalf("batch details: %v", err)
+	}
+	if details.Batch.ConfigHash == nil || *details.Batch.ConfigHash != input.ConfigHash {
+		t.Fatalf("expected config hash %q, got %v", input.ConfigHash, details.Batch.ConfigHash)
+	}
 }
 
 func TestCreateBatchWithInitialCheckpointRunDateConflict(t *testing.T) {
@@ -93,16 +109,16 @@ func TestCreateBatchWithInitialCheckpointRunDateConflict(t *testing.T) {
 	input := CreateBatchInput{
 		RunDate:               runDate,
 		BenchmarkSymbol:       "SPY",
-		BenchmarkInitialPrice: "401.25",
+		BenchmarkInitialPrice: decimal.MustParse("401.25"),
 		Status:                "active",
 		Picks: []NewPick{
-			{Ticker: "AAPL", Action: "BUY", Reasoning: "ok", InitialPrice: "178.10"},
-			{Ticker: "MSFT", Action: "SELL", Reasoning: "ok", InitialPrice: "342.55"},
-			{Ticker: "NVDA", Action: "BUY", Reasoning: "ok", InitialPrice: "610.00"},
+			{Ticker: "AAPL", Action: "BUY", Reasoning: "ok", InitialPrice: decimal.MustParse("178.10")},
+			{Ticker: "MSFT", Action: "SELL", Reasoning: "ok", InitialPrice: decimal.MustParse("342.55")},
+			{Ticker: "NVDA", Action: "BUY", Reasoning: "ok", InitialPrice: decimal.MustParse("610.00")},
 		},
 		CheckpointDate:   runDate,
 		CheckpointStatus: "computed",
-		BenchmarkPrice:   "401.25",
+		BenchmarkPrice:   decimal.MustParse("401.25"),
 	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
@@ -164,8 +180,8 @@ func TestCreateCheckpointWithMetricsComputed(t *testing.T) {
 	}
 
 	checkpointDate := time.Date(2026, 1, 28, 0, 0, 0, 0, time.UTC)
-	benchmarkPrice := "410.00"
-	benchmarkReturn := "2.18200000"
+	benchmarkPrice := decimal.MustParse("410.00")
+	benchmarkReturn := decimal.MustParse("2.18200000")
 
 	input := CreateCheckpointInput{
 		BatchID:            batchID,
@@ -176,15 +192,15 @@ func TestCreateCheckpointWithMetricsComputed(t *testing.T) {
 		Metrics: []NewCheckpointMetric{
 			{
 				PickID:            pick1ID,
-				CurrentPrice:      "181.00",
-				AbsoluteReturnPct: "1.62900000",
-				VsBenchmarkPct:    "-0.55300000",
+				CurrentPrice:      decimal.MustParse("181.00"),
+				AbsoluteReturnPct: decimal.MustParse("1.62900000"),
+				VsBenchmarkPct:    decimal.MustParse("-0.55300000"),
 			},
 			{
 				PickID:            pick2ID,
-				CurrentPrice:      "335.00",
-				AbsoluteReturnPct: "-2.20600000",
-				VsBenchmarkPct:    "-4.38800000",
+				CurrentPrice:      decimal.MustParse("335.00"),
+				AbsoluteReturnPct: decimal.MustParse("-2.20600000"),
+				VsBenchmarkPct:    decimal.MustParse("-4.38800000"),
 			},
 		},
 	}
@@ -222,10 +238,10 @@ func TestCreateCheckpointWithMetricsComputed(t *testing.T) {
 	if err := row.Scan(&storedPrice, &storedReturn); err != nil {
 		t.Fatalf("read checkpoint: %v", err)
 	}
-	if storedPrice != benchmarkPrice {
+	if storedPrice != benchmarkPrice.String() {
 		t.Fatalf("expected benchmark price %s, got %s", benchmarkPrice, storedPrice)
 	}
-	if storedReturn != benchmarkReturn {
+	if storedReturn != benchmarkReturn.String() {
 		t.Fatalf("expected benchmark return %s, got %s", benchmarkReturn, storedReturn)
 	}
 }
@@ -309,6 +325,67 @@ func TestCreateCheckpointWithMetricsConflict(t *testing.T) {
 	}
 }
 
+func TestCreateCheckpointWithMetricsReplacesSkipped(t *testing.T) {
+	truncateTables(t)
+
+	store := NewStore(testPool)
+	batchID := "33333333-4444-5555-6666-888888888888"
+	pickID := "33333333-4444-5555-6666-999999999999"
+	skippedID := "99999999-0000-1111-2222-444444444444"
+
+	if err := seedBatch(batchID, "2026-01-27", "SPY", "400", "active"); err != nil {
+		t.Fatalf("seed batch: %v", err)
+	}
+	if err := seedPick(pickID, batchID, "AAPL", "BUY", "ok", "100"); err != nil {
+		t.Fatalf("seed pick: %v", err)
+	}
+	if _, err := testPool.Exec(context.Background(), `
+        INSERT INTO checkpoints (id, batch_id, checkpoint_date, status, created_at)
+        VALUES ($1, $2, '2026-01-30', 'skipped', now() - interval '1 day')`, skippedID, batchID); err != nil {
+		t.Fatalf("seed skipped checkpoint: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	benchmarkPrice := decimal.MustParse("404")
+	benchmarkReturn := decimal.MustParse("1")
+	computed := CreateCheckpointInput{
+		BatchID:            batchID,
+		CheckpointDate:     time.Date(2026, 1, 30, 0, 0, 0, 0, time.UTC),
+		Status:             "computed",
+		BenchmarkPrice:     &benchmarkPrice,
+		BenchmarkReturnPct: &benchmarkReturn,
+		Metrics: []NewCheckpointMetric{{
+			PickID:            pickID,
+			CurrentPrice:      decimal.MustParse("110"),
+			AbsoluteReturnPct: decimal.MustParse("10"),
+			VsBenchmarkPct:    decimal.MustParse("9"),
+		}},
+	}
+	result, err := store.CreateCheckpointWithMetrics(ctx, computed)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.CheckpointID != skippedID {
+		t.Fatalf("expected the skipped checkpoint %s replaced, got %s", skippedID, result.CheckpointID)
+	}
+
+	var status string
+	var fresh bool
+	if err := testPool.QueryRow(ctx, `SELECT status, created_at > now() - interval '1 minute' FROM checkpoints WHERE id = $1`, skippedID).Scan(&status, &fresh); err != nil {
+		t.Fatalf("load checkpoint: %v", err)
+	}
+	if status != "computed" || !fresh {
+		t.Fatalf("expected a freshly computed checkpoint, got status %s fresh %v", status, fresh)
+	}
+
+	computed.Metrics = nil
+	if _, err := store.CreateCheckpointWithMetrics(ctx, computed); !errors.Is(err, ErrCheckpointConflict) {
+		t.Fatalf("expected ErrCheckpointConflict over a computed checkpoint, got %v", err)
+	}
+}
+
 func TestUpdateBatchStatus(t *testing.T) {
 	truncateTables(t)
 
@@ -322,10 +399,23 @@ func TestUpdateBatchStatus(t *testing.T) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
+	before, err := store.BatchFreshness(ctx, batchID)
+	if err != nil || before == nil {
+		t.Fatalf("batch freshness: %v %v", before, err)
+	}
+
 	if err := store.UpdateBatchStatus(ctx, batchID, "completed"); err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
 
+	after, err := store.BatchFreshness(ctx, batchID)
+	if err != nil || after == nil {
+		t.Fatalf("batch freshness: %v %v", after, err)
+	}
+	if after.Status != "completed" || !after.LastModified.After(before.LastModified) {
+		t.Fatalf("expected the status change to move last modified past %v, got %+v", before.LastModified, after)
+	}
+
 	var status string
 	if err := testPool.QueryRow(ctx, "SELECT status FROM batches WHERE id = $1", batchID).Scan(&status); err != nil {
 		t.Fatalf("read batch: %v", err)
@@ -334,3 +424,258 @@ func TestUpdateBatchStatus(t *testing.T) {
 		t.Fatalf("expected status completed, got %s", status)
 	}
 }
+
+func TestDeleteBatch(t *testing.T) {
+	truncateTables(t)
+
+	store := NewStore(testPool)
+	keptID := "55555555-1111-4000-8000-000000000001"
+	deletedID := "55555555-1111-4000-8000-000000000002"
+
+	if err := seedBatch(keptID, "2026-01-19", "SPY", "401.25", "active"); err != nil {
+		t.Fatalf("seed batch: %v", err)
+	}
+	if err := seedBatch(deletedID, "2026-01-26", "SPY", "405.00", "active"); err != nil {
+		t.Fatalf("seed batch: %v", err)
+	}
+	if err := seedPick("55555555-1111-4000-8000-000000000003", deletedID, "AAPL", "BUY", "r", "180.00"); err != nil {
+		t.Fatalf("seed pick: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	for _, want := range []bool{true, false} {
+		deleted, err := store.DeleteBatch(ctx, deletedID)
+		if err != nil {
+			t.Fatalf("delete batch: %v", err)
+		}
+		if deleted != want {
+			t.Fatalf("expected deleted %v, got %v", want, deleted)
+		}
+	}
+
+	latest, err := store.LatestBatch(ctx)
+	if err != nil {
+		t.Fatalf("latest batch: %v", err)
+	}
+	if latest == nil || latest.Batch.ID != keptID {
+		t.Fatalf("expected latest batch %s, got %+v", keptID, latest)
+	}
+	page, err := store.ListBatches(ctx, 10, nil, BatchFilter{})
+	if err != nil {
+		t.Fatalf("list batches: %v", err)
+	}
+	if len(page.Batches) != 1 || page.Batches[0].ID != keptID {
+		t.Fatalf("expected only the kept batch, got %+v", page.Batches)
+	}
+	detail, err := store.BatchDetails(ctx, deletedID)
+	if err != nil {
+		t.Fatalf("batch details: %v", err)
+	}
+	status, err := store.BatchStatus(ctx, deletedID)
+	if err != nil {
+		t.Fatalf("batch status: %v", err)
+	}
+	history, err := store.PickHistory(ctx, "AAPL")
+	if err != nil {
+		t.Fatalf("pick history: %v", err)
+	}
+	if detail != nil || status != "" || len(history) != 0 {
+		t.Fatalf("expected the deleted batch hidden, got %+v, %q, %+v", detail, status, history)
+	}
+
+	existing, err := store.BatchIDForRunDate(ctx, "2026-01-26")
+	if err != nil {
+		t.Fatalf("batch for run date: %v", err)
+	}
+	if existing != nil {
+		t.Fatalf("expected the deleted batch to free its run_date, got %v", *existing)
+	}
+
+	// The freed run_date can be taken again; the deleted batch then cannot be
+	// restored until the new one is deleted.
+	rerunID := "55555555-1111-4000-8000-000000000004"
+	if err := seedBatch(rerunID, "2026-01-26", "SPY", "406.00", "active"); err != nil {
+		t.Fatalf("seed batch on a freed run_date: %v", err)
+	}
+	if _, err := testPool.Exec(ctx, "UPDATE batches SET deleted_at = NULL WHERE id = $1", deletedID); !isRunDateConflict(err) {
+		t.Fatalf("expected a run_date conflict restoring over a live batch, got %v", err)
+	}
+	if _, err := store.DeleteBatch(ctx, rerunID); err != nil {
+		t.Fatalf("delete batch: %v", err)
+	}
+	if _, err := testPool.Exec(ctx, "UPDATE batches SET deleted_at = NULL WHERE id = $1", deletedID); err != nil {
+		t.Fatalf("restore batch: %v", err)
+	}
+	detail, err = store.BatchDetails(ctx, deletedID)
+	if err != nil {
+		t.Fatalf("batch details: %v", err)
+	}
+	if detail == nil || len(detail.Picks) != 1 {
+		t.Fatalf("expected the restored batch with its pick, got %+v", detail)
+	}
+}
+
+func TestIsTransientDBError(t *testing.T) {
+	cases := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "serialization failure", err: &pgconn.PgError{Code: "40001"}, want: true},
+		{name: "deadlock", err: &pgconn.PgError{Code: "40P01"}, want: true},
+		{name: "wrapped deadlock", err: fmt.Errorf("wrapped: %w", &pgconn.PgError{Code: "40P01"}), want: true},
+		{name: "safe to retry", err: fmt.Errorf("connect: %w", safeToRetryError{}), want: true},
+		{name: "connection failure", err: &pgconn.PgError{Code: "08006"}, want: false},
+		{name: "admin shutdown", err: fmt.Errorf("wrapped: %w", &pgconn.PgError{Code: "57P01"}), want: false},
+		{name: "connection reset", err: fmt.Errorf("read: %w", syscall.ECONNRESET), want: false},
+		{name: "unexpected eof", err: io.ErrUnexpectedEOF, want: false},
+		{name: "unique violation", err: &pgconn.PgError{Code: "23505"}, want: false},
+		{name: "check violation", err: &pgconn.PgError{Code: "23514"}, want: false},
+		{name: "run date conflict", err: ErrRunDateConflict, want: false},
+		{name: "context canceled", err: context.Canceled, want: false},
+		{name: "nil", err: nil, want: false},
+	}
+
+	for _, tc := range cases {
+		if got := isTransientDBError(tc.err); got != tc.want {
+			t.Fatalf("%s: expected %v, got %v", tc.name, tc.want, got)
+		}
+	}
+}
+
+// safeToRetryError mimics the pgconn errors raised before a query reached
+// the server.
+type safeToRetryError struct{}
+
+func (safeToRetryError) Error() string     { return "dial failed" }
+func (safeToRetryError) SafeToRetry() bool { return true }
+
+func TestSetBatchStatus(t *testing.T) {
+	truncateTables(t)
+
+	store := NewStore(testPool)
+	batchID := "44444444-5555-6666-7777-999999999999"
+
+	if err := seedBatch(batchID, "2026-01-27", "SPY", "401.25", "active"); err != nil {
+		t.Fatalf("seed batch: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	batch, previous, err := store.SetBatchStatus(ctx, batchID, BatchStatusCancelled)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if batch == nil || batch.Status != BatchStatusCancelled || previous != BatchStatusActive || batch.RunDate != "2026-01-27" {
+		t.Fatalf("unexpected result %+v, previous %q", batch, previous)
+	}
+
+	if _, _, err := store.SetBatchStatus(ctx, batchID, BatchStatusCompleted); !errors.Is(err, ErrBatchStatusTransition) {
+		t.Fatalf("expected ErrBatchStatusTransition, got %v", err)
+	}
+	if err := store.UpdateBatchStatus(ctx, batchID, BatchStatusCompleted); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	status, err := store.BatchStatus(ctx, batchID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if status != BatchStatusCancelled {
+		t.Fatalf("expected the worker update to leave the cancelled batch alone, got %s", status)
+	}
+
+	var events int
+	if err := testPool.QueryRow(ctx, `SELECT count(*) FROM outbox_events WHERE event_type = $1`, EventBatchStatusChanged).Scan(&events); err != nil {
+		t.Fatalf("count events: %v", err)
+	}
+	if events != 1 {
+		t.Fatalf("expected one batch_status_changed event, got %d", events)
+	}
+
+	batch, _, err = store.SetBatchStatus(ctx, "44444444-5555-6666-7777-000000000000", BatchStatusCancelled)
+	if err != nil || batch != nil {
+		t.Fatalf("expected nil for an unknown batch, got %+v, %v", batch, err)
+	}
+}
+
+func TestUpdateBatchStatusScoresTargetPrices(t *testing.T) {
+	truncateTables(t)
+
+	store := NewStore(testPool)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	target := decimal.MustParse("200.00")
+	created, err := store.CreateBatchWithInitialCheckpoint(ctx, CreateBatchInput{
+		RunDate:               time.Date(2026, 1, 19, 0, 0, 0, 0, time.UTC),
+		BenchmarkSymbol:       "SPY",
+		BenchmarkInitialPrice: decimal.MustParse("400.00"),
+		Status:                BatchStatusActive,
+		Picks: []NewPick{
+			{Ticker: "AAPL", Action: "BUY", Reasoning: "ok", InitialPrice: decimal.MustParse("180.00"), TargetPrice: &target},
+			{Ticker: "MSFT", Action: "SELL", Reasoning: "ok", InitialPrice: decimal.MustParse("400.00")},
+		},
+		CheckpointDate:   time.Date(2026, 1, 16, 0, 0, 0, 0, time.UTC),
+		CheckpointStatus: "computed",
+		BenchmarkPrice:   decimal.MustParse("400.00"),
+	})
+	if err != nil {
+		t.Fatalf("create batch: %v", err)
+	}
+	benchmark := decimal.MustParse("404.00")
+	benchmarkReturn := decimal.MustParse("1.00000000")
+	for i, price := range []string{"190.00", "210.00"} {
+		if _, err := store.CreateCheckpointWithMetrics(ctx, CreateCheckpointInput{
+			BatchID:            created.BatchID,
+			CheckpointDate:     time.Date(2026, 1, 20+i, 0, 0, 0, 0, time.UTC),
+			Status:             "computed",
+			BenchmarkPrice:     &benchmark,
+			BenchmarkReturnPct: &benchmarkReturn,
+			Metrics: []NewCheckpointMetric{
+				{PickID: created.Picks[0].ID, CurrentPrice: decimal.MustParse(price), AbsoluteReturnPct: decimal.MustParse("0"), VsBenchmarkPct: decimal.MustParse("0")},
+				{PickID: created.Picks[1].ID, CurrentPrice: decimal.MustParse("400.00"), AbsoluteReturnPct: decimal.MustParse("0"), VsBenchmarkPct: decimal.MustParse("0")},
+			},
+		}); err != nil {
+			t.Fatalf("create checkpoint: %v", err)
+		}
+	}
+
+	if err := store.UpdateBatchStatus(ctx, created.BatchID, BatchStatusCompleted); err != nil {
+		t.Fatalf("complete batch: %v", err)
+	}
+
+	detail, err := store.BatchDetails(ctx, created.BatchID)
+	if err != nil {
+		t.Fatalf("batch details: %v", err)
+	}
+	aapl, msft := detail.Picks[0], detail.Picks[1]
+	if aapl.TargetPrice == nil || aapl.TargetErrorPct == nil || aapl.TargetErrorPct.String() != "5.00000000" {
+		t.Fatalf("expected AAPL 5%% above target, got %+v", aapl)
+	}
+	if msft.TargetPrice != nil || msft.TargetErrorPct != nil {
+		t.Fatalf("expected MSFT without a target, got %+v", msft)
+	}
+
+	var payload BatchStatusPayload
+	var raw []byte
+	if err := testPool.QueryRow(ctx, `SELECT payload FROM outbox_events WHERE event_type = $1`, EventBatchStatusChanged).Scan(&raw); err != nil {
+		t.Fatalf("read status event: %v", err)
+	}
+	if err := json.Unmarshal(raw, &payload); err != nil {
+		t.Fatalf("decode status event: %v", err)
+	}
+	if len(payload.TargetAccuracy) != 1 || payload.TargetAccuracy[0].Ticker != "AAPL" || payload.TargetAccuracy[0].FinalPrice.String() != "210.00" {
+		t.Fatalf("unexpected target accuracy %+v", payload.TargetAccuracy)
+	}
+
+	stats, err := store.SystemStats(ctx)
+	if err != nil {
+		t.Fatalf("system stats: %v", err)
+	}
+	if stats.ScoredTargets != 1 || stats.TargetMeanAbsErrorPct == nil || stats.TargetMeanAbsErrorPct.String() != "5.0000" {
+		t.Fatalf("unexpected target stats %+v", stats)
+	}
+}
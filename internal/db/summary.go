@@ -0,0 +1,62 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/igor-kupczynski/alpha-monday/internal/decimal"
+)
+
+// PerformanceSummary aggregates pick performance over completed batches. Each
+// pick is scored by its metric at the batch's last computed checkpoint.
+type PerformanceSummary struct {
+	TotalBatches     int
+	CompletedBatches int
+	ScoredPicks      int
+	// The averages and WinRate are nil when no pick is scored. WinRate is
+	// the fraction of scored picks with a positive vs_benchmark_pct.
+	AverageAbsoluteReturnPct *decimal.Decimal
+	AverageVsBenchmarkPct    *decimal.Decimal
+	WinRate                  *decimal.Decimal
+}
+
+func (s *Store) PerformanceSummary(ctx context.Context) (_ PerformanceSummary, err error) {
+	defer s.observe("PerformanceSummary", time.Now(), &err)
+
+	const summarySQL = `
+        WITH final AS (
+            SELECT DISTINCT ON (m.pick_id) m.absolute_return_pct, m.vs_benchmark_pct
+            FROM pick_checkpoint_metrics m
+            JOIN checkpoints c ON c.id = m.checkpoint_id
+            JOIN batches b ON b.id = c.batch_id
+            WHERE b.status = 'completed' AND b.deleted_at IS NULL
+            ORDER BY m.pick_id, c.checkpoint_date DESC
+        )
+        SELECT (SELECT count(*) FROM batches WHERE deleted_at IS NULL),
+               (SELECT count(*) FROM batches WHERE status = 'completed' AND deleted_at IS NULL),
+               count(*),
+               round(avg(absolute_return_pct), 4)::text,
+               round(avg(vs_benchmark_pct), 4)::text,
+               round(count(*) FILTER (WHERE vs_benchmark_pct > 0)::numeric / NULLIF(count(*), 0), 4)::text
+        FROM final`
+
+	var summary PerformanceSummary
+	var absoluteReturn, vsBenchmark, winRate sql.NullString
+	if err := s.pool.QueryRow(ctx, summarySQL).Scan(
+		&summary.TotalBatches, &summary.CompletedBatches, &summary.ScoredPicks,
+		&absoluteReturn, &vsBenchmark, &winRate,
+	); err != nil {
+		return PerformanceSummary{}, err
+	}
+	if summary.AverageAbsoluteReturnPct, err = nullDecimalPtr(absoluteReturn); err != nil {
+		return PerformanceSummary{}, err
+	}
+	if summary.AverageVsBenchmarkPct, err = nullDecimalPtr(vsBenchmark); err != nil {
+		return PerformanceSummary{}, err
+	}
+	if summary.WinRate, err = nullDecimalPtr(winRate); err != nil {
+		return PerformanceSummary{}, err
+	}
+	return summary, nil
+}
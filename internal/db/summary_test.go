@@ -0,0 +1,79 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestPerformanceSummary(t *testing.T) {
+	truncateTables(t)
+
+	store := NewStore(testPool)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	empty, err := store.PerformanceSummary(ctx)
+	if err != nil {
+		t.Fatalf("performance summary: %v", err)
+	}
+	if empty.TotalBatches != 0 || empty.ScoredPicks != 0 || empty.WinRate != nil || empty.AverageVsBenchmarkPct != nil {
+		t.Fatalf("unexpected summary for empty database: %+v", empty)
+	}
+
+	completed := "11111111-1111-1111-1111-111111111111"
+	active := "22222222-2222-2222-2222-222222222222"
+	if err := seedBatch(completed, "2026-01-05", "SPY", "400.00", "completed"); err != nil {
+		t.Fatalf("seed batch: %v", err)
+	}
+	if err := seedBatch(active, "2026-01-19", "SPY", "410.00", "active"); err != nil {
+		t.Fatalf("seed batch: %v", err)
+	}
+	for _, pick := range []struct{ id, batchID, ticker string }{
+		{"aaaaaaaa-0000-0000-0000-000000000001", completed, "AAPL"},
+		{"aaaaaaaa-0000-0000-0000-000000000002", completed, "MSFT"},
+		{"aaaaaaaa-0000-0000-0000-000000000003", active, "NVDA"},
+	} {
+		if err := seedPick(pick.id, pick.batchID, pick.ticker, "BUY", "ok", "100.00"); err != nil {
+			t.Fatalf("seed pick: %v", err)
+		}
+	}
+	for _, checkpoint := range []struct{ id, batchID, date string }{
+		{"cccccccc-0000-0000-0000-000000000001", completed, "2026-01-06"},
+		{"cccccccc-0000-0000-0000-000000000002", completed, "2026-01-16"},
+		{"cccccccc-0000-0000-0000-000000000003", active, "2026-01-20"},
+	} {
+		if err := seedCheckpoint(checkpoint.id, checkpoint.batchID, checkpoint.date, "computed", "404.00", "1.00"); err != nil {
+			t.Fatalf("seed checkpoint: %v", err)
+		}
+	}
+	for _, metric := range []struct{ id, checkpointID, pickID, absolute, vsBenchmark string }{
+		// The earlier checkpoint is superseded by the final one.
+		{"dddddddd-0000-0000-0000-000000000001", "cccccccc-0000-0000-0000-000000000001", "aaaaaaaa-0000-0000-0000-000000000001", "9.00", "8.00"},
+		{"dddddddd-0000-0000-0000-000000000002", "cccccccc-0000-0000-0000-000000000002", "aaaaaaaa-0000-0000-0000-000000000001", "4.00", "3.00"},
+		{"dddddddd-0000-0000-0000-000000000003", "cccccccc-0000-0000-0000-000000000002", "aaaaaaaa-0000-0000-0000-000000000002", "-2.00", "-3.00"},
+		// Active batches are not scored.
+		{"dddddddd-0000-0000-0000-000000000004", "cccccccc-0000-0000-0000-000000000003", "aaaaaaaa-0000-0000-0000-000000000003", "50.00", "49.00"},
+	} {
+		if err := seedMetric(metric.id, metric.checkpointID, metric.pickID, "100.00", metric.absolute, metric.vsBenchmark); err != nil {
+			t.Fatalf("seed metric: %v", err)
+		}
+	}
+
+	summary, err := store.PerformanceSummary(ctx)
+	if err != nil {
+		t.Fatalf("performance summary: %v", err)
+	}
+	if summary.TotalBatches != 2 || summary.CompletedBatches != 1 || summary.ScoredPicks != 2 {
+		t.Fatalf("unexpected counts: %+v", summary)
+	}
+	if summary.AverageAbsoluteReturnPct == nil || summary.AverageAbsoluteReturnPct.String() != "1.0000" {
+		t.Fatalf("expected average absolute return 1.0000, got %v", summary.AverageAbsoluteReturnPct)
+	}
+	if summary.AverageVsBenchmarkPct == nil || summary.AverageVsBenchmarkPct.String() != "0.0000" {
+		t.Fatalf("expected average vs benchmark 0.0000, got %v", summary.AverageVsBenchmarkPct)
+	}
+	if summary.WinRate == nil || summary.WinRate.String() != "0.5000" {
+		t.Fatalf("expected win rate 0.5000, got %v", summary.WinRate)
+	}
+}
@@ -0,0 +1,60 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/igor-kupczynski/alpha-monday/internal/decimal"
+)
+
+// SystemStats are table-wide counts for a cheap status overview.
+type SystemStats struct {
+	Batches            int
+	Picks              int
+	Checkpoints        int
+	SkippedCheckpoints int
+	// SkippedRatio is skipped over all checkpoints, nil when there are none.
+	SkippedRatio  *decimal.Decimal
+	OldestRunDate *string
+	NewestRunDate *string
+	// ScoredTargets counts picks whose target price was scored at batch
+	// completion; TargetMeanAbsErrorPct is their mean absolute error in
+	// percent, nil when none are scored.
+	ScoredTargets         int
+	TargetMeanAbsErrorPct *decimal.Decimal
+}
+
+func (s *Store) SystemStats(ctx context.Context) (_ SystemStats, err error) {
+	defer s.observe("SystemStats", time.Now(), &err)
+
+	const systemStatsSQL = `
+        SELECT b.total, p.total, c.total, c.skipped,
+               round(c.skipped::numeric / NULLIF(c.total, 0), 4)::text,
+               b.oldest::text, b.newest::text,
+               p.scored, round(p.mean_abs_error, 4)::text
+        FROM (SELECT count(*) AS total, min(run_date) AS oldest, max(run_date) AS newest FROM batches WHERE deleted_at IS NULL) b,
+             (SELECT count(*) AS total, count(target_error_pct) AS scored, avg(abs(target_error_pct)) AS mean_abs_error FROM picks
+              WHERE batch_id NOT IN (SELECT id FROM batches WHERE deleted_at IS NOT NULL)) p,
+             (SELECT count(*) AS total, count(*) FILTER (WHERE status = 'skipped') AS skipped FROM checkpoints
+              WHERE batch_id NOT IN (SELECT id FROM batches WHERE deleted_at IS NOT NULL)) c`
+
+	var stats SystemStats
+	var ratio, targetError sql.NullString
+	if err := s.pool.QueryRow(ctx, systemStatsSQL).Scan(
+		&stats.Batches, &stats.Picks, &stats.Checkpoints, &stats.SkippedCheckpoints,
+		&ratio, &stats.OldestRunDate, &stats.NewestRunDate,
+		&stats.ScoredTargets, &targetError,
+	); err != nil {
+		return SystemStats{}, err
+	}
+	stats.SkippedRatio, err = nullDecimalPtr(ratio)
+	if err != nil {
+		return SystemStats{}, err
+	}
+	stats.TargetMeanAbsErrorPct, err = nullDecimalPtr(targetError)
+	if err != nil {
+		return SystemStats{}, err
+	}
+	return stats, nil
+}
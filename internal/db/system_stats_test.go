@@ -0,0 +1,70 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/igor-kupczynski/alpha-monday/internal/decimal"
+)
+
+func TestSystemStats(t *testing.T) {
+	truncateTables(t)
+
+	store := NewStore(testPool)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	empty, err := store.SystemStats(ctx)
+	if err != nil {
+		t.Fatalf("system stats: %v", err)
+	}
+	if empty.Batches != 0 || empty.SkippedRatio != nil || empty.OldestRunDate != nil {
+		t.Fatalf("unexpected stats for empty database: %+v", empty)
+	}
+
+	var batchID string
+	for _, runDate := range []time.Time{
+		time.Date(2026, 1, 19, 0, 0, 0, 0, time.UTC),
+		time.Date(2026, 1, 26, 0, 0, 0, 0, time.UTC),
+	} {
+		result, err := store.CreateBatchWithInitialCheckpoint(ctx, CreateBatchInput{
+			RunDate:               runDate,
+			BenchmarkSymbol:       "SPY",
+			BenchmarkInitialPrice: decimal.MustParse("401.25"),
+			Status:                "active",
+			Picks: []NewPick{
+				{Ticker: "AAPL", Action: "BUY", Reasoning: "ok", InitialPrice: decimal.MustParse("178.10")},
+				{Ticker: "MSFT", Action: "SELL", Reasoning: "ok", InitialPrice: decimal.MustParse("410.00")},
+			},
+			CheckpointDate:   runDate,
+			CheckpointStatus: "computed",
+			BenchmarkPrice:   decimal.MustParse("401.25"),
+		})
+		if err != nil {
+			t.Fatalf("create batch: %v", err)
+		}
+		batchID = result.BatchID
+	}
+	if _, err := store.CreateCheckpointWithMetrics(ctx, CreateCheckpointInput{
+		BatchID:        batchID,
+		CheckpointDate: time.Date(2026, 1, 27, 0, 0, 0, 0, time.UTC),
+		Status:         "skipped",
+	}); err != nil {
+		t.Fatalf("create skipped checkpoint: %v", err)
+	}
+
+	stats, err := store.SystemStats(ctx)
+	if err != nil {
+		t.Fatalf("system stats: %v", err)
+	}
+	if stats.Batches != 2 || stats.Picks != 4 || stats.Checkpoints != 3 || stats.SkippedCheckpoints != 1 {
+		t.Fatalf("unexpected counts: %+v", stats)
+	}
+	if stats.SkippedRatio == nil || stats.SkippedRatio.String() != "0.3333" {
+		t.Fatalf("expected skipped ratio 0.3333, got %v", stats.SkippedRatio)
+	}
+	if stats.OldestRunDate == nil || *stats.OldestRunDate != "2026-01-19" || stats.NewestRunDate == nil || *stats.NewestRunDate != "2026-01-26" {
+		t.Fatalf("unexpected run dates: %v..%v", stats.OldestRunDate, stats.NewestRunDate)
+	}
+}
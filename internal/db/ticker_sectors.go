@@ -0,0 +1,58 @@
+package db
+
+import (
+	"context"
+	"sort"
+	"time"
+)
+
+// TickerSectors returns the stored sector of every known ticker.
+func (s *Store) TickerSectors(ctx context.Context) (_ map[string]string, err error) {
+	defer s.observe("TickerSectors", time.Now(), &err)
+
+	rows, err := s.pool.Query(ctx, `SELECT ticker, sector FROM ticker_sectors`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sectors := map[string]string{}
+	for rows.Next() {
+		var ticker, sector string
+		if err := rows.Scan(&ticker, &sector); err != nil {
+			return nil, err
+		}
+		sectors[ticker] = sector
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return sectors, nil
+}
+
+// UpsertTickerSectors stores the sector of each ticker, replacing existing
+// entries. Tickers not in sectors are left untouched.
+func (s *Store) UpsertTickerSectors(ctx context.Context, sectors map[string]string) (err error) {
+	defer s.observe("UpsertTickerSectors", time.Now(), &err)
+
+	tickers := make([]string, 0, len(sectors))
+	for ticker := range sectors {
+		tickers = append(tickers, ticker)
+	}
+	sort.Strings(tickers)
+	names := make([]string, 0, len(tickers))
+	for _, ticker := range tickers {
+		names = append(names, sectors[ticker])
+	}
+
+	return s.withWriteRetry(ctx, func(ctx context.Context) error {
+		_, err := s.pool.Exec(ctx, `
+            INSERT INTO ticker_sectors (ticker, sector)
+            SELECT * FROM unnest($1::text[], $2::text[])
+            ON CONFLICT (ticker) DO UPDATE
+            SET sector = EXCLUDED.sector, updated_at = now()`,
+			tickers, names,
+		)
+		return err
+	})
+}
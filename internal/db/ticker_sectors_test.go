@@ -0,0 +1,31 @@
+package db
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestUpsertTickerSectors(t *testing.T) {
+	truncateTables(t)
+
+	store := NewStore(testPool)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := store.UpsertTickerSectors(ctx, map[string]string{"AAPL": "Technology", "XOM": "Energy"}); err != nil {
+		t.Fatalf("upsert sectors: %v", err)
+	}
+	if err := store.UpsertTickerSectors(ctx, map[string]string{"AAPL": "Information Technology"}); err != nil {
+		t.Fatalf("upsert sectors: %v", err)
+	}
+
+	sectors, err := store.TickerSectors(ctx)
+	if err != nil {
+		t.Fatalf("ticker sectors: %v", err)
+	}
+	if want := map[string]string{"AAPL": "Information Technology", "XOM": "Energy"}; !reflect.DeepEqual(sectors, want) {
+		t.Fatalf("expected %v, got %v", want, sectors)
+	}
+}
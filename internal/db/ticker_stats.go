@@ -0,0 +1,63 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/igor-kupczynski/alpha-monday/internal/decimal"
+)
+
+// TickerStats is the track record of one ticker across all batches. Picks
+// are scored by the metric recorded when their batch completed.
+type TickerStats struct {
+	Ticker      string
+	Picks       int
+	ScoredPicks int
+	// AverageVsBenchmarkPct and WinRate are nil until a pick of the ticker
+	// is scored. WinRate is the fraction of scored picks with a positive
+	// final vs_benchmark_pct.
+	AverageVsBenchmarkPct *decimal.Decimal
+	WinRate               *decimal.Decimal
+}
+
+// TickerStats returns the statistics of every ticker ever picked, ordered by
+// ticker.
+func (s *Store) TickerStats(ctx context.Context) (_ []TickerStats, err error) {
+	defer s.observe("TickerStats", time.Now(), &err)
+
+	rows, err := s.pool.Query(ctx, `
+        SELECT ticker,
+               count(*),
+               count(final_vs_benchmark_pct),
+               round(avg(final_vs_benchmark_pct), 4)::text,
+               round(count(*) FILTER (WHERE final_vs_benchmark_pct > 0)::numeric / NULLIF(count(final_vs_benchmark_pct), 0), 4)::text
+        FROM picks
+        WHERE batch_id NOT IN (SELECT id FROM batches WHERE deleted_at IS NOT NULL)
+        GROUP BY ticker
+        ORDER BY ticker`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	stats := []TickerStats{}
+	for rows.Next() {
+		var entry TickerStats
+		var vsBenchmark, winRate sql.NullString
+		if err := rows.Scan(&entry.Ticker, &entry.Picks, &entry.ScoredPicks, &vsBenchmark, &winRate); err != nil {
+			return nil, err
+		}
+		if entry.AverageVsBenchmarkPct, err = nullDecimalPtr(vsBenchmark); err != nil {
+			return nil, err
+		}
+		if entry.WinRate, err = nullDecimalPtr(winRate); err != nil {
+			return nil, err
+		}
+		stats = append(stats, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
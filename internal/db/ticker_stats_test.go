@@ -0,0 +1,87 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestTickerStats(t *testing.T) {
+	truncateTables(t)
+
+	store := NewStore(testPool)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	first := "11111111-1111-1111-1111-111111111111"
+	second := "22222222-2222-2222-2222-222222222222"
+	active := "33333333-3333-3333-3333-333333333333"
+	for _, batch := range []struct{ id, runDate string }{
+		{first, "2026-01-05"},
+		{second, "2026-01-12"},
+		{active, "2026-01-19"},
+	} {
+		if err := seedBatch(batch.id, batch.runDate, "SPY", "400.00", "active"); err != nil {
+			t.Fatalf("seed batch: %v", err)
+		}
+	}
+	for _, pick := range []struct{ id, batchID, ticker string }{
+		{"aaaaaaaa-0000-0000-0000-000000000001", first, "AAPL"},
+		{"aaaaaaaa-0000-0000-0000-000000000002", first, "MSFT"},
+		{"aaaaaaaa-0000-0000-0000-000000000003", second, "AAPL"},
+		{"aaaaaaaa-0000-0000-0000-000000000004", active, "AAPL"},
+	} {
+		if err := seedPick(pick.id, pick.batchID, pick.ticker, "BUY", "ok", "100.00"); err != nil {
+			t.Fatalf("seed pick: %v", err)
+		}
+	}
+	for _, checkpoint := range []struct{ id, batchID, date string }{
+		{"cccccccc-0000-0000-0000-000000000001", first, "2026-01-06"},
+		{"cccccccc-0000-0000-0000-000000000002", first, "2026-01-16"},
+		{"cccccccc-0000-0000-0000-000000000003", second, "2026-01-23"},
+		{"cccccccc-0000-0000-0000-000000000004", active, "2026-01-20"},
+	} {
+		if err := seedCheckpoint(checkpoint.id, checkpoint.batchID, checkpoint.date, "computed", "404.00", "1.00"); err != nil {
+			t.Fatalf("seed checkpoint: %v", err)
+		}
+	}
+	for _, metric := range []struct{ id, checkpointID, pickID, vsBenchmark string }{
+		// Superseded by the final checkpoint.
+		{"dddddddd-0000-0000-0000-000000000001", "cccccccc-0000-0000-0000-000000000001", "aaaaaaaa-0000-0000-0000-000000000001", "-8.00"},
+		{"dddddddd-0000-0000-0000-000000000002", "cccccccc-0000-0000-0000-000000000002", "aaaaaaaa-0000-0000-0000-000000000001", "3.00"},
+		{"dddddddd-0000-0000-0000-000000000003", "cccccccc-0000-0000-0000-000000000002", "aaaaaaaa-0000-0000-0000-000000000002", "-3.00"},
+		{"dddddddd-0000-0000-0000-000000000004", "cccccccc-0000-0000-0000-000000000003", "aaaaaaaa-0000-0000-0000-000000000003", "-1.00"},
+		// Active batches are not scored.
+		{"dddddddd-0000-0000-0000-000000000005", "cccccccc-0000-0000-0000-000000000004", "aaaaaaaa-0000-0000-0000-000000000004", "40.00"},
+	} {
+		if err := seedMetric(metric.id, metric.checkpointID, metric.pickID, "100.00", "1.00", metric.vsBenchmark); err != nil {
+			t.Fatalf("seed metric: %v", err)
+		}
+	}
+	for _, batchID := range []string{first, second} {
+		if err := store.UpdateBatchStatus(ctx, batchID, BatchStatusCompleted); err != nil {
+			t.Fatalf("complete batch: %v", err)
+		}
+	}
+
+	stats, err := store.TickerStats(ctx)
+	if err != nil {
+		t.Fatalf("ticker stats: %v", err)
+	}
+	if len(stats) != 2 {
+		t.Fatalf("expected 2 tickers, got %+v", stats)
+	}
+	aapl, msft := stats[0], stats[1]
+	if aapl.Ticker != "AAPL" || aapl.Picks != 3 || aapl.ScoredPicks != 2 {
+		t.Fatalf("unexpected AAPL counts: %+v", aapl)
+	}
+	if aapl.AverageVsBenchmarkPct == nil || aapl.AverageVsBenchmarkPct.String() != "1.0000" {
+		t.Fatalf("expected AAPL average vs benchmark 1.0000, got %v", aapl.AverageVsBenchmarkPct)
+	}
+	if aapl.WinRate == nil || aapl.WinRate.String() != "0.5000" {
+		t.Fatalf("expected AAPL win rate 0.5000, got %v", aapl.WinRate)
+	}
+	if msft.Ticker != "MSFT" || msft.ScoredPicks != 1 || msft.WinRate == nil || msft.WinRate.String() != "0.0000" {
+		t.Fatalf("unexpected MSFT stats: %+v", msft)
+	}
+}
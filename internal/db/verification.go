@@ -0,0 +1,108 @@
+package db
+
+import (
+	"context"
+	"time"
+
+	"github.com/igor-kupczynski/alpha-monday/internal/decimal"
+	"github.com/jackc/pgx/v5"
+)
+
+// EventMetricsInconsistent is enqueued when stored pick metrics disagree with
+// values recomputed from the stored prices.
+const EventMetricsInconsistent = "metrics_inconsistent"
+
+// MetricVerificationRow carries a stored pick metric together with the prices
+// it was derived from.
+type MetricVerificationRow struct {
+	MetricID              string
+	BatchID               string
+	CheckpointID          string
+	CheckpointDate        string
+	PickID                string
+	Ticker                string
+	InitialPrice          decimal.Decimal
+	CurrentPrice          decimal.Decimal
+	BenchmarkInitialPrice decimal.Decimal
+	BenchmarkPrice        decimal.Decimal
+	AbsoluteReturnPct     decimal.Decimal
+	VsBenchmarkPct        decimal.Decimal
+}
+
+type MetricDiscrepancy struct {
+	MetricID       string          `json:"metric_id"`
+	CheckpointID   string          `json:"checkpoint_id"`
+	CheckpointDate string          `json:"checkpoint_date"`
+	Ticker         string          `json:"ticker"`
+	Field          string          `json:"field"`
+	Stored         decimal.Decimal `json:"stored"`
+	Expected       decimal.Decimal `json:"expected"`
+}
+
+type MetricsInconsistentPayload struct {
+	BatchID       string              `json:"batch_id"`
+	Discrepancies []MetricDiscrepancy `json:"discrepancies"`
+}
+
+// ListMetricsForVerification returns metrics of computed checkpoints created at
+// or after since.
+func (s *Store) ListMetricsForVerification(ctx context.Context, since time.Time) (_ []MetricVerificationRow, err error) {
+	defer s.observe("ListMetricsForVerification", time.Now(), &err)
+
+	rows, err := s.pool.Query(ctx, `
+        SELECT m.id::text, b.id::text, c.id::text, c.checkpoint_date::text, p.id::text, p.ticker,
+               p.initial_price::text, m.current_price::text,
+               b.benchmark_initial_price::text, c.benchmark_price::text,
+               m.absolute_return_pct::text, m.vs_benchmark_pct::text
+        FROM pick_checkpoint_metrics m
+        JOIN checkpoints c ON c.id = m.checkpoint_id
+        JOIN picks p ON p.id = m.pick_id
+        JOIN batches b ON b.id = c.batch_id
+        WHERE c.status = 'computed' AND c.created_at >= $1 AND b.deleted_at IS NULL
+        ORDER BY b.run_date, c.checkpoint_date, p.ticker`, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := []MetricVerificationRow{}
+	for rows.Next() {
+		var row MetricVerificationRow
+		if err := rows.Scan(
+			&row.MetricID,
+			&row.BatchID,
+			&row.CheckpointID,
+			&row.CheckpointDate,
+			&row.PickID,
+			&row.Ticker,
+			&row.InitialPrice,
+			&row.CurrentPrice,
+			&row.BenchmarkInitialPrice,
+			&row.BenchmarkPrice,
+			&row.AbsoluteReturnPct,
+			&row.VsBenchmarkPct,
+		); err != nil {
+			return nil, err
+		}
+		result = append(result, row)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// RecordMetricDiscrepancies flags inconsistent metrics of a batch by enqueueing
+// a metrics_inconsistent outbox event.
+func (s *Store) RecordMetricDiscrepancies(ctx context.Context, batchID string, discrepancies []MetricDiscrepancy) (err error) {
+	defer s.observe("RecordMetricDiscrepancies", time.Now(), &err)
+
+	return s.withWriteRetry(ctx, func(ctx context.Context) error {
+		return pgx.BeginFunc(ctx, s.pool, func(tx pgx.Tx) error {
+			return enqueueOutboxEvent(ctx, tx, EventMetricsInconsistent, batchID, MetricsInconsistentPayload{
+				BatchID:       batchID,
+				Discrepancies: discrepancies,
+			})
+		})
+	})
+}
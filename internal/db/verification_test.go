@@ -0,0 +1,85 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/igor-kupczynski/alpha-monday/internal/decimal"
+)
+
+func TestListMetricsForVerificationAndRecord(t *testing.T) {
+	truncateTables(t)
+
+	batchID := "55555555-6666-7777-8888-999999999999"
+	pickID := "55555555-6666-7777-8888-000000000001"
+	computedID := "55555555-6666-7777-8888-000000000002"
+	skippedID := "55555555-6666-7777-8888-000000000003"
+	metricID := "55555555-6666-7777-8888-000000000004"
+
+	if err := seedBatch(batchID, "2026-01-27", "SPY", "200", "active"); err != nil {
+		t.Fatalf("seed batch: %v", err)
+	}
+	if err := seedPick(pickID, batchID, "AAPL", "BUY", "ok", "100"); err != nil {
+		t.Fatalf("seed pick: %v", err)
+	}
+	if err := seedCheckpoint(computedID, batchID, "2026-01-28", "computed", "210", "5"); err != nil {
+		t.Fatalf("seed checkpoint: %v", err)
+	}
+	if err := seedMetric(metricID, computedID, pickID, "110", "10", "5"); err != nil {
+		t.Fatalf("seed metric: %v", err)
+	}
+
+	store := NewStore(testPool)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if _, err := testPool.Exec(ctx, `
+        INSERT INTO checkpoints (id, batch_id, checkpoint_date, status)
+        VALUES ($1, $2, '2026-01-29', 'skipped')`, skippedID, batchID); err != nil {
+		t.Fatalf("seed skipped checkpoint: %v", err)
+	}
+
+	rows, err := store.ListMetricsForVerification(ctx, time.Now().Add(-time.Hour))
+	if err != nil {
+		t.Fatalf("list metrics: %v", err)
+	}
+	if len(rows) != 1 {
+		t.Fatalf("expected 1 metric row, got %d", len(rows))
+	}
+	row := rows[0]
+	if row.MetricID != metricID || row.BatchID != batchID || row.Ticker != "AAPL" {
+		t.Fatalf("unexpected row %+v", row)
+	}
+	if row.InitialPrice.String() != "100" || row.CurrentPrice.String() != "110" || row.BenchmarkInitialPrice.String() != "200" || row.BenchmarkPrice.String() != "210" {
+		t.Fatalf("unexpected prices %+v", row)
+	}
+
+	future, err := store.ListMetricsForVerification(ctx, time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatalf("list future metrics: %v", err)
+	}
+	if len(future) != 0 {
+		t.Fatalf("expected no metrics created after since, got %d", len(future))
+	}
+
+	discrepancy := MetricDiscrepancy{MetricID: metricID, Field: "vs_benchmark_pct", Stored: decimal.MustParse("5"), Expected: decimal.MustParse("4")}
+	if err := store.RecordMetricDiscrepancies(ctx, batchID, []MetricDiscrepancy{discrepancy}); err != nil {
+		t.Fatalf("record discrepancies: %v", err)
+	}
+	events, err := store.ClaimOutboxEvents(ctx, 10, time.Minute)
+	if err != nil {
+		t.Fatalf("claim events: %v", err)
+	}
+	if len(events) != 1 || events[0].EventType != EventMetricsInconsistent {
+		t.Fatalf("expected %s event, got %+v", EventMetricsInconsistent, events)
+	}
+	var payload MetricsInconsistentPayload
+	if err := json.Unmarshal(events[0].Payload, &payload); err != nil {
+		t.Fatalf("decode payload: %v", err)
+	}
+	if len(payload.Discrepancies) != 1 || payload.Discrepancies[0].MetricID != metricID || !payload.Discrepancies[0].Expected.Equal(discrepancy.Expected) {
+		t.Fatalf("unexpected payload %+v", payload)
+	}
+}
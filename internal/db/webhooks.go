@@ -0,0 +1,121 @@
+package db
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// webhookSecretPrefix marks webhook signing secrets so they are recognisable
+// in logs and secret scanners.
+const webhookSecretPrefix = "amwh_"
+
+// WebhookEventTypes are the outbox events a subscription can receive.
+var WebhookEventTypes = []string{EventBatchCreated, EventCheckpointComputed}
+
+// WebhookSubscription is an external URL that receives signed event
+// deliveries. Unlike API keys the secret is stored in plaintext, since the
+// dispatcher needs it to sign each delivery; it is returned by the API only
+// on creation.
+type WebhookSubscription struct {
+	ID         string
+	URL        string
+	Secret     string
+	EventTypes []string
+	CreatedAt  time.Time
+}
+
+// CreateWebhookSubscription registers url for eventTypes and generates its
+// signing secret.
+func (s *Store) CreateWebhookSubscription(ctx context.Context, url string, eventTypes []string) (_ *WebhookSubscription, err error) {
+	defer s.observe("CreateWebhookSubscription", time.Now(), &err)
+
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return nil, err
+	}
+	secret := webhookSecretPrefix + base64.RawURLEncoding.EncodeToString(raw)
+
+	var created WebhookSubscription
+	err = s.withWriteRetry(ctx, func(ctx context.Context) error {
+		return s.pool.QueryRow(ctx, `
+            INSERT INTO webhook_subscriptions (id, url, secret, event_types)
+            VALUES ($1, $2, $3, $4)
+            RETURNING id::text, url, secret, event_types, created_at`,
+			uuid.New(), url, secret, eventTypes,
+		).Scan(&created.ID, &created.URL, &created.Secret, &created.EventTypes, &created.CreatedAt)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
+
+// ListWebhookSubscriptions returns active subscriptions, oldest first.
+func (s *Store) ListWebhookSubscriptions(ctx context.Context) (_ []WebhookSubscription, err error) {
+	defer s.observe("ListWebhookSubscriptions", time.Now(), &err)
+
+	return s.queryWebhookSubscriptions(ctx, "")
+}
+
+// WebhookSubscriptionsFor returns the active subscriptions that receive
+// eventType.
+func (s *Store) WebhookSubscriptionsFor(ctx context.Context, eventType string) (_ []WebhookSubscription, err error) {
+	defer s.observe("WebhookSubscriptionsFor", time.Now(), &err)
+
+	if !slices.Contains(WebhookEventTypes, eventType) {
+		return []WebhookSubscription{}, nil
+	}
+	return s.queryWebhookSubscriptions(ctx, eventType)
+}
+
+// queryWebhookSubscriptions lists active subscriptions, limited to those
+// receiving eventType unless it is empty.
+func (s *Store) queryWebhookSubscriptions(ctx context.Context, eventType string) ([]WebhookSubscription, error) {
+	rows, err := s.pool.Query(ctx, `
+        SELECT id::text, url, secret, event_types, created_at
+        FROM webhook_subscriptions
+        WHERE revoked_at IS NULL AND ($1 = '' OR $1 = ANY(event_types))
+        ORDER BY created_at, id`, eventType)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	subscriptions := []WebhookSubscription{}
+	for rows.Next() {
+		var row WebhookSubscription
+		if err := rows.Scan(&row.ID, &row.URL, &row.Secret, &row.EventTypes, &row.CreatedAt); err != nil {
+			return nil, err
+		}
+		subscriptions = append(subscriptions, row)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return subscriptions, nil
+}
+
+// RevokeWebhookSubscription stops deliveries to a subscription and reports
+// whether an active subscription was revoked.
+func (s *Store) RevokeWebhookSubscription(ctx context.Context, id string) (_ bool, err error) {
+	defer s.observe("RevokeWebhookSubscription", time.Now(), &err)
+
+	var revoked bool
+	err = s.withWriteRetry(ctx, func(ctx context.Context) error {
+		tag, err := s.pool.Exec(ctx, `
+            UPDATE webhook_subscriptions
+            SET revoked_at = now()
+            WHERE id = $1 AND revoked_at IS NULL`, id)
+		if err != nil {
+			return err
+		}
+		revoked = tag.RowsAffected() > 0
+		return nil
+	})
+	return revoked, err
+}
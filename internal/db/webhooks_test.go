@@ -0,0 +1,70 @@
+package db
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWebhookSubscriptions(t *testing.T) {
+	truncateTables(t)
+
+	store := NewStore(testPool)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	all, err := store.CreateWebhookSubscription(ctx, "https://example.com/all", WebhookEventTypes)
+	if err != nil {
+		t.Fatalf("create subscription: %v", err)
+	}
+	if !strings.HasPrefix(all.Secret, webhookSecretPrefix) || len(all.EventTypes) != 2 {
+		t.Fatalf("unexpected subscription %+v", all)
+	}
+	batches, err := store.CreateWebhookSubscription(ctx, "https://example.com/batches", []string{EventBatchCreated})
+	if err != nil {
+		t.Fatalf("create subscription: %v", err)
+	}
+	if _, err := store.CreateWebhookSubscription(ctx, "https://example.com/bad", []string{EventAPIPanic}); err == nil {
+		t.Fatalf("expected unsupported event type to violate the check constraint")
+	}
+
+	listed, err := store.ListWebhookSubscriptions(ctx)
+	if err != nil {
+		t.Fatalf("list subscriptions: %v", err)
+	}
+	if len(listed) != 2 || listed[0].ID != all.ID || listed[1].ID != batches.ID {
+		t.Fatalf("unexpected subscriptions %+v", listed)
+	}
+
+	checkpoint, err := store.WebhookSubscriptionsFor(ctx, EventCheckpointComputed)
+	if err != nil {
+		t.Fatalf("subscriptions for checkpoint: %v", err)
+	}
+	if len(checkpoint) != 1 || checkpoint[0].ID != all.ID || checkpoint[0].Secret != all.Secret {
+		t.Fatalf("expected only the catch-all subscription, got %+v", checkpoint)
+	}
+	skipped, err := store.WebhookSubscriptionsFor(ctx, EventCheckpointSkipped)
+	if err != nil {
+		t.Fatalf("subscriptions for skipped: %v", err)
+	}
+	if len(skipped) != 0 {
+		t.Fatalf("expected no subscriptions for unsupported event, got %+v", skipped)
+	}
+
+	revoked, err := store.RevokeWebhookSubscription(ctx, all.ID)
+	if err != nil || !revoked {
+		t.Fatalf("revoke subscription: %v (revoked=%v)", err, revoked)
+	}
+	revoked, err = store.RevokeWebhookSubscription(ctx, all.ID)
+	if err != nil || revoked {
+		t.Fatalf("expected second revoke to be a no-op: %v (revoked=%v)", err, revoked)
+	}
+	created, err := store.WebhookSubscriptionsFor(ctx, EventBatchCreated)
+	if err != nil {
+		t.Fatalf("subscriptions for batch: %v", err)
+	}
+	if len(created) != 1 || created[0].ID != batches.ID {
+		t.Fatalf("expected only the batch subscription after revoke, got %+v", created)
+	}
+}
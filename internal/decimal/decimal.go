@@ -0,0 +1,253 @@
+// Package decimal provides an exact decimal number backed by big.Rat.
+//
+// A Decimal remembers the number of fractional digits it was parsed or rounded
+// with, so values read from Postgres numeric columns (e.g. "401.25" or
+// "10.00000000") render back exactly as stored.
+package decimal
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// Decimal is an immutable exact decimal. The zero value is 0.
+type Decimal struct {
+	rat   *big.Rat
+	scale int32
+	// number makes MarshalJSON emit a bare JSON number; see AsJSONNumber.
+	number bool
+}
+
+// Zero is the decimal 0.
+var Zero = Decimal{}
+
+// Parse parses a plain decimal string such as "-12.3400". Exponents are
+// accepted; fractions ("1/3"), NaN and infinities are not.
+func Parse(value string) (Decimal, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return Decimal{}, fmt.Errorf("decimal value is required")
+	}
+	if strings.ContainsAny(value, "/") {
+		return Decimal{}, fmt.Errorf("invalid decimal %q", value)
+	}
+	rat, ok := new(big.Rat).SetString(value)
+	if !ok {
+		return Decimal{}, fmt.Errorf("invalid decimal %q", value)
+	}
+	return Decimal{rat: rat, scale: parsedScale(value)}, nil
+}
+
+// MustParse is like Parse but panics on invalid input. Use it for constants.
+func MustParse(value string) Decimal {
+	d, err := Parse(value)
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
+
+// NewFromInt returns value as a decimal with no fractional digits.
+func NewFromInt(value int64) Decimal {
+	return Decimal{rat: new(big.Rat).SetInt64(value)}
+}
+
+func parsedScale(value string) int32 {
+	mantissa := value
+	exponent := 0
+	if idx := strings.IndexAny(value, "eE"); idx >= 0 {
+		mantissa = value[:idx]
+		if _, err := fmt.Sscanf(value[idx+1:], "%d", &exponent); err != nil {
+			exponent = 0
+		}
+	}
+	fraction := 0
+	if idx := strings.IndexByte(mantissa, '.'); idx >= 0 {
+		fraction = len(mantissa) - idx - 1
+	}
+	scale := fraction - exponent
+	if scale < 0 {
+		return 0
+	}
+	return int32(scale)
+}
+
+func (d Decimal) value() *big.Rat {
+	if d.rat == nil {
+		return new(big.Rat)
+	}
+	return d.rat
+}
+
+// Scale is the number of fractional digits String renders.
+func (d Decimal) Scale() int32 {
+	return d.scale
+}
+
+// Add returns d + other.
+func (d Decimal) Add(other Decimal) Decimal {
+	return Decimal{rat: new(big.Rat).Add(d.value(), other.value()), scale: max(d.scale, other.scale)}
+}
+
+// Sub returns d - other.
+func (d Decimal) Sub(other Decimal) Decimal {
+	return Decimal{rat: new(big.Rat).Sub(d.value(), other.value()), scale: max(d.scale, other.scale)}
+}
+
+// Mul returns d * other.
+func (d Decimal) Mul(other Decimal) Decimal {
+	return Decimal{rat: new(big.Rat).Mul(d.value(), other.value()), scale: d.scale + other.scale}
+}
+
+// Quo returns d / other, exact, keeping d's scale for rendering. Round the
+// result to the desired precision before storing or displaying it.
+func (d Decimal) Quo(other Decimal) (Decimal, error) {
+	if other.IsZero() {
+		return Decimal{}, fmt.Errorf("division by zero")
+	}
+	return Decimal{rat: new(big.Rat).Quo(d.value(), other.value()), scale: d.scale}, nil
+}
+
+// Neg returns -d.
+func (d Decimal) Neg() Decimal {
+	return Decimal{rat: new(big.Rat).Neg(d.value()), scale: d.scale}
+}
+
+// Abs returns |d|.
+func (d Decimal) Abs() Decimal {
+	return Decimal{rat: new(big.Rat).Abs(d.value()), scale: d.scale}
+}
+
+// Round rounds d to places fractional digits, half away from zero.
+func (d Decimal) Round(places int32) Decimal {
+	if places < 0 {
+		places = 0
+	}
+	rounded, ok := new(big.Rat).SetString(d.value().FloatString(int(places)))
+	if !ok {
+		// FloatString always produces a parseable value.
+		panic("decimal: round produced an invalid value")
+	}
+	return Decimal{rat: rounded, scale: places}
+}
+
+// RoundMax rounds d to places fractional digits when it carries more; values
+// at or below that scale are returned unchanged. Negative places keeps the
+// scale.
+func (d Decimal) RoundMax(places int32) Decimal {
+	if places < 0 || d.scale <= places {
+		return d
+	}
+	rounded := d.Round(places)
+	rounded.number = d.number
+	return rounded
+}
+
+// Sign returns -1, 0 or +1.
+func (d Decimal) Sign() int {
+	return d.value().Sign()
+}
+
+// IsZero reports whether d == 0.
+func (d Decimal) IsZero() bool {
+	return d.Sign() == 0
+}
+
+// Cmp compares d and other and returns -1, 0 or +1.
+func (d Decimal) Cmp(other Decimal) int {
+	return d.value().Cmp(other.value())
+}
+
+// Equal reports whether d and other are numerically equal, regardless of scale.
+func (d Decimal) Equal(other Decimal) bool {
+	return d.Cmp(other) == 0
+}
+
+// Float64 returns the nearest float64 value.
+func (d Decimal) Float64() float64 {
+	f, _ := d.value().Float64()
+	return f
+}
+
+// String renders d with its scale, e.g. "10.00000000".
+func (d Decimal) String() string {
+	return d.value().FloatString(int(d.scale))
+}
+
+// StringFixed renders d rounded to places fractional digits.
+func (d Decimal) StringFixed(places int32) string {
+	return d.Round(places).String()
+}
+
+// AsJSONNumber returns d marked to marshal as a JSON number instead of a
+// string, for clients that cannot parse string numerics. Arithmetic and Round
+// return unmarked values.
+func (d Decimal) AsJSONNumber() Decimal {
+	d.number = true
+	return d
+}
+
+// MarshalJSON encodes d as a JSON string to preserve precision, or as a bare
+// number when marked by AsJSONNumber.
+func (d Decimal) MarshalJSON() ([]byte, error) {
+	if d.number {
+		return []byte(d.String()), nil
+	}
+	return json.Marshal(d.String())
+}
+
+// UnmarshalJSON accepts a JSON string or number.
+func (d *Decimal) UnmarshalJSON(data []byte) error {
+	text := strings.TrimSpace(string(data))
+	if text == "null" {
+		return fmt.Errorf("decimal cannot be null")
+	}
+	if strings.HasPrefix(text, `"`) {
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+		text = s
+	}
+	parsed, err := Parse(text)
+	if err != nil {
+		return err
+	}
+	*d = parsed
+	return nil
+}
+
+// Value implements driver.Valuer; decimals are sent to Postgres as text.
+func (d Decimal) Value() (driver.Value, error) {
+	return d.String(), nil
+}
+
+// Scan implements sql.Scanner for text and numeric columns.
+func (d *Decimal) Scan(src any) error {
+	switch v := src.(type) {
+	case string:
+		parsed, err := Parse(v)
+		if err != nil {
+			return err
+		}
+		*d = parsed
+		return nil
+	case []byte:
+		parsed, err := Parse(string(v))
+		if err != nil {
+			return err
+		}
+		*d = parsed
+		return nil
+	case int64:
+		*d = NewFromInt(v)
+		return nil
+	case nil:
+		return fmt.Errorf("cannot scan NULL into decimal; use *Decimal")
+	default:
+		return fmt.Errorf("cannot scan %T into decimal", src)
+	}
+}
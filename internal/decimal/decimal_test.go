@@ -0,0 +1,122 @@
+package decimal
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestParsePreservesScale(t *testing.T) {
+	cases := map[string]string{
+		"401.25":      "401.25",
+		"10.00000000": "10.00000000",
+		"-0.5":        "-0.5",
+		"42":          "42",
+		" 7.10 ":      "7.10",
+		"1.5e2":       "150",
+		"1.25e-1":     "0.125",
+	}
+	for input, want := range cases {
+		d, err := Parse(input)
+		if err != nil {
+			t.Fatalf("parse %q: %v", input, err)
+		}
+		if got := d.String(); got != want {
+			t.Fatalf("parse %q: expected %q, got %q", input, want, got)
+		}
+	}
+}
+
+func TestParseRejectsInvalid(t *testing.T) {
+	for _, input := range []string{"", "abc", "1/3", "NaN", "1.2.3"} {
+		if _, err := Parse(input); err == nil {
+			t.Fatalf("expected error for %q", input)
+		}
+	}
+}
+
+func TestArithmeticAndRounding(t *testing.T) {
+	a := MustParse("110")
+	b := MustParse("100")
+
+	diff := a.Sub(b).Mul(NewFromInt(100))
+	pct, err := diff.Quo(b)
+	if err != nil {
+		t.Fatalf("quo: %v", err)
+	}
+	if got := pct.Round(8).String(); got != "10.00000000" {
+		t.Fatalf("expected 10.00000000, got %q", got)
+	}
+
+	third, err := NewFromInt(1).Quo(NewFromInt(3))
+	if err != nil {
+		t.Fatalf("quo: %v", err)
+	}
+	if got := third.StringFixed(4); got != "0.3333" {
+		t.Fatalf("expected 0.3333, got %q", got)
+	}
+	if got := MustParse("-2.345").StringFixed(2); got != "-2.35" {
+		t.Fatalf("expected half away from zero rounding, got %q", got)
+	}
+	if got := MustParse("1.23456789").RoundMax(2).String(); got != "1.23" {
+		t.Fatalf("expected 1.23, got %q", got)
+	}
+	if got := MustParse("2.5").RoundMax(4).String(); got != "2.5" {
+		t.Fatalf("expected the scale kept below the limit, got %q", got)
+	}
+	if got := MustParse("-0.004").RoundMax(-1).String(); got != "-0.004" {
+		t.Fatalf("expected negative places to keep the scale, got %q", got)
+	}
+	if _, err := a.Quo(Zero); err == nil {
+		t.Fatalf("expected division by zero error")
+	}
+	if !MustParse("1.50").Equal(MustParse("1.5")) {
+		t.Fatalf("expected scale-independent equality")
+	}
+}
+
+func TestJSONRoundTrip(t *testing.T) {
+	type payload struct {
+		Price    Decimal  `json:"price"`
+		Optional *Decimal `json:"optional"`
+	}
+
+	var decoded payload
+	if err := json.Unmarshal([]byte(`{"price":"178.10","optional":1.5}`), &decoded); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if decoded.Price.String() != "178.10" || decoded.Optional == nil || decoded.Optional.String() != "1.5" {
+		t.Fatalf("unexpected decoded payload %+v", decoded)
+	}
+
+	decoded.Optional = nil
+	encoded, err := json.Marshal(decoded)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	if string(encoded) != `{"price":"178.10","optional":null}` {
+		t.Fatalf("unexpected encoding %s", encoded)
+	}
+
+	number := MustParse("-0.50").AsJSONNumber()
+	decoded.Optional = &number
+	encoded, err = json.Marshal(decoded)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	if string(encoded) != `{"price":"178.10","optional":-0.50}` {
+		t.Fatalf("unexpected number encoding %s", encoded)
+	}
+}
+
+func TestScan(t *testing.T) {
+	var d Decimal
+	if err := d.Scan([]byte("12.3400")); err != nil {
+		t.Fatalf("scan: %v", err)
+	}
+	if d.String() != "12.3400" {
+		t.Fatalf("expected 12.3400, got %q", d.String())
+	}
+	if err := d.Scan(nil); err == nil {
+		t.Fatalf("expected error scanning NULL")
+	}
+}
@@ -0,0 +1,189 @@
+// Package fixtures loads a few weeks of realistic batches, checkpoints and
+// metrics into the database so the API has data to serve during local
+// development.
+package fixtures
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/igor-kupczynski/alpha-monday/internal/db"
+	"github.com/igor-kupczynski/alpha-monday/internal/decimal"
+)
+
+//go:embed data/batches.json
+var batchesJSON []byte
+
+// Store is the write subset of db.Store used to seed fixtures.
+type Store interface {
+	CreateBatchWithInitialCheckpoint(ctx context.Context, input db.CreateBatchInput) (db.CreateBatchResult, error)
+	CreateCheckpointWithMetrics(ctx context.Context, input db.CreateCheckpointInput) (db.CreateCheckpointResult, error)
+	UpdateBatchStatus(ctx context.Context, batchID string, status string) error
+}
+
+type Batch struct {
+	RunDate               string          `json:"run_date"`
+	BenchmarkSymbol       string          `json:"benchmark_symbol"`
+	BenchmarkInitialPrice decimal.Decimal `json:"benchmark_initial_price"`
+	Status                string          `json:"status"`
+	Picks                 []Pick          `json:"picks"`
+	// Checkpoints start with the initial checkpoint written with the batch.
+	Checkpoints []Checkpoint `json:"checkpoints"`
+}
+
+type Pick struct {
+	Ticker       string           `json:"ticker"`
+	Action       string           `json:"action"`
+	Reasoning    string           `json:"reasoning"`
+	InitialPrice decimal.Decimal  `json:"initial_price"`
+	TargetPrice  *decimal.Decimal `json:"target_price,omitempty"`
+}
+
+type Checkpoint struct {
+	CheckpointDate     string           `json:"checkpoint_date"`
+	Status             string           `json:"status"`
+	BenchmarkPrice     *decimal.Decimal `json:"benchmark_price,omitempty"`
+	BenchmarkReturnPct *decimal.Decimal `json:"benchmark_return_pct,omitempty"`
+	Metrics            []Metric         `json:"metrics,omitempty"`
+}
+
+type Metric struct {
+	Ticker            string          `json:"ticker"`
+	CurrentPrice      decimal.Decimal `json:"current_price"`
+	AbsoluteReturnPct decimal.Decimal `json:"absolute_return_pct"`
+	VsBenchmarkPct    decimal.Decimal `json:"vs_benchmark_pct"`
+}
+
+type LoadResult struct {
+	Created int
+	Skipped int
+}
+
+// Batches returns the embedded fixture batches, oldest first.
+func Batches() ([]Batch, error) {
+	var data struct {
+		Batches []Batch `json:"batches"`
+	}
+	if err := json.Unmarshal(batchesJSON, &data); err != nil {
+		return nil, fmt.Errorf("decode fixtures: %w", err)
+	}
+	return data.Batches, nil
+}
+
+// Load writes every fixture batch through store. Batches whose run date
+// already exists are skipped, so loading twice is safe. Writes go through the
+// regular store methods, so they also enqueue outbox events.
+func Load(ctx context.Context, store Store) (LoadResult, error) {
+	batches, err := Batches()
+	if err != nil {
+		return LoadResult{}, err
+	}
+	return LoadBatches(ctx, store, batches)
+}
+
+// LoadBatches writes batches through store like Load.
+func LoadBatches(ctx context.Context, store Store, batches []Batch) (LoadResult, error) {
+	var result LoadResult
+	for _, batch := range batches {
+		created, err := loadBatch(ctx, store, batch)
+		if err != nil {
+			return result, fmt.Errorf("load batch %s: %w", batch.RunDate, err)
+		}
+		if created {
+			result.Created++
+		} else {
+			result.Skipped++
+		}
+	}
+	return result, nil
+}
+
+func loadBatch(ctx context.Context, store Store, batch Batch) (bool, error) {
+	if len(batch.Checkpoints) == 0 {
+		return false, fmt.Errorf("initial checkpoint is required")
+	}
+	runDate, err := parseDate(batch.RunDate)
+	if err != nil {
+		return false, err
+	}
+	initial := batch.Checkpoints[0]
+	initialDate, err := parseDate(initial.CheckpointDate)
+	if err != nil {
+		return false, err
+	}
+
+	picks := make([]db.NewPick, 0, len(batch.Picks))
+	for _, pick := range batch.Picks {
+		picks = append(picks, db.NewPick(pick))
+	}
+	created, err := store.CreateBatchWithInitialCheckpoint(ctx, db.CreateBatchInput{
+		RunDate:               runDate,
+		BenchmarkSymbol:       batch.BenchmarkSymbol,
+		BenchmarkInitialPrice: batch.BenchmarkInitialPrice,
+		Status:                "active",
+		Picks:                 picks,
+		CheckpointDate:        initialDate,
+		CheckpointStatus:      initial.Status,
+		BenchmarkPrice:        batch.BenchmarkInitialPrice,
+	})
+	if err != nil {
+		if errors.Is(err, db.ErrRunDateConflict) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	pickIDs := make(map[string]string, len(created.Picks))
+	for _, pick := range created.Picks {
+		pickIDs[pick.Ticker] = pick.ID
+	}
+
+	for _, checkpoint := range batch.Checkpoints[1:] {
+		date, err := parseDate(checkpoint.CheckpointDate)
+		if err != nil {
+			return false, err
+		}
+		metrics := make([]db.NewCheckpointMetric, 0, len(checkpoint.Metrics))
+		for _, metric := range checkpoint.Metrics {
+			pickID, ok := pickIDs[metric.Ticker]
+			if !ok {
+				return false, fmt.Errorf("metric for unknown pick %s on %s", metric.Ticker, checkpoint.CheckpointDate)
+			}
+			metrics = append(metrics, db.NewCheckpointMetric{
+				PickID:            pickID,
+				CurrentPrice:      metric.CurrentPrice,
+				AbsoluteReturnPct: metric.AbsoluteReturnPct,
+				VsBenchmarkPct:    metric.VsBenchmarkPct,
+			})
+		}
+		if _, err := store.CreateCheckpointWithMetrics(ctx, db.CreateCheckpointInput{
+			BatchID:            created.BatchID,
+			CheckpointDate:     date,
+			Status:             checkpoint.Status,
+			BenchmarkPrice:     checkpoint.BenchmarkPrice,
+			BenchmarkReturnPct: checkpoint.BenchmarkReturnPct,
+			Metrics:            metrics,
+		}); err != nil {
+			return false, fmt.Errorf("checkpoint %s: %w", checkpoint.CheckpointDate, err)
+		}
+	}
+
+	if batch.Status != "active" {
+		if err := store.UpdateBatchStatus(ctx, created.BatchID, batch.Status); err != nil {
+			return false, fmt.Errorf("update status: %w", err)
+		}
+	}
+	return true, nil
+}
+
+func parseDate(value string) (time.Time, error) {
+	parsed, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date %q: %w", value, err)
+	}
+	return parsed, nil
+}
@@ -0,0 +1,144 @@
+package fixtures
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/igor-kupczynski/alpha-monday/internal/db"
+	"github.com/igor-kupczynski/alpha-monday/internal/decimal"
+)
+
+type fakeStore struct {
+	runDates    map[string]bool
+	batches     []db.CreateBatchInput
+	checkpoints []db.CreateCheckpointInput
+	statuses    map[string]string
+}
+
+func newFakeStore() *fakeStore {
+	return &fakeStore{runDates: map[string]bool{}, statuses: map[string]string{}}
+}
+
+func (f *fakeStore) CreateBatchWithInitialCheckpoint(ctx context.Context, input db.CreateBatchInput) (db.CreateBatchResult, error) {
+	runDate := input.RunDate.Format("2006-01-02")
+	if f.runDates[runDate] {
+		return db.CreateBatchResult{}, db.ErrRunDateConflict
+	}
+	f.runDates[runDate] = true
+	f.batches = append(f.batches, input)
+	result := db.CreateBatchResult{BatchID: fmt.Sprintf("batch-%d", len(f.batches))}
+	for _, pick := range input.Picks {
+		result.Picks = append(result.Picks, db.Pick{ID: result.BatchID + "/" + pick.Ticker, Ticker: pick.Ticker})
+	}
+	return result, nil
+}
+
+func (f *fakeStore) CreateCheckpointWithMetrics(ctx context.Context, input db.CreateCheckpointInput) (db.CreateCheckpointResult, error) {
+	f.checkpoints = append(f.checkpoints, input)
+	return db.CreateCheckpointResult{CheckpointID: fmt.Sprintf("checkpoint-%d", len(f.checkpoints))}, nil
+}
+
+func (f *fakeStore) UpdateBatchStatus(ctx context.Context, batchID string, status string) error {
+	f.statuses[batchID] = status
+	return nil
+}
+
+func TestBatchesAreConsistent(t *testing.T) {
+	batches, err := Batches()
+	if err != nil {
+		t.Fatalf("batches: %v", err)
+	}
+	if len(batches) < 4 {
+		t.Fatalf("expected several weeks of fixtures, got %d", len(batches))
+	}
+	assertConsistent(t, batches)
+}
+
+// assertConsistent checks that stored returns match the stored prices.
+func assertConsistent(t *testing.T, batches []Batch) {
+	t.Helper()
+	for _, batch := range batches {
+		if len(batch.Picks) != 3 {
+			t.Fatalf("%s: expected 3 picks, got %d", batch.RunDate, len(batch.Picks))
+		}
+		initialPrices := map[string]decimal.Decimal{}
+		for _, pick := range batch.Picks {
+			initialPrices[pick.Ticker] = pick.InitialPrice
+		}
+		for _, checkpoint := range batch.Checkpoints[1:] {
+			if checkpoint.Status == "skipped" {
+				if checkpoint.BenchmarkPrice != nil || len(checkpoint.Metrics) > 0 {
+					t.Fatalf("%s %s: skipped checkpoint has prices", batch.RunDate, checkpoint.CheckpointDate)
+				}
+				continue
+			}
+			benchmarkReturn := mustReturnPct(t, batch.BenchmarkInitialPrice, *checkpoint.BenchmarkPrice)
+			if !benchmarkReturn.Equal(*checkpoint.BenchmarkReturnPct) {
+				t.Fatalf("%s %s: benchmark return %s, want %s", batch.RunDate, checkpoint.CheckpointDate, checkpoint.BenchmarkReturnPct, benchmarkReturn)
+			}
+			if len(checkpoint.Metrics) != len(batch.Picks) {
+				t.Fatalf("%s %s: expected a metric per pick", batch.RunDate, checkpoint.CheckpointDate)
+			}
+			for _, metric := range checkpoint.Metrics {
+				absolute := mustReturnPct(t, initialPrices[metric.Ticker], metric.CurrentPrice)
+				if !absolute.Equal(metric.AbsoluteReturnPct) || !absolute.Sub(benchmarkReturn).Equal(metric.VsBenchmarkPct) {
+					t.Fatalf("%s %s %s: inconsistent metric %+v", batch.RunDate, checkpoint.CheckpointDate, metric.Ticker, metric)
+				}
+			}
+		}
+	}
+}
+
+func mustReturnPct(t *testing.T, initial, current decimal.Decimal) decimal.Decimal {
+	t.Helper()
+	result, err := current.Sub(initial).Mul(decimal.NewFromInt(100)).Quo(initial)
+	if err != nil {
+		t.Fatalf("return: %v", err)
+	}
+	return result.Round(8)
+}
+
+func TestLoadSkipsExistingRunDates(t *testing.T) {
+	batches, err := Batches()
+	if err != nil {
+		t.Fatalf("batches: %v", err)
+	}
+	store := newFakeStore()
+
+	result, err := Load(context.Background(), store)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if result.Created != len(batches) || result.Skipped != 0 {
+		t.Fatalf("unexpected first load result: %+v", result)
+	}
+	expectedCheckpoints := 0
+	completed := 0
+	for _, batch := range batches {
+		expectedCheckpoints += len(batch.Checkpoints) - 1
+		if batch.Status == "completed" {
+			completed++
+		}
+	}
+	if len(store.checkpoints) != expectedCheckpoints {
+		t.Fatalf("expected %d checkpoints, got %d", expectedCheckpoints, len(store.checkpoints))
+	}
+	if len(store.statuses) != completed {
+		t.Fatalf("expected %d completed batches, got %v", completed, store.statuses)
+	}
+	for _, metric := range store.checkpoints[0].Metrics {
+		if !strings.HasPrefix(metric.PickID, "batch-1/") {
+			t.Fatalf("metric not linked to a batch-1 pick: %+v", metric)
+		}
+	}
+
+	result, err = Load(context.Background(), store)
+	if err != nil {
+		t.Fatalf("reload: %v", err)
+	}
+	if result.Created != 0 || result.Skipped != len(batches) {
+		t.Fatalf("expected reload to skip all batches, got %+v", result)
+	}
+}
@@ -0,0 +1,216 @@
+package fixtures
+
+import (
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"strconv"
+	"time"
+
+	"github.com/igor-kupczynski/alpha-monday/internal/decimal"
+)
+
+const (
+	generatedPickCount       = 3
+	generatedTradingDays     = 10
+	generatedBenchmark       = "SPY"
+	generatedBenchmarkStart  = 450.0
+	generatedBenchmarkVol    = 0.009
+	generatedTickerVol       = 0.02
+	generatedReturnPrecision = 8
+)
+
+var generatedUniverse = []struct {
+	ticker string
+	price  float64
+}{
+	{"AAPL", 190}, {"MSFT", 380}, {"NVDA", 95}, {"AMZN", 150}, {"GOOGL", 140},
+	{"META", 350}, {"JPM", 170}, {"XOM", 105}, {"TSLA", 230}, {"INTC", 35},
+	{"COST", 650}, {"NKE", 100}, {"LLY", 600}, {"BA", 210}, {"UNH", 510},
+	{"DIS", 95}, {"V", 260}, {"PEP", 170}, {"KO", 60}, {"AMD", 140},
+}
+
+var generatedReasons = map[string][]string{
+	"BUY": {
+		"Earnings momentum and upward estimate revisions support further gains.",
+		"Valuation looks undemanding relative to improving free cash flow.",
+		"Sector rotation and strong guidance point to near-term outperformance.",
+	},
+	"SELL": {
+		"Slowing demand and margin pressure make current estimates look too high.",
+		"Valuation is stretched after a sharp rally without earnings support.",
+		"Competitive pressure is eroding pricing power into the next quarter.",
+	},
+}
+
+// GenerateConfig parameterizes Generate. The same config always produces the
+// same batches.
+type GenerateConfig struct {
+	Weeks int
+	Seed  uint64
+	// FirstRunDate is the Monday of the first batch.
+	FirstRunDate time.Time
+}
+
+// Generate synthesizes weekly batches with random-walk prices: one batch per
+// Monday, three picks, an initial checkpoint on the previous trading day and
+// ten daily checkpoints, all completed. It is meant for load-testing list
+// endpoints and pagination; use Load for hand-curated data.
+func Generate(cfg GenerateConfig) ([]Batch, error) {
+	if cfg.Weeks <= 0 {
+		return nil, fmt.Errorf("weeks must be positive")
+	}
+	if cfg.FirstRunDate.Weekday() != time.Monday {
+		return nil, fmt.Errorf("first run date %s is not a Monday", cfg.FirstRunDate.Format("2006-01-02"))
+	}
+	rng := rand.New(rand.NewPCG(cfg.Seed, cfg.Seed^0x5eed))
+
+	first := time.Date(cfg.FirstRunDate.Year(), cfg.FirstRunDate.Month(), cfg.FirstRunDate.Day(), 0, 0, 0, 0, time.UTC)
+	closes := simulateCloses(rng, first.AddDate(0, 0, -3), first.AddDate(0, 0, 7*cfg.Weeks+14))
+
+	batches := make([]Batch, 0, cfg.Weeks)
+	for week := 0; week < cfg.Weeks; week++ {
+		batch, err := generateBatch(rng, closes, first.AddDate(0, 0, 7*week))
+		if err != nil {
+			return nil, err
+		}
+		batches = append(batches, batch)
+	}
+	return batches, nil
+}
+
+// dailyCloses holds simulated closes by symbol, indexed by trading day.
+type dailyCloses struct {
+	index  map[string]int
+	prices map[string][]float64
+}
+
+func (c dailyCloses) price(symbol string, day time.Time) (decimal.Decimal, error) {
+	i, ok := c.index[day.Format("2006-01-02")]
+	if !ok {
+		return decimal.Decimal{}, fmt.Errorf("no close for %s on %s", symbol, day.Format("2006-01-02"))
+	}
+	return decimal.Parse(strconv.FormatFloat(c.prices[symbol][i], 'f', 2, 64))
+}
+
+// simulateCloses random-walks every symbol over the weekdays in [from, to).
+func simulateCloses(rng *rand.Rand, from, to time.Time) dailyCloses {
+	closes := dailyCloses{index: map[string]int{}, prices: map[string][]float64{}}
+	// Symbols are walked in a fixed order so the seed fully determines prices.
+	symbols := []string{generatedBenchmark}
+	current := []float64{generatedBenchmarkStart}
+	for _, stock := range generatedUniverse {
+		symbols = append(symbols, stock.ticker)
+		current = append(current, stock.price)
+	}
+	for day := from; day.Before(to); day = day.AddDate(0, 0, 1) {
+		if day.Weekday() == time.Saturday || day.Weekday() == time.Sunday {
+			continue
+		}
+		closes.index[day.Format("2006-01-02")] = len(closes.index)
+		for i, symbol := range symbols {
+			vol := generatedTickerVol
+			if symbol == generatedBenchmark {
+				vol = generatedBenchmarkVol
+			}
+			current[i] = math.Max(1, current[i]*(1+0.0003+vol*rng.NormFloat64()))
+			closes.prices[symbol] = append(closes.prices[symbol], current[i])
+		}
+	}
+	return closes
+}
+
+func generateBatch(rng *rand.Rand, closes dailyCloses, runDate time.Time) (Batch, error) {
+	initialDay := runDate.AddDate(0, 0, -3)
+	benchmarkInitial, err := closes.price(generatedBenchmark, initialDay)
+	if err != nil {
+		return Batch{}, err
+	}
+
+	batch := Batch{
+		RunDate:               runDate.Format("2006-01-02"),
+		BenchmarkSymbol:       generatedBenchmark,
+		BenchmarkInitialPrice: benchmarkInitial,
+		Status:                "completed",
+		Checkpoints: []Checkpoint{{
+			CheckpointDate: initialDay.Format("2006-01-02"),
+			Status:         "computed",
+			BenchmarkPrice: &benchmarkInitial,
+		}},
+	}
+	for _, index := range rng.Perm(len(generatedUniverse))[:generatedPickCount] {
+		ticker := generatedUniverse[index].ticker
+		action := "BUY"
+		if rng.IntN(3) == 0 {
+			action = "SELL"
+		}
+		price, err := closes.price(ticker, initialDay)
+		if err != nil {
+			return Batch{}, err
+		}
+		reasons := generatedReasons[action]
+		batch.Picks = append(batch.Picks, Pick{
+			Ticker:       ticker,
+			Action:       action,
+			Reasoning:    reasons[rng.IntN(len(reasons))],
+			InitialPrice: price,
+		})
+	}
+
+	day := runDate
+	for len(batch.Checkpoints) <= generatedTradingDays {
+		checkpoint, err := generateCheckpoint(closes, batch, day)
+		if err != nil {
+			return Batch{}, err
+		}
+		batch.Checkpoints = append(batch.Checkpoints, checkpoint)
+		day = day.AddDate(0, 0, 1)
+		for day.Weekday() == time.Saturday || day.Weekday() == time.Sunday {
+			day = day.AddDate(0, 0, 1)
+		}
+	}
+	return batch, nil
+}
+
+func generateCheckpoint(closes dailyCloses, batch Batch, day time.Time) (Checkpoint, error) {
+	benchmarkPrice, err := closes.price(generatedBenchmark, day)
+	if err != nil {
+		return Checkpoint{}, err
+	}
+	benchmarkReturn, err := returnPct(batch.BenchmarkInitialPrice, benchmarkPrice)
+	if err != nil {
+		return Checkpoint{}, err
+	}
+	checkpoint := Checkpoint{
+		CheckpointDate:     day.Format("2006-01-02"),
+		Status:             "computed",
+		BenchmarkPrice:     &benchmarkPrice,
+		BenchmarkReturnPct: &benchmarkReturn,
+	}
+	for _, pick := range batch.Picks {
+		price, err := closes.price(pick.Ticker, day)
+		if err != nil {
+			return Checkpoint{}, err
+		}
+		absolute, err := returnPct(pick.InitialPrice, price)
+		if err != nil {
+			return Checkpoint{}, err
+		}
+		checkpoint.Metrics = append(checkpoint.Metrics, Metric{
+			Ticker:            pick.Ticker,
+			CurrentPrice:      price,
+			AbsoluteReturnPct: absolute,
+			VsBenchmarkPct:    absolute.Sub(benchmarkReturn),
+		})
+	}
+	return checkpoint, nil
+}
+
+// returnPct matches the worker's return calculation.
+func returnPct(initial, current decimal.Decimal) (decimal.Decimal, error) {
+	result, err := current.Sub(initial).Mul(decimal.NewFromInt(100)).Quo(initial)
+	if err != nil {
+		return decimal.Decimal{}, err
+	}
+	return result.Round(generatedReturnPrecision), nil
+}
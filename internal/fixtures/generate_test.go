@@ -0,0 +1,62 @@
+package fixtures
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestGenerateIsDeterministicPerSeed(t *testing.T) {
+	cfg := GenerateConfig{Weeks: 30, Seed: 42, FirstRunDate: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	first, err := Generate(cfg)
+	if err != nil {
+		t.Fatalf("generate: %v", err)
+	}
+	second, err := Generate(cfg)
+	if err != nil {
+		t.Fatalf("generate again: %v", err)
+	}
+	if mustJSON(t, first) != mustJSON(t, second) {
+		t.Fatalf("expected identical batches for the same seed")
+	}
+	cfg.Seed = 43
+	other, err := Generate(cfg)
+	if err != nil {
+		t.Fatalf("generate other seed: %v", err)
+	}
+	if mustJSON(t, first) == mustJSON(t, other) {
+		t.Fatalf("expected different batches for a different seed")
+	}
+
+	if len(first) != cfg.Weeks {
+		t.Fatalf("expected %d batches, got %d", cfg.Weeks, len(first))
+	}
+	for i, batch := range first {
+		want := cfg.FirstRunDate.AddDate(0, 0, 7*i).Format("2006-01-02")
+		if batch.RunDate != want {
+			t.Fatalf("batch %d: expected run date %s, got %s", i, want, batch.RunDate)
+		}
+		if len(batch.Checkpoints) != 1+generatedTradingDays {
+			t.Fatalf("batch %d: expected %d checkpoints, got %d", i, 1+generatedTradingDays, len(batch.Checkpoints))
+		}
+	}
+	assertConsistent(t, first)
+}
+
+func TestGenerateRejectsInvalidConfig(t *testing.T) {
+	if _, err := Generate(GenerateConfig{Weeks: 0, FirstRunDate: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}); err == nil {
+		t.Fatalf("expected error for zero weeks")
+	}
+	if _, err := Generate(GenerateConfig{Weeks: 1, FirstRunDate: time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)}); err == nil {
+		t.Fatalf("expected error for a non-Monday start")
+	}
+}
+
+func mustJSON(t *testing.T, value any) string {
+	t.Helper()
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	return string(encoded)
+}
@@ -9,23 +9,37 @@ import (
 	"net"
 	"net/http"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/igor-kupczynski/alpha-monday/internal/integrations/retry"
 )
 
 const defaultBaseURL = "https://www.alphavantage.co/query"
 
+// defaultConcurrency is how many pick quotes SnapshotPreviousCloses fetches at
+// once.
+const defaultConcurrency = 4
+
 type Client struct {
 	apiKey      string
 	baseURL     string
 	httpClient  *http.Client
 	retryConfig retry.Config
+	concurrency int
+	pacer       *pacer
 }
 
 type Quote struct {
 	Symbol        string
 	PreviousClose string
 	TradingDay    string
+	// Open, High, Low and Volume describe the latest trading day's session.
+	// They may be empty.
+	Open   string
+	High   string
+	Low    string
+	Volume string
 }
 
 type Option func(*Client)
@@ -52,12 +66,39 @@ func WithRetryConfig(config retry.Config) Option {
 	}
 }
 
+// WithConcurrency bounds how many pick quotes SnapshotPreviousCloses fetches
+// in parallel. Values below 1 fetch sequentially.
+func WithConcurrency(n int) Option {
+	return func(c *Client) {
+		c.concurrency = max(n, 1)
+	}
+}
+
+// WithRequestsPerMinute paces requests, including retries, so that at most n
+// start in any rolling minute. Zero disables pacing.
+func WithRequestsPerMinute(n int) Option {
+	return func(c *Client) {
+		c.pacer = newPacer(n, time.Minute)
+	}
+}
+
+// defaultAttemptTimeout bounds one quote request; Alpha Vantage answers in
+// well under a second when healthy.
+const defaultAttemptTimeout = 20 * time.Second
+
+func defaultRetryConfig() retry.Config {
+	cfg := retry.DefaultConfig()
+	cfg.AttemptTimeout = defaultAttemptTimeout
+	return cfg
+}
+
 func NewClient(apiKey string, opts ...Option) *Client {
 	client := &Client{
 		apiKey:      strings.TrimSpace(apiKey),
 		baseURL:     defaultBaseURL,
 		httpClient:  http.DefaultClient,
-		retryConfig: retry.DefaultConfig(),
+		retryConfig: defaultRetryConfig(),
+		concurrency: defaultConcurrency,
 	}
 
 	for _, opt := range opts {
@@ -67,6 +108,11 @@ func NewClient(apiKey string, opts ...Option) *Client {
 	return client
 }
 
+// SnapshotPreviousCloses quotes the benchmark, then the picks in parallel
+// (bounded by WithConcurrency). The first failure cancels the remaining
+// fetches and is returned. A pick without a usable quote does not: the quotes
+// fetched are returned along with a *MissingQuoteError for each such pick, so
+// callers can replace those picks without quoting the rest again.
 func (c *Client) SnapshotPreviousCloses(ctx context.Context, benchmark string, picks []string) (map[string]Quote, error) {
 	benchmark = strings.TrimSpace(benchmark)
 	if benchmark == "" {
@@ -81,11 +127,12 @@ func (c *Client) SnapshotPreviousCloses(ctx context.Context, benchmark string, p
 	if err != nil {
 		return nil, err
 	}
-	if err := requireQuote(benchmarkQuote); err != nil {
+	if err := RequireQuote(benchmarkQuote); err != nil {
 		return nil, err
 	}
 	result[benchmark] = benchmarkQuote
 
+	var tickers []string
 	for _, pick := range picks {
 		ticker := strings.TrimSpace(pick)
 		if ticker == "" {
@@ -94,17 +141,63 @@ func (c *Client) SnapshotPreviousCloses(ctx context.Context, benchmark string, p
 		if _, seen := result[ticker]; seen {
 			continue
 		}
-		quote, err := c.FetchPreviousClose(ctx, ticker)
-		if err != nil {
-			return nil, err
+		result[ticker] = Quote{}
+		tickers = append(tickers, ticker)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		firstErr error
+		missing  []error
+	)
+	sem := make(chan struct{}, max(c.concurrency, 1))
+	for _, ticker := range tickers {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
 		}
-		if err := requireQuote(quote); err != nil {
-			return nil, err
+		if ctx.Err() != nil {
+			break
 		}
-		result[ticker] = quote
+		wg.Add(1)
+		go func(symbol string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			quote, err := c.FetchPreviousClose(ctx, symbol)
+			if err == nil {
+				err = RequireQuote(quote)
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			var missingErr *MissingQuoteError
+			if errors.As(err, &missingErr) {
+				missing = append(missing, err)
+				delete(result, symbol)
+				return
+			}
+			if err != nil {
+				if firstErr == nil {
+					firstErr = err
+					cancel()
+				}
+				return
+			}
+			result[symbol] = quote
+		}(ticker)
 	}
+	wg.Wait()
 
-	return result, nil
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return result, errors.Join(missing...)
 }
 
 type globalQuoteResponse struct {
@@ -113,7 +206,7 @@ type globalQuoteResponse struct {
 
 func (c *Client) FetchPreviousClose(ctx context.Context, symbol string) (Quote, error) {
 	var quote Quote
-	err := retry.Do(ctx, c.retryConfig, isRetryableError, func() error {
+	err := retry.Do(ctx, c.retryConfig, isRetryableError, func(ctx context.Context) error {
 		result, err := c.fetchPreviousCloseOnce(ctx, symbol)
 		if err != nil {
 			return err
@@ -132,6 +225,9 @@ func (c *Client) fetchPreviousCloseOnce(ctx context.Context, symbol string) (Quo
 	if symbol == "" {
 		return Quote{}, fmt.Errorf("symbol is required")
 	}
+	if err := c.pacer.wait(ctx); err != nil {
+		return Quote{}, err
+	}
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL, nil)
 	if err != nil {
 		return Quote{}, fmt.Errorf("build request: %w", err)
@@ -169,9 +265,74 @@ func (c *Client) fetchPreviousCloseOnce(ctx context.Context, symbol string) (Quo
 		Symbol:        symbol,
 		PreviousClose: strings.TrimSpace(parsed.GlobalQuote["08. previous close"]),
 		TradingDay:    strings.TrimSpace(parsed.GlobalQuote["07. latest trading day"]),
+		Open:          strings.TrimSpace(parsed.GlobalQuote["02. open"]),
+		High:          strings.TrimSpace(parsed.GlobalQuote["03. high"]),
+		Low:           strings.TrimSpace(parsed.GlobalQuote["04. low"]),
+		Volume:        strings.TrimSpace(parsed.GlobalQuote["06. volume"]),
 	}, nil
 }
 
+// Probe checks that the API key is accepted by fetching one quote for symbol.
+// Alpha Vantage reports bad keys and exhausted limits with a 200 response
+// carrying "Error Message", "Information" or "Note", which are returned as
+// errors here. The call counts against the key's request limits.
+func (c *Client) Probe(ctx context.Context, symbol string) error {
+	if strings.TrimSpace(c.apiKey) == "" {
+		return fmt.Errorf("alpha vantage api key is required")
+	}
+	return retry.Do(ctx, c.retryConfig, isRetryableError, func(ctx context.Context) error {
+		if err := c.pacer.wait(ctx); err != nil {
+			return err
+		}
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL, nil)
+		if err != nil {
+			return fmt.Errorf("build request: %w", err)
+		}
+		query := req.URL.Query()
+		query.Set("function", "GLOBAL_QUOTE")
+		query.Set("symbol", symbol)
+		query.Set("apikey", c.apiKey)
+		req.URL.RawQuery = query.Encode()
+
+		resp, err := c.httpClient.Do(req)
+		if err != nil {
+			return fmt.Errorf("alpha vantage request failed: %w", err)
+		}
+		defer resp.Body.Close()
+
+		body, err := io.ReadAll(io.LimitReader(resp.Body, 2<<20))
+		if err != nil {
+			return fmt.Errorf("read response: %w", err)
+		}
+		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			return httpStatusError{
+				status: resp.StatusCode,
+				msg:    fmt.Sprintf("alpha vantage request failed: status %s: %s", resp.Status, strings.TrimSpace(string(body))),
+			}
+		}
+
+		var parsed struct {
+			globalQuoteResponse
+			ErrorMessage string `json:"Error Message"`
+			Information  string `json:"Information"`
+			Note         string `json:"Note"`
+		}
+		if err := json.Unmarshal(body, &parsed); err != nil {
+			return fmt.Errorf("decode response: %w", err)
+		}
+		for _, message := range []string{parsed.ErrorMessage, parsed.Information, parsed.Note} {
+			if message = strings.TrimSpace(message); message != "" {
+				return fmt.Errorf("alpha vantage rejected probe: %s", message)
+			}
+		}
+		return RequireQuote(Quote{
+			Symbol:        symbol,
+			PreviousClose: strings.TrimSpace(parsed.GlobalQuote["08. previous close"]),
+			TradingDay:    strings.TrimSpace(parsed.GlobalQuote["07. latest trading day"]),
+		})
+	})
+}
+
 type httpStatusError struct {
 	status int
 	msg    string
@@ -200,12 +361,25 @@ func isRetryableError(err error) bool {
 	return errors.As(err, &netErr)
 }
 
-func requireQuote(quote Quote) error {
+// MissingQuoteError reports a symbol for which Alpha Vantage returned no
+// usable quote, typically because the symbol is not traded.
+type MissingQuoteError struct {
+	Symbol string
+	Field  string
+}
+
+func (e *MissingQuoteError) Error() string {
+	return fmt.Sprintf("missing %s for %s", e.Field, e.Symbol)
+}
+
+// RequireQuote returns a *MissingQuoteError when quote lacks the previous
+// close or trading day.
+func RequireQuote(quote Quote) error {
 	if strings.TrimSpace(quote.PreviousClose) == "" {
-		return fmt.Errorf("missing previous close for %s", quote.Symbol)
+		return &MissingQuoteError{Symbol: quote.Symbol, Field: "previous close"}
 	}
 	if strings.TrimSpace(quote.TradingDay) == "" {
-		return fmt.Errorf("missing trading day for %s", quote.Symbol)
+		return &MissingQuoteError{Symbol: quote.Symbol, Field: "trading day"}
 	}
 	return nil
 }
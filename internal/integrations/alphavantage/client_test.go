@@ -3,10 +3,13 @@ package alphavantage
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/igor-kupczynski/alpha-monday/internal/integrations/retry"
 )
@@ -59,6 +62,42 @@ func TestFetchPreviousCloseNoRetryOnBadRequest(t *testing.T) {
 	}
 }
 
+func TestFetchPreviousCloseParsesSessionFields(t *testing.T) {
+	server, _ := alphaTestServer([]alphaResponse{
+		{status: http.StatusOK, body: `{"Global Quote":{"01. symbol":"AAPL","02. open":"190.10","03. high":"192.50","04. low":"189.75","06. volume":"51234567","07. latest trading day":"2026-01-30","08. previous close":"191.00"}}`},
+	})
+	defer server.Close()
+	client := NewClient("test-key", WithBaseURL(server.URL), WithHTTPClient(server.Client()))
+
+	quote, err := client.FetchPreviousClose(context.Background(), "AAPL")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if quote.Open != "190.10" || quote.High != "192.50" || quote.Low != "189.75" || quote.Volume != "51234567" {
+		t.Fatalf("unexpected session fields: %+v", quote)
+	}
+}
+
+func TestProbe(t *testing.T) {
+	server, _ := alphaTestServer([]alphaResponse{
+		{status: http.StatusOK, body: alphaQuoteResponse("SPY", "123.45", "2026-01-30")},
+	})
+	defer server.Close()
+	client := NewClient("test-key", WithBaseURL(server.URL), WithHTTPClient(server.Client()))
+	if err := client.Probe(context.Background(), "SPY"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	rejected, _ := alphaTestServer([]alphaResponse{
+		{status: http.StatusOK, body: `{"Error Message":"the parameter apikey is invalid or missing"}`},
+	})
+	defer rejected.Close()
+	client = NewClient("bad-key", WithBaseURL(rejected.URL), WithHTTPClient(rejected.Client()))
+	if err := client.Probe(context.Background(), "SPY"); err == nil || !strings.Contains(err.Error(), "apikey is invalid") {
+		t.Fatalf("expected rejected key error, got %v", err)
+	}
+}
+
 type alphaResponse struct {
 	status int
 	body   string
@@ -93,3 +132,127 @@ func alphaQuoteResponse(symbol, prevClose, tradingDay string) string {
 	data, _ := json.Marshal(payload)
 	return string(data)
 }
+
+func TestSnapshotPreviousClosesReportsMissingQuote(t *testing.T) {
+	server, _ := alphaTestServer([]alphaResponse{
+		{status: http.StatusOK, body: alphaQuoteResponse("SPY", "123.45", "2026-01-30")},
+		{status: http.StatusOK, body: `{"Global Quote":{}}`},
+		{status: http.StatusOK, body: alphaQuoteResponse("AAPL", "190.00", "2026-01-30")},
+	})
+	defer server.Close()
+
+	client := NewClient("test-key", WithBaseURL(server.URL), WithHTTPClient(server.Client()), WithConcurrency(1))
+
+	quotes, err := client.SnapshotPreviousCloses(context.Background(), "SPY", []string{"ZZZZ", "AAPL"})
+	var missing *MissingQuoteError
+	if !errors.As(err, &missing) || missing.Symbol != "ZZZZ" {
+		t.Fatalf("expected missing quote for ZZZZ, got %v", err)
+	}
+	// The missing pick does not cancel the others, whose quotes are kept.
+	if _, ok := quotes["ZZZZ"]; ok || quotes["SPY"].PreviousClose != "123.45" || quotes["AAPL"].PreviousClose != "190.00" {
+		t.Fatalf("expected benchmark and AAPL quotes only, got %+v", quotes)
+	}
+}
+
+func TestFetchDailySeries(t *testing.T) {
+	server, _ := alphaTestServer([]alphaResponse{
+		{status: http.StatusOK, body: `{"Time Series (Daily)":{
+			"2026-01-30":{"1. open":"190.10","2. high":"192.50","3. low":"189.75","4. close":"191.00","5. volume":"51234567"},
+			"2026-01-29":{"1. open":"188.00","2. high":"190.00","3. low":"187.50","4. close":"189.90","5. volume":"40000000"}}}`},
+	})
+	defer server.Close()
+	client := NewClient("test-key", WithBaseURL(server.URL), WithHTTPClient(server.Client()))
+
+	bars, err := client.FetchDailySeries(context.Background(), "AAPL", false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(bars) != 2 || bars[0].Date != "2026-01-29" || bars[1].Close != "191.00" || bars[1].Volume != "51234567" {
+		t.Fatalf("unexpected bars: %+v", bars)
+	}
+
+	limited, _ := alphaTestServer([]alphaResponse{
+		{status: http.StatusOK, body: `{"Information":"premium endpoint"}`},
+	})
+	defer limited.Close()
+	client = NewClient("test-key", WithBaseURL(limited.URL), WithHTTPClient(limited.Client()))
+	if _, err := client.FetchDailySeries(context.Background(), "AAPL", true); err == nil || !strings.Contains(err.Error(), "premium endpoint") {
+		t.Fatalf("expected rejection error, got %v", err)
+	}
+}
+
+func TestFetchCloseOn(t *testing.T) {
+	series := `{"Time Series (Daily)":{"2026-01-30":{"1. open":"190.10","2. high":"192.50","3. low":"189.75","4. close":"191.00","5. volume":"51234567"}}}`
+	server, _ := alphaTestServer([]alphaResponse{
+		{status: http.StatusOK, body: series},
+		{status: http.StatusOK, body: series},
+	})
+	defer server.Close()
+	client := NewClient("test-key", WithBaseURL(server.URL), WithHTTPClient(server.Client()))
+
+	quote, err := client.FetchCloseOn(context.Background(), "AAPL", "2026-01-30")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if quote.PreviousClose != "191.00" || quote.TradingDay != "2026-01-30" || quote.Open != "190.10" {
+		t.Fatalf("unexpected quote: %+v", quote)
+	}
+
+	quote, err = client.FetchCloseOn(context.Background(), "AAPL", "2026-01-19")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if quote.PreviousClose != "" || quote.TradingDay != "2026-01-19" {
+		t.Fatalf("expected empty close for a day without a bar, got %+v", quote)
+	}
+}
+
+func TestSnapshotPreviousClosesFetchesPicksInParallel(t *testing.T) {
+	var inFlight, peak atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		current := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			seen := peak.Load()
+			if current <= seen || peak.CompareAndSwap(seen, current) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+		_, _ = w.Write([]byte(alphaQuoteResponse(r.URL.Query().Get("symbol"), "10.00", "2026-01-30")))
+	}))
+	defer server.Close()
+	client := NewClient("test-key", WithBaseURL(server.URL), WithHTTPClient(server.Client()), WithConcurrency(2))
+
+	quotes, err := client.SnapshotPreviousCloses(context.Background(), "SPY", []string{"AAPL", "MSFT", "NVDA", "AAPL"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(quotes) != 4 || quotes["NVDA"].Symbol != "NVDA" {
+		t.Fatalf("unexpected quotes: %+v", quotes)
+	}
+	if got := peak.Load(); got != 2 {
+		t.Fatalf("expected 2 concurrent pick fetches, got %d", got)
+	}
+}
+
+func TestPacerLimitsRequestsPerWindow(t *testing.T) {
+	p := newPacer(2, 50*time.Millisecond)
+	start := time.Now()
+	for range 3 {
+		if err := p.wait(context.Background()); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+		t.Fatalf("expected the third request to wait for the window, took %s", elapsed)
+	}
+
+	full := newPacer(1, time.Hour)
+	_ = full.wait(context.Background())
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := full.wait(ctx); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context error, got %v", err)
+	}
+}
@@ -0,0 +1,134 @@
+package alphavantage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/igor-kupczynski/alpha-monday/internal/integrations/retry"
+)
+
+// DailyBar is one trading day from TIME_SERIES_DAILY. Fields are the raw
+// strings returned by Alpha Vantage.
+type DailyBar struct {
+	Date   string
+	Open   string
+	High   string
+	Low    string
+	Close  string
+	Volume string
+}
+
+type dailySeriesResponse struct {
+	Series       map[string]map[string]string `json:"Time Series (Daily)"`
+	ErrorMessage string                       `json:"Error Message"`
+	Information  string                       `json:"Information"`
+	Note         string                       `json:"Note"`
+}
+
+// FetchDailySeries returns the daily bars of symbol, oldest first. With full
+// false only the latest 100 trading days are returned; the full history
+// requires a premium key.
+func (c *Client) FetchDailySeries(ctx context.Context, symbol string, full bool) ([]DailyBar, error) {
+	symbol = strings.TrimSpace(symbol)
+	if symbol == "" {
+		return nil, fmt.Errorf("symbol is required")
+	}
+	if c.apiKey == "" {
+		return nil, fmt.Errorf("alpha vantage api key is required")
+	}
+	outputSize := "compact"
+	if full {
+		outputSize = "full"
+	}
+
+	var bars []DailyBar
+	err := retry.Do(ctx, c.retryConfig, isRetryableError, func(ctx context.Context) error {
+		if err := c.pacer.wait(ctx); err != nil {
+			return err
+		}
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL, nil)
+		if err != nil {
+			return fmt.Errorf("build request: %w", err)
+		}
+		query := req.URL.Query()
+		query.Set("function", "TIME_SERIES_DAILY")
+		query.Set("symbol", symbol)
+		query.Set("outputsize", outputSize)
+		query.Set("apikey", c.apiKey)
+		req.URL.RawQuery = query.Encode()
+
+		resp, err := c.httpClient.Do(req)
+		if err != nil {
+			return fmt.Errorf("alpha vantage request failed: %w", err)
+		}
+		defer resp.Body.Close()
+
+		body, err := io.ReadAll(io.LimitReader(resp.Body, 16<<20))
+		if err != nil {
+			return fmt.Errorf("read response: %w", err)
+		}
+		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			return httpStatusError{
+				status: resp.StatusCode,
+				msg:    fmt.Sprintf("alpha vantage request failed: status %s: %s", resp.Status, strings.TrimSpace(string(body))),
+			}
+		}
+
+		var parsed dailySeriesResponse
+		if err := json.Unmarshal(body, &parsed); err != nil {
+			return fmt.Errorf("decode response: %w", err)
+		}
+		for _, message := range []string{parsed.ErrorMessage, parsed.Information, parsed.Note} {
+			if message = strings.TrimSpace(message); message != "" {
+				return fmt.Errorf("alpha vantage rejected daily series for %s: %s", symbol, message)
+			}
+		}
+
+		bars = make([]DailyBar, 0, len(parsed.Series))
+		for date, fields := range parsed.Series {
+			bars = append(bars, DailyBar{
+				Date:   date,
+				Open:   strings.TrimSpace(fields["1. open"]),
+				High:   strings.TrimSpace(fields["2. high"]),
+				Low:    strings.TrimSpace(fields["3. low"]),
+				Close:  strings.TrimSpace(fields["4. close"]),
+				Volume: strings.TrimSpace(fields["5. volume"]),
+			})
+		}
+		sort.Slice(bars, func(i, j int) bool { return bars[i].Date < bars[j].Date })
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return bars, nil
+}
+
+// FetchCloseOn returns symbol's bar for day (YYYY-MM-DD) as a Quote. When
+// the compact series has no bar for day (a market holiday, or more than 100
+// trading days ago), PreviousClose is empty, like a missing latest quote.
+func (c *Client) FetchCloseOn(ctx context.Context, symbol, day string) (Quote, error) {
+	bars, err := c.FetchDailySeries(ctx, symbol, false)
+	if err != nil {
+		return Quote{}, err
+	}
+	for _, bar := range bars {
+		if bar.Date == day {
+			return Quote{
+				Symbol:        symbol,
+				PreviousClose: bar.Close,
+				TradingDay:    bar.Date,
+				Open:          bar.Open,
+				High:          bar.High,
+				Low:           bar.Low,
+				Volume:        bar.Volume,
+			}, nil
+		}
+	}
+	return Quote{Symbol: symbol, TradingDay: day}, nil
+}
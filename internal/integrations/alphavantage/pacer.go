@@ -0,0 +1,55 @@
+package alphavantage
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// pacer holds requests back so that no more than limit start within any
+// window. It only sees this process's requests; limits shared across workers
+// are enforced by the orchestrator's rate limits.
+type pacer struct {
+	mu     sync.Mutex
+	limit  int
+	window time.Duration
+	starts []time.Time
+}
+
+func newPacer(limit int, window time.Duration) *pacer {
+	return &pacer{limit: limit, window: window}
+}
+
+// wait blocks until a request may start and records its start time.
+func (p *pacer) wait(ctx context.Context) error {
+	if p == nil || p.limit <= 0 {
+		return nil
+	}
+	for {
+		p.mu.Lock()
+		now := time.Now()
+		cutoff := now.Add(-p.window)
+		kept := p.starts[:0]
+		for _, start := range p.starts {
+			if start.After(cutoff) {
+				kept = append(kept, start)
+			}
+		}
+		p.starts = kept
+		if len(p.starts) < p.limit {
+			p.starts = append(p.starts, now)
+			p.mu.Unlock()
+			return nil
+		}
+		delay := p.starts[0].Add(p.window).Sub(now)
+		p.mu.Unlock()
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
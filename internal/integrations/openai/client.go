@@ -3,15 +3,22 @@ package openai
 import (
 	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"net"
 	"net/http"
+	"net/url"
 	"regexp"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 
+	"github.com/igor-kupczynski/alpha-monday/internal/decimal"
 	"github.com/igor-kupczynski/alpha-monday/internal/integrations/retry"
 )
 
@@ -20,6 +27,15 @@ const (
 	defaultModel       = "gpt-4o-mini"
 	defaultTemperature = 0.2
 	defaultMaxAttempts = 2
+	// defaultAttemptTimeout bounds one chat completion request.
+	defaultAttemptTimeout = 90 * time.Second
+
+	// PickCount is the number of picks requested and accepted per batch.
+	PickCount = 3
+
+	// UniverseVersion identifies the set of tickers the model is asked to
+	// pick from. Bump it when the universe described in the prompt changes.
+	UniverseVersion = "sp500-v1"
 )
 
 var (
@@ -35,6 +51,9 @@ type Client struct {
 	maxAttempts int
 	httpClient  *http.Client
 	retryConfig retry.Config
+	language    Language
+	blocklist   []string
+	minSectors  int
 }
 
 type Option func(*Client)
@@ -85,6 +104,60 @@ func WithRetryConfig(config retry.Config) Option {
 	}
 }
 
+// WithLanguage sets the language of generated reasoning. Use LookupLanguage to
+// resolve a configured code.
+func WithLanguage(lang Language) Option {
+	return func(c *Client) {
+		if lang.Code != "" {
+			c.language = lang
+		}
+	}
+}
+
+// WithBlocklist sets tickers the model must never pick, such as employer stock
+// or restricted names. Use ParseTickerList to read a configured list.
+func WithBlocklist(tickers []string) Option {
+	return func(c *Client) {
+		c.blocklist = append([]string(nil), tickers...)
+	}
+}
+
+// WithMinSectors requires the picks to span at least n distinct sectors. It is
+// enforced when the request carries sector metadata.
+func WithMinSectors(n int) Option {
+	return func(c *Client) {
+		if n >= 0 {
+			c.minSectors = n
+		}
+	}
+}
+
+// ParseTickerList parses a comma-separated ticker list, upper-casing and
+// de-duplicating entries. The result is sorted.
+func ParseTickerList(value string) ([]string, error) {
+	seen := map[string]bool{}
+	tickers := []string{}
+	for _, part := range strings.Split(value, ",") {
+		ticker := strings.ToUpper(strings.TrimSpace(part))
+		if ticker == "" || seen[ticker] {
+			continue
+		}
+		if !tickerPattern.MatchString(ticker) {
+			return nil, fmt.Errorf("invalid ticker %q", part)
+		}
+		seen[ticker] = true
+		tickers = append(tickers, ticker)
+	}
+	sort.Strings(tickers)
+	return tickers, nil
+}
+
+func defaultRetryConfig() retry.Config {
+	cfg := retry.DefaultConfig()
+	cfg.AttemptTimeout = defaultAttemptTimeout
+	return cfg
+}
+
 func NewClient(apiKey string, opts ...Option) *Client {
 	client := &Client{
 		apiKey:      strings.TrimSpace(apiKey),
@@ -93,7 +166,8 @@ func NewClient(apiKey string, opts ...Option) *Client {
 		temperature: defaultTemperature,
 		maxAttempts: defaultMaxAttempts,
 		httpClient:  http.DefaultClient,
-		retryConfig: retry.DefaultConfig(),
+		retryConfig: defaultRetryConfig(),
+		language:    languages[DefaultLanguage],
 	}
 
 	for _, opt := range opts {
@@ -107,24 +181,68 @@ func NewClient(apiKey string, opts ...Option) *Client {
 	return client
 }
 
+// Pick actions. HOLD expresses a neutral view: the pick is tracked like any
+// other but does not count towards the portfolio return.
+const (
+	ActionBuy  = "BUY"
+	ActionSell = "SELL"
+	ActionHold = "HOLD"
+)
+
 type Pick struct {
 	Ticker    string `json:"ticker"`
 	Action    string `json:"action"`
 	Reasoning string `json:"reasoning"`
+	// TargetPrice is the model's optional expected price at the end of the
+	// two-week horizon.
+	TargetPrice *decimal.Decimal `json:"target_price,omitempty"`
 }
 
-func (c *Client) GeneratePicks(ctx context.Context) ([]Pick, error) {
+// PickRequest carries per-run constraints on top of the client configuration.
+type PickRequest struct {
+	// ExcludeTickers lists tickers the model must not pick, e.g. those picked
+	// in recent weeks. They are named in the prompt and rejected in validation.
+	ExcludeTickers []string
+	// Sectors maps tickers to their stored sector for the sector diversity
+	// check. Tickers without an entry do not count towards any sector.
+	Sectors map[string]string
+}
+
+// pickRules are the checks applied to a parsed response.
+type pickRules struct {
+	lang       Language
+	excluded   map[string]bool
+	sectors    map[string]string
+	minSectors int
+}
+
+func (c *Client) GeneratePicks(ctx context.Context, req PickRequest) ([]Pick, error) {
 	if strings.TrimSpace(c.apiKey) == "" {
 		return nil, fmt.Errorf("openai api key is required")
 	}
 
+	rules := pickRules{
+		lang:     c.language,
+		excluded: make(map[string]bool, len(c.blocklist)+len(req.ExcludeTickers)),
+	}
+	for _, ticker := range c.blocklist {
+		rules.excluded[ticker] = true
+	}
+	for _, ticker := range req.ExcludeTickers {
+		rules.excluded[ticker] = true
+	}
+	if len(req.Sectors) > 0 {
+		rules.sectors = req.Sectors
+		rules.minSectors = c.minSectors
+	}
+
 	var lastErr error
 	for attempt := 1; attempt <= c.maxAttempts; attempt++ {
-		content, err := c.request(ctx)
+		content, err := c.request(ctx, req)
 		if err != nil {
 			return nil, err
 		}
-		picks, err := parseAndValidate(content)
+		picks, err := parseAndValidate(content, rules)
 		if err == nil {
 			return picks, nil
 		}
@@ -156,10 +274,59 @@ type chatResponse struct {
 	} `json:"choices"`
 }
 
-func (c *Client) request(ctx context.Context) (string, error) {
+func (c *Client) messages(req PickRequest) []message {
+	system := "You are a stock analyst. Return exactly " + strconv.Itoa(PickCount) + " unique S&P 500 tickers with BUY, SELL or HOLD and reasoning. " +
+		"Use HOLD for a neutral view. " +
+		"Output only a JSON array of objects with fields ticker, action, reasoning and optionally target_price, " +
+		"your expected price in USD two weeks from now. No extra text. " +
+		"Write the reasoning in " + c.language.Name + "; keep tickers, actions and field names in English."
+	if c.minSectors > 1 {
+		system += " Spread the picks across at least " + strconv.Itoa(c.minSectors) + " different sectors."
+	}
+	if len(c.blocklist) > 0 {
+		system += " Never pick these restricted tickers: " + strings.Join(c.blocklist, ", ") + "."
+	}
+	if len(req.ExcludeTickers) > 0 {
+		system += " Do not pick any of these tickers: " + strings.Join(req.ExcludeTickers, ", ") + "."
+	}
+	return []message{
+		{
+			Role:    "system",
+			Content: system,
+		},
+		{
+			Role:    "user",
+			Content: "Provide " + strconv.Itoa(PickCount) + " unique S&P 500 picks in strict JSON array format.",
+		},
+	}
+}
+
+// ConfigHash returns a hex SHA-256 over everything that shapes the generated
+// picks: the prompt (including the blocklist), model, sampling parameters and
+// universe version. Batches
+// generated with the same configuration share a hash. Per-run exclusions are
+// not part of it.
+func (c *Client) ConfigHash() string {
+	// Marshalling strings and a float cannot fail.
+	payload, _ := json.Marshal(struct {
+		Model           string    `json:"model"`
+		Temperature     float64   `json:"temperature"`
+		Messages        []message `json:"messages"`
+		UniverseVersion string    `json:"universe_version"`
+	}{
+		Model:           c.model,
+		Temperature:     c.temperature,
+		Messages:        c.messages(PickRequest{}),
+		UniverseVersion: UniverseVersion,
+	})
+	sum := sha256.Sum256(payload)
+	return hex.EncodeToString(sum[:])
+}
+
+func (c *Client) request(ctx context.Context, req PickRequest) (string, error) {
 	var content string
-	err := retry.Do(ctx, c.retryConfig, isRetryableError, func() error {
-		result, err := c.requestOnce(ctx)
+	err := retry.Do(ctx, c.retryConfig, isRetryableError, func(ctx context.Context) error {
+		result, err := c.requestOnce(ctx, req)
 		if err != nil {
 			return err
 		}
@@ -172,21 +339,11 @@ func (c *Client) request(ctx context.Context) (string, error) {
 	return content, nil
 }
 
-func (c *Client) requestOnce(ctx context.Context) (string, error) {
+func (c *Client) requestOnce(ctx context.Context, pickReq PickRequest) (string, error) {
 	reqBody := chatRequest{
 		Model:       c.model,
 		Temperature: c.temperature,
-		Messages: []message{
-			{
-				Role: "system",
-				Content: "You are a stock analyst. Return exactly 3 unique S&P 500 tickers with BUY/SELL and reasoning. " +
-					"Output only a JSON array of objects with fields ticker, action, reasoning. No extra text.",
-			},
-			{
-				Role:    "user",
-				Content: "Provide 3 unique S&P 500 picks in strict JSON array format.",
-			},
-		},
+		Messages:    c.messages(pickReq),
 	}
 
 	payload, err := json.Marshal(reqBody)
@@ -232,6 +389,40 @@ func (c *Client) requestOnce(ctx context.Context) (string, error) {
 	return content, nil
 }
 
+// Probe checks that the API key is accepted and the configured model is
+// available by fetching the model, which costs no tokens.
+func (c *Client) Probe(ctx context.Context) error {
+	if strings.TrimSpace(c.apiKey) == "" {
+		return fmt.Errorf("openai api key is required")
+	}
+	modelURL := strings.TrimSuffix(c.endpoint, "/chat/completions") + "/models/" + url.PathEscape(c.model)
+	return retry.Do(ctx, c.retryConfig, isRetryableError, func(ctx context.Context) error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, modelURL, nil)
+		if err != nil {
+			return fmt.Errorf("build request: %w", err)
+		}
+		req.Header.Set("Authorization", "Bearer "+c.apiKey)
+
+		resp, err := c.httpClient.Do(req)
+		if err != nil {
+			return fmt.Errorf("openai request failed: %w", err)
+		}
+		defer resp.Body.Close()
+
+		body, err := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+		if err != nil {
+			return fmt.Errorf("read response: %w", err)
+		}
+		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			return httpStatusError{
+				status: resp.StatusCode,
+				msg:    fmt.Sprintf("openai model %s unavailable: status %s: %s", c.model, resp.Status, strings.TrimSpace(string(body))),
+			}
+		}
+		return nil
+	})
+}
+
 type httpStatusError struct {
 	status int
 	msg    string
@@ -260,7 +451,7 @@ func isRetryableError(err error) bool {
 	return errors.As(err, &netErr)
 }
 
-func parseAndValidate(content string) ([]Pick, error) {
+func parseAndValidate(content string, rules pickRules) ([]Pick, error) {
 	decoder := json.NewDecoder(strings.NewReader(content))
 	decoder.DisallowUnknownFields()
 
@@ -272,7 +463,7 @@ func parseAndValidate(content string) ([]Pick, error) {
 		return nil, fmt.Errorf("%w: %v", ErrInvalidOutput, err)
 	}
 
-	if err := validatePicks(picks); err != nil {
+	if err := validatePicks(picks, rules); err != nil {
 		return nil, err
 	}
 	return picks, nil
@@ -286,9 +477,9 @@ func ensureEOF(decoder *json.Decoder) error {
 	return fmt.Errorf("extra json content detected")
 }
 
-func validatePicks(picks []Pick) error {
-	if len(picks) != 3 {
-		return fmt.Errorf("%w: expected 3 picks, got %d", ErrInvalidOutput, len(picks))
+func validatePicks(picks []Pick, rules pickRules) error {
+	if len(picks) != PickCount {
+		return fmt.Errorf("%w: expected %d picks, got %d", ErrInvalidOutput, PickCount, len(picks))
 	}
 	seen := map[string]bool{}
 	for _, pick := range picks {
@@ -300,12 +491,32 @@ func validatePicks(picks []Pick) error {
 			return fmt.Errorf("%w: duplicate ticker %q", ErrInvalidOutput, ticker)
 		}
 		seen[ticker] = true
-		if pick.Action != "BUY" && pick.Action != "SELL" {
+		if rules.excluded[ticker] {
+			return fmt.Errorf("%w: ticker %s is excluded", ErrInvalidOutput, ticker)
+		}
+		if pick.Action != ActionBuy && pick.Action != ActionSell && pick.Action != ActionHold {
 			return fmt.Errorf("%w: invalid action %q", ErrInvalidOutput, pick.Action)
 		}
+		if pick.TargetPrice != nil && pick.TargetPrice.Sign() <= 0 {
+			return fmt.Errorf("%w: target_price for %s must be positive", ErrInvalidOutput, ticker)
+		}
 		if strings.TrimSpace(pick.Reasoning) == "" {
 			return fmt.Errorf("%w: missing reasoning for %s", ErrInvalidOutput, ticker)
 		}
+		if !rules.lang.matchesScript(pick.Reasoning) {
+			return fmt.Errorf("%w: reasoning for %s is not in %s", ErrInvalidOutput, ticker, rules.lang.Name)
+		}
+	}
+	if rules.minSectors > 1 {
+		spanned := map[string]bool{}
+		for _, pick := range picks {
+			if sector, ok := rules.sectors[strings.TrimSpace(pick.Ticker)]; ok {
+				spanned[sector] = true
+			}
+		}
+		if len(spanned) < rules.minSectors {
+			return fmt.Errorf("%w: picks span %d known sectors, need %d", ErrInvalidOutput, len(spanned), rules.minSectors)
+		}
 	}
 	return nil
 }
@@ -3,8 +3,11 @@ package openai
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
+	"reflect"
+	"strings"
 	"sync/atomic"
 	"testing"
 
@@ -24,7 +27,7 @@ func TestGeneratePicksInvalidJSONRetries(t *testing.T) {
 		WithMaxAttempts(2),
 	)
 
-	_, err := client.GeneratePicks(context.Background())
+	_, err := client.GeneratePicks(context.Background(), PickRequest{})
 	if err == nil {
 		t.Fatalf("expected error for invalid json")
 	}
@@ -54,7 +57,7 @@ func TestGeneratePicksWrongCountRetries(t *testing.T) {
 		WithMaxAttempts(2),
 	)
 
-	_, err = client.GeneratePicks(context.Background())
+	_, err = client.GeneratePicks(context.Background(), PickRequest{})
 	if err == nil {
 		t.Fatalf("expected error for wrong count")
 	}
@@ -85,7 +88,7 @@ func TestGeneratePicksDuplicateTickersRetries(t *testing.T) {
 		WithMaxAttempts(2),
 	)
 
-	_, err = client.GeneratePicks(context.Background())
+	_, err = client.GeneratePicks(context.Background(), PickRequest{})
 	if err == nil {
 		t.Fatalf("expected error for duplicate tickers")
 	}
@@ -97,7 +100,7 @@ func TestGeneratePicksDuplicateTickersRetries(t *testing.T) {
 func TestGeneratePicksBadActionRetries(t *testing.T) {
 	content, err := json.Marshal([]Pick{
 		{Ticker: "AAPL", Action: "BUY", Reasoning: "ok"},
-		{Ticker: "MSFT", Action: "HOLD", Reasoning: "bad"},
+		{Ticker: "MSFT", Action: "SHORT", Reasoning: "bad"},
 		{Ticker: "NVDA", Action: "SELL", Reasoning: "ok"},
 	})
 	if err != nil {
@@ -116,7 +119,7 @@ func TestGeneratePicksBadActionRetries(t *testing.T) {
 		WithMaxAttempts(2),
 	)
 
-	_, err = client.GeneratePicks(context.Background())
+	_, err = client.GeneratePicks(context.Background(), PickRequest{})
 	if err == nil {
 		t.Fatalf("expected error for bad action")
 	}
@@ -125,11 +128,33 @@ func TestGeneratePicksBadActionRetries(t *testing.T) {
 	}
 }
 
+func TestGeneratePicksTargetPrice(t *testing.T) {
+	valid := `[{"ticker":"AAPL","action":"BUY","reasoning":"ok","target_price":210.5},` +
+		`{"ticker":"MSFT","action":"SELL","reasoning":"ok"},` +
+		`{"ticker":"NVDA","action":"HOLD","reasoning":"ok","target_price":null}]`
+	lang, err := LookupLanguage(DefaultLanguage)
+	if err != nil {
+		t.Fatalf("lookup language: %v", err)
+	}
+	picks, err := parseAndValidate(valid, pickRules{lang: lang})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if picks[0].TargetPrice == nil || picks[0].TargetPrice.String() != "210.5" || picks[1].TargetPrice != nil || picks[2].TargetPrice != nil {
+		t.Fatalf("unexpected target prices %+v", picks)
+	}
+
+	invalid := strings.Replace(valid, "210.5", "-1", 1)
+	if _, err := parseAndValidate(invalid, pickRules{lang: lang}); !errors.Is(err, ErrInvalidOutput) {
+		t.Fatalf("expected invalid output for a negative target, got %v", err)
+	}
+}
+
 func TestGeneratePicksSuccess(t *testing.T) {
 	content, err := json.Marshal([]Pick{
 		{Ticker: "AAPL", Action: "BUY", Reasoning: "ok"},
 		{Ticker: "MSFT", Action: "SELL", Reasoning: "ok"},
-		{Ticker: "NVDA", Action: "BUY", Reasoning: "ok"},
+		{Ticker: "NVDA", Action: "HOLD", Reasoning: "ok"},
 	})
 	if err != nil {
 		t.Fatalf("marshal picks: %v", err)
@@ -146,12 +171,12 @@ func TestGeneratePicksSuccess(t *testing.T) {
 		WithMaxAttempts(2),
 	)
 
-	picks, err := client.GeneratePicks(context.Background())
+	picks, err := client.GeneratePicks(context.Background(), PickRequest{})
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
-	if len(picks) != 3 {
-		t.Fatalf("expected 3 picks, got %d", len(picks))
+	if len(picks) != 3 || picks[2].Action != ActionHold {
+		t.Fatalf("expected 3 picks ending with HOLD, got %+v", picks)
 	}
 	if calls.Load() != 1 {
 		t.Fatalf("expected 1 attempt, got %d", calls.Load())
@@ -182,7 +207,7 @@ func TestGeneratePicksRetriesOnTransientStatus(t *testing.T) {
 		WithRetryConfig(retry.Config{MaxAttempts: 3, BaseDelay: 0, MaxDelay: 0, Jitter: 0}),
 	)
 
-	picks, err := client.GeneratePicks(context.Background())
+	picks, err := client.GeneratePicks(context.Background(), PickRequest{})
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -194,6 +219,97 @@ func TestGeneratePicksRetriesOnTransientStatus(t *testing.T) {
 	}
 }
 
+func TestGeneratePicksRequestsConfiguredLanguage(t *testing.T) {
+	english, err := json.Marshal([]Pick{
+		{Ticker: "AAPL", Action: "BUY", Reasoning: "Strong services growth"},
+		{Ticker: "MSFT", Action: "SELL", Reasoning: "Stretched valuation"},
+		{Ticker: "NVDA", Action: "BUY", Reasoning: "Datacenter demand"},
+	})
+	if err != nil {
+		t.Fatalf("marshal picks: %v", err)
+	}
+	japanese, err := json.Marshal([]Pick{
+		{Ticker: "AAPL", Action: "BUY", Reasoning: "サービス事業が好調"},
+		{Ticker: "MSFT", Action: "SELL", Reasoning: "割高な評価"},
+		{Ticker: "NVDA", Action: "BUY", Reasoning: "データセンター需要が強い"},
+	})
+	if err != nil {
+		t.Fatalf("marshal picks: %v", err)
+	}
+
+	var systemPrompt string
+	var calls atomic.Int32
+	responses := []string{wrapChatResponse(string(english)), wrapChatResponse(string(japanese))}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req chatRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err == nil && len(req.Messages) > 0 {
+			systemPrompt = req.Messages[0].Content
+		}
+		idx := int(calls.Add(1)) - 1
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(responses[idx]))
+	}))
+	defer server.Close()
+
+	lang, err := LookupLanguage("ja")
+	if err != nil {
+		t.Fatalf("lookup language: %v", err)
+	}
+	client := NewClient("test-key",
+		WithEndpoint(server.URL),
+		WithHTTPClient(server.Client()),
+		WithMaxAttempts(2),
+		WithLanguage(lang),
+	)
+
+	picks, err := client.GeneratePicks(context.Background(), PickRequest{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if calls.Load() != 2 {
+		t.Fatalf("expected English reasoning to be rejected and retried, got %d calls", calls.Load())
+	}
+	if picks[0].Reasoning != "サービス事業が好調" {
+		t.Fatalf("unexpected reasoning %q", picks[0].Reasoning)
+	}
+	if !strings.Contains(systemPrompt, "Write the reasoning in Japanese") {
+		t.Fatalf("expected language instruction in prompt, got %q", systemPrompt)
+	}
+}
+
+func TestProbe(t *testing.T) {
+	var path string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		if r.Header.Get("Authorization") != "Bearer test-key" {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"error":{"code":"invalid_api_key"}}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"id":"gpt-4o-mini","object":"model"}`))
+	}))
+	defer server.Close()
+
+	client := NewClient("test-key", WithEndpoint(server.URL+"/v1/chat/completions"), WithHTTPClient(server.Client()))
+	if err := client.Probe(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if path != "/v1/models/gpt-4o-mini" {
+		t.Fatalf("expected model lookup, got %s", path)
+	}
+
+	client = NewClient("bad-key", WithEndpoint(server.URL+"/v1/chat/completions"), WithHTTPClient(server.Client()))
+	if err := client.Probe(context.Background()); err == nil || !strings.Contains(err.Error(), "401") {
+		t.Fatalf("expected unauthorized error, got %v", err)
+	}
+}
+
+func TestLookupLanguageRejectsUnknown(t *testing.T) {
+	if _, err := LookupLanguage("xx"); err == nil {
+		t.Fatalf("expected error for unknown language")
+	}
+}
+
 func openAITestServer(responses []string) (*httptest.Server, *atomic.Int32) {
 	var calls atomic.Int32
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -239,3 +355,154 @@ func wrapChatResponse(content string) string {
 	data, _ := json.Marshal(resp)
 	return string(data)
 }
+
+func TestConfigHash(t *testing.T) {
+	base := NewClient("key-a")
+	if got := NewClient("key-b").ConfigHash(); got != base.ConfigHash() {
+		t.Fatalf("expected api key not to affect hash")
+	}
+	if len(base.ConfigHash()) != 64 {
+		t.Fatalf("expected hex sha256, got %q", base.ConfigHash())
+	}
+
+	polish, err := LookupLanguage("pl")
+	if err != nil {
+		t.Fatalf("lookup language: %v", err)
+	}
+	for name, other := range map[string]*Client{
+		"model":       NewClient("key-a", WithModel("gpt-4o")),
+		"temperature": NewClient("key-a", WithTemperature(0.7)),
+		"language":    NewClient("key-a", WithLanguage(polish)),
+	} {
+		if other.ConfigHash() == base.ConfigHash() {
+			t.Fatalf("expected %s to change hash", name)
+		}
+	}
+}
+
+func TestGeneratePicksExcludesTickers(t *testing.T) {
+	repeated, err := json.Marshal([]Pick{
+		{Ticker: "AAPL", Action: "BUY", Reasoning: "ok"},
+		{Ticker: "MSFT", Action: "SELL", Reasoning: "ok"},
+		{Ticker: "NVDA", Action: "BUY", Reasoning: "ok"},
+	})
+	if err != nil {
+		t.Fatalf("marshal picks: %v", err)
+	}
+	fresh, err := json.Marshal([]Pick{
+		{Ticker: "JNJ", Action: "BUY", Reasoning: "ok"},
+		{Ticker: "MSFT", Action: "SELL", Reasoning: "ok"},
+		{Ticker: "NVDA", Action: "BUY", Reasoning: "ok"},
+	})
+	if err != nil {
+		t.Fatalf("marshal picks: %v", err)
+	}
+
+	var systemPrompt string
+	var calls atomic.Int32
+	responses := []string{wrapChatResponse(string(repeated)), wrapChatResponse(string(fresh))}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req chatRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err == nil && len(req.Messages) > 0 {
+			systemPrompt = req.Messages[0].Content
+		}
+		idx := int(calls.Add(1)) - 1
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(responses[idx]))
+	}))
+	defer server.Close()
+
+	client := NewClient("test-key",
+		WithEndpoint(server.URL),
+		WithHTTPClient(server.Client()),
+		WithMaxAttempts(2),
+	)
+
+	picks, err := client.GeneratePicks(context.Background(), PickRequest{ExcludeTickers: []string{"AAPL", "TSLA"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if calls.Load() != 2 {
+		t.Fatalf("expected excluded ticker to be rejected and retried, got %d calls", calls.Load())
+	}
+	if picks[0].Ticker != "JNJ" {
+		t.Fatalf("unexpected picks %+v", picks)
+	}
+	if !strings.Contains(systemPrompt, "Do not pick any of these tickers: AAPL, TSLA.") {
+		t.Fatalf("expected exclusions in prompt, got %q", systemPrompt)
+	}
+}
+
+func TestGeneratePicksHonoursBlocklist(t *testing.T) {
+	blocked, err := json.Marshal([]Pick{
+		{Ticker: "AAPL", Action: "BUY", Reasoning: "ok"},
+		{Ticker: "MSFT", Action: "SELL", Reasoning: "ok"},
+		{Ticker: "NVDA", Action: "BUY", Reasoning: "ok"},
+	})
+	if err != nil {
+		t.Fatalf("marshal picks: %v", err)
+	}
+
+	server, calls := openAITestServer([]string{
+		wrapChatResponse(string(blocked)),
+		wrapChatResponse(string(blocked)),
+	})
+	defer server.Close()
+
+	blocklist, err := ParseTickerList(" nvda, TSLA,,nvda ")
+	if err != nil {
+		t.Fatalf("parse blocklist: %v", err)
+	}
+	if want := []string{"NVDA", "TSLA"}; !reflect.DeepEqual(blocklist, want) {
+		t.Fatalf("expected %v, got %v", want, blocklist)
+	}
+	client := NewClient("test-key",
+		WithEndpoint(server.URL),
+		WithHTTPClient(server.Client()),
+		WithMaxAttempts(2),
+		WithBlocklist(blocklist),
+	)
+
+	if _, err := client.GeneratePicks(context.Background(), PickRequest{}); !errors.Is(err, ErrInvalidOutput) {
+		t.Fatalf("expected blocklisted ticker to be rejected, got %v", err)
+	}
+	if calls.Load() != 2 {
+		t.Fatalf("expected 2 attempts, got %d", calls.Load())
+	}
+	if prompt := client.messages(PickRequest{})[0].Content; !strings.Contains(prompt, "Never pick these restricted tickers: NVDA, TSLA.") {
+		t.Fatalf("expected blocklist in prompt, got %q", prompt)
+	}
+	if client.ConfigHash() == NewClient("test-key").ConfigHash() {
+		t.Fatalf("expected blocklist to change config hash")
+	}
+
+	if _, err := ParseTickerList("AAPL, BRK.B"); err == nil {
+		t.Fatalf("expected error for malformed ticker")
+	}
+}
+
+func TestValidatePicksSectorDiversity(t *testing.T) {
+	lang, err := LookupLanguage(DefaultLanguage)
+	if err != nil {
+		t.Fatalf("lookup language: %v", err)
+	}
+	picks := []Pick{
+		{Ticker: "AAPL", Action: "BUY", Reasoning: "ok"},
+		{Ticker: "MSFT", Action: "BUY", Reasoning: "ok"},
+		{Ticker: "NEWCO", Action: "BUY", Reasoning: "ok"},
+	}
+	sectors := map[string]string{"AAPL": "Technology", "MSFT": "Technology", "XOM": "Energy"}
+
+	if err := validatePicks(picks, pickRules{lang: lang, sectors: sectors, minSectors: 2}); !errors.Is(err, ErrInvalidOutput) {
+		t.Fatalf("expected concentrated picks to be rejected, got %v", err)
+	}
+	picks[1].Ticker = "XOM"
+	if err := validatePicks(picks, pickRules{lang: lang, sectors: sectors, minSectors: 2}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	client := NewClient("test-key", WithMinSectors(2))
+	if prompt := client.messages(PickRequest{})[0].Content; !strings.Contains(prompt, "at least 2 different sectors") {
+		t.Fatalf("expected sector instruction in prompt, got %q", prompt)
+	}
+}
@@ -0,0 +1,80 @@
+package openai
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// DefaultLanguage is the reasoning language used when none is configured.
+const DefaultLanguage = "en"
+
+// minScriptShare is the fraction of letters in a reasoning text that must
+// belong to the language's script. It leaves room for tickers and company
+// names, which are usually written in Latin script.
+const minScriptShare = 0.5
+
+// Language describes a supported reasoning language.
+type Language struct {
+	Code    string
+	Name    string
+	scripts []*unicode.RangeTable
+}
+
+var languages = map[string]Language{
+	"en": {Code: "en", Name: "English", scripts: []*unicode.RangeTable{unicode.Latin}},
+	"de": {Code: "de", Name: "German", scripts: []*unicode.RangeTable{unicode.Latin}},
+	"es": {Code: "es", Name: "Spanish", scripts: []*unicode.RangeTable{unicode.Latin}},
+	"fr": {Code: "fr", Name: "French", scripts: []*unicode.RangeTable{unicode.Latin}},
+	"it": {Code: "it", Name: "Italian", scripts: []*unicode.RangeTable{unicode.Latin}},
+	"pl": {Code: "pl", Name: "Polish", scripts: []*unicode.RangeTable{unicode.Latin}},
+	"pt": {Code: "pt", Name: "Portuguese", scripts: []*unicode.RangeTable{unicode.Latin}},
+	"ru": {Code: "ru", Name: "Russian", scripts: []*unicode.RangeTable{unicode.Cyrillic}},
+	"uk": {Code: "uk", Name: "Ukrainian", scripts: []*unicode.RangeTable{unicode.Cyrillic}},
+	"el": {Code: "el", Name: "Greek", scripts: []*unicode.RangeTable{unicode.Greek}},
+	"ar": {Code: "ar", Name: "Arabic", scripts: []*unicode.RangeTable{unicode.Arabic}},
+	"he": {Code: "he", Name: "Hebrew", scripts: []*unicode.RangeTable{unicode.Hebrew}},
+	"hi": {Code: "hi", Name: "Hindi", scripts: []*unicode.RangeTable{unicode.Devanagari}},
+	"ja": {Code: "ja", Name: "Japanese", scripts: []*unicode.RangeTable{unicode.Hiragana, unicode.Katakana, unicode.Han}},
+	"ko": {Code: "ko", Name: "Korean", scripts: []*unicode.RangeTable{unicode.Hangul}},
+	"zh": {Code: "zh", Name: "Chinese", scripts: []*unicode.RangeTable{unicode.Han}},
+}
+
+// LookupLanguage returns the language for an ISO 639-1 code such as "pl".
+func LookupLanguage(code string) (Language, error) {
+	lang, ok := languages[strings.ToLower(strings.TrimSpace(code))]
+	if !ok {
+		return Language{}, fmt.Errorf("unsupported language %q (supported: %s)", code, strings.Join(SupportedLanguages(), ", "))
+	}
+	return lang, nil
+}
+
+// SupportedLanguages lists the supported language codes in sorted order.
+func SupportedLanguages() []string {
+	codes := make([]string, 0, len(languages))
+	for code := range languages {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	return codes
+}
+
+// matchesScript reports whether most letters in text are written in the
+// language's script.
+func (l Language) matchesScript(text string) bool {
+	var letters, matching int
+	for _, r := range text {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		letters++
+		if unicode.IsOneOf(l.scripts, r) {
+			matching++
+		}
+	}
+	if letters == 0 {
+		return false
+	}
+	return float64(matching)/float64(letters) >= minScriptShare
+}
@@ -2,16 +2,52 @@ package retry
 
 import (
 	"context"
-	"math/rand"
+	"errors"
+	"fmt"
+	"math/rand/v2"
 	"time"
 )
 
+// ErrAttemptTimeout wraps the error of an attempt cut off by
+// Config.AttemptTimeout. Such attempts are always retried.
+var ErrAttemptTimeout = errors.New("attempt timed out")
+
 // Config defines retry behavior for transient failures.
 type Config struct {
 	MaxAttempts int
 	BaseDelay   time.Duration
 	MaxDelay    time.Duration
 	Jitter      float64
+	// AttemptTimeout bounds each attempt with its own deadline, so a hanging call is retried instead of using up the caller's
+	// whole budget. Zero leaves attempts bounded only by the caller's context.
+	AttemptTimeout time.Duration
+	// Rand returns the jitter fraction in [0, 1). Nil uses the shared
+	// math/rand source.
+	Rand func() float64
+	// Clock waits out the delay between attempts. Nil uses real timers.
+	Clock Clock
+}
+
+// Clock waits between attempts. Tests inject one to observe delays without
+// sleeping.
+type Clock interface {
+	// Sleep blocks for delay or until ctx is done, returning ctx's error in
+	// the latter case.
+	Sleep(ctx context.Context, delay time.Duration) error
+}
+
+type realClock struct{}
+
+func (realClock) Sleep(ctx context.Context, delay time.Duration) error {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
 }
 
 // DefaultConfig returns the default retry policy (3 attempts, exponential backoff, jitter).
@@ -24,8 +60,9 @@ func DefaultConfig() Config {
 	}
 }
 
-// Do executes fn with retries when shouldRetry returns true.
-func Do(ctx context.Context, cfg Config, shouldRetry func(error) bool, fn func() error) error {
+// Do executes fn with retries when shouldRetry returns true. fn receives the
+// attempt's context, which carries the AttemptTimeout deadline.
+func Do(ctx context.Context, cfg Config, shouldRetry func(error) bool, fn func(context.Context) error) error {
 	if fn == nil {
 		return nil
 	}
@@ -36,19 +73,23 @@ func Do(ctx context.Context, cfg Config, shouldRetry func(error) bool, fn func()
 	if shouldRetry == nil {
 		shouldRetry = func(error) bool { return false }
 	}
+	clock := cfg.Clock
+	if clock == nil {
+		clock = realClock{}
+	}
 
 	var lastErr error
 	for attempt := 1; attempt <= maxAttempts; attempt++ {
 		if err := ctx.Err(); err != nil {
 			return err
 		}
-		if err := fn(); err != nil {
+		if err := runAttempt(ctx, cfg.AttemptTimeout, fn); err != nil {
 			lastErr = err
-			if attempt == maxAttempts || !shouldRetry(err) {
+			if attempt == maxAttempts || !(errors.Is(err, ErrAttemptTimeout) || shouldRetry(err)) {
 				return err
 			}
 			if delay := nextDelay(cfg, attempt); delay > 0 {
-				if err := sleep(ctx, delay); err != nil {
+				if err := clock.Sleep(ctx, delay); err != nil {
 					return err
 				}
 			}
@@ -60,6 +101,21 @@ func Do(ctx context.Context, cfg Config, shouldRetry func(error) bool, fn func()
 	return lastErr
 }
 
+// runAttempt calls fn under its own deadline when timeout is set. An error
+// caused by that deadline, rather than by ctx, is marked ErrAttemptTimeout.
+func runAttempt(ctx context.Context, timeout time.Duration, fn func(context.Context) error) error {
+	if timeout <= 0 {
+		return fn(ctx)
+	}
+	attemptCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	err := fn(attemptCtx)
+	if err != nil && ctx.Err() == nil && errors.Is(attemptCtx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("%w after %s: %w", ErrAttemptTimeout, timeout, err)
+	}
+	return err
+}
+
 func nextDelay(cfg Config, attempt int) time.Duration {
 	if cfg.BaseDelay <= 0 {
 		return 0
@@ -72,24 +128,14 @@ func nextDelay(cfg Config, attempt int) time.Duration {
 		delay = cfg.MaxDelay
 	}
 	if cfg.Jitter > 0 && delay > 0 {
-		rng := rand.New(rand.NewSource(time.Now().UnixNano()))
-		jitter := time.Duration(rng.Float64() * cfg.Jitter * float64(delay))
-		delay += jitter
+		random := cfg.Rand
+		if random == nil {
+			random = rand.Float64
+		}
+		delay += time.Duration(random() * cfg.Jitter * float64(delay))
 	}
 	if cfg.MaxDelay > 0 && delay > cfg.MaxDelay {
 		delay = cfg.MaxDelay
 	}
 	return delay
 }
-
-func sleep(ctx context.Context, delay time.Duration) error {
-	timer := time.NewTimer(delay)
-	defer timer.Stop()
-
-	select {
-	case <-ctx.Done():
-		return ctx.Err()
-	case <-timer.C:
-		return nil
-	}
-}
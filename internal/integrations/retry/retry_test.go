@@ -0,0 +1,88 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestDoRetriesTimedOutAttempt(t *testing.T) {
+	cfg := Config{MaxAttempts: 3, AttemptTimeout: 20 * time.Millisecond}
+	attempts := 0
+	err := Do(context.Background(), cfg, nil, func(ctx context.Context) error {
+		attempts++
+		if attempts == 1 {
+			<-ctx.Done()
+			return ctx.Err()
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if attempts != 2 {
+		t.Fatalf("expected a retry after the hung attempt, got %d attempts", attempts)
+	}
+}
+
+func TestDoReportsAttemptTimeout(t *testing.T) {
+	cfg := Config{MaxAttempts: 2, AttemptTimeout: 10 * time.Millisecond}
+	err := Do(context.Background(), cfg, nil, func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	if !errors.Is(err, ErrAttemptTimeout) || !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected attempt timeout, got %v", err)
+	}
+
+	// A deadline on the caller's context is not an attempt timeout.
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	attempts := 0
+	err = Do(ctx, Config{MaxAttempts: 3, AttemptTimeout: time.Second}, nil, func(ctx context.Context) error {
+		attempts++
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	if errors.Is(err, ErrAttemptTimeout) || !errors.Is(err, context.DeadlineExceeded) || attempts != 1 {
+		t.Fatalf("expected the caller's deadline after one attempt, got %v after %d", err, attempts)
+	}
+}
+
+type recordingClock struct {
+	delays []time.Duration
+}
+
+func (c *recordingClock) Sleep(ctx context.Context, delay time.Duration) error {
+	c.delays = append(c.delays, delay)
+	return ctx.Err()
+}
+
+func TestDoUsesInjectedClockAndRand(t *testing.T) {
+	clock := &recordingClock{}
+	cfg := Config{
+		MaxAttempts: 4,
+		BaseDelay:   100 * time.Millisecond,
+		MaxDelay:    350 * time.Millisecond,
+		Jitter:      0.5,
+		Rand:        func() float64 { return 0.5 },
+		Clock:       clock,
+	}
+	failure := errors.New("transient")
+	err := Do(context.Background(), cfg, func(error) bool { return true }, func(context.Context) error { return failure })
+	if !errors.Is(err, failure) {
+		t.Fatalf("expected last error, got %v", err)
+	}
+
+	// 100ms and 200ms plus 25% jitter, then 400ms capped at MaxDelay.
+	want := []time.Duration{125 * time.Millisecond, 250 * time.Millisecond, 350 * time.Millisecond}
+	if len(clock.delays) != len(want) {
+		t.Fatalf("expected delays %v, got %v", want, clock.delays)
+	}
+	for i := range want {
+		if clock.delays[i] != want[i] {
+			t.Fatalf("expected delays %v, got %v", want, clock.delays)
+		}
+	}
+}
@@ -0,0 +1,228 @@
+// Package vcr records HTTP interactions of the integration clients to fixture
+// files and replays them, so the worker can run end to end without network
+// access or API keys.
+package vcr
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+type Mode string
+
+const (
+	ModeOff    Mode = ""
+	ModeRecord Mode = "record"
+	ModeReplay Mode = "replay"
+)
+
+// redactedParams are query parameters removed before a request is stored or
+// matched, so fixtures never contain credentials and replay with any key.
+var redactedParams = []string{"apikey", "api_key"}
+
+var unsafeName = regexp.MustCompile(`[^a-zA-Z0-9]+`)
+
+func ParseMode(value string) (Mode, error) {
+	switch mode := Mode(strings.ToLower(strings.TrimSpace(value))); mode {
+	case ModeOff, ModeRecord, ModeReplay:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("unknown vcr mode %q (want record or replay)", value)
+	}
+}
+
+// Cassette is the fixture file for one request: every recorded response to
+// it, in order.
+type Cassette struct {
+	Request      Request       `json:"request"`
+	Interactions []Interaction `json:"interactions"`
+}
+
+type Request struct {
+	Method string `json:"method"`
+	URL    string `json:"url"`
+	Body   string `json:"body,omitempty"`
+}
+
+type Interaction struct {
+	Status      int    `json:"status"`
+	ContentType string `json:"content_type,omitempty"`
+	Body        string `json:"body"`
+}
+
+// Transport is an http.RoundTripper that records to or replays from dir. In
+// record mode requests go to next and each response is appended to the
+// request's cassette. In replay mode responses are served from cassettes in
+// recorded order, repeating the last one once exhausted; a request without a
+// cassette fails.
+type Transport struct {
+	mode Mode
+	dir  string
+	next http.RoundTripper
+
+	mu     sync.Mutex
+	played map[string]int
+}
+
+func NewTransport(mode Mode, dir string, next http.RoundTripper) (*Transport, error) {
+	if mode != ModeRecord && mode != ModeReplay {
+		return nil, fmt.Errorf("vcr transport requires record or replay mode")
+	}
+	if strings.TrimSpace(dir) == "" {
+		return nil, fmt.Errorf("vcr fixture directory is required")
+	}
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	if mode == ModeRecord {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return nil, fmt.Errorf("create fixture directory: %w", err)
+		}
+	}
+	return &Transport{mode: mode, dir: dir, next: next, played: map[string]int{}}, nil
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	stored, err := storedRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	path := t.fixturePath(stored)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.mode == ModeReplay {
+		return t.replay(req, stored, path)
+	}
+	return t.record(req, stored, path)
+}
+
+func (t *Transport) replay(req *http.Request, stored Request, path string) (*http.Response, error) {
+	cassette, err := readCassette(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("vcr: no fixture for %s %s", stored.Method, stored.URL)
+		}
+		return nil, err
+	}
+	if len(cassette.Interactions) == 0 {
+		return nil, fmt.Errorf("vcr: empty fixture %s", path)
+	}
+	index := min(t.played[path], len(cassette.Interactions)-1)
+	t.played[path]++
+	return interactionResponse(req, cassette.Interactions[index]), nil
+}
+
+func (t *Transport) record(req *http.Request, stored Request, path string) (*http.Response, error) {
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("vcr: read response: %w", err)
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	cassette, err := readCassette(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	cassette.Request = stored
+	cassette.Interactions = append(cassette.Interactions, Interaction{
+		Status:      resp.StatusCode,
+		ContentType: resp.Header.Get("Content-Type"),
+		Body:        string(body),
+	})
+	encoded, err := json.MarshalIndent(cassette, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(path, append(encoded, '\n'), 0o644); err != nil {
+		return nil, fmt.Errorf("vcr: write fixture: %w", err)
+	}
+	return resp, nil
+}
+
+// fixturePath names the cassette after the host and path for readability plus
+// a hash of the redacted request for uniqueness.
+func (t *Transport) fixturePath(stored Request) string {
+	sum := sha256.Sum256([]byte(stored.Method + "\n" + stored.URL + "\n" + stored.Body))
+	name := strings.ToLower(strings.Trim(unsafeName.ReplaceAllString(stored.Method+"_"+hostPath(stored.URL), "_"), "_"))
+	return filepath.Join(t.dir, name+"-"+hex.EncodeToString(sum[:6])+".json")
+}
+
+func storedRequest(req *http.Request) (Request, error) {
+	u := *req.URL
+	query := u.Query()
+	for _, param := range redactedParams {
+		query.Del(param)
+	}
+	u.RawQuery = query.Encode()
+
+	stored := Request{Method: req.Method, URL: u.String()}
+	if req.Body != nil && req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return Request{}, fmt.Errorf("vcr: read request body: %w", err)
+		}
+		defer body.Close()
+		encoded, err := io.ReadAll(body)
+		if err != nil {
+			return Request{}, fmt.Errorf("vcr: read request body: %w", err)
+		}
+		stored.Body = string(encoded)
+	}
+	return stored, nil
+}
+
+func hostPath(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+	return u.Host + u.Path
+}
+
+func readCassette(path string) (Cassette, error) {
+	var cassette Cassette
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return cassette, err
+	}
+	if err := json.Unmarshal(data, &cassette); err != nil {
+		return cassette, fmt.Errorf("vcr: decode fixture %s: %w", path, err)
+	}
+	return cassette, nil
+}
+
+func interactionResponse(req *http.Request, interaction Interaction) *http.Response {
+	header := http.Header{}
+	if interaction.ContentType != "" {
+		header.Set("Content-Type", interaction.ContentType)
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", interaction.Status, http.StatusText(interaction.Status)),
+		StatusCode:    interaction.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(strings.NewReader(interaction.Body)),
+		ContentLength: int64(len(interaction.Body)),
+		Request:       req,
+	}
+}
@@ -0,0 +1,112 @@
+package vcr
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestTransportRecordsThenReplaysOffline(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := calls.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		if n == 1 {
+			w.WriteHeader(http.StatusTooManyRequests)
+			_, _ = w.Write([]byte(`{"error":"slow down"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"price":"123.45"}`))
+	}))
+
+	dir := t.TempDir()
+	recorder, err := NewTransport(ModeRecord, dir, server.Client().Transport)
+	if err != nil {
+		t.Fatalf("new recorder: %v", err)
+	}
+	target := server.URL + "/query?function=GLOBAL_QUOTE&symbol=SPY&apikey=secret-key"
+	for i := 0; i < 2; i++ {
+		status, _ := doGet(t, recorder, target)
+		if i == 0 && status != http.StatusTooManyRequests {
+			t.Fatalf("expected recorded 429, got %d", status)
+		}
+	}
+	server.Close()
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil || len(files) != 1 {
+		t.Fatalf("expected one fixture, got %v (%v)", files, err)
+	}
+	data, err := os.ReadFile(files[0])
+	if err != nil {
+		t.Fatalf("read fixture: %v", err)
+	}
+	if strings.Contains(string(data), "secret-key") {
+		t.Fatalf("fixture leaks api key: %s", data)
+	}
+
+	player, err := NewTransport(ModeReplay, dir, nil)
+	if err != nil {
+		t.Fatalf("new player: %v", err)
+	}
+	other := strings.Replace(target, "secret-key", "other-key", 1)
+	expected := []struct {
+		status int
+		body   string
+	}{
+		{http.StatusTooManyRequests, `{"error":"slow down"}`},
+		{http.StatusOK, `{"price":"123.45"}`},
+		{http.StatusOK, `{"price":"123.45"}`},
+	}
+	for i, want := range expected {
+		status, body := doGet(t, player, other)
+		if status != want.status || body != want.body {
+			t.Fatalf("replay %d: expected %d %s, got %d %s", i, want.status, want.body, status, body)
+		}
+	}
+	if calls.Load() != 2 {
+		t.Fatalf("expected replay to stay offline, server saw %d calls", calls.Load())
+	}
+}
+
+func TestTransportReplayMissFails(t *testing.T) {
+	player, err := NewTransport(ModeReplay, t.TempDir(), nil)
+	if err != nil {
+		t.Fatalf("new player: %v", err)
+	}
+	req, _ := http.NewRequest(http.MethodPost, "https://api.openai.com/v1/chat/completions", strings.NewReader(`{}`))
+	if _, err := player.RoundTrip(req); err == nil || !strings.Contains(err.Error(), "no fixture") {
+		t.Fatalf("expected missing fixture error, got %v", err)
+	}
+}
+
+func TestParseMode(t *testing.T) {
+	for value, want := range map[string]Mode{"": ModeOff, " Record ": ModeRecord, "replay": ModeReplay} {
+		got, err := ParseMode(value)
+		if err != nil || got != want {
+			t.Fatalf("ParseMode(%q) = %q, %v; want %q", value, got, err, want)
+		}
+	}
+	if _, err := ParseMode("rewind"); err == nil {
+		t.Fatalf("expected error for unknown mode")
+	}
+}
+
+func doGet(t *testing.T, transport http.RoundTripper, target string) (int, string) {
+	t.Helper()
+	resp, err := (&http.Client{Transport: transport}).Get(target)
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("read body: %v", err)
+	}
+	return resp.StatusCode, string(body)
+}
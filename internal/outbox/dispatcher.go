@@ -0,0 +1,224 @@
+package outbox
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/igor-kupczynski/alpha-monday/internal/db"
+)
+
+const (
+	defaultPollInterval = 10 * time.Second
+	defaultBatchSize    = 20
+	defaultMaxAttempts  = 10
+	defaultLease        = 2 * time.Minute
+	defaultBaseBackoff  = 30 * time.Second
+	defaultMaxBackoff   = time.Hour
+)
+
+// Store is the outbox subset of db.Store used by the dispatcher.
+type Store interface {
+	ClaimOutboxEvents(ctx context.Context, limit int, lease time.Duration) ([]db.OutboxEvent, error)
+	MarkOutboxDelivered(ctx context.Context, id string) error
+	MarkOutboxFailed(ctx context.Context, id string, lastError string, nextAttemptAt *time.Time, delivered []string) error
+}
+
+// Sink delivers a single event to an external destination.
+type Sink interface {
+	Name() string
+	Send(ctx context.Context, event db.OutboxEvent) error
+}
+
+// FanoutSink is a Sink that delivers to several destinations on its own, such
+// as one per subscriber. The dispatcher delivers to each target separately, so
+// a failing target does not re-send the event to the others.
+type FanoutSink interface {
+	Sink
+	Targets(ctx context.Context, event db.OutboxEvent) ([]Target, error)
+}
+
+// Target is one destination of an event. Name identifies it across attempts
+// and must be stable, e.g. "subscription:<id>".
+type Target struct {
+	Name string
+	Send func(ctx context.Context) error
+}
+
+type Config struct {
+	PollInterval time.Duration
+	BatchSize    int
+	MaxAttempts  int
+	Lease        time.Duration
+	BaseBackoff  time.Duration
+	MaxBackoff   time.Duration
+}
+
+// DefaultConfig polls every 10s and gives up on an event after 10 attempts,
+// backing off exponentially from 30s up to 1h between attempts.
+func DefaultConfig() Config {
+	return Config{
+		PollInterval: defaultPollInterval,
+		BatchSize:    defaultBatchSize,
+		MaxAttempts:  defaultMaxAttempts,
+		Lease:        defaultLease,
+		BaseBackoff:  defaultBaseBackoff,
+		MaxBackoff:   defaultMaxBackoff,
+	}
+}
+
+// Dispatcher polls the outbox and delivers each event to every sink target.
+// Delivery is at-least-once: when some targets fail, the ones that accepted the
+// event are recorded and the retry goes only to the failed ones, but a target
+// may still see an event twice if the worker stops between sending and
+// recording.
+type Dispatcher struct {
+	store  Store
+	sinks  []Sink
+	config Config
+	logger *slog.Logger
+	now    func() time.Time
+}
+
+func NewDispatcher(store Store, sinks []Sink, config Config, logger *slog.Logger) *Dispatcher {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	defaults := DefaultConfig()
+	if config.PollInterval <= 0 {
+		config.PollInterval = defaults.PollInterval
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = defaults.BatchSize
+	}
+	if config.MaxAttempts <= 0 {
+		config.MaxAttempts = defaults.MaxAttempts
+	}
+	if config.Lease <= 0 {
+		config.Lease = defaults.Lease
+	}
+	if config.BaseBackoff <= 0 {
+		config.BaseBackoff = defaults.BaseBackoff
+	}
+	if config.MaxBackoff <= 0 {
+		config.MaxBackoff = defaults.MaxBackoff
+	}
+	return &Dispatcher{
+		store:  store,
+		sinks:  sinks,
+		config: config,
+		logger: logger,
+		now:    time.Now,
+	}
+}
+
+// Run polls until ctx is cancelled.
+func (d *Dispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.config.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := d.DispatchOnce(ctx); err != nil && ctx.Err() == nil {
+			d.logger.Error("outbox dispatch failed", "error", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// DispatchOnce claims one batch of due events and delivers them. It returns the
+// number of events claimed.
+func (d *Dispatcher) DispatchOnce(ctx context.Context) (int, error) {
+	events, err := d.store.ClaimOutboxEvents(ctx, d.config.BatchSize, d.config.Lease)
+	if err != nil {
+		return 0, fmt.Errorf("claim outbox events: %w", err)
+	}
+
+	for _, event := range events {
+		if err := d.deliver(ctx, event); err != nil {
+			return len(events), err
+		}
+	}
+	return len(events), nil
+}
+
+func (d *Dispatcher) deliver(ctx context.Context, event db.OutboxEvent) error {
+	done := make(map[string]bool, len(event.DeliveredTargets))
+	for _, target := range event.DeliveredTargets {
+		done[target] = true
+	}
+
+	var failures, delivered []string
+	for _, sink := range d.sinks {
+		targets, err := sinkTargets(ctx, sink, event)
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %v", sink.Name(), err))
+			continue
+		}
+		for _, target := range targets {
+			if done[target.Name] {
+				continue
+			}
+			if err := target.Send(ctx); err != nil {
+				failures = append(failures, fmt.Sprintf("%s: %v", target.Name, err))
+				continue
+			}
+			delivered = append(delivered, target.Name)
+		}
+	}
+
+	if len(failures) == 0 {
+		if err := d.store.MarkOutboxDelivered(ctx, event.ID); err != nil {
+			return fmt.Errorf("mark outbox event delivered: %w", err)
+		}
+		d.logger.Info("outbox event delivered", "event_id", event.ID, "event_type", event.EventType, "attempts", event.Attempts)
+		return nil
+	}
+
+	lastError := strings.Join(failures, "; ")
+	var nextAttemptAt *time.Time
+	if event.Attempts < d.config.MaxAttempts {
+		next := d.now().Add(d.backoff(event.Attempts))
+		nextAttemptAt = &next
+	}
+	if err := d.store.MarkOutboxFailed(ctx, event.ID, lastError, nextAttemptAt, delivered); err != nil {
+		return fmt.Errorf("mark outbox event failed: %w", err)
+	}
+
+	if nextAttemptAt == nil {
+		d.logger.Error("outbox event abandoned", "event_id", event.ID, "event_type", event.EventType, "attempts", event.Attempts, "error", lastError)
+		return nil
+	}
+	d.logger.Warn("outbox event delivery failed", "event_id", event.ID, "event_type", event.EventType, "attempts", event.Attempts, "next_attempt_at", *nextAttemptAt, "error", lastError)
+	return nil
+}
+
+// sinkTargets returns a fan-out sink's targets, or the sink itself as a single
+// target named after it.
+func sinkTargets(ctx context.Context, sink Sink, event db.OutboxEvent) ([]Target, error) {
+	if fanout, ok := sink.(FanoutSink); ok {
+		return fanout.Targets(ctx, event)
+	}
+	return []Target{{Name: sink.Name(), Send: func(ctx context.Context) error {
+		return sink.Send(ctx, event)
+	}}}, nil
+}
+
+func (d *Dispatcher) backoff(attempts int) time.Duration {
+	if attempts < 1 {
+		attempts = 1
+	}
+	delay := d.config.BaseBackoff
+	for i := 1; i < attempts; i++ {
+		delay *= 2
+		if delay >= d.config.MaxBackoff {
+			return d.config.MaxBackoff
+		}
+	}
+	return delay
+}
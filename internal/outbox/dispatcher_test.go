@@ -0,0 +1,342 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/smtp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/igor-kupczynski/alpha-monday/internal/db"
+	"github.com/igor-kupczynski/alpha-monday/internal/decimal"
+)
+
+type fakeStore struct {
+	events    []db.OutboxEvent
+	delivered []string
+	failed    map[string]*time.Time
+	lastError map[string]string
+	targets   map[string][]string
+}
+
+func (f *fakeStore) ClaimOutboxEvents(ctx context.Context, limit int, lease time.Duration) ([]db.OutboxEvent, error) {
+	events := f.events
+	f.events = nil
+	return events, nil
+}
+
+func (f *fakeStore) MarkOutboxDelivered(ctx context.Context, id string) error {
+	f.delivered = append(f.delivered, id)
+	return nil
+}
+
+func (f *fakeStore) MarkOutboxFailed(ctx context.Context, id string, lastError string, nextAttemptAt *time.Time, delivered []string) error {
+	if f.failed == nil {
+		f.failed = map[string]*time.Time{}
+		f.lastError = map[string]string{}
+		f.targets = map[string][]string{}
+	}
+	f.failed[id] = nextAttemptAt
+	f.lastError[id] = lastError
+	f.targets[id] = append(f.targets[id], delivered...)
+	return nil
+}
+
+type fakeSink struct {
+	name string
+	err  error
+	sent []string
+}
+
+func (f *fakeSink) Name() string {
+	return f.name
+}
+
+func (f *fakeSink) Send(ctx context.Context, event db.OutboxEvent) error {
+	f.sent = append(f.sent, event.ID)
+	return f.err
+}
+
+func TestDispatchOnceMarksDelivered(t *testing.T) {
+	store := &fakeStore{events: []db.OutboxEvent{{ID: "e1", EventType: db.EventBatchCreated, Attempts: 1}}}
+	sink := &fakeSink{name: "ok"}
+	dispatcher := NewDispatcher(store, []Sink{sink}, Config{}, testLogger())
+
+	claimed, err := dispatcher.DispatchOnce(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if claimed != 1 {
+		t.Fatalf("expected 1 claimed event, got %d", claimed)
+	}
+	if len(sink.sent) != 1 || len(store.delivered) != 1 || store.delivered[0] != "e1" {
+		t.Fatalf("expected event delivered, sent=%v delivered=%v", sink.sent, store.delivered)
+	}
+}
+
+func TestDispatchOnceSchedulesRetryWithBackoff(t *testing.T) {
+	store := &fakeStore{events: []db.OutboxEvent{{ID: "e1", EventType: db.EventBatchCreated, Attempts: 3}}}
+	healthy := &fakeSink{name: "ok"}
+	broken := &fakeSink{name: "broken", err: errors.New("boom")}
+	dispatcher := NewDispatcher(store, []Sink{healthy, broken}, Config{BaseBackoff: time.Second, MaxBackoff: time.Minute}, testLogger())
+	now := time.Date(2026, 2, 2, 9, 0, 0, 0, time.UTC)
+	dispatcher.now = func() time.Time { return now }
+
+	if _, err := dispatcher.DispatchOnce(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(store.delivered) != 0 {
+		t.Fatalf("expected no delivered events, got %v", store.delivered)
+	}
+	next, ok := store.failed["e1"]
+	if !ok || next == nil {
+		t.Fatalf("expected retry to be scheduled")
+	}
+	if want := now.Add(4 * time.Second); !next.Equal(want) {
+		t.Fatalf("expected next attempt at %v, got %v", want, *next)
+	}
+	if !strings.Contains(store.lastError["e1"], "broken: boom") {
+		t.Fatalf("expected sink error recorded, got %q", store.lastError["e1"])
+	}
+	if got := store.targets["e1"]; len(got) != 1 || got[0] != "ok" {
+		t.Fatalf("expected the healthy sink recorded as delivered, got %v", got)
+	}
+}
+
+func TestDispatchOnceRetriesOnlyFailedTargets(t *testing.T) {
+	var okHits, failingHits int
+	ok := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		okHits++
+	}))
+	defer ok.Close()
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		failingHits++
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer failing.Close()
+
+	subscriptions := NewSubscriptionSink(&fakeSubscriptionStore{subscriptions: []db.WebhookSubscription{
+		{ID: "s1", URL: ok.URL, Secret: "amwh_secret"},
+		{ID: "s2", URL: failing.URL, Secret: "amwh_other"},
+	}}, ok.Client())
+	slack := &fakeSink{name: "slack"}
+	event := db.OutboxEvent{ID: "e1", EventType: db.EventBatchCreated, Attempts: 1, Payload: json.RawMessage(`{"batch_id":"b1"}`)}
+	store := &fakeStore{events: []db.OutboxEvent{event}}
+	dispatcher := NewDispatcher(store, []Sink{subscriptions, slack}, Config{}, testLogger())
+
+	if _, err := dispatcher.DispatchOnce(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	delivered := store.targets["e1"]
+	if len(delivered) != 2 || delivered[0] != "subscription:s1" || delivered[1] != "slack" {
+		t.Fatalf("expected subscription s1 and slack recorded, got %v", delivered)
+	}
+	if !strings.Contains(store.lastError["e1"], "subscription:s2") {
+		t.Fatalf("expected the failing subscription in the error, got %q", store.lastError["e1"])
+	}
+
+	// The retry carries the recorded targets and only reaches s2.
+	event.Attempts = 2
+	event.DeliveredTargets = delivered
+	store.events = []db.OutboxEvent{event}
+	if _, err := dispatcher.DispatchOnce(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if okHits != 1 || len(slack.sent) != 1 || failingHits != 2 {
+		t.Fatalf("expected only s2 retried, got ok=%d slack=%d failing=%d", okHits, len(slack.sent), failingHits)
+	}
+}
+
+func TestDispatchOnceAbandonsAfterMaxAttempts(t *testing.T) {
+	store := &fakeStore{events: []db.OutboxEvent{{ID: "e1", EventType: db.EventBatchCreated, Attempts: 5}}}
+	broken := &fakeSink{name: "broken", err: errors.New("boom")}
+	dispatcher := NewDispatcher(store, []Sink{broken}, Config{MaxAttempts: 5}, testLogger())
+
+	if _, err := dispatcher.DispatchOnce(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	next, ok := store.failed["e1"]
+	if !ok {
+		t.Fatalf("expected failure to be recorded")
+	}
+	if next != nil {
+		t.Fatalf("expected event to be abandoned, got retry at %v", *next)
+	}
+}
+
+func TestWebhookSinkPostsEnvelope(t *testing.T) {
+	var got Envelope
+	var eventHeader string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		eventHeader = r.Header.Get("X-Alpha-Monday-Event")
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("decode body: %v", err)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	sink := NewWebhookSink(server.URL, server.Client())
+	event := db.OutboxEvent{ID: "e1", EventType: db.EventCheckpointComputed, Payload: json.RawMessage(`{"batch_id":"b1"}`)}
+	if err := sink.Send(context.Background(), event); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if eventHeader != db.EventCheckpointComputed {
+		t.Fatalf("expected event header, got %q", eventHeader)
+	}
+	if got.ID != "e1" || string(got.Payload) != `{"batch_id":"b1"}` {
+		t.Fatalf("unexpected envelope: %+v", got)
+	}
+}
+
+type fakeSubscriptionStore struct {
+	subscriptions []db.WebhookSubscription
+}
+
+func (f *fakeSubscriptionStore) WebhookSubscriptionsFor(ctx context.Context, eventType string) ([]db.WebhookSubscription, error) {
+	return f.subscriptions, nil
+}
+
+func TestSubscriptionSinkSignsDeliveries(t *testing.T) {
+	var signature, timestamp string
+	var body []byte
+	ok := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		signature = r.Header.Get("X-Alpha-Monday-Signature")
+		timestamp = r.Header.Get("X-Alpha-Monday-Timestamp")
+		body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer ok.Close()
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer failing.Close()
+
+	store := &fakeSubscriptionStore{subscriptions: []db.WebhookSubscription{
+		{ID: "s1", URL: ok.URL, Secret: "amwh_secret"},
+		{ID: "s2", URL: failing.URL, Secret: "amwh_other"},
+	}}
+	sink := NewSubscriptionSink(store, ok.Client())
+	sink.now = func() time.Time { return time.Unix(1767225600, 0) }
+
+	event := db.OutboxEvent{ID: "e1", EventType: db.EventBatchCreated, Payload: json.RawMessage(`{"batch_id":"b1"}`)}
+	err := sink.Send(context.Background(), event)
+	if err == nil || !strings.Contains(err.Error(), "subscription:s2") {
+		t.Fatalf("expected failure naming subscription s2, got %v", err)
+	}
+	if timestamp != "1767225600" {
+		t.Fatalf("unexpected timestamp %q", timestamp)
+	}
+	if want := Sign("amwh_secret", timestamp, body); signature != want || !strings.HasPrefix(signature, "sha256=") {
+		t.Fatalf("expected signature %q, got %q", want, signature)
+	}
+	if Sign("amwh_other", timestamp, body) == signature {
+		t.Fatalf("expected signatures to depend on the secret")
+	}
+
+	store.subscriptions = store.subscriptions[:1]
+	if err := sink.Send(context.Background(), event); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestSlackSinkFailsOnNon2xx(t *testing.T) {
+	var text string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]string
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		text = body["text"]
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	sink := NewSlackSink(server.URL, "", server.Client())
+	payload, _ := json.Marshal(db.BatchCreatedPayload{
+		BatchID: "b1",
+		RunDate: "2026-02-02",
+		Picks:   []db.PickPayloadItem{{Ticker: "AAPL", Action: "BUY"}},
+	})
+	err := sink.Send(context.Background(), db.OutboxEvent{ID: "e1", EventType: db.EventBatchCreated, Payload: payload})
+	if err == nil {
+		t.Fatalf("expected error for 500 response")
+	}
+	if text != "New Alpha Monday batch for 2026-02-02: BUY AAPL" {
+		t.Fatalf("unexpected slack text %q", text)
+	}
+}
+
+func TestEmailSinkBuildsMessage(t *testing.T) {
+	sink := NewEmailSink("smtp.example.com:587", "", "", "alerts@example.com", []string{"a@example.com", "b@example.com"}, "")
+	var gotTo []string
+	var gotMsg string
+	sink.sendMail = func(addr string, auth smtp.Auth, from string, to []string, msg []byte) error {
+		gotTo = to
+		gotMsg = string(msg)
+		return nil
+	}
+
+	payload, _ := json.Marshal(db.BatchStatusPayload{BatchID: "b1", Status: "completed"})
+	if err := sink.Send(context.Background(), db.OutboxEvent{ID: "e1", EventType: db.EventBatchStatusChanged, Payload: payload}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(gotTo) != 2 {
+		t.Fatalf("expected 2 recipients, got %v", gotTo)
+	}
+	if !strings.Contains(gotMsg, "Subject: Batch b1 is now completed\r\n") {
+		t.Fatalf("unexpected message: %q", gotMsg)
+	}
+}
+
+func TestSummaryReportsTargetAccuracy(t *testing.T) {
+	payload, _ := json.Marshal(db.BatchStatusPayload{BatchID: "b1", Status: "completed", TargetAccuracy: []db.TargetAccuracyItem{
+		{Ticker: "AAPL", TargetPrice: decimal.MustParse("200.00"), FinalPrice: decimal.MustParse("210.00"), ErrorPct: decimal.MustParse("5.00000000")},
+	}})
+	got := Summary(db.OutboxEvent{ID: "e1", EventType: db.EventBatchStatusChanged, Payload: payload})
+	if want := "Batch b1 is now completed; targets: AAPL 210.00 vs target 200.00 (5.00%)"; got != want {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
+}
+
+func TestSinksEmbedBatchChart(t *testing.T) {
+	batchID := "b1"
+	event := db.OutboxEvent{ID: "e1", EventType: db.EventBatchStatusChanged, BatchID: &batchID, Payload: json.RawMessage(`{"batch_id":"b1","status":"completed"}`)}
+	wantURL := "https://api.example.com/batches/b1/chart.png"
+
+	var slackBody struct {
+		Blocks []map[string]any `json:"blocks"`
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&slackBody)
+	}))
+	defer server.Close()
+
+	if err := NewSlackSink(server.URL, "https://api.example.com/", server.Client()).Send(context.Background(), event); err != nil {
+		t.Fatalf("slack send: %v", err)
+	}
+	if len(slackBody.Blocks) != 2 || slackBody.Blocks[1]["image_url"] != wantURL {
+		t.Fatalf("expected chart image block, got %+v", slackBody.Blocks)
+	}
+
+	sink := NewEmailSink("smtp.example.com:587", "", "", "alerts@example.com", []string{"a@example.com"}, "https://api.example.com")
+	var gotMsg string
+	sink.sendMail = func(addr string, auth smtp.Auth, from string, to []string, msg []byte) error {
+		gotMsg = string(msg)
+		return nil
+	}
+	if err := sink.Send(context.Background(), event); err != nil {
+		t.Fatalf("email send: %v", err)
+	}
+	if !strings.Contains(gotMsg, "multipart/alternative") || !strings.Contains(gotMsg, `<img src="`+wantURL+`"`) {
+		t.Fatalf("expected embedded chart in email, got %q", gotMsg)
+	}
+}
+
+func testLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
@@ -0,0 +1,245 @@
+package outbox
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"net/smtp"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/igor-kupczynski/alpha-monday/internal/db"
+)
+
+// Summary renders a one-line human readable description of an event.
+func Summary(event db.OutboxEvent) string {
+	switch event.EventType {
+	case db.EventBatchCreated:
+		var payload db.BatchCreatedPayload
+		if err := json.Unmarshal(event.Payload, &payload); err == nil {
+			tickers := make([]string, 0, len(payload.Picks))
+			for _, pick := range payload.Picks {
+				tickers = append(tickers, pick.Action+" "+pick.Ticker)
+			}
+			return fmt.Sprintf("New Alpha Monday batch for %s: %s", payload.RunDate, strings.Join(tickers, ", "))
+		}
+	case db.EventCheckpointComputed, db.EventCheckpointSkipped:
+		var payload db.CheckpointPayload
+		if err := json.Unmarshal(event.Payload, &payload); err == nil {
+			if payload.Status == "skipped" {
+				return fmt.Sprintf("Checkpoint %s skipped for batch %s", payload.CheckpointDate, payload.BatchID)
+			}
+			benchmark := "n/a"
+			if payload.BenchmarkReturnPct != nil {
+				benchmark = payload.BenchmarkReturnPct.String() + "%"
+			}
+			return fmt.Sprintf("Checkpoint %s computed for batch %s (benchmark %s)", payload.CheckpointDate, payload.BatchID, benchmark)
+		}
+	case db.EventMetricsInconsistent:
+		var payload db.MetricsInconsistentPayload
+		if err := json.Unmarshal(event.Payload, &payload); err == nil {
+			return fmt.Sprintf("%d metric discrepancies found in batch %s", len(payload.Discrepancies), payload.BatchID)
+		}
+	case db.EventAPIPanic:
+		var payload db.APIPanicPayload
+		if err := json.Unmarshal(event.Payload, &payload); err == nil {
+			return fmt.Sprintf("API panic on %s %s (request %s): %s", payload.Method, payload.Route, payload.RequestID, payload.Error)
+		}
+	case db.EventBatchStatusChanged:
+		var payload db.BatchStatusPayload
+		if err := json.Unmarshal(event.Payload, &payload); err == nil {
+			summary := fmt.Sprintf("Batch %s is now %s", payload.BatchID, payload.Status)
+			if len(payload.TargetAccuracy) > 0 {
+				targets := make([]string, 0, len(payload.TargetAccuracy))
+				for _, item := range payload.TargetAccuracy {
+					targets = append(targets, fmt.Sprintf("%s %s vs target %s (%s%%)", item.Ticker, item.FinalPrice, item.TargetPrice, item.ErrorPct.StringFixed(2)))
+				}
+				summary += "; targets: " + strings.Join(targets, ", ")
+			}
+			return summary
+		}
+	}
+	return fmt.Sprintf("Alpha Monday event %s (%s)", event.EventType, event.ID)
+}
+
+// Envelope is the JSON body posted to generic webhooks.
+type Envelope struct {
+	ID        string          `json:"id"`
+	Type      string          `json:"type"`
+	BatchID   *string         `json:"batch_id,omitempty"`
+	CreatedAt time.Time       `json:"created_at"`
+	Payload   json.RawMessage `json:"payload"`
+}
+
+func NewEnvelope(event db.OutboxEvent) Envelope {
+	return Envelope{
+		ID:        event.ID,
+		Type:      event.EventType,
+		BatchID:   event.BatchID,
+		CreatedAt: event.CreatedAt.UTC(),
+		Payload:   event.Payload,
+	}
+}
+
+// ChartURL returns the batch chart image URL for events tied to a batch, or
+// "" when apiBaseURL is empty or the event has no batch.
+func ChartURL(apiBaseURL string, event db.OutboxEvent) string {
+	if apiBaseURL == "" || event.BatchID == nil {
+		return ""
+	}
+	return strings.TrimRight(apiBaseURL, "/") + "/batches/" + url.PathEscape(*event.BatchID) + "/chart.png"
+}
+
+// SlackSink posts event summaries to a Slack incoming webhook. When
+// apiBaseURL is set, batch events embed the batch chart image.
+type SlackSink struct {
+	webhookURL string
+	apiBaseURL string
+	httpClient *http.Client
+}
+
+func NewSlackSink(webhookURL, apiBaseURL string, httpClient *http.Client) *SlackSink {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	return &SlackSink{webhookURL: webhookURL, apiBaseURL: apiBaseURL, httpClient: httpClient}
+}
+
+func (s *SlackSink) Name() string {
+	return "slack"
+}
+
+func (s *SlackSink) Send(ctx context.Context, event db.OutboxEvent) error {
+	summary := Summary(event)
+	message := map[string]any{"text": summary}
+	if chartURL := ChartURL(s.apiBaseURL, event); chartURL != "" {
+		message["blocks"] = []map[string]any{
+			{"type": "section", "text": map[string]string{"type": "mrkdwn", "text": summary}},
+			{"type": "image", "image_url": chartURL, "alt_text": "Portfolio vs benchmark returns"},
+		}
+	}
+	body, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+	return postJSON(ctx, s.httpClient, s.webhookURL, body, nil)
+}
+
+// WebhookSink posts the event envelope as JSON to a URL.
+type WebhookSink struct {
+	url        string
+	httpClient *http.Client
+}
+
+func NewWebhookSink(url string, httpClient *http.Client) *WebhookSink {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	return &WebhookSink{url: url, httpClient: httpClient}
+}
+
+func (s *WebhookSink) Name() string {
+	return "webhook"
+}
+
+func (s *WebhookSink) Send(ctx context.Context, event db.OutboxEvent) error {
+	body, err := json.Marshal(NewEnvelope(event))
+	if err != nil {
+		return err
+	}
+	return postJSON(ctx, s.httpClient, s.url, body, map[string]string{
+		"X-Alpha-Monday-Event":    event.EventType,
+		"X-Alpha-Monday-Event-Id": event.ID,
+	})
+}
+
+type sendMailFunc func(addr string, auth smtp.Auth, from string, to []string, msg []byte) error
+
+// EmailSink sends event summaries over SMTP. When apiBaseURL is set, batch
+// events are sent as multipart mail whose HTML part embeds the batch chart.
+type EmailSink struct {
+	addr       string
+	auth       smtp.Auth
+	from       string
+	to         []string
+	apiBaseURL string
+	sendMail   sendMailFunc
+}
+
+// NewEmailSink sends mail through addr (host:port). Username and password are
+// optional; when set, PLAIN auth is used.
+func NewEmailSink(addr, username, password, from string, to []string, apiBaseURL string) *EmailSink {
+	var auth smtp.Auth
+	if username != "" {
+		host := addr
+		if idx := strings.LastIndex(addr, ":"); idx >= 0 {
+			host = addr[:idx]
+		}
+		auth = smtp.PlainAuth("", username, password, host)
+	}
+	return &EmailSink{addr: addr, auth: auth, from: from, to: to, apiBaseURL: apiBaseURL, sendMail: smtp.SendMail}
+}
+
+func (s *EmailSink) Name() string {
+	return "email"
+}
+
+func (s *EmailSink) Send(ctx context.Context, event db.OutboxEvent) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	summary := Summary(event)
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", s.from)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(s.to, ", "))
+	fmt.Fprintf(&msg, "Subject: %s\r\n", summary)
+	msg.WriteString("MIME-Version: 1.0\r\n")
+
+	chartURL := ChartURL(s.apiBaseURL, event)
+	if chartURL == "" {
+		msg.WriteString("Content-Type: text/plain; charset=utf-8\r\n\r\n")
+		msg.WriteString(summary + "\r\n\r\n")
+		msg.Write(event.Payload)
+		msg.WriteString("\r\n")
+		return s.sendMail(s.addr, s.auth, s.from, s.to, msg.Bytes())
+	}
+
+	const boundary = "alpha-monday-alternative"
+	fmt.Fprintf(&msg, "Content-Type: multipart/alternative; boundary=%q\r\n\r\n", boundary)
+	fmt.Fprintf(&msg, "--%s\r\nContent-Type: text/plain; charset=utf-8\r\n\r\n", boundary)
+	msg.WriteString(summary + "\r\n\r\nChart: " + chartURL + "\r\n\r\n")
+	msg.Write(event.Payload)
+	fmt.Fprintf(&msg, "\r\n--%s\r\nContent-Type: text/html; charset=utf-8\r\n\r\n", boundary)
+	fmt.Fprintf(&msg, "<p>%s</p>\r\n<p><img src=\"%s\" alt=\"Portfolio vs benchmark returns\" width=\"800\"></p>\r\n<pre>%s</pre>\r\n",
+		html.EscapeString(summary), html.EscapeString(chartURL), html.EscapeString(string(event.Payload)))
+	fmt.Fprintf(&msg, "--%s--\r\n", boundary)
+	return s.sendMail(s.addr, s.auth, s.from, s.to, msg.Bytes())
+}
+
+func postJSON(ctx context.Context, client *http.Client, url string, body []byte, headers map[string]string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return nil
+}
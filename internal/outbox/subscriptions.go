@@ -0,0 +1,106 @@
+package outbox
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/igor-kupczynski/alpha-monday/internal/db"
+)
+
+// SubscriptionStore is the db.Store subset used to look up subscribers.
+type SubscriptionStore interface {
+	WebhookSubscriptionsFor(ctx context.Context, eventType string) ([]db.WebhookSubscription, error)
+}
+
+// SubscriptionSink posts the event envelope to every webhook subscription
+// registered for the event type, signing each delivery with the
+// subscription's secret. It is a FanoutSink: each subscription is a target
+// the dispatcher retries on its own, so a failing subscriber does not re-send
+// the event to the others. Receivers still deduplicate on
+// X-Alpha-Monday-Event-Id, as delivery is at-least-once.
+type SubscriptionSink struct {
+	store      SubscriptionStore
+	httpClient *http.Client
+	now        func() time.Time
+}
+
+func NewSubscriptionSink(store SubscriptionStore, httpClient *http.Client) *SubscriptionSink {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	return &SubscriptionSink{store: store, httpClient: httpClient, now: time.Now}
+}
+
+func (s *SubscriptionSink) Name() string {
+	return "subscriptions"
+}
+
+// Send delivers to every subscription and joins their errors. The dispatcher
+// uses Targets instead, to retry subscriptions one by one.
+func (s *SubscriptionSink) Send(ctx context.Context, event db.OutboxEvent) error {
+	targets, err := s.Targets(ctx, event)
+	if err != nil {
+		return err
+	}
+	var failures []error
+	for _, target := range targets {
+		if err := target.Send(ctx); err != nil {
+			failures = append(failures, fmt.Errorf("%s: %w", target.Name, err))
+		}
+	}
+	return errors.Join(failures...)
+}
+
+// Targets returns one target per subscription to the event type, named
+// "subscription:<id>".
+func (s *SubscriptionSink) Targets(ctx context.Context, event db.OutboxEvent) ([]Target, error) {
+	subscriptions, err := s.store.WebhookSubscriptionsFor(ctx, event.EventType)
+	if err != nil {
+		return nil, fmt.Errorf("list subscriptions: %w", err)
+	}
+	if len(subscriptions) == 0 {
+		return nil, nil
+	}
+
+	body, err := json.Marshal(NewEnvelope(event))
+	if err != nil {
+		return nil, err
+	}
+	timestamp := strconv.FormatInt(s.now().Unix(), 10)
+
+	targets := make([]Target, 0, len(subscriptions))
+	for _, subscription := range subscriptions {
+		targets = append(targets, Target{
+			Name: "subscription:" + subscription.ID,
+			Send: func(ctx context.Context) error {
+				return postJSON(ctx, s.httpClient, subscription.URL, body, map[string]string{
+					"X-Alpha-Monday-Event":     event.EventType,
+					"X-Alpha-Monday-Event-Id":  event.ID,
+					"X-Alpha-Monday-Timestamp": timestamp,
+					"X-Alpha-Monday-Signature": Sign(subscription.Secret, timestamp, body),
+				})
+			},
+		})
+	}
+	return targets, nil
+}
+
+// Sign returns the X-Alpha-Monday-Signature value for a delivery:
+// "sha256=" and the hex HMAC-SHA256 of "<timestamp>.<body>" keyed with the
+// subscription secret. Receivers recompute it to authenticate the request
+// and reject stale timestamps to prevent replays.
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
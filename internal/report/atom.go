@@ -0,0 +1,128 @@
+package report
+
+import (
+	"encoding/xml"
+	"fmt"
+	"html"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/igor-kupczynski/alpha-monday/internal/db"
+)
+
+const atomNamespace = "http://www.w3.org/2005/Atom"
+
+type atomFeed struct {
+	XMLName xml.Name    `xml:"feed"`
+	XMLNS   string      `xml:"xmlns,attr"`
+	ID      string      `xml:"id"`
+	Title   string      `xml:"title"`
+	Updated string      `xml:"updated"`
+	Links   []atomLink  `xml:"link"`
+	Author  atomAuthor  `xml:"author"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Rel  string `xml:"rel,attr,omitempty"`
+	Type string `xml:"type,attr,omitempty"`
+	Href string `xml:"href,attr"`
+}
+
+type atomAuthor struct {
+	Name string `xml:"name"`
+}
+
+type atomEntry struct {
+	ID        string      `xml:"id"`
+	Title     string      `xml:"title"`
+	Updated   string      `xml:"updated"`
+	Published string      `xml:"published"`
+	Link      atomLink    `xml:"link"`
+	Content   atomContent `xml:"content"`
+}
+
+type atomContent struct {
+	Type string `xml:"type,attr"`
+	Body string `xml:",chardata"`
+}
+
+// WriteAtom renders batches as an Atom feed, one entry per batch listing its
+// picks with the model's reasoning. baseURL is the API's public root; feed and
+// entry links are built from it. The feed's updated time is the newest
+// entry's, or now when there are no entries.
+func WriteAtom(w io.Writer, baseURL string, entries []db.FeedEntry, now time.Time) error {
+	baseURL = strings.TrimRight(baseURL, "/")
+	updated := now
+	if len(entries) > 0 {
+		updated = entries[0].CreatedAt
+		for _, entry := range entries[1:] {
+			if entry.CreatedAt.After(updated) {
+				updated = entry.CreatedAt
+			}
+		}
+	}
+
+	feed := atomFeed{
+		XMLNS:   atomNamespace,
+		ID:      baseURL + "/feed.atom",
+		Title:   "Alpha Monday weekly picks",
+		Updated: atomTime(updated),
+		Links: []atomLink{
+			{Rel: "self", Type: "application/atom+xml", Href: baseURL + "/feed.atom"},
+		},
+		Author:  atomAuthor{Name: "Alpha Monday"},
+		Entries: make([]atomEntry, 0, len(entries)),
+	}
+	for _, entry := range entries {
+		feed.Entries = append(feed.Entries, atomEntry{
+			ID:        "urn:uuid:" + entry.Batch.ID,
+			Title:     atomTitle(entry),
+			Updated:   atomTime(entry.CreatedAt),
+			Published: atomTime(entry.CreatedAt),
+			Link:      atomLink{Rel: "alternate", Type: "application/json", Href: baseURL + "/batches/" + entry.Batch.ID},
+			Content:   atomContent{Type: "html", Body: atomHTML(entry)},
+		})
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(feed); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+func atomTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
+
+func atomTitle(entry db.FeedEntry) string {
+	picks := make([]string, 0, len(entry.Picks))
+	for _, pick := range entry.Picks {
+		picks = append(picks, pick.Action+" "+pick.Ticker)
+	}
+	return fmt.Sprintf("Picks for %s: %s", entry.Batch.RunDate, strings.Join(picks, ", "))
+}
+
+// atomHTML is the entry content: one list item per pick with its initial
+// price, optional target and reasoning. The XML encoder escapes it again, as
+// Atom requires for type="html".
+func atomHTML(entry db.FeedEntry) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "<p>Benchmark %s at %s.</p><ul>", html.EscapeString(entry.Batch.BenchmarkSymbol), entry.Batch.BenchmarkInitialPrice)
+	for _, pick := range entry.Picks {
+		fmt.Fprintf(&b, "<li><strong>%s %s</strong> at %s", html.EscapeString(pick.Action), html.EscapeString(pick.Ticker), pick.InitialPrice)
+		if pick.TargetPrice != nil {
+			fmt.Fprintf(&b, " (target %s)", pick.TargetPrice)
+		}
+		fmt.Fprintf(&b, ": %s</li>", html.EscapeString(pick.Reasoning))
+	}
+	b.WriteString("</ul>")
+	return b.String()
+}
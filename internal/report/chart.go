@@ -0,0 +1,144 @@
+package report
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"io"
+	"math"
+
+	"github.com/igor-kupczynski/alpha-monday/internal/decimal"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+)
+
+const (
+	chartWidth     = 800
+	chartHeightPx  = 400
+	chartPadLeft   = 70
+	chartPadRight  = 20
+	chartPadTop    = 40
+	chartPadBottom = 50
+)
+
+var (
+	chartBackground = color.RGBA{255, 255, 255, 255}
+	chartGrid       = color.RGBA{220, 220, 220, 255}
+	chartText       = color.RGBA{60, 60, 60, 255}
+)
+
+// RenderChartPNG writes a PNG line chart of portfolio vs benchmark returns.
+// With fewer than two data points it renders an empty chart with a note.
+func RenderChartPNG(w io.Writer, title string, series Series) error {
+	img := image.NewRGBA(image.Rect(0, 0, chartWidth, chartHeightPx))
+	draw.Draw(img, img.Bounds(), &image.Uniform{C: chartBackground}, image.Point{}, draw.Src)
+
+	drawText(img, chartPadLeft, 22, title, chartText)
+
+	plot := image.Rect(chartPadLeft, chartPadTop, chartWidth-chartPadRight, chartHeightPx-chartPadBottom)
+	drawRect(img, plot, chartGrid)
+
+	low, high, ok := valueRange(series.Portfolio, series.Benchmark)
+	if !ok || len(series.Dates) < 2 {
+		drawText(img, plot.Min.X+10, plot.Min.Y+20, "Not enough checkpoints to chart yet.", chartText)
+		return png.Encode(w, img)
+	}
+
+	scaleX := func(i int) float64 {
+		return float64(plot.Min.X) + float64(plot.Dx())*float64(i)/float64(len(series.Dates)-1)
+	}
+	scaleY := func(v float64) float64 {
+		return float64(plot.Max.Y) - float64(plot.Dy())*(v-low)/(high-low)
+	}
+
+	for _, tick := range []float64{low, 0, high} {
+		if tick == 0 && (low == 0 || high == 0) {
+			continue
+		}
+		y := scaleY(tick)
+		drawLine(img, float64(plot.Min.X), y, float64(plot.Max.X), y, 0.5, chartGrid)
+		drawText(img, 8, int(y)+4, fmt.Sprintf("%+.2f%%", tick), chartText)
+	}
+	drawText(img, plot.Min.X, plot.Max.Y+18, series.Dates[0], chartText)
+	last := series.Dates[len(series.Dates)-1]
+	drawText(img, plot.Max.X-textWidth(last), plot.Max.Y+18, last, chartText)
+
+	lines := []struct {
+		label  string
+		values []*decimal.Decimal
+		color  color.RGBA
+	}{
+		{"Benchmark", series.Benchmark, rgba(benchmarkColor)},
+		{"Portfolio", series.Portfolio, rgba(portfolioColor)},
+	}
+	for i, line := range lines {
+		prev := -1
+		for j, value := range line.values {
+			if value == nil {
+				continue
+			}
+			if prev >= 0 {
+				drawLine(img, scaleX(prev), scaleY(line.values[prev].Float64()), scaleX(j), scaleY(value.Float64()), 1.5, line.color)
+			}
+			prev = j
+		}
+
+		legendX := float64(plot.Min.X + 200 + i*120)
+		legendY := float64(plot.Max.Y + 36)
+		drawLine(img, legendX, legendY-4, legendX+20, legendY-4, 1.5, line.color)
+		drawText(img, int(legendX)+26, int(legendY), line.label, chartText)
+	}
+
+	return png.Encode(w, img)
+}
+
+func rgba(c [3]int) color.RGBA {
+	return color.RGBA{uint8(c[0]), uint8(c[1]), uint8(c[2]), 255}
+}
+
+func drawText(img draw.Image, x, y int, text string, c color.Color) {
+	drawer := font.Drawer{
+		Dst:  img,
+		Src:  image.NewUniform(c),
+		Face: basicfont.Face7x13,
+		Dot:  fixed.P(x, y),
+	}
+	drawer.DrawString(text)
+}
+
+func textWidth(text string) int {
+	return font.MeasureString(basicfont.Face7x13, text).Round()
+}
+
+func drawRect(img draw.Image, r image.Rectangle, c color.Color) {
+	minX, minY := float64(r.Min.X), float64(r.Min.Y)
+	maxX, maxY := float64(r.Max.X), float64(r.Max.Y)
+	drawLine(img, minX, minY, maxX, minY, 0.5, c)
+	drawLine(img, minX, maxY, maxX, maxY, 0.5, c)
+	drawLine(img, minX, minY, minX, maxY, 0.5, c)
+	drawLine(img, maxX, minY, maxX, maxY, 0.5, c)
+}
+
+// drawLine stamps a disc of the given radius along the segment, which is
+// plenty for a handful of straight segments.
+func drawLine(img draw.Image, x1, y1, x2, y2, radius float64, c color.Color) {
+	steps := int(math.Ceil(math.Max(math.Abs(x2-x1), math.Abs(y2-y1))))
+	if steps == 0 {
+		steps = 1
+	}
+	for i := 0; i <= steps; i++ {
+		t := float64(i) / float64(steps)
+		cx := x1 + (x2-x1)*t
+		cy := y1 + (y2-y1)*t
+		for dy := -radius; dy <= radius; dy++ {
+			for dx := -radius; dx <= radius; dx++ {
+				if dx*dx+dy*dy <= radius*radius+0.25 {
+					img.Set(int(math.Round(cx+dx)), int(math.Round(cy+dy)), c)
+				}
+			}
+		}
+	}
+}
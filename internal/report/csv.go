@@ -0,0 +1,81 @@
+package report
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+
+	"github.com/igor-kupczynski/alpha-monday/internal/db"
+	"github.com/igor-kupczynski/alpha-monday/internal/decimal"
+)
+
+// csvHeader names the columns written by WriteCSV.
+var csvHeader = []string{
+	"run_date", "benchmark_symbol", "checkpoint_date", "checkpoint_status",
+	"benchmark_price", "benchmark_return_pct",
+	"ticker", "action", "initial_price", "target_price",
+	"current_price", "open_price", "high_price", "low_price", "volume",
+	"absolute_return_pct", "vs_benchmark_pct",
+}
+
+// WriteCSV writes one row per checkpoint and pick, checkpoints oldest first and
+// picks in batch order. Metric columns are empty where a checkpoint was
+// skipped or has no metric for the pick. Rows are flushed as they are written
+// so large batches stream.
+func WriteCSV(w io.Writer, detail db.BatchDetails) error {
+	out := csv.NewWriter(w)
+	if err := out.Write(csvHeader); err != nil {
+		return err
+	}
+	for _, checkpoint := range detail.Checkpoints {
+		metrics := metricsByPick(checkpoint)
+		for _, pick := range detail.Picks {
+			if err := out.Write(exportRow(detail.Batch, checkpoint, pick, metrics)); err != nil {
+				return err
+			}
+		}
+		out.Flush()
+		if err := out.Error(); err != nil {
+			return err
+		}
+	}
+	out.Flush()
+	return out.Error()
+}
+
+// exportRow is one csvHeader row: the pick at the checkpoint, with metric
+// columns empty when metrics has no entry for the pick.
+func exportRow(batch db.Batch, checkpoint db.Checkpoint, pick db.Pick, metrics map[string]db.PickMetric) []string {
+	row := []string{
+		batch.RunDate, batch.BenchmarkSymbol, checkpoint.CheckpointDate, checkpoint.Status,
+		csvDecimal(checkpoint.BenchmarkPrice), csvDecimal(checkpoint.BenchmarkReturnPct),
+		pick.Ticker, pick.Action, pick.InitialPrice.String(), csvDecimal(pick.TargetPrice),
+	}
+	metric, ok := metrics[pick.ID]
+	if !ok {
+		return append(row, "", "", "", "", "", "", "")
+	}
+	volume := ""
+	if metric.Volume != nil {
+		volume = strconv.FormatInt(*metric.Volume, 10)
+	}
+	return append(row,
+		metric.CurrentPrice.String(), csvDecimal(metric.OpenPrice), csvDecimal(metric.HighPrice), csvDecimal(metric.LowPrice), volume,
+		metric.AbsoluteReturnPct.String(), metric.VsBenchmarkPct.String(),
+	)
+}
+
+func csvDecimal(value *decimal.Decimal) string {
+	if value == nil {
+		return ""
+	}
+	return value.String()
+}
+
+func metricsByPick(checkpoint db.Checkpoint) map[string]db.PickMetric {
+	metrics := make(map[string]db.PickMetric, len(checkpoint.Metrics))
+	for _, metric := range checkpoint.Metrics {
+		metrics[metric.PickID] = metric
+	}
+	return metrics
+}
@@ -0,0 +1,76 @@
+package report
+
+import (
+	"github.com/igor-kupczynski/alpha-monday/internal/db"
+	"github.com/igor-kupczynski/alpha-monday/internal/decimal"
+)
+
+// thinTurnover is the average daily traded value, in dollars, below which a
+// pick is flagged as thinly traded.
+var thinTurnover = decimal.NewFromInt(10_000_000)
+
+// Liquidity summarises the trading activity of one pick over the checkpoints
+// that reported a volume. The pointer fields are nil when no checkpoint did.
+type Liquidity struct {
+	PickID string
+	Ticker string
+	// Days is the number of checkpoints with a reported volume.
+	Days int
+	// AverageVolume is the mean daily share volume.
+	AverageVolume *int64
+	// AverageTurnover is the mean daily traded value (close x volume).
+	AverageTurnover *decimal.Decimal
+	// VWAP is the volume-weighted average of the checkpoint closes.
+	VWAP *decimal.Decimal
+	// Thin is set when AverageTurnover is below $10M.
+	Thin bool
+}
+
+// BuildLiquidity computes per-pick volume statistics from the volumes stored
+// with checkpoint metrics. Picks keep their order.
+func BuildLiquidity(picks []db.Pick, checkpoints []db.Checkpoint) []Liquidity {
+	type totals struct {
+		days     int
+		volume   int64
+		turnover decimal.Decimal
+	}
+	sums := make(map[string]*totals, len(picks))
+	for _, pick := range picks {
+		sums[pick.ID] = &totals{}
+	}
+	for _, checkpoint := range checkpoints {
+		for _, metric := range checkpoint.Metrics {
+			sum, ok := sums[metric.PickID]
+			if !ok || metric.Volume == nil {
+				continue
+			}
+			sum.days++
+			sum.volume += *metric.Volume
+			sum.turnover = sum.turnover.Add(metric.CurrentPrice.Mul(decimal.NewFromInt(*metric.Volume)))
+		}
+	}
+
+	result := make([]Liquidity, 0, len(picks))
+	for _, pick := range picks {
+		sum := sums[pick.ID]
+		entry := Liquidity{PickID: pick.ID, Ticker: pick.Ticker, Days: sum.days}
+		if sum.days > 0 {
+			days := decimal.NewFromInt(int64(sum.days))
+			averageVolume := sum.volume / int64(sum.days)
+			entry.AverageVolume = &averageVolume
+			if turnover, err := sum.turnover.Quo(days); err == nil {
+				turnover = turnover.Round(2)
+				entry.AverageTurnover = &turnover
+				entry.Thin = turnover.Cmp(thinTurnover) < 0
+			}
+			if sum.volume > 0 {
+				if vwap, err := sum.turnover.Quo(decimal.NewFromInt(sum.volume)); err == nil {
+					vwap = vwap.Round(4)
+					entry.VWAP = &vwap
+				}
+			}
+		}
+		result = append(result, entry)
+	}
+	return result
+}
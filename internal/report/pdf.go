@@ -0,0 +1,319 @@
+package report
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/go-pdf/fpdf"
+	"github.com/igor-kupczynski/alpha-monday/internal/db"
+	"github.com/igor-kupczynski/alpha-monday/internal/decimal"
+)
+
+const (
+	pageMargin   = 15.0
+	contentWidth = 180.0
+	chartHeight  = 75.0
+)
+
+var (
+	benchmarkColor = [3]int{128, 128, 128}
+	portfolioColor = [3]int{31, 119, 180}
+)
+
+// RenderPDF writes a one-document report for a batch: a summary of portfolio
+// vs benchmark, the picks table, pick liquidity, a return chart and each
+// pick's reasoning.
+// Core PDF fonts are used, so text is limited to the Windows-1252 character
+// set; other characters render as placeholders.
+func RenderPDF(w io.Writer, detail db.BatchDetails) error {
+	series := BuildSeries(detail.Picks, detail.Checkpoints)
+	latest := series.Latest()
+
+	pdf := fpdf.New("P", "mm", "A4", "")
+	pdf.SetMargins(pageMargin, pageMargin, pageMargin)
+	pdf.SetAutoPageBreak(true, pageMargin)
+	pdf.SetCreationDate(detail.LastModified)
+	pdf.SetTitle("Alpha Monday batch "+detail.Batch.RunDate, true)
+	pdf.SetCreator("alpha-monday", true)
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+	pdf.AddPage()
+
+	pdf.SetFont("Helvetica", "B", 18)
+	pdf.CellFormat(contentWidth, 10, tr("Alpha Monday — batch "+detail.Batch.RunDate), "", 1, "L", false, 0, "")
+	pdf.SetFont("Helvetica", "", 10)
+	pdf.SetTextColor(90, 90, 90)
+	pdf.CellFormat(contentWidth, 6, tr(fmt.Sprintf("Status: %s · Benchmark: %s at %s",
+		detail.Batch.Status, detail.Batch.BenchmarkSymbol, detail.Batch.BenchmarkInitialPrice.String())), "", 1, "L", false, 0, "")
+	pdf.SetTextColor(0, 0, 0)
+	pdf.Ln(4)
+
+	renderSummary(pdf, tr, series, latest)
+	pdf.Ln(4)
+	renderPicksTable(pdf, tr, detail, series, latest)
+	pdf.Ln(6)
+	renderLiquidity(pdf, tr, BuildLiquidity(detail.Picks, detail.Checkpoints))
+	pdf.Ln(6)
+	renderChart(pdf, tr, series)
+	pdf.Ln(6)
+	renderReasoning(pdf, tr, detail.Picks)
+
+	return pdf.Output(w)
+}
+
+func renderSummary(pdf *fpdf.Fpdf, tr func(string) string, series Series, latest int) {
+	pdf.SetFont("Helvetica", "B", 12)
+	if latest < 0 {
+		pdf.CellFormat(contentWidth, 7, "No computed checkpoints yet", "", 1, "L", false, 0, "")
+		return
+	}
+	pdf.CellFormat(contentWidth, 7, tr("Performance as of "+series.Dates[latest]), "", 1, "L", false, 0, "")
+
+	portfolio := series.Portfolio[latest]
+	benchmark := series.Benchmark[latest]
+	var excess *decimal.Decimal
+	if portfolio != nil && benchmark != nil {
+		value := portfolio.Sub(*benchmark)
+		excess = &value
+	}
+
+	rows := [][2]string{
+		{"Portfolio return", formatPct(portfolio)},
+		{"Benchmark return", formatPct(benchmark)},
+		{"Portfolio vs benchmark", formatPct(excess)},
+	}
+	pdf.SetFont("Helvetica", "", 10)
+	for _, row := range rows {
+		pdf.CellFormat(60, 6, tr(row[0]), "", 0, "L", false, 0, "")
+		pdf.CellFormat(40, 6, tr(row[1]), "", 1, "R", false, 0, "")
+	}
+}
+
+func renderPicksTable(pdf *fpdf.Fpdf, tr func(string) string, detail db.BatchDetails, series Series, latest int) {
+	headers := []string{"Ticker", "Action", "Initial price", "Latest price", "Return", "vs benchmark"}
+	widths := []float64{25, 20, 32, 32, 33, 38}
+
+	latestPrices := map[string]string{}
+	latestVsBenchmark := map[string]*decimal.Decimal{}
+	if latest >= 0 {
+		for _, checkpoint := range detail.Checkpoints {
+			if checkpoint.CheckpointDate != series.Dates[latest] {
+				continue
+			}
+			for _, metric := range checkpoint.Metrics {
+				value := metric.VsBenchmarkPct
+				latestPrices[metric.PickID] = metric.CurrentPrice.String()
+				latestVsBenchmark[metric.PickID] = &value
+			}
+		}
+	}
+
+	pdf.SetFont("Helvetica", "B", 10)
+	pdf.SetFillColor(235, 235, 235)
+	for i, header := range headers {
+		align := "R"
+		if i < 2 {
+			align = "L"
+		}
+		pdf.CellFormat(widths[i], 7, header, "B", 0, align, true, 0, "")
+	}
+	pdf.Ln(-1)
+
+	pdf.SetFont("Helvetica", "", 10)
+	for i, pick := range detail.Picks {
+		var pickReturn *decimal.Decimal
+		if latest >= 0 {
+			pickReturn = series.Picks[i].Returns[latest]
+		}
+		latestPrice, ok := latestPrices[pick.ID]
+		if !ok {
+			latestPrice = "n/a"
+		}
+		cells := []string{
+			pick.Ticker,
+			pick.Action,
+			pick.InitialPrice.String(),
+			latestPrice,
+			formatPct(pickReturn),
+			formatPct(latestVsBenchmark[pick.ID]),
+		}
+		for j, cell := range cells {
+			align := "R"
+			if j < 2 {
+				align = "L"
+			}
+			pdf.CellFormat(widths[j], 6, tr(cell), "B", 0, align, false, 0, "")
+		}
+		pdf.Ln(-1)
+	}
+}
+
+func renderLiquidity(pdf *fpdf.Fpdf, tr func(string) string, liquidity []Liquidity) {
+	pdf.SetFont("Helvetica", "B", 12)
+	pdf.CellFormat(contentWidth, 7, "Liquidity", "", 1, "L", false, 0, "")
+
+	reported := false
+	for _, entry := range liquidity {
+		reported = reported || entry.Days > 0
+	}
+	if !reported {
+		pdf.SetFont("Helvetica", "", 10)
+		pdf.CellFormat(contentWidth, 6, "No volume data reported for these checkpoints.", "", 1, "L", false, 0, "")
+		return
+	}
+
+	headers := []string{"Ticker", "Days", "Avg volume", "Avg turnover", "VWAP", ""}
+	widths := []float64{25, 20, 35, 40, 35, 25}
+	pdf.SetFont("Helvetica", "B", 10)
+	pdf.SetFillColor(235, 235, 235)
+	for i, header := range headers {
+		align := "R"
+		if i == 0 {
+			align = "L"
+		}
+		pdf.CellFormat(widths[i], 7, header, "B", 0, align, true, 0, "")
+	}
+	pdf.Ln(-1)
+
+	pdf.SetFont("Helvetica", "", 10)
+	for _, entry := range liquidity {
+		volume, turnover, vwap, note := "n/a", "n/a", "n/a", ""
+		if entry.AverageVolume != nil {
+			volume = fmt.Sprintf("%d", *entry.AverageVolume)
+		}
+		if entry.AverageTurnover != nil {
+			turnover = "$" + entry.AverageTurnover.StringFixed(0)
+		}
+		if entry.VWAP != nil {
+			vwap = entry.VWAP.StringFixed(2)
+		}
+		if entry.Thin {
+			note = "thin"
+		}
+		cells := []string{entry.Ticker, fmt.Sprintf("%d", entry.Days), volume, turnover, vwap, note}
+		for j, cell := range cells {
+			align := "R"
+			if j == 0 {
+				align = "L"
+			}
+			pdf.CellFormat(widths[j], 6, tr(cell), "B", 0, align, false, 0, "")
+		}
+		pdf.Ln(-1)
+	}
+}
+
+func renderChart(pdf *fpdf.Fpdf, tr func(string) string, series Series) {
+	pdf.SetFont("Helvetica", "B", 12)
+	pdf.CellFormat(contentWidth, 7, "Portfolio vs benchmark return", "", 1, "L", false, 0, "")
+
+	low, high, ok := valueRange(series.Portfolio, series.Benchmark)
+	if !ok || len(series.Dates) < 2 {
+		pdf.SetFont("Helvetica", "", 10)
+		pdf.CellFormat(contentWidth, 6, "Not enough checkpoints to chart yet.", "", 1, "L", false, 0, "")
+		return
+	}
+
+	const labelWidth = 16.0
+	x0 := pageMargin + labelWidth
+	width := contentWidth - labelWidth
+	if pdf.GetY()+chartHeight+12 > 297-pageMargin {
+		pdf.AddPage()
+	}
+	y0 := pdf.GetY() + 2
+	scaleX := func(i int) float64 {
+		return x0 + width*float64(i)/float64(len(series.Dates)-1)
+	}
+	scaleY := func(v float64) float64 {
+		return y0 + chartHeight - chartHeight*(v-low)/(high-low)
+	}
+
+	pdf.SetDrawColor(200, 200, 200)
+	pdf.SetLineWidth(0.2)
+	pdf.Rect(x0, y0, width, chartHeight, "D")
+	pdf.SetDashPattern([]float64{1, 1}, 0)
+	pdf.Line(x0, scaleY(0), x0+width, scaleY(0))
+	pdf.SetDashPattern([]float64{}, 0)
+
+	pdf.SetFont("Helvetica", "", 8)
+	for _, tick := range []float64{low, 0, high} {
+		pdf.Text(pageMargin, scaleY(tick)+1, fmt.Sprintf("%+.2f%%", tick))
+	}
+	pdf.Text(x0, y0+chartHeight+4, series.Dates[0])
+	last := series.Dates[len(series.Dates)-1]
+	pdf.Text(x0+width-pdf.GetStringWidth(last), y0+chartHeight+4, last)
+
+	drawLine := func(values []*decimal.Decimal, color [3]int) {
+		pdf.SetDrawColor(color[0], color[1], color[2])
+		pdf.SetLineWidth(0.6)
+		prev := -1
+		for i, value := range values {
+			if value == nil {
+				continue
+			}
+			if prev >= 0 {
+				pdf.Line(scaleX(prev), scaleY(values[prev].Float64()), scaleX(i), scaleY(value.Float64()))
+			}
+			prev = i
+		}
+	}
+	drawLine(series.Benchmark, benchmarkColor)
+	drawLine(series.Portfolio, portfolioColor)
+
+	legendY := y0 + chartHeight + 9
+	for i, entry := range []struct {
+		label string
+		color [3]int
+	}{{"Portfolio", portfolioColor}, {"Benchmark", benchmarkColor}} {
+		x := x0 + float64(i)*35
+		pdf.SetDrawColor(entry.color[0], entry.color[1], entry.color[2])
+		pdf.SetLineWidth(0.6)
+		pdf.Line(x, legendY-1, x+6, legendY-1)
+		pdf.Text(x+8, legendY, tr(entry.label))
+	}
+	pdf.SetDrawColor(0, 0, 0)
+	pdf.SetLineWidth(0.2)
+	pdf.SetY(legendY + 2)
+}
+
+func renderReasoning(pdf *fpdf.Fpdf, tr func(string) string, picks []db.Pick) {
+	pdf.SetFont("Helvetica", "B", 12)
+	pdf.CellFormat(contentWidth, 7, "Reasoning", "", 1, "L", false, 0, "")
+	for _, pick := range picks {
+		pdf.SetFont("Helvetica", "B", 10)
+		pdf.CellFormat(contentWidth, 6, tr(pick.Ticker+" ("+pick.Action+")"), "", 1, "L", false, 0, "")
+		pdf.SetFont("Helvetica", "", 10)
+		pdf.MultiCell(contentWidth, 5, tr(pick.Reasoning), "", "L", false)
+		pdf.Ln(2)
+	}
+}
+
+// valueRange returns the min and max across all series, always including 0 so
+// the zero line is visible.
+func valueRange(series ...[]*decimal.Decimal) (float64, float64, bool) {
+	low, high := 0.0, 0.0
+	found := false
+	for _, values := range series {
+		for _, value := range values {
+			if value == nil {
+				continue
+			}
+			found = true
+			low = min(low, value.Float64())
+			high = max(high, value.Float64())
+		}
+	}
+	if high == low {
+		high = low + 1
+	}
+	return low, high, found
+}
+
+func formatPct(value *decimal.Decimal) string {
+	if value == nil {
+		return "n/a"
+	}
+	text := value.StringFixed(2) + "%"
+	if value.Sign() > 0 {
+		text = "+" + text
+	}
+	return text
+}
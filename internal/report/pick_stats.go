@@ -0,0 +1,63 @@
+package report
+
+import (
+	"github.com/igor-kupczynski/alpha-monday/internal/db"
+	"github.com/igor-kupczynski/alpha-monday/internal/decimal"
+)
+
+// pctScale is the number of decimals kept for derived percentages, matching
+// the stored metrics.
+const pctScale = 8
+
+var hundred = decimal.NewFromInt(100)
+
+// PickStats summarises the price path of one pick. Both fields are nil when
+// the pick has no metrics. They follow the price, not the position: a falling
+// price is a drawdown for SELL picks too, like absolute_return_pct.
+type PickStats struct {
+	// MaxDrawdownPct is the largest fall from a running peak (the initial
+	// price counts as the first peak), in percent of that peak. It is zero
+	// when the price never fell below an earlier peak.
+	MaxDrawdownPct *decimal.Decimal
+	// BestDay is the checkpoint with the largest gain over the previous one
+	// (the first checkpoint is compared to the initial price).
+	BestDay *DayChange
+}
+
+// DayChange is the price change into one checkpoint, in percent.
+type DayChange struct {
+	Date      string
+	ChangePct decimal.Decimal
+}
+
+// BuildPickStats computes drawdown and best-day statistics from a pick's
+// metrics, which must be ordered oldest first.
+func BuildPickStats(initialPrice decimal.Decimal, metrics []db.PickCheckpointMetric) PickStats {
+	var stats PickStats
+	if len(metrics) == 0 || initialPrice.Sign() <= 0 {
+		return stats
+	}
+
+	peak, previous := initialPrice, initialPrice
+	drawdown := decimal.NewFromInt(0)
+	for _, entry := range metrics {
+		price := entry.Metric.CurrentPrice
+		if change, err := price.Sub(previous).Mul(hundred).Quo(previous); err == nil {
+			change = change.Round(pctScale)
+			if stats.BestDay == nil || change.Cmp(stats.BestDay.ChangePct) > 0 {
+				stats.BestDay = &DayChange{Date: entry.CheckpointDate, ChangePct: change}
+			}
+		}
+		if price.Cmp(peak) > 0 {
+			peak = price
+		} else if fall, err := peak.Sub(price).Mul(hundred).Quo(peak); err == nil && fall.Cmp(drawdown) > 0 {
+			drawdown = fall
+		}
+		if price.Sign() > 0 {
+			previous = price
+		}
+	}
+	drawdown = drawdown.Round(pctScale)
+	stats.MaxDrawdownPct = &drawdown
+	return stats
+}
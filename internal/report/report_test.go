@@ -0,0 +1,305 @@
+package report
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/xml"
+	"image/png"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/igor-kupczynski/alpha-monday/internal/db"
+	"github.com/igor-kupczynski/alpha-monday/internal/decimal"
+)
+
+func TestBuildSeriesAlignsCheckpoints(t *testing.T) {
+	detail := testDetail()
+	series := BuildSeries(detail.Picks, detail.Checkpoints)
+
+	if len(series.Dates) != 3 || series.Dates[1] != "2026-01-21" {
+		t.Fatalf("unexpected dates %v", series.Dates)
+	}
+	if series.Benchmark[1] != nil || series.Portfolio[1] != nil {
+		t.Fatalf("expected gaps for skipped checkpoint")
+	}
+	if got := series.Picks[1].Returns[2].String(); got != "-2.00000000" {
+		t.Fatalf("expected MSFT return -2.00000000, got %s", got)
+	}
+	// AAPL BUY +4, MSFT SELL -2 counts as +2: mean 3.
+	if got := series.Portfolio[2].String(); got != "3.00000000" {
+		t.Fatalf("expected portfolio 3.00000000, got %s", got)
+	}
+	if latest := series.Latest(); latest != 2 {
+		t.Fatalf("expected latest index 2, got %d", latest)
+	}
+}
+
+func TestBuildTimeseriesSkipsMissingPoints(t *testing.T) {
+	detail := testDetail()
+	price := decimal.MustParse("402.00")
+	detail.Checkpoints[0].BenchmarkPrice = &price
+	series := BuildTimeseries(detail.Batch, detail.Picks, detail.Checkpoints)
+
+	// The third checkpoint has no benchmark price and the second is skipped.
+	if len(series.Benchmark.Points) != 1 || series.Benchmark.Ticker != "SPY" || series.Benchmark.Points[0].Price.String() != "402.00" {
+		t.Fatalf("unexpected benchmark line %+v", series.Benchmark)
+	}
+	msft := series.Picks[1]
+	if msft.Ticker != "MSFT" || len(msft.Points) != 2 {
+		t.Fatalf("unexpected MSFT line %+v", msft)
+	}
+	if got := msft.Points[1]; got.Date != "2026-01-22" || got.Price.String() != "392.00" || got.ReturnPct.String() != "-2.00000000" {
+		t.Fatalf("unexpected MSFT point %+v", got)
+	}
+}
+
+func TestBuildSeriesLeavesHoldOutOfPortfolio(t *testing.T) {
+	detail := testDetail()
+	detail.Picks[1].Action = "HOLD"
+	series := BuildSeries(detail.Picks, detail.Checkpoints)
+
+	if got := series.Picks[1].Returns[2].String(); got != "-2.00000000" {
+		t.Fatalf("expected HOLD pick return to be tracked, got %s", got)
+	}
+	// Only AAPL BUY +4 counts.
+	if got := series.Portfolio[2].String(); got != "4.00000000" {
+		t.Fatalf("expected portfolio 4.00000000, got %s", got)
+	}
+
+	detail.Picks[0].Action = "HOLD"
+	series = BuildSeries(detail.Picks, detail.Checkpoints)
+	if series.Portfolio[2] != nil {
+		t.Fatalf("expected no portfolio return for an all-HOLD batch, got %s", series.Portfolio[2])
+	}
+}
+
+func TestBuildLiquidity(t *testing.T) {
+	detail := testDetail()
+	volume := func(m db.PickMetric, v int64) db.PickMetric {
+		m.Volume = &v
+		return m
+	}
+	detail.Checkpoints[0].Metrics[0] = volume(detail.Checkpoints[0].Metrics[0], 300_000)
+	detail.Checkpoints[2].Metrics[0] = volume(detail.Checkpoints[2].Metrics[0], 100_000)
+
+	liquidity := BuildLiquidity(detail.Picks, detail.Checkpoints)
+	if len(liquidity) != 2 {
+		t.Fatalf("expected one entry per pick, got %d", len(liquidity))
+	}
+	aapl := liquidity[0]
+	if aapl.Days != 2 || *aapl.AverageVolume != 200_000 {
+		t.Fatalf("unexpected AAPL volume stats: %+v", aapl)
+	}
+	// (101 x 300k + 104 x 100k) / 2 days = 20.35M; / 400k shares = 101.75.
+	if got := aapl.AverageTurnover.String(); got != "20350000.00" {
+		t.Fatalf("expected average turnover 20350000.00, got %s", got)
+	}
+	if got := aapl.VWAP.String(); got != "101.7500" {
+		t.Fatalf("expected VWAP 101.7500, got %s", got)
+	}
+	if aapl.Thin {
+		t.Fatalf("expected AAPL not to be thin")
+	}
+	if msft := liquidity[1]; msft.Days != 0 || msft.AverageVolume != nil || msft.VWAP != nil || msft.Thin {
+		t.Fatalf("expected no stats without volume, got %+v", msft)
+	}
+
+	detail.Checkpoints[2].Metrics[0] = volume(detail.Checkpoints[2].Metrics[0], 1_000)
+	detail.Checkpoints[0].Metrics[0] = volume(detail.Checkpoints[0].Metrics[0], 1_000)
+	if !BuildLiquidity(detail.Picks, detail.Checkpoints)[0].Thin {
+		t.Fatalf("expected a 1k-share pick to be flagged thin")
+	}
+}
+
+func TestBuildPickStats(t *testing.T) {
+	metrics := []db.PickCheckpointMetric{
+		{CheckpointDate: "2026-01-20", Metric: metric("p1", "110.00", "10.00000000", "0")},
+		{CheckpointDate: "2026-01-21", Metric: metric("p1", "88.00", "-12.00000000", "0")},
+		{CheckpointDate: "2026-01-22", Metric: metric("p1", "99.00", "-1.00000000", "0")},
+	}
+	stats := BuildPickStats(decimal.MustParse("100.00"), metrics)
+
+	// Peak 110 to trough 88 is a 20% drawdown.
+	if stats.MaxDrawdownPct == nil || stats.MaxDrawdownPct.String() != "20.00000000" {
+		t.Fatalf("expected drawdown 20.00000000, got %v", stats.MaxDrawdownPct)
+	}
+	// 88 to 99 (+12.5%) beats 100 to 110 (+10%).
+	if stats.BestDay == nil || stats.BestDay.Date != "2026-01-22" || stats.BestDay.ChangePct.String() != "12.50000000" {
+		t.Fatalf("unexpected best day %+v", stats.BestDay)
+	}
+
+	empty := BuildPickStats(decimal.MustParse("100.00"), nil)
+	if empty.MaxDrawdownPct != nil || empty.BestDay != nil {
+		t.Fatalf("expected empty stats without metrics, got %+v", empty)
+	}
+}
+
+func TestRenderPDF(t *testing.T) {
+	var buf bytes.Buffer
+	if err := RenderPDF(&buf, testDetail()); err != nil {
+		t.Fatalf("render: %v", err)
+	}
+	if !bytes.HasPrefix(buf.Bytes(), []byte("%PDF-")) {
+		t.Fatalf("expected PDF header, got %q", buf.Bytes()[:min(buf.Len(), 8)])
+	}
+}
+
+func TestWriteCSV(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteCSV(&buf, testDetail()); err != nil {
+		t.Fatalf("write csv: %v", err)
+	}
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("read csv: %v", err)
+	}
+	// Header plus 3 checkpoints x 2 picks.
+	if len(records) != 7 {
+		t.Fatalf("expected 7 records, got %d", len(records))
+	}
+	if records[0][0] != "run_date" || len(records[0]) != len(csvHeader) {
+		t.Fatalf("unexpected header %v", records[0])
+	}
+	if got := records[3]; got[2] != "2026-01-21" || got[3] != "skipped" || got[6] != "AAPL" || got[10] != "" {
+		t.Fatalf("expected empty metrics for the skipped checkpoint, got %v", got)
+	}
+	if got := records[6]; got[6] != "MSFT" || got[10] != "392.00" || got[16] != "-3.00000000" {
+		t.Fatalf("unexpected last row %v", got)
+	}
+}
+
+func TestWriteXLSX(t *testing.T) {
+	detail := testDetail()
+	detail.Picks[0].Reasoning = "Services <growth> & buybacks"
+	var buf bytes.Buffer
+	if err := WriteXLSX(&buf, detail); err != nil {
+		t.Fatalf("write xlsx: %v", err)
+	}
+	archive, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("open zip: %v", err)
+	}
+
+	type sheet struct {
+		Rows []struct {
+			Cells []struct {
+				Ref    string `xml:"r,attr"`
+				Type   string `xml:"t,attr"`
+				Value  string `xml:"v"`
+				Inline string `xml:"is>t"`
+			} `xml:"c"`
+		} `xml:"sheetData>row"`
+	}
+	sheets := map[string]sheet{}
+	for _, f := range archive.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatalf("open %s: %v", f.Name, err)
+		}
+		var parsed sheet
+		if err := xml.NewDecoder(rc).Decode(&parsed); err != nil {
+			t.Fatalf("%s is not well-formed: %v", f.Name, err)
+		}
+		rc.Close()
+		sheets[f.Name] = parsed
+	}
+
+	picks, ok := sheets["xl/worksheets/sheet1.xml"]
+	if !ok || len(picks.Rows) != 3 {
+		t.Fatalf("expected header plus 2 pick rows, got %+v", picks)
+	}
+	// No target price, so the empty target cells are left out.
+	first := picks.Rows[1].Cells
+	if got := first[len(first)-1]; got.Ref != "F2" || got.Inline != "Services <growth> & buybacks" {
+		t.Fatalf("unexpected reasoning cell %+v", got)
+	}
+	checkpoints := sheets["xl/worksheets/sheet2.xml"]
+	// Header plus 3 checkpoints x 2 picks, as in the CSV export.
+	if len(checkpoints.Rows) != 7 {
+		t.Fatalf("expected 7 checkpoint rows, got %d", len(checkpoints.Rows))
+	}
+	last := checkpoints.Rows[6].Cells
+	if got := last[len(last)-1]; got.Ref != "Q7" || got.Type != "" || got.Value != "-3.00000000" {
+		t.Fatalf("expected numeric vs_benchmark_pct in Q7, got %+v", got)
+	}
+
+	if xlsxColumn(0) != "A" || xlsxColumn(25) != "Z" || xlsxColumn(26) != "AA" || xlsxColumn(701) != "ZZ" {
+		t.Fatalf("unexpected column names")
+	}
+}
+
+func TestWriteAtom(t *testing.T) {
+	detail := testDetail()
+	detail.Picks[0].Reasoning = "Services <growth> & buybacks"
+	created := time.Date(2026, 1, 19, 14, 30, 0, 0, time.UTC)
+	entries := []db.FeedEntry{{Batch: detail.Batch, Picks: detail.Picks, CreatedAt: created}}
+
+	var buf bytes.Buffer
+	if err := WriteAtom(&buf, "https://api.example.com/", entries, time.Now()); err != nil {
+		t.Fatalf("write atom: %v", err)
+	}
+	var feed atomFeed
+	if err := xml.Unmarshal(buf.Bytes(), &feed); err != nil {
+		t.Fatalf("parse atom: %v\n%s", err, buf.String())
+	}
+	if feed.ID != "https://api.example.com/feed.atom" || feed.Updated != "2026-01-19T14:30:00Z" || len(feed.Entries) != 1 {
+		t.Fatalf("unexpected feed %+v", feed)
+	}
+	entry := feed.Entries[0]
+	if entry.ID != "urn:uuid:b1" || entry.Title != "Picks for 2026-01-19: BUY AAPL, SELL MSFT" {
+		t.Fatalf("unexpected entry %+v", entry)
+	}
+	if entry.Link.Href != "https://api.example.com/batches/b1" {
+		t.Fatalf("unexpected entry link %q", entry.Link.Href)
+	}
+	if entry.Content.Type != "html" || !strings.Contains(entry.Content.Body, "Services &lt;growth&gt; &amp; buybacks") {
+		t.Fatalf("expected escaped reasoning in html content, got %q", entry.Content.Body)
+	}
+}
+
+func testDetail() db.BatchDetails {
+	return db.BatchDetails{
+		Batch: db.Batch{ID: "b1", RunDate: "2026-01-19", Status: "completed", BenchmarkSymbol: "SPY", BenchmarkInitialPrice: decimal.MustParse("400.00")},
+		Picks: []db.Pick{
+			{ID: "p1", Ticker: "AAPL", Action: "BUY", Reasoning: "Services growth", InitialPrice: decimal.MustParse("100.00")},
+			{ID: "p2", Ticker: "MSFT", Action: "SELL", Reasoning: "Valuation", InitialPrice: decimal.MustParse("400.00")},
+		},
+		Checkpoints: []db.Checkpoint{
+			checkpoint("2026-01-20", "0.50000000", metric("p1", "101.00", "1.00000000", "0.50000000"), metric("p2", "404.00", "1.00000000", "0.50000000")),
+			{CheckpointDate: "2026-01-21", Status: "skipped"},
+			checkpoint("2026-01-22", "1.00000000", metric("p1", "104.00", "4.00000000", "3.00000000"), metric("p2", "392.00", "-2.00000000", "-3.00000000")),
+		},
+		LastModified: time.Date(2026, 1, 22, 21, 0, 0, 0, time.UTC),
+	}
+}
+
+func checkpoint(date, benchmarkReturn string, metrics ...db.PickMetric) db.Checkpoint {
+	ret := decimal.MustParse(benchmarkReturn)
+	return db.Checkpoint{CheckpointDate: date, Status: "computed", BenchmarkReturnPct: &ret, Metrics: metrics}
+}
+
+func metric(pickID, price, absolute, vs string) db.PickMetric {
+	return db.PickMetric{
+		PickID:            pickID,
+		CurrentPrice:      decimal.MustParse(price),
+		AbsoluteReturnPct: decimal.MustParse(absolute),
+		VsBenchmarkPct:    decimal.MustParse(vs),
+	}
+}
+
+func TestRenderChartPNG(t *testing.T) {
+	detail := testDetail()
+	var buf bytes.Buffer
+	if err := RenderChartPNG(&buf, "SPY batch 2026-01-19", BuildSeries(detail.Picks, detail.Checkpoints)); err != nil {
+		t.Fatalf("render: %v", err)
+	}
+	img, err := png.Decode(&buf)
+	if err != nil {
+		t.Fatalf("decode png: %v", err)
+	}
+	if bounds := img.Bounds(); bounds.Dx() != chartWidth || bounds.Dy() != chartHeightPx {
+		t.Fatalf("unexpected chart size %v", bounds)
+	}
+}
@@ -0,0 +1,99 @@
+// Package report renders batch performance as date-aligned series, charts and
+// PDF reports.
+package report
+
+import (
+	"github.com/igor-kupczynski/alpha-monday/internal/db"
+	"github.com/igor-kupczynski/alpha-monday/internal/decimal"
+)
+
+// Series holds a batch's returns aligned on checkpoint dates. Every slice has
+// one entry per date; entries are nil where a checkpoint was skipped or a
+// pick has no metric.
+type Series struct {
+	Dates     []string
+	Benchmark []*decimal.Decimal
+	Portfolio []*decimal.Decimal
+	Picks     []PickSeries
+}
+
+// PickSeries is the absolute return of one pick per checkpoint date.
+type PickSeries struct {
+	PickID  string
+	Ticker  string
+	Action  string
+	Returns []*decimal.Decimal
+}
+
+// BuildSeries aligns checkpoints and pick metrics on checkpoint dates. The
+// portfolio is the equal-weighted mean of pick returns, with SELL picks counted
+// as shorts (their return is negated). HOLD picks are neutral: their returns
+// are listed but left out of the portfolio.
+func BuildSeries(picks []db.Pick, checkpoints []db.Checkpoint) Series {
+	series := Series{
+		Dates:     make([]string, 0, len(checkpoints)),
+		Benchmark: make([]*decimal.Decimal, 0, len(checkpoints)),
+		Portfolio: make([]*decimal.Decimal, 0, len(checkpoints)),
+		Picks:     make([]PickSeries, 0, len(picks)),
+	}
+	index := make(map[string]int, len(picks))
+	for i, pick := range picks {
+		index[pick.ID] = i
+		series.Picks = append(series.Picks, PickSeries{
+			PickID:  pick.ID,
+			Ticker:  pick.Ticker,
+			Action:  pick.Action,
+			Returns: make([]*decimal.Decimal, 0, len(checkpoints)),
+		})
+	}
+
+	for _, checkpoint := range checkpoints {
+		series.Dates = append(series.Dates, checkpoint.CheckpointDate)
+		series.Benchmark = append(series.Benchmark, checkpoint.BenchmarkReturnPct)
+
+		returns := make([]*decimal.Decimal, len(picks))
+		for _, metric := range checkpoint.Metrics {
+			if i, ok := index[metric.PickID]; ok {
+				value := metric.AbsoluteReturnPct
+				returns[i] = &value
+			}
+		}
+
+		var total decimal.Decimal
+		count := 0
+		for i := range series.Picks {
+			series.Picks[i].Returns = append(series.Picks[i].Returns, returns[i])
+			if returns[i] == nil || series.Picks[i].Action == "HOLD" {
+				continue
+			}
+			value := *returns[i]
+			if series.Picks[i].Action == "SELL" {
+				value = value.Neg()
+			}
+			total = total.Add(value)
+			count++
+		}
+		if count == 0 {
+			series.Portfolio = append(series.Portfolio, nil)
+			continue
+		}
+		mean, err := total.Quo(decimal.NewFromInt(int64(count)))
+		if err != nil {
+			series.Portfolio = append(series.Portfolio, nil)
+			continue
+		}
+		mean = mean.Round(8)
+		series.Portfolio = append(series.Portfolio, &mean)
+	}
+	return series
+}
+
+// Latest returns the index of the last date with a benchmark return, or -1.
+func (s Series) Latest() int {
+	for i := len(s.Dates) - 1; i >= 0; i-- {
+		if s.Benchmark[i] != nil {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,66 @@
+package report
+
+import (
+	"github.com/igor-kupczynski/alpha-monday/internal/db"
+	"github.com/igor-kupczynski/alpha-monday/internal/decimal"
+)
+
+// Point is one observation of a price line.
+type Point struct {
+	Date      string
+	Price     decimal.Decimal
+	ReturnPct decimal.Decimal
+}
+
+// Line is the price history of the benchmark or of one pick.
+type Line struct {
+	// PickID and Action are empty for the benchmark.
+	PickID string
+	Ticker string
+	Action string
+	Points []Point
+}
+
+// Timeseries holds per-line points for charting libraries that plot each
+// line from its own list of points.
+type Timeseries struct {
+	Benchmark Line
+	Picks     []Line
+}
+
+// BuildTimeseries turns checkpoints into one ordered list of points per line.
+// Unlike BuildSeries it does not align lines on dates: skipped checkpoints and
+// missing metrics are left out instead of being filled with gaps.
+func BuildTimeseries(batch db.Batch, picks []db.Pick, checkpoints []db.Checkpoint) Timeseries {
+	series := Timeseries{
+		Benchmark: Line{Ticker: batch.BenchmarkSymbol, Points: []Point{}},
+		Picks:     make([]Line, 0, len(picks)),
+	}
+	index := make(map[string]int, len(picks))
+	for i, pick := range picks {
+		index[pick.ID] = i
+		series.Picks = append(series.Picks, Line{PickID: pick.ID, Ticker: pick.Ticker, Action: pick.Action, Points: []Point{}})
+	}
+
+	for _, checkpoint := range checkpoints {
+		if checkpoint.BenchmarkPrice != nil && checkpoint.BenchmarkReturnPct != nil {
+			series.Benchmark.Points = append(series.Benchmark.Points, Point{
+				Date:      checkpoint.CheckpointDate,
+				Price:     *checkpoint.BenchmarkPrice,
+				ReturnPct: *checkpoint.BenchmarkReturnPct,
+			})
+		}
+		for _, metric := range checkpoint.Metrics {
+			i, ok := index[metric.PickID]
+			if !ok {
+				continue
+			}
+			series.Picks[i].Points = append(series.Picks[i].Points, Point{
+				Date:      checkpoint.CheckpointDate,
+				Price:     metric.CurrentPrice,
+				ReturnPct: metric.AbsoluteReturnPct,
+			})
+		}
+	}
+	return series
+}
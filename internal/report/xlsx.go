@@ -0,0 +1,160 @@
+package report
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+
+	"github.com/igor-kupczynski/alpha-monday/internal/db"
+)
+
+// xlsxPicksHeader names the columns of the Picks sheet; the Checkpoints sheet
+// uses csvHeader.
+var xlsxPicksHeader = []string{
+	"ticker", "action", "initial_price", "target_price", "target_error_pct", "reasoning",
+}
+
+// xlsxNumericColumns are written as number cells so they sum and chart in
+// Excel; everything else is text.
+var xlsxNumericColumns = []string{
+	"benchmark_price", "benchmark_return_pct", "initial_price", "target_price", "target_error_pct",
+	"current_price", "open_price", "high_price", "low_price", "volume",
+	"absolute_return_pct", "vs_benchmark_pct",
+}
+
+const xlsxContentTypes = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">
+<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>
+<Default Extension="xml" ContentType="application/xml"/>
+<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>
+<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>
+<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>
+<Override PartName="/xl/worksheets/sheet2.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>
+</Types>`
+
+const xlsxRootRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>
+</Relationships>`
+
+const xlsxWorkbook = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">
+<sheets>
+<sheet name="Picks" sheetId="1" r:id="rId1"/>
+<sheet name="Checkpoints" sheetId="2" r:id="rId2"/>
+</sheets>
+</workbook>`
+
+const xlsxWorkbookRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>
+<Relationship Id="rId2" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet2.xml"/>
+<Relationship Id="rId3" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>
+</Relationships>`
+
+// xlsxStyles has the default cell format and a bold one (s="1") for headers.
+const xlsxStyles = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">
+<fonts count="2"><font><sz val="11"/><name val="Calibri"/></font><font><b/><sz val="11"/><name val="Calibri"/></font></fonts>
+<fills count="2"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill></fills>
+<borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders>
+<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>
+<cellXfs count="2"><xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/><xf numFmtId="0" fontId="1" fillId="0" borderId="0" xfId="0" applyFont="1"/></cellXfs>
+</styleSheet>`
+
+// WriteXLSX writes the batch as an Excel workbook with two sheets: Picks, one
+// row per pick, and Checkpoints, the rows of WriteCSV. Unlike the CSV export
+// the workbook is built in memory, as the zip container needs each part
+// complete before the next.
+func WriteXLSX(w io.Writer, detail db.BatchDetails) error {
+	picks := [][]string{xlsxPicksHeader}
+	for _, pick := range detail.Picks {
+		picks = append(picks, []string{
+			pick.Ticker, pick.Action, pick.InitialPrice.String(), csvDecimal(pick.TargetPrice), csvDecimal(pick.TargetErrorPct), pick.Reasoning,
+		})
+	}
+	checkpoints := [][]string{csvHeader}
+	for _, checkpoint := range detail.Checkpoints {
+		metrics := metricsByPick(checkpoint)
+		for _, pick := range detail.Picks {
+			checkpoints = append(checkpoints, exportRow(detail.Batch, checkpoint, pick, metrics))
+		}
+	}
+
+	archive := zip.NewWriter(w)
+	parts := []struct {
+		name string
+		body []byte
+	}{
+		{"[Content_Types].xml", []byte(xlsxContentTypes)},
+		{"_rels/.rels", []byte(xlsxRootRels)},
+		{"xl/workbook.xml", []byte(xlsxWorkbook)},
+		{"xl/_rels/workbook.xml.rels", []byte(xlsxWorkbookRels)},
+		{"xl/styles.xml", []byte(xlsxStyles)},
+		{"xl/worksheets/sheet1.xml", xlsxSheet(picks)},
+		{"xl/worksheets/sheet2.xml", xlsxSheet(checkpoints)},
+	}
+	for _, part := range parts {
+		f, err := archive.CreateHeader(&zip.FileHeader{Name: part.name, Method: zip.Deflate, Modified: detail.LastModified})
+		if err != nil {
+			return err
+		}
+		if _, err := f.Write(part.body); err != nil {
+			return err
+		}
+	}
+	return archive.Close()
+}
+
+// xlsxSheet renders rows as worksheet XML. The first row is the header: it is
+// bold and decides which columns are numeric. Empty values leave the cell out.
+func xlsxSheet(rows [][]string) []byte {
+	numeric := make([]bool, len(rows[0]))
+	for i, name := range rows[0] {
+		numeric[i] = slices.Contains(xlsxNumericColumns, name)
+	}
+
+	var b bytes.Buffer
+	b.WriteString(xml.Header)
+	b.WriteString(`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">`)
+	b.WriteString(`<sheetViews><sheetView workbookViewId="0"><pane ySplit="1" topLeftCell="A2" activePane="bottomLeft" state="frozen"/></sheetView></sheetViews>`)
+	b.WriteString(`<sheetData>`)
+	for r, row := range rows {
+		fmt.Fprintf(&b, `<row r="%d">`, r+1)
+		for c, value := range row {
+			if value == "" {
+				continue
+			}
+			ref := xlsxColumn(c) + strconv.Itoa(r+1)
+			switch {
+			case r == 0:
+				fmt.Fprintf(&b, `<c r="%s" s="1" t="inlineStr"><is><t>`, ref)
+			case numeric[c]:
+				fmt.Fprintf(&b, `<c r="%s"><v>%s</v></c>`, ref, value)
+				continue
+			default:
+				fmt.Fprintf(&b, `<c r="%s" t="inlineStr"><is><t xml:space="preserve">`, ref)
+			}
+			// EscapeText also replaces characters XML cannot carry.
+			_ = xml.EscapeText(&b, []byte(value))
+			b.WriteString(`</t></is></c>`)
+		}
+		b.WriteString(`</row>`)
+	}
+	b.WriteString(`</sheetData></worksheet>`)
+	return b.Bytes()
+}
+
+// xlsxColumn returns the column letters for the zero-based index i: A, B, ...,
+// Z, AA, AB, ...
+func xlsxColumn(i int) string {
+	name := ""
+	for i++; i > 0; i = (i - 1) / 26 {
+		name = string(rune('A'+(i-1)%26)) + name
+	}
+	return name
+}
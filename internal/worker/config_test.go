@@ -3,6 +3,10 @@ package worker
 import (
 	"log/slog"
 	"testing"
+	"time"
+
+	"github.com/igor-kupczynski/alpha-monday/internal/db"
+	"github.com/igor-kupczynski/alpha-monday/internal/integrations/vcr"
 )
 
 func TestLoadConfigRequiresHatchetToken(t *testing.T) {
@@ -16,6 +20,224 @@ func TestLoadConfigRequiresHatchetToken(t *testing.T) {
 	}
 }
 
+func TestLoadConfigStandaloneEngine(t *testing.T) {
+	t.Setenv("OPENAI_API_KEY", "openai")
+	t.Setenv("ALPHA_VANTAGE_API_KEY", "alpha")
+	t.Setenv("HATCHET_CLIENT_TOKEN", "")
+	t.Setenv("WORKER_ENGINE", "standalone")
+	t.Setenv("STANDALONE_POLL_INTERVAL", "5s")
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Engine != EngineStandalone {
+		t.Fatalf("expected standalone engine, got %q", cfg.Engine)
+	}
+	if cfg.Standalone.PollInterval != 5*time.Second {
+		t.Fatalf("expected 5s poll interval, got %s", cfg.Standalone.PollInterval)
+	}
+
+	t.Setenv("WORKER_ENGINE", "airflow")
+	if _, err := LoadConfig(); err == nil {
+		t.Fatalf("expected error for unknown WORKER_ENGINE")
+	}
+}
+
+func TestLoadConfigPreflight(t *testing.T) {
+	t.Setenv("OPENAI_API_KEY", "openai")
+	t.Setenv("ALPHA_VANTAGE_API_KEY", "alpha")
+	t.Setenv("WORKER_ENGINE", "standalone")
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Preflight != PreflightOff {
+		t.Fatalf("expected preflight off by default, got %q", cfg.Preflight)
+	}
+
+	t.Setenv("WORKER_PREFLIGHT", "Require")
+	cfg, err = LoadConfig()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Preflight != PreflightRequire {
+		t.Fatalf("expected require, got %q", cfg.Preflight)
+	}
+
+	t.Setenv("WORKER_PREFLIGHT", "sometimes")
+	if _, err := LoadConfig(); err == nil {
+		t.Fatalf("expected error for unknown WORKER_PREFLIGHT")
+	}
+}
+
+func TestLoadConfigSchemaCheck(t *testing.T) {
+	t.Setenv("OPENAI_API_KEY", "openai")
+	t.Setenv("ALPHA_VANTAGE_API_KEY", "alpha")
+	t.Setenv("WORKER_ENGINE", "standalone")
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.SchemaCheck != db.SchemaCheckWarn {
+		t.Fatalf("expected schema check warn by default, got %q", cfg.SchemaCheck)
+	}
+
+	t.Setenv("SCHEMA_CHECK", "REQUIRE")
+	cfg, err = LoadConfig()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.SchemaCheck != db.SchemaCheckRequire {
+		t.Fatalf("expected require, got %q", cfg.SchemaCheck)
+	}
+
+	t.Setenv("SCHEMA_CHECK", "strict")
+	if _, err := LoadConfig(); err == nil {
+		t.Fatalf("expected error for unknown SCHEMA_CHECK")
+	}
+}
+
+func TestLoadConfigStaleBatchAfter(t *testing.T) {
+	t.Setenv("OPENAI_API_KEY", "openai")
+	t.Setenv("ALPHA_VANTAGE_API_KEY", "alpha")
+	t.Setenv("WORKER_ENGINE", "standalone")
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.StaleBatchAfter != DefaultStaleBatchAfter {
+		t.Fatalf("expected default stale batch grace, got %s", cfg.StaleBatchAfter)
+	}
+
+	t.Setenv("WORKER_STALE_BATCH_AFTER", "0")
+	cfg, err = LoadConfig()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.StaleBatchAfter != 0 {
+		t.Fatalf("expected sweep disabled, got %s", cfg.StaleBatchAfter)
+	}
+
+	t.Setenv("WORKER_STALE_BATCH_AFTER", "-1h")
+	if _, err := LoadConfig(); err == nil {
+		t.Fatalf("expected error for negative WORKER_STALE_BATCH_AFTER")
+	}
+}
+
+func TestLoadConfigRecentPickWeeks(t *testing.T) {
+	t.Setenv("OPENAI_API_KEY", "openai")
+	t.Setenv("ALPHA_VANTAGE_API_KEY", "alpha")
+	t.Setenv("WORKER_ENGINE", "standalone")
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.RecentPickWeeks != DefaultRecentPickWeeks {
+		t.Fatalf("expected default recent pick window, got %d", cfg.RecentPickWeeks)
+	}
+
+	t.Setenv("WORKER_RECENT_PICK_WEEKS", "0")
+	cfg, err = LoadConfig()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.RecentPickWeeks != 0 {
+		t.Fatalf("expected repeats allowed, got %d", cfg.RecentPickWeeks)
+	}
+
+	t.Setenv("WORKER_RECENT_PICK_WEEKS", "-1")
+	if _, err := LoadConfig(); err == nil {
+		t.Fatalf("expected error for negative WORKER_RECENT_PICK_WEEKS")
+	}
+}
+
+func TestLoadConfigTickerBlocklist(t *testing.T) {
+	t.Setenv("OPENAI_API_KEY", "openai")
+	t.Setenv("ALPHA_VANTAGE_API_KEY", "alpha")
+	t.Setenv("WORKER_ENGINE", "standalone")
+	t.Setenv("TICKER_BLOCKLIST", "msft, aapl")
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(cfg.TickerBlocklist) != 2 || cfg.TickerBlocklist[0] != "AAPL" || cfg.TickerBlocklist[1] != "MSFT" {
+		t.Fatalf("unexpected blocklist %v", cfg.TickerBlocklist)
+	}
+
+	t.Setenv("TICKER_BLOCKLIST", "AAPL;MSFT")
+	if _, err := LoadConfig(); err == nil {
+		t.Fatalf("expected error for malformed TICKER_BLOCKLIST")
+	}
+}
+
+func TestLoadConfigMinPickSectors(t *testing.T) {
+	t.Setenv("OPENAI_API_KEY", "openai")
+	t.Setenv("ALPHA_VANTAGE_API_KEY", "alpha")
+	t.Setenv("WORKER_ENGINE", "standalone")
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.MinPickSectors != DefaultMinPickSectors {
+		t.Fatalf("expected default min sectors, got %d", cfg.MinPickSectors)
+	}
+
+	t.Setenv("PICK_MIN_SECTORS", "4")
+	if _, err := LoadConfig(); err == nil {
+		t.Fatalf("expected error for PICK_MIN_SECTORS above the pick count")
+	}
+}
+
+func TestLoadConfigTemporalEngine(t *testing.T) {
+	t.Setenv("OPENAI_API_KEY", "openai")
+	t.Setenv("ALPHA_VANTAGE_API_KEY", "alpha")
+	t.Setenv("HATCHET_CLIENT_TOKEN", "")
+	t.Setenv("WORKER_ENGINE", "temporal")
+	t.Setenv("TEMPORAL_TASK_QUEUE", "picks")
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := TemporalConfig{HostPort: "localhost:7233", Namespace: "default", TaskQueue: "picks"}
+	if cfg.Engine != EngineTemporal || cfg.Temporal != expected {
+		t.Fatalf("unexpected temporal config %q %+v", cfg.Engine, cfg.Temporal)
+	}
+}
+
+func TestLoadConfigVCRReplayWithoutKeys(t *testing.T) {
+	t.Setenv("OPENAI_API_KEY", "")
+	t.Setenv("ALPHA_VANTAGE_API_KEY", "")
+	t.Setenv("WORKER_ENGINE", "standalone")
+	t.Setenv("INTEGRATIONS_VCR_MODE", "replay")
+	t.Setenv("INTEGRATIONS_VCR_DIR", "/tmp/fixtures")
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.VCR.Mode != vcr.ModeReplay || cfg.VCR.Dir != "/tmp/fixtures" {
+		t.Fatalf("unexpected vcr config: %+v", cfg.VCR)
+	}
+
+	t.Setenv("INTEGRATIONS_VCR_MODE", "record")
+	if _, err := LoadConfig(); err == nil {
+		t.Fatalf("expected api keys to be required when recording")
+	}
+
+	t.Setenv("INTEGRATIONS_VCR_MODE", "rewind")
+	if _, err := LoadConfig(); err == nil {
+		t.Fatalf("expected error for unknown INTEGRATIONS_VCR_MODE")
+	}
+}
+
 func TestLoadConfigDefaults(t *testing.T) {
 	t.Setenv("OPENAI_API_KEY", "openai")
 	t.Setenv("OPENAI_MODEL", "")
@@ -45,4 +267,216 @@ func TestLoadConfigDefaults(t *testing.T) {
 	if cfg.HatchetClientHostPort != "" {
 		t.Fatalf("expected empty hatchet host port, got %q", cfg.HatchetClientHostPort)
 	}
+
+	if cfg.ReasoningLanguage.Code != "en" {
+		t.Fatalf("expected default reasoning language en, got %q", cfg.ReasoningLanguage.Code)
+	}
+}
+
+func TestLoadConfigReasoningLanguage(t *testing.T) {
+	t.Setenv("OPENAI_API_KEY", "openai")
+	t.Setenv("ALPHA_VANTAGE_API_KEY", "alpha")
+	t.Setenv("HATCHET_CLIENT_TOKEN", "token")
+	t.Setenv("REASONING_LANGUAGE", "PL")
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.ReasoningLanguage.Code != "pl" || cfg.ReasoningLanguage.Name != "Polish" {
+		t.Fatalf("expected Polish reasoning language, got %+v", cfg.ReasoningLanguage)
+	}
+
+	t.Setenv("REASONING_LANGUAGE", "klingon")
+	if _, err := LoadConfig(); err == nil {
+		t.Fatalf("expected error for unsupported REASONING_LANGUAGE")
+	}
+}
+
+func TestLoadConfigRateLimits(t *testing.T) {
+	t.Setenv("OPENAI_API_KEY", "openai")
+	t.Setenv("ALPHA_VANTAGE_API_KEY", "alpha")
+	t.Setenv("HATCHET_CLIENT_TOKEN", "token")
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.RateLimits != DefaultRateLimitConfig() {
+		t.Fatalf("expected default rate limits, got %+v", cfg.RateLimits)
+	}
+
+	t.Setenv("ALPHA_VANTAGE_RATE_LIMIT_PER_MINUTE", "75")
+	t.Setenv("ALPHA_VANTAGE_RATE_LIMIT_PER_DAY", "25")
+	t.Setenv("ALPHA_VANTAGE_RATE_LIMIT_UNITS", "5")
+	cfg, err = LoadConfig()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.RateLimits != (RateLimitConfig{PerMinute: 75, PerDay: 25, Units: 5}) {
+		t.Fatalf("unexpected rate limits %+v", cfg.RateLimits)
+	}
+
+	t.Setenv("ALPHA_VANTAGE_RATE_LIMIT_PER_DAY", "0")
+	if _, err := LoadConfig(); err == nil {
+		t.Fatalf("expected error for non-positive ALPHA_VANTAGE_RATE_LIMIT_PER_DAY")
+	}
+
+	t.Setenv("ALPHA_VANTAGE_RATE_LIMIT_PER_DAY", "25")
+	t.Setenv("ALPHA_VANTAGE_RATE_LIMIT_UNITS", "30")
+	if _, err := LoadConfig(); err == nil {
+		t.Fatalf("expected error when units exceed the day limit")
+	}
+}
+
+func TestLoadConfigDBPoolSettings(t *testing.T) {
+	t.Setenv("OPENAI_API_KEY", "openai")
+	t.Setenv("ALPHA_VANTAGE_API_KEY", "alpha")
+	t.Setenv("HATCHET_CLIENT_TOKEN", "token")
+	t.Setenv("DB_QUERY_EXEC_MODE", "simple_protocol")
+	t.Setenv("DB_STATEMENT_CACHE_CAPACITY", "0")
+	t.Setenv("DB_DESCRIPTION_CACHE_CAPACITY", "")
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.DBPool.QueryExecMode != "simple_protocol" {
+		t.Fatalf("expected query exec mode simple_protocol, got %q", cfg.DBPool.QueryExecMode)
+	}
+	if cfg.DBPool.StatementCacheCapacity == nil || *cfg.DBPool.StatementCacheCapacity != 0 {
+		t.Fatalf("expected statement cache capacity 0, got %v", cfg.DBPool.StatementCacheCapacity)
+	}
+	if cfg.DBPool.DescriptionCacheCapacity != nil {
+		t.Fatalf("expected default description cache capacity, got %v", *cfg.DBPool.DescriptionCacheCapacity)
+	}
+	if cfg.DBPool.QueryLog.SlowThreshold != db.DefaultSlowQueryThreshold {
+		t.Fatalf("expected default slow query threshold, got %s", cfg.DBPool.QueryLog.SlowThreshold)
+	}
+
+	t.Setenv("DB_SLOW_QUERY_THRESHOLD", "2s")
+	cfg, err = LoadConfig()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.DBPool.QueryLog.SlowThreshold != 2*time.Second {
+		t.Fatalf("expected slow query threshold 2s, got %s", cfg.DBPool.QueryLog.SlowThreshold)
+	}
+
+	t.Setenv("DB_SLOW_QUERY_THRESHOLD", "fast")
+	if _, err := LoadConfig(); err == nil {
+		t.Fatalf("expected error for invalid DB_SLOW_QUERY_THRESHOLD")
+	}
+	t.Setenv("DB_SLOW_QUERY_THRESHOLD", "")
+
+	t.Setenv("DB_QUERY_EXEC_MODE", "prepared")
+	if _, err := LoadConfig(); err == nil {
+		t.Fatalf("expected error for invalid DB_QUERY_EXEC_MODE")
+	}
+
+	t.Setenv("DB_QUERY_EXEC_MODE", "")
+	t.Setenv("DB_STATEMENT_CACHE_CAPACITY", "-1")
+	if _, err := LoadConfig(); err == nil {
+		t.Fatalf("expected error for negative DB_STATEMENT_CACHE_CAPACITY")
+	}
+}
+
+func TestLoadConfigOutboxSettings(t *testing.T) {
+	t.Setenv("OPENAI_API_KEY", "openai")
+	t.Setenv("ALPHA_VANTAGE_API_KEY", "alpha")
+	t.Setenv("HATCHET_CLIENT_TOKEN", "token")
+	t.Setenv("OUTBOX_SLACK_WEBHOOK_URL", "https://hooks.slack.test/x")
+	t.Setenv("OUTBOX_SMTP_ADDR", "smtp.example.com:587")
+	t.Setenv("OUTBOX_EMAIL_FROM", "alerts@example.com")
+	t.Setenv("OUTBOX_EMAIL_TO", "a@example.com, b@example.com")
+	t.Setenv("OUTBOX_POLL_INTERVAL", "30s")
+	t.Setenv("OUTBOX_MAX_ATTEMPTS", "4")
+	t.Setenv("OUTBOX_API_BASE_URL", "https://api.example.com")
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Outbox.SlackWebhookURL != "https://hooks.slack.test/x" {
+		t.Fatalf("unexpected slack webhook url %q", cfg.Outbox.SlackWebhookURL)
+	}
+	if len(cfg.Outbox.EmailTo) != 2 || cfg.Outbox.EmailTo[1] != "b@example.com" {
+		t.Fatalf("unexpected email recipients %v", cfg.Outbox.EmailTo)
+	}
+	if cfg.Outbox.PollInterval != 30*time.Second {
+		t.Fatalf("expected poll interval 30s, got %v", cfg.Outbox.PollInterval)
+	}
+	if cfg.Outbox.MaxAttempts != 4 {
+		t.Fatalf("expected max attempts 4, got %d", cfg.Outbox.MaxAttempts)
+	}
+	if cfg.Outbox.APIBaseURL != "https://api.example.com" {
+		t.Fatalf("unexpected api base url %q", cfg.Outbox.APIBaseURL)
+	}
+
+	t.Setenv("OUTBOX_EMAIL_TO", "")
+	if _, err := LoadConfig(); err == nil {
+		t.Fatalf("expected error when OUTBOX_EMAIL_TO missing for SMTP sink")
+	}
+}
+
+func TestLoadConfigQuoteFanout(t *testing.T) {
+	t.Setenv("OPENAI_API_KEY", "openai")
+	t.Setenv("ALPHA_VANTAGE_API_KEY", "alpha")
+	t.Setenv("WORKER_ENGINE", "standalone")
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.QuoteFanout != QuoteFanoutParallel || cfg.QuoteConcurrency != DefaultQuoteConcurrency {
+		t.Fatalf("expected parallel fan-out of %d, got %s/%d", DefaultQuoteConcurrency, cfg.QuoteFanout, cfg.QuoteConcurrency)
+	}
+
+	t.Setenv("WORKER_QUOTE_FANOUT", "Sequential")
+	t.Setenv("WORKER_QUOTE_CONCURRENCY", "8")
+	cfg, err = LoadConfig()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.QuoteFanout != QuoteFanoutSequential || cfg.QuoteConcurrency != 8 {
+		t.Fatalf("expected sequential/8, got %s/%d", cfg.QuoteFanout, cfg.QuoteConcurrency)
+	}
+
+	t.Setenv("WORKER_QUOTE_CONCURRENCY", "0")
+	if _, err := LoadConfig(); err == nil {
+		t.Fatalf("expected error for zero WORKER_QUOTE_CONCURRENCY")
+	}
+	t.Setenv("WORKER_QUOTE_CONCURRENCY", "")
+	t.Setenv("WORKER_QUOTE_FANOUT", "burst")
+	if _, err := LoadConfig(); err == nil {
+		t.Fatalf("expected error for unknown WORKER_QUOTE_FANOUT")
+	}
+}
+
+func TestLoadConfigMetricsAddr(t *testing.T) {
+	t.Setenv("OPENAI_API_KEY", "openai")
+	t.Setenv("ALPHA_VANTAGE_API_KEY", "alpha")
+	t.Setenv("WORKER_ENGINE", "standalone")
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.MetricsAddr != "" {
+		t.Fatalf("expected no metrics listener by default, got %q", cfg.MetricsAddr)
+	}
+
+	t.Setenv("WORKER_METRICS_ADDR", ":9091")
+	cfg, err = LoadConfig()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.MetricsAddr != ":9091" {
+		t.Fatalf("expected :9091, got %q", cfg.MetricsAddr)
+	}
+
+	t.Setenv("WORKER_METRICS_ADDR", "9091")
+	if _, err := LoadConfig(); err == nil {
+		t.Fatalf("expected error for WORKER_METRICS_ADDR without a port separator")
+	}
 }
@@ -2,13 +2,17 @@ package worker
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
+	"log/slog"
 	"sync"
 	"testing"
 	"time"
 
 	hatchetworker "github.com/hatchet-dev/hatchet/pkg/worker"
 	"github.com/igor-kupczynski/alpha-monday/internal/db"
+	"github.com/igor-kupczynski/alpha-monday/internal/decimal"
 	"github.com/igor-kupczynski/alpha-monday/internal/integrations/alphavantage"
 )
 
@@ -20,19 +24,27 @@ func (f *fakeClock) Now() time.Time {
 	return f.now
 }
 
-type fakeSleeper struct {
-	clock *fakeClock
-	calls []time.Time
+type fakeOrchestrator struct {
+	clock    *fakeClock
+	sleeps   []time.Time
+	children []string
+	inputs   []any
 }
 
-func (f *fakeSleeper) SleepUntil(ctx durableSleepContext, target time.Time) error {
-	f.calls = append(f.calls, target)
+func (f *fakeOrchestrator) SleepUntil(ctx context.Context, target time.Time) error {
+	f.sleeps = append(f.sleeps, target)
 	if target.After(f.clock.now) {
 		f.clock.now = target
 	}
 	return nil
 }
 
+func (f *fakeOrchestrator) RunChild(ctx context.Context, workflowID string, input any) error {
+	f.children = append(f.children, workflowID)
+	f.inputs = append(f.inputs, input)
+	return nil
+}
+
 type fakeDurableContext struct {
 	context.Context
 	sleepForCalls []time.Duration
@@ -49,6 +61,15 @@ type fakeStore struct {
 	statusUpdates    []string
 	statusBatchIDs   []string
 	createCheckpoint error
+	verification     []db.MetricVerificationRow
+	discrepancies    map[string][]db.MetricDiscrepancy
+	verifySince      time.Time
+	runOutcomes      []string
+	recentTickers    []string
+	recentSince      time.Time
+	sectors          map[string]string
+	// batchStatuses overrides BatchStatus, which reports active otherwise.
+	batchStatuses map[string]string
 }
 
 func (f *fakeStore) CreateBatchWithInitialCheckpoint(ctx context.Context, input db.CreateBatchInput) (db.CreateBatchResult, error) {
@@ -73,6 +94,52 @@ func (f *fakeStore) UpdateBatchStatus(ctx context.Context, batchID string, statu
 	return nil
 }
 
+func (f *fakeStore) BatchStatus(ctx context.Context, batchID string) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if status, ok := f.batchStatuses[batchID]; ok {
+		return status, nil
+	}
+	return "active", nil
+}
+
+func (f *fakeStore) ListMetricsForVerification(ctx context.Context, since time.Time) ([]db.MetricVerificationRow, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.verifySince = since
+	return f.verification, nil
+}
+
+func (f *fakeStore) RecordMetricDiscrepancies(ctx context.Context, batchID string, discrepancies []db.MetricDiscrepancy) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.discrepancies == nil {
+		f.discrepancies = map[string][]db.MetricDiscrepancy{}
+	}
+	f.discrepancies[batchID] = append(f.discrepancies[batchID], discrepancies...)
+	return nil
+}
+
+func (f *fakeStore) RecordRunOutcome(ctx context.Context, batchID, step, outcome string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.runOutcomes = append(f.runOutcomes, step+":"+outcome)
+	return nil
+}
+
+func (f *fakeStore) RecentPickTickers(ctx context.Context, since time.Time) ([]string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.recentSince = since
+	return f.recentTickers, nil
+}
+
+func (f *fakeStore) TickerSectors(ctx context.Context) (map[string]string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.sectors, nil
+}
+
 type sequenceAlpha struct {
 	mu              sync.Mutex
 	nextTradingDay  time.Time
@@ -127,6 +194,74 @@ func (s *staticAlpha) SnapshotPreviousCloses(ctx context.Context, benchmark stri
 	return nil, fmt.Errorf("not implemented")
 }
 
+func TestDailyCheckpointSkipsInactiveBatch(t *testing.T) {
+	store := &fakeStore{batchStatuses: map[string]string{"batch-123": "cancelled"}}
+	alpha := &sequenceAlpha{}
+	steps := NewSteps(store, nil, alpha, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	status, err := steps.dailyCheckpointTask(context.Background(), DailyCheckpointInput{
+		BatchID:         "batch-123",
+		BenchmarkSymbol: "SPY",
+		ScheduledAt:     "2026-01-12T09:00:00Z",
+		MarkCompleted:   true,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if status != checkpointStatusSkipped || len(store.checkpoints) != 0 || len(store.statusUpdates) != 0 {
+		t.Fatalf("expected nothing recorded for a cancelled batch, got %s %+v %v", status, store.checkpoints, store.statusUpdates)
+	}
+}
+
+// historicalAlpha serves closes by day for checkpoint recomputes; the latest
+// quote is never available.
+type historicalAlpha struct {
+	staticAlpha
+	closes map[string]map[string]string
+}
+
+func (h *historicalAlpha) FetchCloseOn(ctx context.Context, symbol, day string) (alphavantage.Quote, error) {
+	return alphavantage.Quote{Symbol: symbol, PreviousClose: h.closes[day][symbol], TradingDay: day}, nil
+}
+
+func TestDailyCheckpointRecomputesDate(t *testing.T) {
+	store := &fakeStore{}
+	steps := &Steps{
+		alphaVantage: &historicalAlpha{closes: map[string]map[string]string{
+			"2026-01-07": {"SPY": "102.00", "AAPL": "50.00"},
+		}},
+		store: store,
+		clock: &fakeClock{now: time.Date(2026, 1, 12, 9, 0, 0, 0, time.UTC)},
+	}
+
+	status, err := steps.dailyCheckpointTask(context.Background(), DailyCheckpointInput{
+		BatchID:               "batch-123",
+		BenchmarkSymbol:       "SPY",
+		BenchmarkInitialPrice: decimal.MustParse("100.00"),
+		Picks:                 []PickState{{PickID: "pick-1", Ticker: "AAPL", InitialPrice: decimal.MustParse("45.00")}},
+		ScheduledAt:           "2026-01-12T09:00:00Z",
+		CheckpointDate:        "2026-01-07",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if status != checkpointStatusComputed || len(store.checkpoints) != 1 {
+		t.Fatalf("expected one computed checkpoint, got %s %+v", status, store.checkpoints)
+	}
+	input := store.checkpoints[0]
+	if want := time.Date(2026, 1, 7, 0, 0, 0, 0, time.UTC); !input.CheckpointDate.Equal(want) {
+		t.Fatalf("expected checkpoint date %s, got %s", want, input.CheckpointDate)
+	}
+	if input.BenchmarkPrice.String() != "102.00" || input.Metrics[0].CurrentPrice.String() != "50.00" {
+		t.Fatalf("expected the day's closes, got %+v", input)
+	}
+
+	steps.alphaVantage = &staticAlpha{}
+	if _, err := steps.recomputeCheckpoint(context.Background(), WeeklyPickState{BenchmarkSymbol: "SPY"}, "2026-01-07"); err == nil {
+		t.Fatalf("expected an error without historical quotes")
+	}
+}
+
 func TestDailyCheckpointLoopSchedulesAndCompletes(t *testing.T) {
 	location, err := time.LoadLocation("America/New_York")
 	if err != nil {
@@ -136,7 +271,7 @@ func TestDailyCheckpointLoopSchedulesAndCompletes(t *testing.T) {
 	runDate := "2026-01-05"
 	startTime := time.Date(2026, 1, 5, 8, 0, 0, 0, location)
 	clock := &fakeClock{now: startTime}
-	sleeper := &fakeSleeper{clock: clock}
+	orch := &fakeOrchestrator{clock: clock}
 
 	alpha := &sequenceAlpha{
 		nextTradingDay:  time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC),
@@ -145,48 +280,46 @@ func TestDailyCheckpointLoopSchedulesAndCompletes(t *testing.T) {
 		benchmarkSymbol: "SPY",
 	}
 
-	var childCalls []DailyCheckpointInput
 	steps := &Steps{
 		alphaVantage: alpha,
 		clock:        clock,
-		sleeper:      sleeper,
-		spawnChildWorkflow: func(ctx durableSleepContext, workflowName string, input any) error {
-			if workflowName != DailyCheckpointWorkflowID {
-				t.Fatalf("expected workflow %q, got %q", DailyCheckpointWorkflowID, workflowName)
-			}
-			payload, ok := input.(DailyCheckpointInput)
-			if !ok {
-				t.Fatalf("expected DailyCheckpointInput, got %T", input)
-			}
-			childCalls = append(childCalls, payload)
-			return nil
-		},
 	}
 
 	state := WeeklyPickState{
 		BatchID:               "batch-123",
 		RunDate:               runDate,
 		BenchmarkSymbol:       "SPY",
-		BenchmarkInitialPrice: "95.00",
+		BenchmarkInitialPrice: decimal.MustParse("95.00"),
 		Picks: []PickState{
-			{PickID: "pick-1", Ticker: "AAPL", InitialPrice: "45.00"},
-			{PickID: "pick-2", Ticker: "MSFT", InitialPrice: "60.00"},
+			{PickID: "pick-1", Ticker: "AAPL", InitialPrice: decimal.MustParse("45.00")},
+			{PickID: "pick-2", Ticker: "MSFT", InitialPrice: decimal.MustParse("60.00")},
 		},
 	}
 
-	ctx := &fakeDurableContext{Context: context.Background()}
-	if err := steps.runDailyCheckpoints(ctx, state); err != nil {
+	if err := steps.runDailyCheckpoints(context.Background(), orch, state); err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
 
 	expectedTargets := expectedDailyTargets(runDate, location)
-	if len(sleeper.calls) != len(expectedTargets) {
-		t.Fatalf("expected %d sleep calls, got %d", len(expectedTargets), len(sleeper.calls))
+	if len(orch.sleeps) != len(expectedTargets) {
+		t.Fatalf("expected %d sleep calls, got %d", len(expectedTargets), len(orch.sleeps))
 	}
 	for i, target := range expectedTargets {
-		if !sleeper.calls[i].Equal(target) {
-			t.Fatalf("expected sleep target %s, got %s", target, sleeper.calls[i])
+		if !orch.sleeps[i].Equal(target) {
+			t.Fatalf("expected sleep target %s, got %s", target, orch.sleeps[i])
+		}
+	}
+
+	var childCalls []DailyCheckpointInput
+	for i, workflowID := range orch.children {
+		if workflowID != DailyCheckpointWorkflowID {
+			t.Fatalf("expected workflow %q, got %q", DailyCheckpointWorkflowID, workflowID)
 		}
+		payload, ok := orch.inputs[i].(DailyCheckpointInput)
+		if !ok {
+			t.Fatalf("expected DailyCheckpointInput, got %T", orch.inputs[i])
+		}
+		childCalls = append(childCalls, payload)
 	}
 
 	if len(childCalls) != dailyCheckpointDays {
@@ -237,9 +370,9 @@ func TestDailyCheckpointMarksBatchCompleted(t *testing.T) {
 	input := DailyCheckpointInput{
 		BatchID:               "batch-999",
 		BenchmarkSymbol:       "SPY",
-		BenchmarkInitialPrice: "95.00",
+		BenchmarkInitialPrice: decimal.MustParse("95.00"),
 		Picks: []PickState{
-			{PickID: "pick-1", Ticker: "AAPL", InitialPrice: "45.00"},
+			{PickID: "pick-1", Ticker: "AAPL", InitialPrice: decimal.MustParse("45.00")},
 		},
 		ScheduledAt:   scheduledAt.Format(time.RFC3339),
 		MarkCompleted: true,
@@ -255,16 +388,136 @@ func TestDailyCheckpointMarksBatchCompleted(t *testing.T) {
 	if len(store.statusBatchIDs) != 1 || store.statusBatchIDs[0] != input.BatchID {
 		t.Fatalf("expected batch_id %q, got %v", input.BatchID, store.statusBatchIDs)
 	}
+	if len(store.runOutcomes) != 1 || store.runOutcomes[0] != DailyCheckpointWorkflowID+":"+db.RunOutcomeSuccess {
+		t.Fatalf("expected one success outcome, got %v", store.runOutcomes)
+	}
+}
+
+func TestDailyCheckpointStoresQuoteFieldsAndPrices(t *testing.T) {
+	location, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatalf("load location: %v", err)
+	}
+
+	store := &fakeStore{}
+	alpha := &staticAlpha{
+		quotes: map[string]alphavantage.Quote{
+			"SPY":  {Symbol: "SPY", PreviousClose: "100.00", TradingDay: "2026-01-05"},
+			"AAPL": {Symbol: "AAPL", PreviousClose: "50.00", TradingDay: "2026-01-05", Open: "49.10", High: "50.40", Low: "48.90", Volume: "1200"},
+		},
+	}
+	steps := &Steps{alphaVantage: alpha, store: store, clock: &fakeClock{now: time.Date(2026, 1, 6, 9, 0, 0, 0, location)}}
+
+	input := DailyCheckpointInput{
+		BatchID:               "batch-1",
+		BenchmarkSymbol:       "SPY",
+		BenchmarkInitialPrice: decimal.MustParse("95.00"),
+		Picks: []PickState{
+			{PickID: "pick-1", Ticker: "AAPL", InitialPrice: decimal.MustParse("45.00")},
+		},
+		ScheduledAt: time.Date(2026, 1, 6, 9, 0, 0, 0, location).Format(time.RFC3339),
+	}
+	if _, err := steps.runDailyCheckpointTask(context.Background(), input); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(store.checkpoints) != 1 {
+		t.Fatalf("expected one checkpoint, got %d", len(store.checkpoints))
+	}
+	checkpoint := store.checkpoints[0]
+	metric := checkpoint.Metrics[0]
+	if metric.OpenPrice == nil || !metric.OpenPrice.Equal(decimal.MustParse("49.10")) || metric.Volume == nil || *metric.Volume != 1200 {
+		t.Fatalf("expected session fields on metric, got %+v", metric)
+	}
+	if len(checkpoint.Prices) != 2 {
+		t.Fatalf("expected benchmark and pick prices, got %+v", checkpoint.Prices)
+	}
+	for _, price := range checkpoint.Prices {
+		if price.TradingDay.Format("2006-01-02") != "2026-01-05" {
+			t.Fatalf("unexpected trading day for %s: %s", price.Ticker, price.TradingDay)
+		}
+	}
+	if checkpoint.Prices[0].Ticker != "SPY" || checkpoint.Prices[0].Open != nil || checkpoint.Prices[1].Ticker != "AAPL" || !checkpoint.Prices[1].Close.Equal(decimal.MustParse("50.00")) {
+		t.Fatalf("unexpected prices: %+v", checkpoint.Prices)
+	}
 }
 
-func TestRealSleeperUsesDurableSleep(t *testing.T) {
+func TestDailyCheckpointTaskRecordsOutcomes(t *testing.T) {
+	location, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatalf("load location: %v", err)
+	}
+
+	store := &fakeStore{}
+	alpha := &staticAlpha{
+		quotes: map[string]alphavantage.Quote{
+			"SPY": {Symbol: "SPY", PreviousClose: "", TradingDay: ""},
+		},
+	}
+	steps := &Steps{
+		alphaVantage: alpha,
+		store:        store,
+		clock:        &fakeClock{now: time.Date(2026, 1, 6, 9, 0, 0, 0, location)},
+	}
+	input := DailyCheckpointInput{
+		BatchID:               "batch-999",
+		BenchmarkSymbol:       "SPY",
+		BenchmarkInitialPrice: decimal.MustParse("95.00"),
+		Picks: []PickState{
+			{PickID: "pick-1", Ticker: "AAPL", InitialPrice: decimal.MustParse("45.00")},
+		},
+		ScheduledAt: time.Date(2026, 1, 6, 9, 0, 0, 0, location).Format(time.RFC3339),
+	}
+
+	if _, err := steps.runDailyCheckpointTask(context.Background(), input); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	store.createCheckpoint = fmt.Errorf("db down")
+	if _, err := steps.runDailyCheckpointTask(context.Background(), input); err == nil {
+		t.Fatalf("expected error when the checkpoint cannot be stored")
+	}
+
+	expected := []string{DailyCheckpointWorkflowID + ":" + db.RunOutcomeSkip, DailyCheckpointWorkflowID + ":" + db.RunOutcomeFailure}
+	if fmt.Sprint(store.runOutcomes) != fmt.Sprint(expected) {
+		t.Fatalf("expected outcomes %v, got %v", expected, store.runOutcomes)
+	}
+}
+
+func TestDailyCheckpointTaskMarksBatchFailedOnUnrecoverableError(t *testing.T) {
+	store := &fakeStore{}
+	steps := &Steps{
+		alphaVantage: &staticAlpha{},
+		store:        store,
+		logger:       slog.Default(),
+		clock:        &fakeClock{now: time.Date(2026, 1, 6, 9, 0, 0, 0, time.UTC)},
+	}
+
+	_, err := steps.runDailyCheckpointTask(context.Background(), DailyCheckpointInput{BatchID: "batch-1", ScheduledAt: "yesterday"})
+	if !errors.Is(err, errUnrecoverable) {
+		t.Fatalf("expected unrecoverable error, got %v", err)
+	}
+	if len(store.statusUpdates) != 1 || store.statusUpdates[0] != batchStatusFailed {
+		t.Fatalf("expected batch marked failed, got %v", store.statusUpdates)
+	}
+
+	store.createCheckpoint = fmt.Errorf("db down")
+	_, err = steps.runDailyCheckpointTask(context.Background(), DailyCheckpointInput{BatchID: "batch-1", ScheduledAt: time.Date(2026, 1, 6, 9, 0, 0, 0, time.UTC).Format(time.RFC3339)})
+	if err == nil || errors.Is(err, errUnrecoverable) {
+		t.Fatalf("expected a retryable error, got %v", err)
+	}
+	if len(store.statusUpdates) != 1 {
+		t.Fatalf("expected no status change for retryable errors, got %v", store.statusUpdates)
+	}
+}
+
+func TestHatchetOrchestratorUsesDurableSleep(t *testing.T) {
 	now := time.Date(2026, 1, 5, 8, 0, 0, 0, time.UTC)
 	clock := &fakeClock{now: now}
-	sleeper := realSleeper{clock: clock}
 	ctx := &fakeDurableContext{Context: context.Background()}
+	orch := hatchetOrchestrator{ctx: ctx, clock: clock}
 
 	target := now.Add(2 * time.Hour)
-	if err := sleeper.SleepUntil(ctx, target); err != nil {
+	if err := orch.SleepUntil(ctx, target); err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
 	if len(ctx.sleepForCalls) != 1 {
@@ -275,7 +528,7 @@ func TestRealSleeperUsesDurableSleep(t *testing.T) {
 	}
 
 	past := now.Add(-5 * time.Minute)
-	if err := sleeper.SleepUntil(ctx, past); err != nil {
+	if err := orch.SleepUntil(ctx, past); err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
 	if len(ctx.sleepForCalls) != 1 {
@@ -301,21 +554,20 @@ func TestDailyCheckpointSkippedWhenBenchmarkMissing(t *testing.T) {
 		alphaVantage: alpha,
 		store:        store,
 		clock:        clock,
-		sleeper:      &fakeSleeper{clock: clock},
 	}
 
 	state := WeeklyPickState{
 		BatchID:               "batch-456",
 		RunDate:               "2026-01-05",
 		BenchmarkSymbol:       "SPY",
-		BenchmarkInitialPrice: "100.00",
+		BenchmarkInitialPrice: decimal.MustParse("100.00"),
 		Picks: []PickState{
-			{PickID: "pick-1", Ticker: "AAPL", InitialPrice: "45.00"},
+			{PickID: "pick-1", Ticker: "AAPL", InitialPrice: decimal.MustParse("45.00")},
 		},
 	}
 
 	scheduledAt := time.Date(2026, 1, 6, 9, 0, 0, 0, location)
-	if err := steps.runDailyCheckpoint(context.Background(), state, scheduledAt); err != nil {
+	if _, err := steps.runDailyCheckpoint(context.Background(), state, scheduledAt); err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
 
@@ -357,21 +609,20 @@ func TestDailyCheckpointSkippedWhenPickMissing(t *testing.T) {
 		alphaVantage: alpha,
 		store:        store,
 		clock:        clock,
-		sleeper:      &fakeSleeper{clock: clock},
 	}
 
 	state := WeeklyPickState{
 		BatchID:               "batch-789",
 		RunDate:               "2026-01-05",
 		BenchmarkSymbol:       "SPY",
-		BenchmarkInitialPrice: "100.00",
+		BenchmarkInitialPrice: decimal.MustParse("100.00"),
 		Picks: []PickState{
-			{PickID: "pick-1", Ticker: "AAPL", InitialPrice: "45.00"},
+			{PickID: "pick-1", Ticker: "AAPL", InitialPrice: decimal.MustParse("45.00")},
 		},
 	}
 
 	scheduledAt := time.Date(2026, 1, 6, 9, 0, 0, 0, location)
-	if err := steps.runDailyCheckpoint(context.Background(), state, scheduledAt); err != nil {
+	if _, err := steps.runDailyCheckpoint(context.Background(), state, scheduledAt); err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
 
@@ -398,39 +649,108 @@ func TestDailyCheckpointSkippedWhenPickMissing(t *testing.T) {
 	}
 }
 
+// blockingAlpha fails one symbol and blocks the others until their context
+// is cancelled, tracking the peak number of concurrent fetches.
+type blockingAlpha struct {
+	mu       sync.Mutex
+	failing  string
+	inFlight int
+	peak     int
+	started  []string
+}
+
+func (b *blockingAlpha) FetchPreviousClose(ctx context.Context, symbol string) (alphavantage.Quote, error) {
+	b.mu.Lock()
+	b.inFlight++
+	b.peak = max(b.peak, b.inFlight)
+	b.started = append(b.started, symbol)
+	b.mu.Unlock()
+	defer func() {
+		b.mu.Lock()
+		b.inFlight--
+		b.mu.Unlock()
+	}()
+
+	if symbol == b.failing {
+		time.Sleep(10 * time.Millisecond)
+		return alphavantage.Quote{}, errors.New("quote failed")
+	}
+	<-ctx.Done()
+	return alphavantage.Quote{}, ctx.Err()
+}
+
+func (b *blockingAlpha) SnapshotPreviousCloses(ctx context.Context, benchmark string, picks []string) (map[string]alphavantage.Quote, error) {
+	return nil, fmt.Errorf("not implemented")
+}
+
+func TestFetchPickQuotesCancelsOnFirstError(t *testing.T) {
+	picks := []PickState{{Ticker: "AAPL"}, {Ticker: "MSFT"}, {Ticker: "NVDA"}, {Ticker: "AMZN"}}
+
+	alpha := &blockingAlpha{failing: "MSFT"}
+	steps := &Steps{alphaVantage: alpha, quoteConcurrency: 2}
+	done := make(chan error, 1)
+	go func() {
+		_, err := steps.fetchPickQuotes(context.Background(), picks)
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err == nil || err.Error() != "quote failed" {
+			t.Fatalf("expected the first failure, got %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("fetchPickQuotes did not return after a failure")
+	}
+	if alpha.inFlight != 0 {
+		t.Fatalf("expected no fetches left running, got %d", alpha.inFlight)
+	}
+	if alpha.peak != 2 {
+		t.Fatalf("expected 2 concurrent fetches, got %d", alpha.peak)
+	}
+	if len(alpha.started) != 2 {
+		t.Fatalf("expected no fetches started after the failure, got %v", alpha.started)
+	}
+
+	sequential := &blockingAlpha{failing: "AAPL"}
+	steps = &Steps{alphaVantage: sequential, quoteFanout: QuoteFanoutSequential, quoteConcurrency: 4}
+	if _, err := steps.fetchPickQuotes(context.Background(), picks); err == nil {
+		t.Fatalf("expected error")
+	}
+	if sequential.peak != 1 || len(sequential.started) != 1 {
+		t.Fatalf("expected one sequential fetch before stopping, got peak %d started %v", sequential.peak, sequential.started)
+	}
+}
+
 func TestComputeMetrics(t *testing.T) {
-	benchmarkReturn, err := calculateReturnPct("100", "95")
+	benchmarkReturn, err := calculateReturnPct(decimal.MustParse("100"), decimal.MustParse("95"))
 	if err != nil {
 		t.Fatalf("benchmark return: %v", err)
 	}
-	absoluteReturn, err := calculateReturnPct("50", "55")
+	absoluteReturn, err := calculateReturnPct(decimal.MustParse("50"), decimal.MustParse("55"))
 	if err != nil {
 		t.Fatalf("absolute return: %v", err)
 	}
-	vsBenchmark, err := subtractDecimalStrings(absoluteReturn, benchmarkReturn)
-	if err != nil {
-		t.Fatalf("vs benchmark: %v", err)
-	}
+	vsBenchmark := absoluteReturn.Sub(benchmarkReturn)
 
-	if benchmarkReturn != "-5.00000000" {
+	if benchmarkReturn.String() != "-5.00000000" {
 		t.Fatalf("expected benchmark return -5.00000000, got %s", benchmarkReturn)
 	}
-	if absoluteReturn != "10.00000000" {
+	if absoluteReturn.String() != "10.00000000" {
 		t.Fatalf("expected absolute return 10.00000000, got %s", absoluteReturn)
 	}
-	if vsBenchmark != "15.00000000" {
+	if vsBenchmark.String() != "15.00000000" {
 		t.Fatalf("expected vs benchmark 15.00000000, got %s", vsBenchmark)
 	}
 }
 
 func TestComputeMetricsRejectsInvalidInputs(t *testing.T) {
-	if _, err := calculateReturnPct("0", "100"); err == nil {
+	if _, err := calculateReturnPct(decimal.MustParse("0"), decimal.MustParse("100")); err == nil {
 		t.Fatalf("expected error for zero initial price")
 	}
-	if _, err := calculateReturnPct("-1", "100"); err == nil {
+	if _, err := calculateReturnPct(decimal.MustParse("-1"), decimal.MustParse("100")); err == nil {
 		t.Fatalf("expected error for negative initial price")
 	}
-	if _, err := calculateReturnPct("100", "-1"); err == nil {
+	if _, err := calculateReturnPct(decimal.MustParse("100"), decimal.MustParse("-1")); err == nil {
 		t.Fatalf("expected error for negative current price")
 	}
 }
@@ -0,0 +1,147 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net"
+	"strconv"
+	"time"
+
+	hatchetclient "github.com/hatchet-dev/hatchet/pkg/client"
+	hatchetworker "github.com/hatchet-dev/hatchet/pkg/worker"
+	hatchet "github.com/hatchet-dev/hatchet/sdks/go"
+)
+
+// NewHatchetClient connects to Hatchet with token. hostPort overrides the
+// engine address from the token when set.
+func NewHatchetClient(token, hostPort string) (*hatchet.Client, error) {
+	clientOpts := []hatchetclient.ClientOpt{
+		hatchetclient.WithToken(token),
+	}
+	if hostPort != "" {
+		host, portStr, err := net.SplitHostPort(hostPort)
+		if err != nil {
+			return nil, fmt.Errorf("invalid HATCHET_CLIENT_HOST_PORT: %w", err)
+		}
+		port, err := strconv.Atoi(portStr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid HATCHET_CLIENT_HOST_PORT port: %w", err)
+		}
+		clientOpts = append(clientOpts, hatchetclient.WithHostPort(host, port))
+	}
+	return hatchet.NewClient(clientOpts...)
+}
+
+// HatchetRunner triggers workflow runs from outside a worker, e.g. the API's
+// admin endpoints.
+type HatchetRunner struct {
+	client *hatchet.Client
+}
+
+func NewHatchetRunner(client *hatchet.Client) *HatchetRunner {
+	return &HatchetRunner{client: client}
+}
+
+// RunWorkflow enqueues workflowID with input and returns the run id without
+// waiting for the run to finish.
+func (r *HatchetRunner) RunWorkflow(ctx context.Context, workflowID string, input any) (string, error) {
+	ref, err := r.client.RunNoWait(ctx, workflowID, input)
+	if err != nil {
+		return "", err
+	}
+	return ref.RunId, nil
+}
+
+// HatchetEngine runs the workflows on a Hatchet worker.
+type HatchetEngine struct {
+	client     *hatchet.Client
+	workerName string
+	limits     RateLimitConfig
+	logger     *slog.Logger
+	worker     *hatchet.Worker
+	// limiter paces replacement quotes in the snapshot step. Hatchet acquires
+	// rate limit units only when a step starts, so later calls cannot draw
+	// on the shared limits.
+	limiter *callLimiter
+}
+
+func NewHatchetEngine(client *hatchet.Client, workerName string, limits RateLimitConfig, logger *slog.Logger) *HatchetEngine {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &HatchetEngine{client: client, workerName: workerName, limits: limits, logger: logger, limiter: newCallLimiter(limits)}
+}
+
+// Register upserts the Alpha Vantage rate limits and registers every workflow,
+// including cron triggers, with a new Hatchet worker.
+func (e *HatchetEngine) Register(steps *Steps) error {
+	if err := ConfigureRateLimits(e.client, e.limits, e.logger); err != nil {
+		return fmt.Errorf("configure rate limits: %w", err)
+	}
+	if steps != nil {
+		steps.reserveQuotes = e.limiter.wait
+	}
+	workflows, err := BuildWorkflows(e.client, e.logger, steps, e.limits)
+	if err != nil {
+		return fmt.Errorf("build workflows: %w", err)
+	}
+	w, err := e.client.NewWorker(e.workerName, hatchet.WithWorkflows(workflows...))
+	if err != nil {
+		return fmt.Errorf("init worker: %w", err)
+	}
+	e.worker = w
+	return nil
+}
+
+func (e *HatchetEngine) Start() (func() error, error) {
+	if e.worker == nil {
+		return nil, fmt.Errorf("workflows are not registered")
+	}
+	return e.worker.Start()
+}
+
+type hatchetDurableContext interface {
+	context.Context
+	SleepFor(duration time.Duration) (*hatchetworker.SingleWaitResult, error)
+}
+
+// hatchetOrchestrator implements Orchestrator on top of a durable task
+// context: sleeps are durable and children run as Hatchet workflows.
+type hatchetOrchestrator struct {
+	ctx   hatchetDurableContext
+	clock Clock
+}
+
+func (o hatchetOrchestrator) SleepUntil(ctx context.Context, target time.Time) error {
+	if o.clock == nil {
+		o.clock = realClock{}
+	}
+	now := o.clock.Now()
+	if !target.After(now) {
+		return nil
+	}
+	if o.ctx == nil {
+		return fmt.Errorf("durable context is required for sleep")
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	_, err := o.ctx.SleepFor(target.Sub(now))
+	return err
+}
+
+func (o hatchetOrchestrator) RunChild(_ context.Context, workflowID string, input any) error {
+	spawner, ok := o.ctx.(interface {
+		SpawnWorkflow(workflowName string, input any, opts *hatchetworker.SpawnWorkflowOpts) (*hatchetclient.Workflow, error)
+	})
+	if !ok {
+		return fmt.Errorf("durable context does not support SpawnWorkflow")
+	}
+	workflow, err := spawner.SpawnWorkflow(workflowID, input, nil)
+	if err != nil {
+		return err
+	}
+	_, err = workflow.Result()
+	return err
+}
@@ -0,0 +1,116 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	hatchet "github.com/hatchet-dev/hatchet/sdks/go"
+	"github.com/igor-kupczynski/alpha-monday/internal/db"
+	"github.com/igor-kupczynski/alpha-monday/internal/decimal"
+)
+
+const (
+	defaultVerificationLookbackDays = 2
+)
+
+// metricVerificationTolerance is the allowed difference, in percentage points,
+// between a stored metric and its recomputed value.
+var metricVerificationTolerance = decimal.MustParse("0.000001")
+
+type VerifyMetricsInput struct {
+	LookbackDays int `json:"lookback_days"`
+}
+
+type VerifyMetricsOutput struct {
+	Checked       int                    `json:"checked"`
+	Discrepancies []db.MetricDiscrepancy `json:"discrepancies"`
+}
+
+func (s *Steps) VerifyMetrics(ctx hatchet.Context, input VerifyMetricsInput) (*VerifyMetricsOutput, error) {
+	return s.verifyMetrics(ctx, input)
+}
+
+// verifyMetrics recomputes absolute_return_pct and vs_benchmark_pct from the
+// stored prices of recently created checkpoints and flags rows that disagree
+// beyond metricVerificationTolerance.
+func (s *Steps) verifyMetrics(ctx context.Context, input VerifyMetricsInput) (*VerifyMetricsOutput, error) {
+	if s.store == nil {
+		return nil, fmt.Errorf("db store not configured")
+	}
+	lookbackDays := input.LookbackDays
+	if lookbackDays <= 0 {
+		lookbackDays = defaultVerificationLookbackDays
+	}
+	since := s.clock.Now().Add(-time.Duration(lookbackDays) * 24 * time.Hour)
+	rows, err := s.store.ListMetricsForVerification(ctx, since)
+	if err != nil {
+		return nil, fmt.Errorf("list metrics: %w", err)
+	}
+
+	output := &VerifyMetricsOutput{Checked: len(rows), Discrepancies: []db.MetricDiscrepancy{}}
+	byBatch := map[string][]db.MetricDiscrepancy{}
+	batchOrder := []string{}
+	for _, row := range rows {
+		discrepancies, err := verifyMetricRow(row, metricVerificationTolerance)
+		if err != nil {
+			return nil, fmt.Errorf("verify metric %s: %w", row.MetricID, err)
+		}
+		if len(discrepancies) == 0 {
+			continue
+		}
+		if _, ok := byBatch[row.BatchID]; !ok {
+			batchOrder = append(batchOrder, row.BatchID)
+		}
+		byBatch[row.BatchID] = append(byBatch[row.BatchID], discrepancies...)
+		output.Discrepancies = append(output.Discrepancies, discrepancies...)
+	}
+
+	for _, batchID := range batchOrder {
+		s.logger.Warn("metric discrepancies found", "batch_id", batchID, "discrepancies", byBatch[batchID])
+		if err := s.store.RecordMetricDiscrepancies(ctx, batchID, byBatch[batchID]); err != nil {
+			return nil, fmt.Errorf("record metric discrepancies: %w", err)
+		}
+	}
+
+	s.logger.Info("metrics verified", "checked", output.Checked, "discrepancies", len(output.Discrepancies))
+	return output, nil
+}
+
+func verifyMetricRow(row db.MetricVerificationRow, tolerance decimal.Decimal) ([]db.MetricDiscrepancy, error) {
+	absoluteReturn, err := calculateReturnPct(row.InitialPrice, row.CurrentPrice)
+	if err != nil {
+		return nil, err
+	}
+	benchmarkReturn, err := calculateReturnPct(row.BenchmarkInitialPrice, row.BenchmarkPrice)
+	if err != nil {
+		return nil, err
+	}
+	vsBenchmark := absoluteReturn.Sub(benchmarkReturn)
+
+	checks := []struct {
+		field    string
+		stored   decimal.Decimal
+		expected decimal.Decimal
+	}{
+		{field: "absolute_return_pct", stored: row.AbsoluteReturnPct, expected: absoluteReturn},
+		{field: "vs_benchmark_pct", stored: row.VsBenchmarkPct, expected: vsBenchmark},
+	}
+
+	var discrepancies []db.MetricDiscrepancy
+	for _, check := range checks {
+		if check.stored.Sub(check.expected).Abs().Cmp(tolerance) <= 0 {
+			continue
+		}
+		discrepancies = append(discrepancies, db.MetricDiscrepancy{
+			MetricID:       row.MetricID,
+			CheckpointID:   row.CheckpointID,
+			CheckpointDate: row.CheckpointDate,
+			Ticker:         row.Ticker,
+			Field:          check.field,
+			Stored:         check.stored,
+			Expected:       check.expected,
+		})
+	}
+	return discrepancies, nil
+}
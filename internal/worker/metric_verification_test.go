@@ -0,0 +1,91 @@
+package worker
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/igor-kupczynski/alpha-monday/internal/db"
+	"github.com/igor-kupczynski/alpha-monday/internal/decimal"
+)
+
+func TestVerifyMetricsFlagsDiscrepancies(t *testing.T) {
+	store := &fakeStore{
+		verification: []db.MetricVerificationRow{
+			{
+				MetricID:              "m1",
+				BatchID:               "b1",
+				CheckpointID:          "c1",
+				CheckpointDate:        "2026-02-03",
+				Ticker:                "AAPL",
+				InitialPrice:          decimal.MustParse("100"),
+				CurrentPrice:          decimal.MustParse("110"),
+				BenchmarkInitialPrice: decimal.MustParse("200"),
+				BenchmarkPrice:        decimal.MustParse("210"),
+				AbsoluteReturnPct:     decimal.MustParse("10.00000000"),
+				VsBenchmarkPct:        decimal.MustParse("5.00000000"),
+			},
+			{
+				MetricID:              "m2",
+				BatchID:               "b1",
+				CheckpointID:          "c1",
+				CheckpointDate:        "2026-02-03",
+				Ticker:                "MSFT",
+				InitialPrice:          decimal.MustParse("50"),
+				CurrentPrice:          decimal.MustParse("45"),
+				BenchmarkInitialPrice: decimal.MustParse("200"),
+				BenchmarkPrice:        decimal.MustParse("210"),
+				AbsoluteReturnPct:     decimal.MustParse("-10.00000000"),
+				VsBenchmarkPct:        decimal.MustParse("-14.00000000"),
+			},
+		},
+	}
+	now := time.Date(2026, 2, 4, 10, 30, 0, 0, time.UTC)
+	steps := NewSteps(store, nil, nil, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	steps.clock = &fakeClock{now: now}
+
+	output, err := steps.verifyMetrics(context.Background(), VerifyMetricsInput{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if output.Checked != 2 {
+		t.Fatalf("expected 2 checked metrics, got %d", output.Checked)
+	}
+	if want := now.Add(-48 * time.Hour); !store.verifySince.Equal(want) {
+		t.Fatalf("expected default lookback since %v, got %v", want, store.verifySince)
+	}
+	if len(output.Discrepancies) != 1 {
+		t.Fatalf("expected 1 discrepancy, got %+v", output.Discrepancies)
+	}
+	discrepancy := output.Discrepancies[0]
+	if discrepancy.MetricID != "m2" || discrepancy.Field != "vs_benchmark_pct" || discrepancy.Expected.String() != "-15.00000000" {
+		t.Fatalf("unexpected discrepancy %+v", discrepancy)
+	}
+	if len(store.discrepancies["b1"]) != 1 {
+		t.Fatalf("expected discrepancy recorded for batch b1, got %+v", store.discrepancies)
+	}
+}
+
+func TestVerifyMetricsRejectsNonPositivePrice(t *testing.T) {
+	store := &fakeStore{
+		verification: []db.MetricVerificationRow{
+			{
+				MetricID:              "m1",
+				BatchID:               "b1",
+				InitialPrice:          decimal.MustParse("0"),
+				CurrentPrice:          decimal.MustParse("110"),
+				BenchmarkInitialPrice: decimal.MustParse("200"),
+				BenchmarkPrice:        decimal.MustParse("210"),
+				AbsoluteReturnPct:     decimal.MustParse("10"),
+				VsBenchmarkPct:        decimal.MustParse("5"),
+			},
+		},
+	}
+	steps := NewSteps(store, nil, nil, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	if _, err := steps.verifyMetrics(context.Background(), VerifyMetricsInput{LookbackDays: 30}); err == nil {
+		t.Fatalf("expected error for zero initial price")
+	}
+}
@@ -0,0 +1,24 @@
+package worker
+
+import (
+	"context"
+	"time"
+)
+
+// Orchestrator is the durable-execution surface the workflow logic depends on.
+// Each engine provides an implementation bound to the current run, so Steps
+// carries no engine types and can be exercised with fakes.
+type Orchestrator interface {
+	// SleepUntil durably suspends the run until target. It returns immediately
+	// when target is not in the future.
+	SleepUntil(ctx context.Context, target time.Time) error
+	// RunChild starts workflowID with input and waits for it to finish.
+	RunChild(ctx context.Context, workflowID string, input any) error
+}
+
+// Engine hosts the workflows: it registers them together with their cron
+// schedules and rate limits, then runs a worker until cleanup is called.
+type Engine interface {
+	Register(steps *Steps) error
+	Start() (cleanup func() error, err error)
+}
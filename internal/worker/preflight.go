@@ -0,0 +1,93 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Preflight modes selectable with WORKER_PREFLIGHT.
+const (
+	PreflightOff     = "off"
+	PreflightLog     = "log"
+	PreflightRequire = "require"
+)
+
+const preflightTimeout = 30 * time.Second
+
+// PreflightSymbol is the quote fetched to probe Alpha Vantage.
+const PreflightSymbol = defaultBenchmarkSymbol
+
+// ProviderProbe is a lightweight credential check against one external
+// provider.
+type ProviderProbe struct {
+	Name  string
+	Probe func(ctx context.Context) error
+}
+
+// PreflightMetrics exports the outcome of the startup provider probes.
+type PreflightMetrics struct {
+	up *prometheus.GaugeVec
+}
+
+// NewPreflightMetrics creates the probe gauge and registers it with registerer.
+func NewPreflightMetrics(registerer prometheus.Registerer) (*PreflightMetrics, error) {
+	metrics := &PreflightMetrics{
+		up: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: "alpha_monday",
+			Subsystem: "worker",
+			Name:      "provider_preflight_up",
+			Help:      "1 when the provider accepted the startup credential probe, 0 when it failed.",
+		}, []string{"provider"}),
+	}
+	if registerer != nil {
+		if err := registerer.Register(metrics.up); err != nil {
+			return nil, err
+		}
+	}
+	return metrics, nil
+}
+
+func (m *PreflightMetrics) set(provider string, ok bool) {
+	if m == nil {
+		return
+	}
+	value := 0.0
+	if ok {
+		value = 1
+	}
+	m.up.WithLabelValues(provider).Set(value)
+}
+
+// RunPreflight runs every probe, logging and exporting each outcome. It
+// returns an error naming the failed providers, so a bad key surfaces at
+// startup rather than in the Monday run.
+func RunPreflight(ctx context.Context, probes []ProviderProbe, metrics *PreflightMetrics, logger *slog.Logger) error {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	var failed []string
+	for _, probe := range probes {
+		probeCtx, cancel := context.WithTimeout(ctx, preflightTimeout)
+		start := time.Now()
+		err := probe.Probe(probeCtx)
+		cancel()
+		duration := time.Since(start)
+
+		metrics.set(probe.Name, err == nil)
+		if err != nil {
+			failed = append(failed, probe.Name)
+			logger.Error("provider preflight failed", "provider", probe.Name, "duration_ms", duration.Milliseconds(), "error", err)
+			continue
+		}
+		logger.Info("provider preflight passed", "provider", probe.Name, "duration_ms", duration.Milliseconds())
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("provider preflight failed: %s", strings.Join(failed, ", "))
+	}
+	return nil
+}
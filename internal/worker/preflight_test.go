@@ -0,0 +1,33 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestRunPreflight(t *testing.T) {
+	metrics, err := NewPreflightMetrics(prometheus.NewRegistry())
+	if err != nil {
+		t.Fatalf("metrics: %v", err)
+	}
+	probes := []ProviderProbe{
+		{Name: "openai", Probe: func(ctx context.Context) error { return nil }},
+		{Name: "alphavantage", Probe: func(ctx context.Context) error { return errors.New("invalid key") }},
+	}
+
+	err = RunPreflight(context.Background(), probes, metrics, nil)
+	if err == nil || !strings.Contains(err.Error(), "alphavantage") || strings.Contains(err.Error(), "openai") {
+		t.Fatalf("expected only alphavantage to fail, got %v", err)
+	}
+	if got := testutil.ToFloat64(metrics.up.WithLabelValues("openai")); got != 1 {
+		t.Fatalf("expected openai up, got %v", got)
+	}
+	if got := testutil.ToFloat64(metrics.up.WithLabelValues("alphavantage")); got != 0 {
+		t.Fatalf("expected alphavantage down, got %v", got)
+	}
+}
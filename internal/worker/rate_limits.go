@@ -3,29 +3,87 @@ package worker
 import (
 	"fmt"
 	"log/slog"
+	"strconv"
 
 	"github.com/hatchet-dev/hatchet/pkg/client/types"
 	hatchet "github.com/hatchet-dev/hatchet/sdks/go"
 	"github.com/hatchet-dev/hatchet/sdks/go/features"
+	"github.com/igor-kupczynski/alpha-monday/internal/integrations/openai"
 )
 
-func ConfigureRateLimits(client *hatchet.Client, logger *slog.Logger) error {
+// alphaVantageBenchmarkCalls is the number of benchmark quotes fetched per
+// rate-limited step run, on top of one quote per pick.
+const alphaVantageBenchmarkCalls = 1
+
+// RateLimitConfig sizes the Hatchet rate limits guarding Alpha Vantage calls.
+// The defaults match the historical free tier; premium keys can raise them and
+// the current free tier (25/day) needs PerDay lowered.
+type RateLimitConfig struct {
+	PerMinute int
+	PerDay    int
+	// Units overrides the units each rate-limited step run consumes. Zero
+	// derives them from the number of Alpha Vantage calls the step makes.
+	Units int
+}
+
+// DefaultRateLimitConfig returns the 5/min, 500/day limits with derived units.
+func DefaultRateLimitConfig() RateLimitConfig {
+	return RateLimitConfig{
+		PerMinute: alphaVantageRateLimitMaxMinute,
+		PerDay:    alphaVantageRateLimitMaxDay,
+	}
+}
+
+// Validate rejects limits a step could never satisfy.
+func (c RateLimitConfig) Validate() error {
+	if c.PerMinute <= 0 || c.PerDay <= 0 || c.Units < 0 {
+		return fmt.Errorf("rate limits must be positive and units non-negative")
+	}
+	units := c.stepUnits(openai.PickCount)
+	if units > c.PerMinute || units > c.PerDay {
+		return fmt.Errorf("units per step (%d) exceed the minute (%d) or day (%d) limit", units, c.PerMinute, c.PerDay)
+	}
+	return nil
+}
+
+// stepUnits returns the units for a step that quotes pickCount picks and the
+// benchmark.
+func (c RateLimitConfig) stepUnits(pickCount int) int {
+	if c.Units > 0 {
+		return c.Units
+	}
+	return pickCount + alphaVantageBenchmarkCalls
+}
+
+// stepUnitsExpr returns a CEL expression computing the units from the
+// size of the step input's picks list, or "" when units are overridden.
+func (c RateLimitConfig) stepUnitsExpr(picksField string) string {
+	if c.Units > 0 {
+		return ""
+	}
+	return "size(input." + picksField + ") + " + strconv.Itoa(alphaVantageBenchmarkCalls)
+}
+
+func ConfigureRateLimits(client *hatchet.Client, limits RateLimitConfig, logger *slog.Logger) error {
 	if client == nil {
 		return fmt.Errorf("hatchet client is required")
 	}
 	if logger == nil {
 		logger = slog.Default()
 	}
+	if err := limits.Validate(); err != nil {
+		return err
+	}
 	if err := client.RateLimits().Upsert(features.CreateRatelimitOpts{
 		Key:      alphaVantageRateLimitMinuteKey,
-		Limit:    alphaVantageRateLimitMaxMinute,
+		Limit:    limits.PerMinute,
 		Duration: types.Minute,
 	}); err != nil {
 		return fmt.Errorf("configure minute rate limit: %w", err)
 	}
 	if err := client.RateLimits().Upsert(features.CreateRatelimitOpts{
 		Key:      alphaVantageRateLimitDayKey,
-		Limit:    alphaVantageRateLimitMaxDay,
+		Limit:    limits.PerDay,
 		Duration: types.Day,
 	}); err != nil {
 		return fmt.Errorf("configure day rate limit: %w", err)
@@ -33,9 +91,10 @@ func ConfigureRateLimits(client *hatchet.Client, logger *slog.Logger) error {
 
 	logger.Info("hatchet rate limits configured",
 		"minute_key", alphaVantageRateLimitMinuteKey,
-		"minute_max", alphaVantageRateLimitMaxMinute,
+		"minute_max", limits.PerMinute,
 		"day_key", alphaVantageRateLimitDayKey,
-		"day_max", alphaVantageRateLimitMaxDay,
+		"day_max", limits.PerDay,
+		"units_per_step", limits.stepUnits(openai.PickCount),
 	)
 	return nil
 }
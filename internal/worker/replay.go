@@ -0,0 +1,283 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/igor-kupczynski/alpha-monday/internal/db"
+	"github.com/igor-kupczynski/alpha-monday/internal/decimal"
+	"github.com/igor-kupczynski/alpha-monday/internal/integrations/alphavantage"
+	"github.com/igor-kupczynski/alpha-monday/internal/integrations/openai"
+)
+
+// ReplayReport describes a replayed batch and how its checkpoints differ from
+// the source batch.
+type ReplayReport struct {
+	SourceBatchID string
+	BatchID       string
+	Checkpoints   int
+	Differences   []string
+}
+
+// ReplayBatch re-runs a past weekly batch through the real steps and daily
+// loop, with OpenAI and Alpha Vantage replaced by the picks and prices stored
+// for source. Results are written to target, which must be a scratch database:
+// the replayed batch keeps the source run date. Time is simulated, so the run
+// completes immediately.
+//
+// Prices are served by date: a daily run gets the source checkpoint for its
+// previous trading day (or the latest earlier one), and skipped source
+// checkpoints replay as missing quotes.
+func ReplayBatch(ctx context.Context, source *db.BatchDetails, target Store, logger *slog.Logger) (*ReplayReport, error) {
+	if source == nil {
+		return nil, fmt.Errorf("source batch is required")
+	}
+	if len(source.Picks) == 0 || len(source.Checkpoints) == 0 {
+		return nil, fmt.Errorf("batch %s has no picks or checkpoints to replay", source.Batch.ID)
+	}
+	location, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		return nil, fmt.Errorf("load timezone: %w", err)
+	}
+	runDate, err := parseDateInLocation(source.Batch.RunDate, location)
+	if err != nil {
+		return nil, fmt.Errorf("invalid run_date %q: %w", source.Batch.RunDate, err)
+	}
+
+	clock := &replayClock{now: time.Date(runDate.Year(), runDate.Month(), runDate.Day(), 9, 0, 0, 0, location)}
+	recorder := &replayRecorder{Store: target}
+	steps := NewSteps(recorder, replayOpenAI{picks: source.Picks, configHash: source.Batch.ConfigHash}, &replayAlpha{source: source, clock: clock}, logger)
+	steps.clock = clock
+
+	picks, err := steps.generatePicks(ctx, WeeklyPickInput{})
+	if err != nil {
+		return nil, err
+	}
+	picks.BenchmarkSymbol = source.Batch.BenchmarkSymbol
+	snapshot, err := steps.snapshotInitialPrices(ctx, *picks)
+	if err != nil {
+		return nil, err
+	}
+	state, err := steps.persistBatch(ctx, *snapshot)
+	if err != nil {
+		return nil, err
+	}
+	if err := steps.runDailyCheckpoints(ctx, replayOrchestrator{steps: steps, clock: clock}, *state); err != nil {
+		return nil, err
+	}
+
+	report := &ReplayReport{
+		SourceBatchID: source.Batch.ID,
+		BatchID:       state.BatchID,
+		Checkpoints:   1 + len(recorder.checkpoints),
+	}
+	report.Differences = diffReplay(source, *state, recorder.checkpoints)
+	return report, nil
+}
+
+type replayClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *replayClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *replayClock) set(now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = now
+}
+
+// replayOrchestrator advances the simulated clock instead of sleeping and runs
+// daily checkpoints inline.
+type replayOrchestrator struct {
+	steps *Steps
+	clock *replayClock
+}
+
+func (o replayOrchestrator) SleepUntil(_ context.Context, target time.Time) error {
+	if target.After(o.clock.Now()) {
+		o.clock.set(target)
+	}
+	return nil
+}
+
+func (o replayOrchestrator) RunChild(ctx context.Context, workflowID string, input any) error {
+	if workflowID != DailyCheckpointWorkflowID {
+		return fmt.Errorf("unexpected child workflow %s", workflowID)
+	}
+	_, err := o.steps.runDailyCheckpointTask(ctx, input.(DailyCheckpointInput))
+	return err
+}
+
+type replayOpenAI struct {
+	picks      []db.Pick
+	configHash *string
+}
+
+func (r replayOpenAI) GeneratePicks(context.Context, openai.PickRequest) ([]openai.Pick, error) {
+	picks := make([]openai.Pick, 0, len(r.picks))
+	for _, pick := range r.picks {
+		picks = append(picks, openai.Pick{Ticker: pick.Ticker, Action: pick.Action, Reasoning: pick.Reasoning})
+	}
+	return picks, nil
+}
+
+// ConfigHash reports the source batch's hash so the replay groups with it.
+func (r replayOpenAI) ConfigHash() string {
+	if r.configHash == nil {
+		return ""
+	}
+	return *r.configHash
+}
+
+// replayAlpha serves quotes from the source batch for the simulated time.
+type replayAlpha struct {
+	source *db.BatchDetails
+	clock  *replayClock
+}
+
+func (r *replayAlpha) SnapshotPreviousCloses(_ context.Context, benchmark string, picks []string) (map[string]alphavantage.Quote, error) {
+	tradingDay := r.source.Checkpoints[0].CheckpointDate
+	quotes := map[string]alphavantage.Quote{
+		benchmark: {Symbol: benchmark, PreviousClose: r.source.Batch.BenchmarkInitialPrice.String(), TradingDay: tradingDay},
+	}
+	for _, pick := range r.source.Picks {
+		quotes[pick.Ticker] = alphavantage.Quote{Symbol: pick.Ticker, PreviousClose: pick.InitialPrice.String(), TradingDay: tradingDay}
+	}
+	return quotes, nil
+}
+
+func (r *replayAlpha) FetchPreviousClose(_ context.Context, symbol string) (alphavantage.Quote, error) {
+	checkpoint := r.checkpointFor(previousTradingDayFallback(r.clock.Now()).Format("2006-01-02"))
+	if checkpoint == nil || checkpoint.Status != checkpointStatusComputed {
+		return alphavantage.Quote{Symbol: symbol}, nil
+	}
+	quote := alphavantage.Quote{Symbol: symbol, TradingDay: checkpoint.CheckpointDate}
+	if symbol == r.source.Batch.BenchmarkSymbol {
+		if checkpoint.BenchmarkPrice != nil {
+			quote.PreviousClose = checkpoint.BenchmarkPrice.String()
+		}
+		return quote, nil
+	}
+	for _, metric := range checkpoint.Metrics {
+		if tickerForPick(r.source.Picks, metric.PickID) == symbol {
+			quote.PreviousClose = metric.CurrentPrice.String()
+		}
+	}
+	return quote, nil
+}
+
+// checkpointFor returns the latest source checkpoint on or before day.
+func (r *replayAlpha) checkpointFor(day string) *db.Checkpoint {
+	var found *db.Checkpoint
+	for i := range r.source.Checkpoints {
+		if r.source.Checkpoints[i].CheckpointDate <= day {
+			found = &r.source.Checkpoints[i]
+		}
+	}
+	return found
+}
+
+// replayRecorder keeps the checkpoints written during a replay for diffing.
+type replayRecorder struct {
+	Store
+	mu          sync.Mutex
+	checkpoints []db.CreateCheckpointInput
+}
+
+func (r *replayRecorder) CreateCheckpointWithMetrics(ctx context.Context, input db.CreateCheckpointInput) (db.CreateCheckpointResult, error) {
+	result, err := r.Store.CreateCheckpointWithMetrics(ctx, input)
+	if err == nil {
+		r.mu.Lock()
+		r.checkpoints = append(r.checkpoints, input)
+		r.mu.Unlock()
+	}
+	return result, err
+}
+
+func diffReplay(source *db.BatchDetails, state WeeklyPickState, replayed []db.CreateCheckpointInput) []string {
+	var diffs []string
+	tickers := map[string]string{}
+	for _, pick := range state.Picks {
+		tickers[pick.PickID] = pick.Ticker
+	}
+
+	// The initial checkpoint is written by persistBatch and always matches.
+	sourceByDate := map[string]db.Checkpoint{}
+	for _, checkpoint := range source.Checkpoints[1:] {
+		sourceByDate[checkpoint.CheckpointDate] = checkpoint
+	}
+	seen := map[string]bool{}
+	for _, input := range replayed {
+		date := input.CheckpointDate.Format("2006-01-02")
+		seen[date] = true
+		original, ok := sourceByDate[date]
+		if !ok {
+			diffs = append(diffs, fmt.Sprintf("%s: checkpoint only in replay", date))
+			continue
+		}
+		if original.Status != input.Status {
+			diffs = append(diffs, fmt.Sprintf("%s: status %s, replay %s", date, original.Status, input.Status))
+		}
+		if !equalDecimal(original.BenchmarkReturnPct, input.BenchmarkReturnPct) {
+			diffs = append(diffs, fmt.Sprintf("%s: benchmark return %s, replay %s", date, formatOptional(original.BenchmarkReturnPct), formatOptional(input.BenchmarkReturnPct)))
+		}
+		originalMetrics := map[string]db.PickMetric{}
+		for _, metric := range original.Metrics {
+			originalMetrics[tickerForPick(source.Picks, metric.PickID)] = metric
+		}
+		for _, metric := range input.Metrics {
+			ticker := tickers[metric.PickID]
+			want, ok := originalMetrics[ticker]
+			if !ok {
+				diffs = append(diffs, fmt.Sprintf("%s %s: metric only in replay", date, ticker))
+				continue
+			}
+			if !want.AbsoluteReturnPct.Equal(metric.AbsoluteReturnPct) {
+				diffs = append(diffs, fmt.Sprintf("%s %s: return %s, replay %s", date, ticker, want.AbsoluteReturnPct, metric.AbsoluteReturnPct))
+			}
+			if !want.VsBenchmarkPct.Equal(metric.VsBenchmarkPct) {
+				diffs = append(diffs, fmt.Sprintf("%s %s: vs benchmark %s, replay %s", date, ticker, want.VsBenchmarkPct, metric.VsBenchmarkPct))
+			}
+		}
+	}
+	for date := range sourceByDate {
+		if !seen[date] {
+			diffs = append(diffs, fmt.Sprintf("%s: checkpoint missing from replay", date))
+		}
+	}
+	sort.Strings(diffs)
+	return diffs
+}
+
+func tickerForPick(picks []db.Pick, pickID string) string {
+	for _, pick := range picks {
+		if pick.ID == pickID {
+			return pick.Ticker
+		}
+	}
+	return ""
+}
+
+func equalDecimal(a, b *decimal.Decimal) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return a.Equal(*b)
+}
+
+func formatOptional(value *decimal.Decimal) string {
+	if value == nil {
+		return "null"
+	}
+	return value.String()
+}
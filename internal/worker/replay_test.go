@@ -0,0 +1,117 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/igor-kupczynski/alpha-monday/internal/db"
+	"github.com/igor-kupczynski/alpha-monday/internal/decimal"
+)
+
+// replayStore is a fakeStore that also creates batches and rejects duplicate
+// checkpoint dates like the database does.
+type replayStore struct {
+	fakeStore
+	batch db.CreateBatchInput
+	dates map[string]bool
+}
+
+func (r *replayStore) CreateBatchWithInitialCheckpoint(ctx context.Context, input db.CreateBatchInput) (db.CreateBatchResult, error) {
+	r.batch = input
+	r.dates = map[string]bool{input.CheckpointDate.Format("2006-01-02"): true}
+	result := db.CreateBatchResult{BatchID: "replay-batch", CheckpointID: "replay-checkpoint"}
+	for i, pick := range input.Picks {
+		result.Picks = append(result.Picks, db.Pick{ID: fmt.Sprintf("replay-pick-%d", i), Ticker: pick.Ticker, Action: pick.Action, Reasoning: pick.Reasoning, InitialPrice: pick.InitialPrice})
+	}
+	return result, nil
+}
+
+func (r *replayStore) CreateCheckpointWithMetrics(ctx context.Context, input db.CreateCheckpointInput) (db.CreateCheckpointResult, error) {
+	date := input.CheckpointDate.Format("2006-01-02")
+	if r.dates[date] {
+		return db.CreateCheckpointResult{}, db.ErrCheckpointConflict
+	}
+	r.dates[date] = true
+	return r.fakeStore.CreateCheckpointWithMetrics(ctx, input)
+}
+
+func replaySource(t *testing.T) *db.BatchDetails {
+	t.Helper()
+	source := &db.BatchDetails{
+		Batch: db.Batch{ID: "source-batch", RunDate: "2026-01-05", Status: "completed", BenchmarkSymbol: "SPY", BenchmarkInitialPrice: decimal.MustParse("100.00")},
+		Picks: []db.Pick{
+			{ID: "pick-1", Ticker: "AAPL", Action: "BUY", Reasoning: "r1", InitialPrice: decimal.MustParse("50.00")},
+			{ID: "pick-2", Ticker: "MSFT", Action: "BUY", Reasoning: "r2", InitialPrice: decimal.MustParse("200.00")},
+		},
+	}
+	initial := source.Batch.BenchmarkInitialPrice
+	source.Checkpoints = append(source.Checkpoints, db.Checkpoint{CheckpointDate: "2026-01-02", Status: checkpointStatusComputed, BenchmarkPrice: &initial})
+
+	day := time.Date(2026, 1, 5, 0, 0, 0, 0, time.UTC)
+	for i := 0; day.Before(time.Date(2026, 1, 17, 0, 0, 0, 0, time.UTC)); day = day.AddDate(0, 0, 1) {
+		if day.Weekday() == time.Saturday || day.Weekday() == time.Sunday {
+			continue
+		}
+		i++
+		date := day.Format("2006-01-02")
+		if date == "2026-01-08" {
+			source.Checkpoints = append(source.Checkpoints, db.Checkpoint{CheckpointDate: date, Status: checkpointStatusSkipped})
+			continue
+		}
+		benchmark := decimal.NewFromInt(int64(100 + i))
+		benchmarkReturn, err := calculateReturnPct(initial, benchmark)
+		if err != nil {
+			t.Fatalf("benchmark return: %v", err)
+		}
+		checkpoint := db.Checkpoint{CheckpointDate: date, Status: checkpointStatusComputed, BenchmarkPrice: &benchmark, BenchmarkReturnPct: &benchmarkReturn}
+		for _, pick := range source.Picks {
+			price := pick.InitialPrice.Add(decimal.NewFromInt(int64(2 * i)))
+			absolute, err := calculateReturnPct(pick.InitialPrice, price)
+			if err != nil {
+				t.Fatalf("pick return: %v", err)
+			}
+			checkpoint.Metrics = append(checkpoint.Metrics, db.PickMetric{PickID: pick.ID, CurrentPrice: price, AbsoluteReturnPct: absolute, VsBenchmarkPct: absolute.Sub(benchmarkReturn)})
+		}
+		source.Checkpoints = append(source.Checkpoints, checkpoint)
+	}
+	return source
+}
+
+func TestReplayBatchReproducesSourceCheckpoints(t *testing.T) {
+	source := replaySource(t)
+	store := &replayStore{}
+
+	report, err := ReplayBatch(context.Background(), source, store, nil)
+	if err != nil {
+		t.Fatalf("replay: %v", err)
+	}
+	if len(report.Differences) != 0 {
+		t.Fatalf("expected identical replay, got %v", report.Differences)
+	}
+	if report.Checkpoints != len(source.Checkpoints) {
+		t.Fatalf("expected %d checkpoints, got %d", len(source.Checkpoints), report.Checkpoints)
+	}
+	if store.batch.RunDate.Format("2006-01-02") != source.Batch.RunDate || !store.batch.BenchmarkInitialPrice.Equal(source.Batch.BenchmarkInitialPrice) {
+		t.Fatalf("unexpected replayed batch: %+v", store.batch)
+	}
+	if len(store.statusUpdates) != 1 || store.statusUpdates[0] != batchStatusCompleted {
+		t.Fatalf("expected batch to be completed, got %v", store.statusUpdates)
+	}
+}
+
+func TestReplayBatchReportsDifferences(t *testing.T) {
+	source := replaySource(t)
+	// Simulate a stored metric that the current code no longer reproduces.
+	source.Checkpoints[2].Metrics[0].AbsoluteReturnPct = decimal.MustParse("99.0000")
+
+	report, err := ReplayBatch(context.Background(), source, &replayStore{}, nil)
+	if err != nil {
+		t.Fatalf("replay: %v", err)
+	}
+	if len(report.Differences) != 1 || !strings.Contains(report.Differences[0], "2026-01-06 AAPL: return 99.0000") {
+		t.Fatalf("expected one AAPL return difference, got %v", report.Differences)
+	}
+}
@@ -0,0 +1,76 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/igor-kupczynski/alpha-monday/internal/db"
+)
+
+const (
+	// DefaultStaleBatchAfter is how long past its checkpoint horizon an active
+	// batch is left alone before the startup sweep closes it.
+	DefaultStaleBatchAfter = 7 * 24 * time.Hour
+
+	// staleBatchFinalWeekDay is the first horizon day of the final week; a
+	// stale batch with a checkpoint from then on ran to the end and only
+	// missed being marked completed.
+	staleBatchFinalWeekDay = 7
+)
+
+// StaleBatchStore is the Store subset used by SweepStaleBatches.
+type StaleBatchStore interface {
+	ListStaleBatches(ctx context.Context, runDateBefore time.Time) ([]db.StaleBatch, error)
+	UpdateBatchStatus(ctx context.Context, batchID string, status string) error
+}
+
+// SweepStaleBatches closes batches still marked active more than after past
+// the end of their checkpoint horizon, typically left behind when a workflow
+// run was lost. Each batch becomes completed when its checkpoints reached the
+// final week, expired otherwise; the status change enqueues the usual
+// batch_status_changed event. It returns the number of batches closed.
+func SweepStaleBatches(ctx context.Context, store StaleBatchStore, now time.Time, after time.Duration, logger *slog.Logger) (int, error) {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	cutoff := now.Add(-after).AddDate(0, 0, -dailyCheckpointDays)
+	batches, err := store.ListStaleBatches(ctx, cutoff)
+	if err != nil {
+		return 0, fmt.Errorf("list stale batches: %w", err)
+	}
+
+	closed := 0
+	for _, batch := range batches {
+		status, err := staleBatchStatus(batch)
+		if err != nil {
+			logger.Warn("stale batch skipped", "batch_id", batch.ID, "error", err)
+			continue
+		}
+		if err := store.UpdateBatchStatus(ctx, batch.ID, status); err != nil {
+			return closed, fmt.Errorf("update batch %s status: %w", batch.ID, err)
+		}
+		closed++
+		logger.Info("stale batch closed", "batch_id", batch.ID, "run_date", batch.RunDate, "last_checkpoint_date", batch.LastCheckpointDate, "status", status)
+	}
+	return closed, nil
+}
+
+func staleBatchStatus(batch db.StaleBatch) (string, error) {
+	runDate, err := parseDate(batch.RunDate)
+	if err != nil {
+		return "", fmt.Errorf("invalid run_date %q: %w", batch.RunDate, err)
+	}
+	if batch.LastCheckpointDate == nil {
+		return batchStatusExpired, nil
+	}
+	lastCheckpoint, err := parseDate(*batch.LastCheckpointDate)
+	if err != nil {
+		return "", fmt.Errorf("invalid checkpoint_date %q: %w", *batch.LastCheckpointDate, err)
+	}
+	if lastCheckpoint.Before(runDate.AddDate(0, 0, staleBatchFinalWeekDay)) {
+		return batchStatusExpired, nil
+	}
+	return batchStatusCompleted, nil
+}
@@ -0,0 +1,52 @@
+package worker
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/igor-kupczynski/alpha-monday/internal/db"
+)
+
+type staleStore struct {
+	fakeStore
+	stale  []db.StaleBatch
+	before time.Time
+}
+
+func (s *staleStore) ListStaleBatches(ctx context.Context, runDateBefore time.Time) ([]db.StaleBatch, error) {
+	s.before = runDateBefore
+	return s.stale, nil
+}
+
+func TestSweepStaleBatches(t *testing.T) {
+	lastWeek := "2026-01-14"
+	firstWeek := "2026-01-07"
+	store := &staleStore{stale: []db.StaleBatch{
+		{ID: "finished", RunDate: "2026-01-05", LastCheckpointDate: &lastWeek},
+		{ID: "abandoned", RunDate: "2026-01-05", LastCheckpointDate: &firstWeek},
+		{ID: "empty", RunDate: "2026-01-05"},
+		{ID: "broken", RunDate: "not-a-date"},
+	}}
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	closed, err := SweepStaleBatches(context.Background(), store, now, 7*24*time.Hour, nil)
+	if err != nil {
+		t.Fatalf("sweep: %v", err)
+	}
+	if closed != 3 {
+		t.Fatalf("expected 3 closed batches, got %d", closed)
+	}
+	if want := time.Date(2026, 2, 8, 12, 0, 0, 0, time.UTC); !store.before.Equal(want) {
+		t.Fatalf("expected cutoff %s, got %s", want, store.before)
+	}
+	want := []string{batchStatusCompleted, batchStatusExpired, batchStatusExpired}
+	if len(store.statusUpdates) != len(want) {
+		t.Fatalf("expected status updates %v, got %v", want, store.statusUpdates)
+	}
+	for i := range want {
+		if store.statusUpdates[i] != want[i] {
+			t.Fatalf("expected status updates %v, got %v", want, store.statusUpdates)
+		}
+	}
+}
@@ -0,0 +1,426 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/igor-kupczynski/alpha-monday/internal/db"
+	"github.com/robfig/cron/v3"
+)
+
+const (
+	defaultStandalonePollInterval = 30 * time.Second
+	standaloneBatchSize           = 10
+	standaloneLease               = 15 * time.Minute
+	standaloneMaxAttempts         = 5
+	standaloneBaseBackoff         = time.Minute
+	standaloneMaxBackoff          = time.Hour
+	standaloneTimezone            = "America/New_York"
+)
+
+// JobStore is the scheduled_jobs subset of db.Store used by StandaloneEngine.
+type JobStore interface {
+	EnqueueScheduledJob(ctx context.Context, task, dedupeKey string, input any, runAt time.Time) (bool, error)
+	ClaimScheduledJobs(ctx context.Context, limit int, lease time.Duration) ([]db.ScheduledJob, error)
+	MarkScheduledJobCompleted(ctx context.Context, id string) error
+	MarkScheduledJobFailed(ctx context.Context, id string, lastError string, nextRunAt *time.Time) error
+}
+
+type StandaloneConfig struct {
+	PollInterval time.Duration
+}
+
+// StandaloneEngine runs the workflows without Hatchet. An in-process cron
+// enqueues the first step of each scheduled workflow into scheduled_jobs and a
+// poller runs due jobs one at a time. Each step enqueues the next one with its
+// output as input, and the daily loop enqueues the 14 checkpoint runs at their
+// scheduled times, so a restarted worker resumes where it left off.
+type StandaloneEngine struct {
+	store   JobStore
+	limits  RateLimitConfig
+	config  StandaloneConfig
+	logger  *slog.Logger
+	now     func() time.Time
+	limiter *callLimiter
+	steps   *Steps
+	specs   []workflowSpec
+	tasks   map[string]standaloneTask
+}
+
+// standaloneTask runs one step for a claimed job and returns the input for the
+// next step in the workflow, or nil when the step is the last one.
+type standaloneTask func(ctx context.Context, job db.ScheduledJob) (any, error)
+
+func NewStandaloneEngine(store JobStore, limits RateLimitConfig, config StandaloneConfig, logger *slog.Logger) *StandaloneEngine {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	if config.PollInterval <= 0 {
+		config.PollInterval = defaultStandalonePollInterval
+	}
+	return &StandaloneEngine{
+		store:   store,
+		limits:  limits,
+		config:  config,
+		logger:  logger,
+		now:     time.Now,
+		limiter: newCallLimiter(limits),
+	}
+}
+
+func (e *StandaloneEngine) Register(steps *Steps) error {
+	if steps == nil {
+		return fmt.Errorf("steps are required")
+	}
+	if err := e.limits.Validate(); err != nil {
+		return err
+	}
+	e.steps = steps
+	steps.reserveQuotes = e.limiter.wait
+	e.specs = workflowSpecs(e.limits)
+	e.tasks = map[string]standaloneTask{
+		StepGeneratePicksID: func(ctx context.Context, job db.ScheduledJob) (any, error) {
+			var input WeeklyPickInput
+			if err := json.Unmarshal(job.Input, &input); err != nil {
+				return nil, err
+			}
+			return steps.generatePicks(ctx, input)
+		},
+		StepSnapshotPricesID: func(ctx context.Context, job db.ScheduledJob) (any, error) {
+			var input GeneratePicksOutput
+			if err := json.Unmarshal(job.Input, &input); err != nil {
+				return nil, err
+			}
+			if err := e.limiter.wait(ctx, e.limits.stepUnits(len(input.Picks))); err != nil {
+				return nil, err
+			}
+			return steps.snapshotInitialPrices(ctx, input)
+		},
+		StepPersistBatchID: func(ctx context.Context, job db.ScheduledJob) (any, error) {
+			var input SnapshotOutput
+			if err := json.Unmarshal(job.Input, &input); err != nil {
+				return nil, err
+			}
+			return steps.persistBatch(ctx, input)
+		},
+		StepDailyCheckpointLoopID: func(ctx context.Context, job db.ScheduledJob) (any, error) {
+			var state WeeklyPickState
+			if err := json.Unmarshal(job.Input, &state); err != nil {
+				return nil, err
+			}
+			orch := &jobOrchestrator{store: e.store, parentID: job.ID, now: e.now}
+			return nil, steps.runDailyCheckpoints(ctx, orch, state)
+		},
+		DailyCheckpointWorkflowID: func(ctx context.Context, job db.ScheduledJob) (any, error) {
+			var input DailyCheckpointInput
+			if err := json.Unmarshal(job.Input, &input); err != nil {
+				return nil, err
+			}
+			if err := e.limiter.wait(ctx, e.limits.stepUnits(len(input.Picks))); err != nil {
+				return nil, err
+			}
+			_, err := steps.runDailyCheckpointTask(ctx, input)
+			return nil, err
+		},
+		StepVerifyMetricsID: func(ctx context.Context, job db.ScheduledJob) (any, error) {
+			var input VerifyMetricsInput
+			if err := json.Unmarshal(job.Input, &input); err != nil {
+				return nil, err
+			}
+			_, err := steps.verifyMetrics(ctx, input)
+			return nil, err
+		},
+	}
+	for _, spec := range e.specs {
+		for _, step := range spec.Steps {
+			if e.tasks[step.ID] == nil {
+				return fmt.Errorf("missing standalone task for step %q", step.ID)
+			}
+		}
+	}
+	return nil
+}
+
+// Start runs the cron scheduler and the job poller until cleanup is called.
+func (e *StandaloneEngine) Start() (func() error, error) {
+	if e.tasks == nil {
+		return nil, fmt.Errorf("workflows are not registered")
+	}
+	location, err := time.LoadLocation(standaloneTimezone)
+	if err != nil {
+		return nil, fmt.Errorf("load timezone: %w", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	scheduler := cron.New(cron.WithLocation(location))
+	for _, spec := range e.specs {
+		if spec.Cron == "" {
+			continue
+		}
+		spec := spec
+		if _, err := scheduler.AddFunc(spec.Cron, func() { e.trigger(ctx, spec) }); err != nil {
+			cancel()
+			return nil, fmt.Errorf("schedule %s: %w", spec.ID, err)
+		}
+	}
+	scheduler.Start()
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		e.poll(ctx)
+	}()
+
+	e.logger.Info("standalone scheduler started", "poll_interval", e.config.PollInterval, "timezone", standaloneTimezone)
+	return func() error {
+		<-scheduler.Stop().Done()
+		cancel()
+		wg.Wait()
+		return nil
+	}, nil
+}
+
+// trigger enqueues the first step of spec. The cron tick time is the dedupe
+// key, so several workers sharing a database enqueue a single run.
+func (e *StandaloneEngine) trigger(ctx context.Context, spec workflowSpec) {
+	tick := e.now().UTC().Truncate(time.Minute)
+	inserted, err := e.store.EnqueueScheduledJob(ctx, spec.Steps[0].ID, tick.Format(time.RFC3339), struct{}{}, tick)
+	if err != nil {
+		e.logger.Error("standalone cron enqueue failed", "workflow", spec.ID, "error", err)
+		return
+	}
+	if inserted {
+		e.logger.Info("standalone workflow scheduled", "workflow", spec.ID, "run_at", tick)
+	}
+}
+
+func (e *StandaloneEngine) poll(ctx context.Context) {
+	ticker := time.NewTicker(e.config.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := e.RunOnce(ctx); err != nil && ctx.Err() == nil {
+			e.logger.Error("standalone job poll failed", "error", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce claims one batch of due jobs and runs them in order. It returns the
+// number of jobs claimed.
+func (e *StandaloneEngine) RunOnce(ctx context.Context) (int, error) {
+	jobs, err := e.store.ClaimScheduledJobs(ctx, standaloneBatchSize, standaloneLease)
+	if err != nil {
+		return 0, fmt.Errorf("claim scheduled jobs: %w", err)
+	}
+	for _, job := range jobs {
+		if err := e.run(ctx, job); err != nil {
+			return len(jobs), err
+		}
+	}
+	return len(jobs), nil
+}
+
+func (e *StandaloneEngine) run(ctx context.Context, job db.ScheduledJob) error {
+	task := e.tasks[job.Task]
+	if task == nil {
+		e.logger.Error("standalone job has unknown task", "job_id", job.ID, "step_name", job.Task)
+		return e.store.MarkScheduledJobFailed(ctx, job.ID, "unknown task "+job.Task, nil)
+	}
+
+	start := e.now()
+	fields := []any{"job_id", job.ID, "step_name", job.Task, "attempt", job.Attempts}
+	e.logger.Info("workflow step started", fields...)
+
+	output, err := task(ctx, job)
+	if err == nil {
+		err = e.enqueueNext(ctx, job, output)
+	}
+	duration := e.now().Sub(start)
+
+	if err != nil {
+		var nextRunAt *time.Time
+		if job.Attempts < standaloneMaxAttempts {
+			next := e.now().Add(standaloneBackoff(job.Attempts))
+			nextRunAt = &next
+		}
+		e.logger.Error("workflow step failed", append(fields, "duration_ms", duration.Milliseconds(), "retrying", nextRunAt != nil, "error", err)...)
+		if markErr := e.store.MarkScheduledJobFailed(ctx, job.ID, err.Error(), nextRunAt); markErr != nil {
+			return fmt.Errorf("mark scheduled job failed: %w", markErr)
+		}
+		return nil
+	}
+
+	if err := e.store.MarkScheduledJobCompleted(ctx, job.ID); err != nil {
+		return fmt.Errorf("mark scheduled job completed: %w", err)
+	}
+	e.logger.Info("workflow step completed", append(fields, "duration_ms", duration.Milliseconds())...)
+	return nil
+}
+
+// enqueueNext schedules the step after job.Task in its workflow with output as
+// input. The parent job ID is the dedupe key, so retries never fork a run.
+func (e *StandaloneEngine) enqueueNext(ctx context.Context, job db.ScheduledJob, output any) error {
+	next := nextStepID(e.specs, job.Task)
+	if next == "" || output == nil {
+		return nil
+	}
+	_, err := e.store.EnqueueScheduledJob(ctx, next, job.ID, output, e.now())
+	return err
+}
+
+func nextStepID(specs []workflowSpec, stepID string) string {
+	for _, spec := range specs {
+		for i, step := range spec.Steps {
+			if step.ID == stepID && i+1 < len(spec.Steps) {
+				return spec.Steps[i+1].ID
+			}
+		}
+	}
+	return ""
+}
+
+func standaloneBackoff(attempts int) time.Duration {
+	delay := standaloneBaseBackoff
+	for i := 1; i < attempts; i++ {
+		delay *= 2
+		if delay >= standaloneMaxBackoff {
+			return standaloneMaxBackoff
+		}
+	}
+	return delay
+}
+
+// jobOrchestrator persists child runs instead of waiting for them: SleepUntil
+// sets the time the next child becomes due and RunChild enqueues it. The
+// parent job ID and child sequence form the dedupe key, so re-running the
+// parent is idempotent.
+type jobOrchestrator struct {
+	store    JobStore
+	parentID string
+	now      func() time.Time
+	runAt    time.Time
+	seq      int
+}
+
+func (o *jobOrchestrator) SleepUntil(_ context.Context, target time.Time) error {
+	o.runAt = target
+	return nil
+}
+
+func (o *jobOrchestrator) RunChild(ctx context.Context, workflowID string, input any) error {
+	runAt := o.runAt
+	if runAt.IsZero() {
+		runAt = o.now()
+	}
+	key := o.parentID + "/" + strconv.Itoa(o.seq)
+	o.seq++
+	if _, err := o.store.EnqueueScheduledJob(ctx, workflowID, key, input, runAt); err != nil {
+		return fmt.Errorf("enqueue %s: %w", workflowID, err)
+	}
+	return nil
+}
+
+// callLimiter enforces the Alpha Vantage per-minute and per-day budgets
+// in-process, standing in for Hatchet rate limits. The windows are not
+// persisted, so a restart starts with a full budget.
+type callLimiter struct {
+	mu     sync.Mutex
+	limits RateLimitConfig
+	now    func() time.Time
+	sleep  func(ctx context.Context, d time.Duration) error
+	calls  []limiterEntry
+}
+
+type limiterEntry struct {
+	at    time.Time
+	units int
+}
+
+func newCallLimiter(limits RateLimitConfig) *callLimiter {
+	return &callLimiter{limits: limits, now: time.Now, sleep: sleepContext}
+}
+
+// wait blocks until units fit in both windows, then records them.
+func (l *callLimiter) wait(ctx context.Context, units int) error {
+	for {
+		l.mu.Lock()
+		delay := l.reserve(units)
+		l.mu.Unlock()
+		if delay == 0 {
+			return nil
+		}
+		if err := l.sleep(ctx, delay); err != nil {
+			return err
+		}
+	}
+}
+
+// reserve records units and returns 0 when they fit, or how long to wait.
+func (l *callLimiter) reserve(units int) time.Duration {
+	now := l.now()
+	windows := []struct {
+		length time.Duration
+		limit  int
+	}{
+		{time.Minute, l.limits.PerMinute},
+		{24 * time.Hour, l.limits.PerDay},
+	}
+
+	kept := l.calls[:0]
+	for _, entry := range l.calls {
+		if now.Sub(entry.at) < 24*time.Hour {
+			kept = append(kept, entry)
+		}
+	}
+	l.calls = kept
+
+	var delay time.Duration
+	for _, window := range windows {
+		used := 0
+		for _, entry := range l.calls {
+			if now.Sub(entry.at) < window.length {
+				used += entry.units
+			}
+		}
+		// Free the oldest entries in the window until the new units fit.
+		for _, entry := range l.calls {
+			if used+units <= window.limit {
+				break
+			}
+			if now.Sub(entry.at) >= window.length {
+				continue
+			}
+			used -= entry.units
+			if wait := entry.at.Add(window.length).Sub(now); wait > delay {
+				delay = wait
+			}
+		}
+	}
+	if delay > 0 {
+		return delay
+	}
+	l.calls = append(l.calls, limiterEntry{at: now, units: units})
+	return 0
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,255 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/igor-kupczynski/alpha-monday/internal/db"
+	"github.com/igor-kupczynski/alpha-monday/internal/decimal"
+	"github.com/igor-kupczynski/alpha-monday/internal/integrations/alphavantage"
+)
+
+type fakeJob struct {
+	db.ScheduledJob
+	completed bool
+	abandoned bool
+	lastError string
+}
+
+type fakeJobStore struct {
+	clock *fakeClock
+	jobs  []*fakeJob
+}
+
+func (f *fakeJobStore) EnqueueScheduledJob(ctx context.Context, task, dedupeKey string, input any, runAt time.Time) (bool, error) {
+	for _, job := range f.jobs {
+		if job.Task == task && job.DedupeKey == dedupeKey {
+			return false, nil
+		}
+	}
+	encoded, err := json.Marshal(input)
+	if err != nil {
+		return false, err
+	}
+	f.jobs = append(f.jobs, &fakeJob{ScheduledJob: db.ScheduledJob{
+		ID:        fmt.Sprintf("job-%d", len(f.jobs)+1),
+		Task:      task,
+		DedupeKey: dedupeKey,
+		Input:     encoded,
+		RunAt:     runAt,
+	}})
+	return true, nil
+}
+
+func (f *fakeJobStore) ClaimScheduledJobs(ctx context.Context, limit int, lease time.Duration) ([]db.ScheduledJob, error) {
+	var claimed []db.ScheduledJob
+	for _, job := range f.jobs {
+		if job.completed || job.abandoned || job.RunAt.After(f.clock.now) || len(claimed) == limit {
+			continue
+		}
+		job.Attempts++
+		job.RunAt = f.clock.now.Add(lease)
+		claimed = append(claimed, job.ScheduledJob)
+	}
+	return claimed, nil
+}
+
+func (f *fakeJobStore) MarkScheduledJobCompleted(ctx context.Context, id string) error {
+	f.find(id).completed = true
+	return nil
+}
+
+func (f *fakeJobStore) MarkScheduledJobFailed(ctx context.Context, id string, lastError string, nextRunAt *time.Time) error {
+	job := f.find(id)
+	job.lastError = lastError
+	if nextRunAt == nil {
+		job.abandoned = true
+		return nil
+	}
+	job.RunAt = *nextRunAt
+	return nil
+}
+
+func (f *fakeJobStore) find(id string) *fakeJob {
+	for _, job := range f.jobs {
+		if job.ID == id {
+			return job
+		}
+	}
+	return nil
+}
+
+func (f *fakeJobStore) byTask(task string) []*fakeJob {
+	var jobs []*fakeJob
+	for _, job := range f.jobs {
+		if job.Task == task {
+			jobs = append(jobs, job)
+		}
+	}
+	return jobs
+}
+
+func newTestStandaloneEngine(t *testing.T, jobs *fakeJobStore, steps *Steps) *StandaloneEngine {
+	t.Helper()
+	engine := NewStandaloneEngine(jobs, DefaultRateLimitConfig(), StandaloneConfig{}, nil)
+	engine.now = jobs.clock.Now
+	engine.limiter.now = jobs.clock.Now
+	if err := engine.Register(steps); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+	return engine
+}
+
+func TestStandaloneEngineSchedulesDailyCheckpoints(t *testing.T) {
+	location, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatalf("load location: %v", err)
+	}
+
+	clock := &fakeClock{now: time.Date(2026, 1, 5, 8, 0, 0, 0, location)}
+	jobs := &fakeJobStore{clock: clock}
+	store := &fakeStore{}
+	steps := NewSteps(store, nil, &staticAlpha{quotes: map[string]alphavantage.Quote{
+		"SPY":  {Symbol: "SPY", PreviousClose: "100.00", TradingDay: "2026-01-02"},
+		"AAPL": {Symbol: "AAPL", PreviousClose: "50.00", TradingDay: "2026-01-02"},
+	}}, nil)
+	steps.clock = clock
+	engine := newTestStandaloneEngine(t, jobs, steps)
+
+	state := WeeklyPickState{
+		BatchID:               "batch-123",
+		RunDate:               "2026-01-05",
+		BenchmarkSymbol:       "SPY",
+		BenchmarkInitialPrice: decimal.MustParse("95.00"),
+		Picks: []PickState{
+			{PickID: "pick-1", Ticker: "AAPL", InitialPrice: decimal.MustParse("45.00")},
+		},
+	}
+	if _, err := jobs.EnqueueScheduledJob(context.Background(), StepDailyCheckpointLoopID, "parent", state, clock.now); err != nil {
+		t.Fatalf("enqueue loop: %v", err)
+	}
+
+	if _, err := engine.RunOnce(context.Background()); err != nil {
+		t.Fatalf("run loop: %v", err)
+	}
+	loop := jobs.byTask(StepDailyCheckpointLoopID)[0]
+	if !loop.completed {
+		t.Fatalf("expected loop job to complete, last error %q", loop.lastError)
+	}
+
+	daily := jobs.byTask(DailyCheckpointWorkflowID)
+	targets := expectedDailyTargets(state.RunDate, location)
+	if len(daily) != len(targets) {
+		t.Fatalf("expected %d daily jobs, got %d", len(targets), len(daily))
+	}
+	for i, job := range daily {
+		if !job.RunAt.Equal(targets[i]) {
+			t.Fatalf("expected daily job %d at %s, got %s", i, targets[i], job.RunAt)
+		}
+		if job.DedupeKey != fmt.Sprintf("%s/%d", loop.ID, i) {
+			t.Fatalf("unexpected dedupe key %q", job.DedupeKey)
+		}
+	}
+
+	// Re-running the loop job must not duplicate the schedule.
+	if _, err := engine.tasks[StepDailyCheckpointLoopID](context.Background(), loop.ScheduledJob); err != nil {
+		t.Fatalf("rerun loop: %v", err)
+	}
+	if got := len(jobs.byTask(DailyCheckpointWorkflowID)); got != len(targets) {
+		t.Fatalf("expected %d daily jobs after rerun, got %d", len(targets), got)
+	}
+
+	clock.now = targets[0]
+	claimed, err := engine.RunOnce(context.Background())
+	if err != nil {
+		t.Fatalf("run daily: %v", err)
+	}
+	if claimed != 1 || !daily[0].completed {
+		t.Fatalf("expected first daily job to run, claimed %d, last error %q", claimed, daily[0].lastError)
+	}
+	if len(store.checkpoints) != 1 || store.checkpoints[0].BatchID != state.BatchID {
+		t.Fatalf("expected one checkpoint for %s, got %+v", state.BatchID, store.checkpoints)
+	}
+}
+
+func TestStandaloneEngineRetriesThenAbandons(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2026, 1, 6, 9, 0, 0, 0, time.UTC)}
+	jobs := &fakeJobStore{clock: clock}
+	steps := NewSteps(&fakeStore{}, nil, &staticAlpha{err: fmt.Errorf("upstream down")}, nil)
+	steps.clock = clock
+	engine := newTestStandaloneEngine(t, jobs, steps)
+
+	input := DailyCheckpointInput{
+		BatchID:         "batch-1",
+		BenchmarkSymbol: "SPY",
+		Picks:           []PickState{{PickID: "pick-1", Ticker: "AAPL"}},
+		ScheduledAt:     clock.now.Format(time.RFC3339),
+	}
+	if _, err := jobs.EnqueueScheduledJob(context.Background(), DailyCheckpointWorkflowID, "k", input, clock.now); err != nil {
+		t.Fatalf("enqueue: %v", err)
+	}
+	job := jobs.jobs[0]
+
+	if _, err := engine.RunOnce(context.Background()); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if job.completed || job.abandoned || !job.RunAt.Equal(clock.now.Add(standaloneBaseBackoff)) {
+		t.Fatalf("expected retry after %s, got %+v", standaloneBaseBackoff, job)
+	}
+
+	for i := 1; i < standaloneMaxAttempts; i++ {
+		clock.now = job.RunAt
+		if _, err := engine.RunOnce(context.Background()); err != nil {
+			t.Fatalf("run attempt %d: %v", i+1, err)
+		}
+	}
+	if !job.abandoned || job.Attempts != standaloneMaxAttempts {
+		t.Fatalf("expected job abandoned after %d attempts, got %+v", standaloneMaxAttempts, job)
+	}
+}
+
+func TestNextStepIDFollowsWeeklyWorkflow(t *testing.T) {
+	specs := workflowSpecs(DefaultRateLimitConfig())
+	chain := []string{StepGeneratePicksID}
+	for next := nextStepID(specs, chain[0]); next != ""; next = nextStepID(specs, next) {
+		chain = append(chain, next)
+	}
+	expected := []string{StepGeneratePicksID, StepSnapshotPricesID, StepPersistBatchID, StepDailyCheckpointLoopID}
+	if fmt.Sprint(chain) != fmt.Sprint(expected) {
+		t.Fatalf("expected chain %v, got %v", expected, chain)
+	}
+}
+
+func TestCallLimiterWaitsForMinuteWindow(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2026, 1, 6, 9, 0, 0, 0, time.UTC)}
+	limiter := newCallLimiter(RateLimitConfig{PerMinute: 5, PerDay: 8})
+	limiter.now = clock.Now
+	var slept []time.Duration
+	limiter.sleep = func(ctx context.Context, d time.Duration) error {
+		slept = append(slept, d)
+		clock.now = clock.now.Add(d)
+		return nil
+	}
+
+	if err := limiter.wait(context.Background(), 4); err != nil {
+		t.Fatalf("first wait: %v", err)
+	}
+	clock.now = clock.now.Add(10 * time.Second)
+	if err := limiter.wait(context.Background(), 4); err != nil {
+		t.Fatalf("second wait: %v", err)
+	}
+	if len(slept) != 1 || slept[0] != 50*time.Second {
+		t.Fatalf("expected a single 50s wait, got %v", slept)
+	}
+
+	// The day budget (8) is now spent, so the next call waits a full day.
+	if err := limiter.wait(context.Background(), 1); err != nil {
+		t.Fatalf("third wait: %v", err)
+	}
+	if len(slept) != 2 || slept[1] != 24*time.Hour-time.Minute {
+		t.Fatalf("expected to wait for the day window, got %v", slept)
+	}
+}
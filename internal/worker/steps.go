@@ -5,14 +5,15 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
-	"math/big"
+	"slices"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
-	hatchetclient "github.com/hatchet-dev/hatchet/pkg/client"
-	hatchetworker "github.com/hatchet-dev/hatchet/pkg/worker"
 	hatchet "github.com/hatchet-dev/hatchet/sdks/go"
 	"github.com/igor-kupczynski/alpha-monday/internal/db"
+	"github.com/igor-kupczynski/alpha-monday/internal/decimal"
 	"github.com/igor-kupczynski/alpha-monday/internal/integrations/alphavantage"
 	"github.com/igor-kupczynski/alpha-monday/internal/integrations/openai"
 )
@@ -23,15 +24,24 @@ const (
 	dailyCheckpointHour    = 9
 	dailyCheckpointMinute  = 0
 	metricPrecisionScale   = 8
-	priceFanoutConcurrency = 3
+	// quoteReplacementRounds bounds how often the snapshot step asks OpenAI
+	// for new picks when a picked ticker has no usable quote.
+	quoteReplacementRounds = 2
 )
 
 const (
 	checkpointStatusComputed = "computed"
 	checkpointStatusSkipped  = "skipped"
+	batchStatusActive        = "active"
 	batchStatusCompleted     = "completed"
+	batchStatusFailed        = "failed"
+	batchStatusExpired       = "expired"
 )
 
+// errUnrecoverable marks step errors that no retry can fix, such as a
+// malformed checkpoint input. The batch is marked failed when one occurs.
+var errUnrecoverable = errors.New("unrecoverable")
+
 type Clock interface {
 	Now() time.Time
 }
@@ -42,39 +52,9 @@ func (realClock) Now() time.Time {
 	return time.Now()
 }
 
-type durableSleepContext interface {
-	context.Context
-	SleepFor(duration time.Duration) (*hatchetworker.SingleWaitResult, error)
-}
-
-type Sleeper interface {
-	SleepUntil(ctx durableSleepContext, target time.Time) error
-}
-
-type realSleeper struct {
-	clock Clock
-}
-
-func (s realSleeper) SleepUntil(ctx durableSleepContext, target time.Time) error {
-	if s.clock == nil {
-		s.clock = realClock{}
-	}
-	now := s.clock.Now()
-	if !target.After(now) {
-		return nil
-	}
-	if ctx == nil {
-		return fmt.Errorf("durable context is required for sleep")
-	}
-	if err := ctx.Err(); err != nil {
-		return err
-	}
-	_, err := ctx.SleepFor(target.Sub(now))
-	return err
-}
-
 type OpenAIClient interface {
-	GeneratePicks(ctx context.Context) ([]openai.Pick, error)
+	GeneratePicks(ctx context.Context, req openai.PickRequest) ([]openai.Pick, error)
+	ConfigHash() string
 }
 
 type AlphaVantageClient interface {
@@ -86,21 +66,57 @@ type Store interface {
 	CreateBatchWithInitialCheckpoint(ctx context.Context, input db.CreateBatchInput) (db.CreateBatchResult, error)
 	CreateCheckpointWithMetrics(ctx context.Context, input db.CreateCheckpointInput) (db.CreateCheckpointResult, error)
 	UpdateBatchStatus(ctx context.Context, batchID string, status string) error
+	BatchStatus(ctx context.Context, batchID string) (string, error)
+	ListMetricsForVerification(ctx context.Context, since time.Time) ([]db.MetricVerificationRow, error)
+	RecordMetricDiscrepancies(ctx context.Context, batchID string, discrepancies []db.MetricDiscrepancy) error
+	RecordRunOutcome(ctx context.Context, batchID, step, outcome string) error
+	RecentPickTickers(ctx context.Context, since time.Time) ([]string, error)
+	TickerSectors(ctx context.Context) (map[string]string, error)
 }
 
-type spawnChildWorkflowFunc func(ctx durableSleepContext, workflowName string, input any) error
-
 type Steps struct {
-	openAI             OpenAIClient
-	alphaVantage       AlphaVantageClient
-	store              Store
-	logger             *slog.Logger
-	clock              Clock
-	sleeper            Sleeper
-	spawnChildWorkflow spawnChildWorkflowFunc
+	openAI          OpenAIClient
+	alphaVantage    AlphaVantageClient
+	store           Store
+	logger          *slog.Logger
+	clock           Clock
+	recentPickWeeks int
+	// quoteFanout and quoteConcurrency control how fetchPickQuotes quotes
+	// picks; zero values mean parallel with DefaultQuoteConcurrency.
+	quoteFanout      string
+	quoteConcurrency int
+	// reserveQuotes, when set by the engine, waits for rate limit units
+	// covering calls replacement quotes, which the units reserved for the
+	// snapshot step do not include.
+	reserveQuotes func(ctx context.Context, calls int) error
+}
+
+// StepsOption configures optional step behaviour.
+type StepsOption func(*Steps)
+
+// WithRecentPickWindow keeps the model from repeating tickers picked by
+// batches in the last weeks; zero disables the check.
+func WithRecentPickWindow(weeks int) StepsOption {
+	return func(s *Steps) {
+		if weeks >= 0 {
+			s.recentPickWeeks = weeks
+		}
+	}
+}
+
+// WithQuoteFanout sets how daily checkpoints fetch pick quotes: in parallel
+// with up to concurrency requests in flight, or one at a time with
+// QuoteFanoutSequential.
+func WithQuoteFanout(strategy string, concurrency int) StepsOption {
+	return func(s *Steps) {
+		s.quoteFanout = strategy
+		if concurrency > 0 {
+			s.quoteConcurrency = concurrency
+		}
+	}
 }
 
-func NewSteps(store Store, openAI OpenAIClient, alpha AlphaVantageClient, logger *slog.Logger) *Steps {
+func NewSteps(store Store, openAI OpenAIClient, alpha AlphaVantageClient, logger *slog.Logger, opts ...StepsOption) *Steps {
 	if logger == nil {
 		logger = slog.Default()
 	}
@@ -111,47 +127,61 @@ func NewSteps(store Store, openAI OpenAIClient, alpha AlphaVantageClient, logger
 		logger:       logger,
 		clock:        realClock{},
 	}
-	steps.sleeper = realSleeper{clock: steps.clock}
-	steps.spawnChildWorkflow = defaultSpawnChildWorkflow
+	for _, opt := range opts {
+		opt(steps)
+	}
 	return steps
 }
 
 type PickDraft struct {
-	Ticker    string `json:"ticker"`
-	Action    string `json:"action"`
-	Reasoning string `json:"reasoning"`
+	Ticker      string           `json:"ticker"`
+	Action      string           `json:"action"`
+	Reasoning   string           `json:"reasoning"`
+	TargetPrice *decimal.Decimal `json:"target_price,omitempty"`
 }
 
 type GeneratePicksOutput struct {
 	RunDate         string      `json:"run_date"`
 	BenchmarkSymbol string      `json:"benchmark_symbol"`
+	ConfigHash      string      `json:"config_hash,omitempty"`
 	Picks           []PickDraft `json:"picks"`
 }
 
 type PickWithPrice struct {
-	Ticker       string `json:"ticker"`
-	Action       string `json:"action"`
-	Reasoning    string `json:"reasoning"`
-	InitialPrice string `json:"initial_price"`
+	Ticker       string           `json:"ticker"`
+	Action       string           `json:"action"`
+	Reasoning    string           `json:"reasoning"`
+	InitialPrice decimal.Decimal  `json:"initial_price"`
+	TargetPrice  *decimal.Decimal `json:"target_price,omitempty"`
 }
 
 type SnapshotOutput struct {
 	RunDate               string          `json:"run_date"`
 	BenchmarkSymbol       string          `json:"benchmark_symbol"`
-	BenchmarkInitialPrice string          `json:"benchmark_initial_price"`
+	BenchmarkInitialPrice decimal.Decimal `json:"benchmark_initial_price"`
 	CheckpointDate        string          `json:"checkpoint_date"`
+	ConfigHash            string          `json:"config_hash,omitempty"`
 	Picks                 []PickWithPrice `json:"picks"`
 }
 
-type WeeklyPickInput struct{}
+// WeeklyPickInput triggers the weekly workflow. RunDate overrides the batch
+// run date (YYYY-MM-DD) for on-demand runs; cron runs leave it empty and use
+// the current date.
+type WeeklyPickInput struct {
+	RunDate string `json:"run_date,omitempty"`
+}
 
 type DailyCheckpointInput struct {
-	BatchID               string      `json:"batch_id"`
-	BenchmarkSymbol       string      `json:"benchmark_symbol"`
-	BenchmarkInitialPrice string      `json:"benchmark_initial_price"`
-	Picks                 []PickState `json:"picks"`
-	ScheduledAt           string      `json:"scheduled_at"`
-	MarkCompleted         bool        `json:"mark_completed"`
+	BatchID               string          `json:"batch_id"`
+	BenchmarkSymbol       string          `json:"benchmark_symbol"`
+	BenchmarkInitialPrice decimal.Decimal `json:"benchmark_initial_price"`
+	Picks                 []PickState     `json:"picks"`
+	ScheduledAt           string          `json:"scheduled_at"`
+	MarkCompleted         bool            `json:"mark_completed"`
+	// CheckpointDate (YYYY-MM-DD) recomputes that trading day's checkpoint
+	// from its historical closes instead of the latest ones; set by the API's
+	// recompute endpoint.
+	CheckpointDate string `json:"checkpoint_date,omitempty"`
 }
 
 type DailyCheckpointResult struct {
@@ -162,29 +192,39 @@ type DailyCheckpointLoopOutput struct {
 	Completed bool `json:"completed"`
 }
 
-func (s *Steps) GeneratePicks(ctx hatchet.Context, _ WeeklyPickInput) (*GeneratePicksOutput, error) {
+func (s *Steps) GeneratePicks(ctx hatchet.Context, input WeeklyPickInput) (*GeneratePicksOutput, error) {
+	return s.generatePicks(ctx, input)
+}
+
+func (s *Steps) generatePicks(ctx context.Context, input WeeklyPickInput) (*GeneratePicksOutput, error) {
 	if s.openAI == nil {
 		return nil, fmt.Errorf("openai client not configured")
 	}
 
-	picks, err := s.openAI.GeneratePicks(ctx)
+	now := s.clock.Now()
+	runDate := formatDate(now)
+	if input.RunDate != "" {
+		if _, err := parseDate(input.RunDate); err != nil {
+			return nil, fmt.Errorf("invalid run_date %q: %w: %w", input.RunDate, err, errUnrecoverable)
+		}
+		runDate = input.RunDate
+	}
+	req, err := s.pickRequest(ctx, now)
 	if err != nil {
 		return nil, err
 	}
 
-	drafts := make([]PickDraft, 0, len(picks))
-	for _, pick := range picks {
-		drafts = append(drafts, PickDraft{
-			Ticker:    pick.Ticker,
-			Action:    pick.Action,
-			Reasoning: pick.Reasoning,
-		})
+	picks, err := s.openAI.GeneratePicks(ctx, req)
+	if err != nil {
+		return nil, err
 	}
 
-	runDate := formatDate(s.clock.Now())
+	drafts := toPickDrafts(picks)
+
 	output := &GeneratePicksOutput{
 		RunDate:         runDate,
 		BenchmarkSymbol: defaultBenchmarkSymbol,
+		ConfigHash:      s.openAI.ConfigHash(),
 		Picks:           drafts,
 	}
 
@@ -193,25 +233,63 @@ func (s *Steps) GeneratePicks(ctx hatchet.Context, _ WeeklyPickInput) (*Generate
 	return output, nil
 }
 
-func (s *Steps) SnapshotInitialPrices(ctx hatchet.Context, _ WeeklyPickInput) (*SnapshotOutput, error) {
-	if s.alphaVantage == nil {
-		return nil, fmt.Errorf("alpha vantage client not configured")
+func toPickDrafts(picks []openai.Pick) []PickDraft {
+	drafts := make([]PickDraft, 0, len(picks))
+	for _, pick := range picks {
+		drafts = append(drafts, PickDraft{
+			Ticker:      pick.Ticker,
+			Action:      pick.Action,
+			Reasoning:   pick.Reasoning,
+			TargetPrice: pick.TargetPrice,
+		})
 	}
+	return drafts
+}
 
+// pickRequest builds the per-run generation constraints: tickers picked in the
+// recent-pick window are excluded, and stored sectors back the diversity check.
+func (s *Steps) pickRequest(ctx context.Context, now time.Time) (openai.PickRequest, error) {
+	var req openai.PickRequest
+	if s.store == nil {
+		return req, nil
+	}
+	sectors, err := s.store.TickerSectors(ctx)
+	if err != nil {
+		return req, fmt.Errorf("load ticker sectors: %w", err)
+	}
+	req.Sectors = sectors
+	if s.recentPickWeeks == 0 {
+		return req, nil
+	}
+	since := now.AddDate(0, 0, -7*s.recentPickWeeks)
+	recent, err := s.store.RecentPickTickers(ctx, since)
+	if err != nil {
+		return req, fmt.Errorf("list recent picks: %w", err)
+	}
+	if len(recent) > 0 {
+		s.logger.Info("excluding recent picks", "since", formatDate(since), "tickers", recent)
+	}
+	req.ExcludeTickers = recent
+	return req, nil
+}
+
+func (s *Steps) SnapshotInitialPrices(ctx hatchet.Context, _ WeeklyPickInput) (*SnapshotOutput, error) {
 	var input GeneratePicksOutput
 	if err := ctx.StepOutput(StepGeneratePicksID, &input); err != nil {
 		return nil, err
 	}
+	return s.snapshotInitialPrices(ctx, input)
+}
+
+func (s *Steps) snapshotInitialPrices(ctx context.Context, input GeneratePicksOutput) (*SnapshotOutput, error) {
+	if s.alphaVantage == nil {
+		return nil, fmt.Errorf("alpha vantage client not configured")
+	}
 	if len(input.Picks) == 0 {
 		return nil, fmt.Errorf("no picks found from generate step")
 	}
 
-	tickers := make([]string, 0, len(input.Picks))
-	for _, pick := range input.Picks {
-		tickers = append(tickers, pick.Ticker)
-	}
-
-	prices, err := s.alphaVantage.SnapshotPreviousCloses(ctx, input.BenchmarkSymbol, tickers)
+	input, prices, err := s.quotePicks(ctx, input)
 	if err != nil {
 		return nil, err
 	}
@@ -223,6 +301,10 @@ func (s *Steps) SnapshotInitialPrices(ctx hatchet.Context, _ WeeklyPickInput) (*
 	if strings.TrimSpace(benchmarkQuote.PreviousClose) == "" {
 		return nil, fmt.Errorf("missing benchmark price for %s", input.BenchmarkSymbol)
 	}
+	benchmarkPrice, err := decimal.Parse(benchmarkQuote.PreviousClose)
+	if err != nil {
+		return nil, fmt.Errorf("invalid benchmark price for %s: %w", input.BenchmarkSymbol, err)
+	}
 	if strings.TrimSpace(benchmarkQuote.TradingDay) == "" {
 		return nil, fmt.Errorf("missing benchmark trading day for %s", input.BenchmarkSymbol)
 	}
@@ -233,40 +315,148 @@ func (s *Steps) SnapshotInitialPrices(ctx hatchet.Context, _ WeeklyPickInput) (*
 		if !ok {
 			return nil, fmt.Errorf("missing quote for %s", pick.Ticker)
 		}
-		price := strings.TrimSpace(quote.PreviousClose)
-		if price == "" {
+		if strings.TrimSpace(quote.PreviousClose) == "" {
 			return nil, fmt.Errorf("missing previous close for %s", pick.Ticker)
 		}
+		price, err := decimal.Parse(quote.PreviousClose)
+		if err != nil {
+			return nil, fmt.Errorf("invalid previous close for %s: %w", pick.Ticker, err)
+		}
 		picks = append(picks, PickWithPrice{
 			Ticker:       pick.Ticker,
 			Action:       pick.Action,
 			Reasoning:    pick.Reasoning,
 			InitialPrice: price,
+			TargetPrice:  pick.TargetPrice,
 		})
 	}
 
 	output := &SnapshotOutput{
 		RunDate:               input.RunDate,
 		BenchmarkSymbol:       input.BenchmarkSymbol,
-		BenchmarkInitialPrice: benchmarkQuote.PreviousClose,
+		BenchmarkInitialPrice: benchmarkPrice,
 		CheckpointDate:        benchmarkQuote.TradingDay,
+		ConfigHash:            input.ConfigHash,
 		Picks:                 picks,
 	}
 
-	s.logger.Info("initial prices snapped", "run_date", input.RunDate, "benchmark_price", benchmarkQuote.PreviousClose)
+	s.logger.Info("initial prices snapped", "run_date", input.RunDate, "benchmark_price", benchmarkPrice.String())
 
 	return output, nil
 }
 
-func (s *Steps) PersistBatch(ctx hatchet.Context, _ WeeklyPickInput) (*WeeklyPickState, error) {
-	if s.store == nil {
-		return nil, fmt.Errorf("db store not configured")
+// quotePicks fetches the benchmark and pick quotes. When picked tickers have
+// no usable quote (typically untradeable symbols), it asks OpenAI for
+// replacements excluding them, up to quoteReplacementRounds times. Only the
+// replacements are quoted in later rounds; the benchmark and the picks already
+// quoted are kept.
+func (s *Steps) quotePicks(ctx context.Context, input GeneratePicksOutput) (GeneratePicksOutput, map[string]alphavantage.Quote, error) {
+	tickers := make([]string, 0, len(input.Picks))
+	for _, pick := range input.Picks {
+		tickers = append(tickers, pick.Ticker)
 	}
+	prices, err := s.alphaVantage.SnapshotPreviousCloses(ctx, input.BenchmarkSymbol, tickers)
 
+	var unquotable []string
+	for round := 0; err != nil; round++ {
+		var missing *alphavantage.MissingQuoteError
+		if prices == nil || !errors.As(err, &missing) || s.openAI == nil || round == quoteReplacementRounds {
+			return input, nil, err
+		}
+
+		var quoted, dropped []string
+		for _, pick := range input.Picks {
+			if _, ok := prices[pick.Ticker]; ok {
+				quoted = append(quoted, pick.Ticker)
+			} else {
+				dropped = append(dropped, pick.Ticker)
+			}
+		}
+		unquotable = append(unquotable, dropped...)
+		s.logger.Warn("picks have no usable quote, requesting replacements", "run_date", input.RunDate, "tickers", dropped, "round", round+1)
+
+		replacements, replaceErr := s.replacementPicks(ctx, len(dropped), append(quoted, unquotable...))
+		if replaceErr != nil {
+			return input, nil, fmt.Errorf("replace unquotable picks %v: %w", dropped, replaceErr)
+		}
+		if s.reserveQuotes != nil {
+			if err := s.reserveQuotes(ctx, len(replacements)); err != nil {
+				return input, nil, err
+			}
+		}
+
+		var missingErrs []error
+		next := 0
+		input.Picks = slices.Clone(input.Picks)
+		for i, pick := range input.Picks {
+			if _, ok := prices[pick.Ticker]; ok {
+				continue
+			}
+			replacement := replacements[next]
+			next++
+			input.Picks[i] = replacement
+			quote, quoteErr := s.alphaVantage.FetchPreviousClose(ctx, replacement.Ticker)
+			if quoteErr == nil {
+				quoteErr = alphavantage.RequireQuote(quote)
+			}
+			if errors.As(quoteErr, &missing) {
+				missingErrs = append(missingErrs, quoteErr)
+				continue
+			}
+			if quoteErr != nil {
+				return input, nil, quoteErr
+			}
+			prices[replacement.Ticker] = quote
+		}
+		err = errors.Join(missingErrs...)
+	}
+	return input, prices, nil
+}
+
+// replacementPicks asks OpenAI for count picks outside exclude. The model
+// returns a full pick set, of which the first count new tickers are used.
+func (s *Steps) replacementPicks(ctx context.Context, count int, exclude []string) ([]PickDraft, error) {
+	req, err := s.pickRequest(ctx, s.clock.Now())
+	if err != nil {
+		return nil, err
+	}
+	req.ExcludeTickers = append(req.ExcludeTickers, exclude...)
+	picks, err := s.openAI.GeneratePicks(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	excluded := make(map[string]bool, len(exclude))
+	for _, ticker := range exclude {
+		excluded[ticker] = true
+	}
+	replacements := make([]PickDraft, 0, count)
+	for _, draft := range toPickDrafts(picks) {
+		if len(replacements) == count {
+			break
+		}
+		if !excluded[draft.Ticker] {
+			replacements = append(replacements, draft)
+			excluded[draft.Ticker] = true
+		}
+	}
+	if len(replacements) < count {
+		return nil, fmt.Errorf("expected %d replacement picks, got %d", count, len(replacements))
+	}
+	return replacements, nil
+}
+
+func (s *Steps) PersistBatch(ctx hatchet.Context, _ WeeklyPickInput) (*WeeklyPickState, error) {
 	var input SnapshotOutput
 	if err := ctx.StepOutput(StepSnapshotPricesID, &input); err != nil {
 		return nil, err
 	}
+	return s.persistBatch(ctx, input)
+}
+
+func (s *Steps) persistBatch(ctx context.Context, input SnapshotOutput) (*WeeklyPickState, error) {
+	if s.store == nil {
+		return nil, fmt.Errorf("db store not configured")
+	}
 
 	runDate, err := parseDate(input.RunDate)
 	if err != nil {
@@ -284,6 +474,7 @@ func (s *Steps) PersistBatch(ctx hatchet.Context, _ WeeklyPickInput) (*WeeklyPic
 			Action:       pick.Action,
 			Reasoning:    pick.Reasoning,
 			InitialPrice: pick.InitialPrice,
+			TargetPrice:  pick.TargetPrice,
 		})
 	}
 
@@ -291,6 +482,7 @@ func (s *Steps) PersistBatch(ctx hatchet.Context, _ WeeklyPickInput) (*WeeklyPic
 		RunDate:               runDate,
 		BenchmarkSymbol:       input.BenchmarkSymbol,
 		BenchmarkInitialPrice: input.BenchmarkInitialPrice,
+		ConfigHash:            input.ConfigHash,
 		Status:                "active",
 		Picks:                 picks,
 		CheckpointDate:        checkpointDate,
@@ -324,33 +516,27 @@ func (s *Steps) PersistBatch(ctx hatchet.Context, _ WeeklyPickInput) (*WeeklyPic
 	}
 
 	s.logger.Info("batch persisted", "batch_id", result.BatchID, "checkpoint_id", result.CheckpointID, "picks", state.Picks)
+	s.recordRunOutcome(ctx, result.BatchID, StepPersistBatchID, db.RunOutcomeSuccess)
 
 	return state, nil
 }
 
 func (s *Steps) DailyCheckpointLoop(ctx hatchet.DurableContext, _ WeeklyPickInput) (*DailyCheckpointLoopOutput, error) {
-	if s.sleeper == nil {
-		s.sleeper = realSleeper{clock: s.clock}
-	}
-	if s.spawnChildWorkflow == nil {
-		s.spawnChildWorkflow = defaultSpawnChildWorkflow
-	}
-
 	var state WeeklyPickState
 	if err := ctx.StepOutput(StepPersistBatchID, &state); err != nil {
 		return nil, err
 	}
 
-	if err := s.runDailyCheckpoints(ctx, state); err != nil {
+	if err := s.runDailyCheckpoints(ctx, hatchetOrchestrator{ctx: ctx, clock: s.clock}, state); err != nil {
 		return nil, err
 	}
 	return &DailyCheckpointLoopOutput{Completed: true}, nil
 }
 
-func (s *Steps) runDailyCheckpoints(ctx durableSleepContext, state WeeklyPickState) error {
-	if s.spawnChildWorkflow == nil {
-		s.spawnChildWorkflow = defaultSpawnChildWorkflow
-	}
+// runDailyCheckpoints sleeps until each daily checkpoint time and runs the
+// daily checkpoint workflow as a child, marking the batch completed on the
+// last day.
+func (s *Steps) runDailyCheckpoints(ctx context.Context, orch Orchestrator, state WeeklyPickState) error {
 	location, err := time.LoadLocation("America/New_York")
 	if err != nil {
 		return fmt.Errorf("load timezone: %w", err)
@@ -364,7 +550,7 @@ func (s *Steps) runDailyCheckpoints(ctx durableSleepContext, state WeeklyPickSta
 	base := time.Date(runDate.Year(), runDate.Month(), runDate.Day(), dailyCheckpointHour, dailyCheckpointMinute, 0, 0, location)
 	for day := 0; day < dailyCheckpointDays; day++ {
 		scheduledAt := base.AddDate(0, 0, day)
-		if err := s.sleeper.SleepUntil(ctx, scheduledAt); err != nil {
+		if err := orch.SleepUntil(ctx, scheduledAt); err != nil {
 			return err
 		}
 		input := DailyCheckpointInput{
@@ -375,7 +561,7 @@ func (s *Steps) runDailyCheckpoints(ctx durableSleepContext, state WeeklyPickSta
 			ScheduledAt:           scheduledAt.Format(time.RFC3339),
 			MarkCompleted:         day == dailyCheckpointDays-1,
 		}
-		if err := s.spawnChildWorkflow(ctx, DailyCheckpointWorkflowID, input); err != nil {
+		if err := orch.RunChild(ctx, DailyCheckpointWorkflowID, input); err != nil {
 			return err
 		}
 	}
@@ -383,39 +569,43 @@ func (s *Steps) runDailyCheckpoints(ctx durableSleepContext, state WeeklyPickSta
 	return nil
 }
 
-func defaultSpawnChildWorkflow(ctx durableSleepContext, workflowName string, input any) error {
-	spawner, ok := ctx.(interface {
-		SpawnWorkflow(workflowName string, input any, opts *hatchetworker.SpawnWorkflowOpts) (*hatchetclient.Workflow, error)
-	})
-	if !ok {
-		return fmt.Errorf("durable context does not support SpawnWorkflow")
-	}
-	workflow, err := spawner.SpawnWorkflow(workflowName, input, nil)
-	if err != nil {
-		return err
-	}
-	_, err = workflow.Result()
-	return err
-}
-
 func (s *Steps) DailyCheckpoint(ctx hatchet.Context, input DailyCheckpointInput) (*DailyCheckpointResult, error) {
 	return s.runDailyCheckpointTask(ctx, input)
 }
 
+// runDailyCheckpointTask runs one daily checkpoint and counts its outcome
+// against the batch.
 func (s *Steps) runDailyCheckpointTask(ctx context.Context, input DailyCheckpointInput) (*DailyCheckpointResult, error) {
+	status, err := s.dailyCheckpointTask(ctx, input)
+	switch {
+	case err != nil:
+		s.recordRunOutcome(ctx, input.BatchID, DailyCheckpointWorkflowID, db.RunOutcomeFailure)
+		if errors.Is(err, errUnrecoverable) {
+			s.markBatchFailed(ctx, input.BatchID, err)
+		}
+		return nil, err
+	case status == checkpointStatusSkipped:
+		s.recordRunOutcome(ctx, input.BatchID, DailyCheckpointWorkflowID, db.RunOutcomeSkip)
+	default:
+		s.recordRunOutcome(ctx, input.BatchID, DailyCheckpointWorkflowID, db.RunOutcomeSuccess)
+	}
+	return &DailyCheckpointResult{Status: "ok"}, nil
+}
+
+func (s *Steps) dailyCheckpointTask(ctx context.Context, input DailyCheckpointInput) (string, error) {
 	if s.alphaVantage == nil {
-		return nil, fmt.Errorf("alpha vantage client not configured")
+		return "", fmt.Errorf("alpha vantage client not configured")
 	}
 	if s.store == nil {
-		return nil, fmt.Errorf("db store not configured")
+		return "", fmt.Errorf("db store not configured")
 	}
 	if strings.TrimSpace(input.ScheduledAt) == "" {
-		return nil, fmt.Errorf("scheduled_at is required")
+		return "", fmt.Errorf("%w: scheduled_at is required", errUnrecoverable)
 	}
 
 	scheduledAt, err := time.Parse(time.RFC3339, input.ScheduledAt)
 	if err != nil {
-		return nil, fmt.Errorf("invalid scheduled_at %q: %w", input.ScheduledAt, err)
+		return "", fmt.Errorf("%w: invalid scheduled_at %q: %v", errUnrecoverable, input.ScheduledAt, err)
 	}
 
 	state := WeeklyPickState{
@@ -425,82 +615,178 @@ func (s *Steps) runDailyCheckpointTask(ctx context.Context, input DailyCheckpoin
 		Picks:                 input.Picks,
 	}
 
-	if err := s.runDailyCheckpoint(ctx, state, scheduledAt); err != nil {
-		return nil, err
+	if input.CheckpointDate != "" {
+		return s.recomputeCheckpoint(ctx, state, input.CheckpointDate)
+	}
+
+	// An admin may have completed, cancelled or deleted the batch; its
+	// remaining checkpoints are not collected. A deleted batch has no status.
+	batchStatus, err := s.store.BatchStatus(ctx, input.BatchID)
+	if err != nil {
+		return "", fmt.Errorf("load batch status: %w", err)
+	}
+	if batchStatus != batchStatusActive {
+		s.logger.Info("batch no longer active, checkpoint not collected", "batch_id", input.BatchID, "status", batchStatus)
+		return checkpointStatusSkipped, nil
+	}
+
+	status, err := s.runDailyCheckpoint(ctx, state, scheduledAt)
+	if err != nil {
+		return "", err
 	}
 
 	if input.MarkCompleted {
 		if err := s.store.UpdateBatchStatus(ctx, input.BatchID, batchStatusCompleted); err != nil {
-			return nil, fmt.Errorf("update batch status: %w", err)
+			return "", fmt.Errorf("update batch status: %w", err)
 		}
 	}
 
-	return &DailyCheckpointResult{Status: "ok"}, nil
+	return status, nil
+}
+
+// quoteFunc fetches one symbol's quote for a checkpoint.
+type quoteFunc func(ctx context.Context, symbol string) (alphavantage.Quote, error)
+
+// historicalQuoter is implemented by Alpha Vantage clients that can look up a
+// past day's close, which recomputing a checkpoint needs.
+type historicalQuoter interface {
+	FetchCloseOn(ctx context.Context, symbol, day string) (alphavantage.Quote, error)
+}
+
+// runDailyCheckpoint computes and persists one checkpoint from the latest
+// closes, returning its status.
+func (s *Steps) runDailyCheckpoint(ctx context.Context, state WeeklyPickState, scheduledAt time.Time) (string, error) {
+	return s.computeCheckpoint(ctx, state, previousTradingDayFallback(scheduledAt), s.alphaVantage.FetchPreviousClose)
 }
 
-func (s *Steps) runDailyCheckpoint(ctx context.Context, state WeeklyPickState, scheduledAt time.Time) error {
-	benchmarkQuote, err := s.alphaVantage.FetchPreviousClose(ctx, state.BenchmarkSymbol)
+// recomputeCheckpoint computes the checkpoint for a past trading day from that
+// day's closes. The store replaces a skipped checkpoint for the day; a
+// computed one is left as is.
+func (s *Steps) recomputeCheckpoint(ctx context.Context, state WeeklyPickState, day string) (string, error) {
+	checkpointDate, err := parseDate(day)
 	if err != nil {
-		return err
+		return "", fmt.Errorf("invalid checkpoint_date %q: %w", day, err)
+	}
+	quoter, ok := s.alphaVantage.(historicalQuoter)
+	if !ok {
+		return "", fmt.Errorf("alpha vantage client does not support historical quotes")
+	}
+	return s.computeCheckpoint(ctx, state, checkpointDate, func(ctx context.Context, symbol string) (alphavantage.Quote, error) {
+		return quoter.FetchCloseOn(ctx, symbol, day)
+	})
+}
+
+// computeCheckpoint computes and persists one checkpoint from the quotes
+// returned by fetch. checkpointDate is used when the benchmark has no quote.
+func (s *Steps) computeCheckpoint(ctx context.Context, state WeeklyPickState, checkpointDate time.Time, fetch quoteFunc) (string, error) {
+	benchmarkQuote, err := fetch(ctx, state.BenchmarkSymbol)
+	if err != nil {
+		return "", err
 	}
 
-	checkpointDate := previousTradingDayFallback(scheduledAt)
 	if strings.TrimSpace(benchmarkQuote.PreviousClose) == "" {
-		return s.persistCheckpoint(ctx, state, checkpointDate, nil, nil, nil, checkpointStatusSkipped)
+		return checkpointStatusSkipped, s.persistCheckpoint(ctx, state, checkpointDate, nil, nil, nil, nil, checkpointStatusSkipped)
 	}
 	if strings.TrimSpace(benchmarkQuote.TradingDay) == "" {
-		return fmt.Errorf("missing benchmark trading day for %s", state.BenchmarkSymbol)
+		return "", fmt.Errorf("missing benchmark trading day for %s", state.BenchmarkSymbol)
 	}
 
 	parsedDate, err := parseDate(benchmarkQuote.TradingDay)
 	if err != nil {
-		return fmt.Errorf("invalid trading day %q: %w", benchmarkQuote.TradingDay, err)
+		return "", fmt.Errorf("invalid trading day %q: %w", benchmarkQuote.TradingDay, err)
 	}
 	checkpointDate = parsedDate
 
-	pickQuotes, err := s.fetchPickQuotes(ctx, state.Picks)
+	pickQuotes, err := s.fetchQuotes(ctx, state.Picks, fetch)
 	if err != nil {
-		return err
+		return "", err
 	}
 
 	for _, pick := range state.Picks {
 		quote := pickQuotes[pick.Ticker]
 		if strings.TrimSpace(quote.PreviousClose) == "" {
-			return s.persistCheckpoint(ctx, state, checkpointDate, nil, nil, nil, checkpointStatusSkipped)
+			return checkpointStatusSkipped, s.persistCheckpoint(ctx, state, checkpointDate, nil, nil, nil, nil, checkpointStatusSkipped)
 		}
 	}
 
-	benchmarkPrice := strings.TrimSpace(benchmarkQuote.PreviousClose)
+	benchmarkPrice, err := decimal.Parse(benchmarkQuote.PreviousClose)
+	if err != nil {
+		return "", fmt.Errorf("invalid benchmark price for %s: %w", state.BenchmarkSymbol, err)
+	}
 	benchmarkReturn, err := calculateReturnPct(state.BenchmarkInitialPrice, benchmarkPrice)
 	if err != nil {
-		return err
+		return "", err
 	}
 
+	prices := []db.DailyPrice{dailyPrice(state.BenchmarkSymbol, benchmarkQuote, checkpointDate, benchmarkPrice)}
 	metrics := make([]db.NewCheckpointMetric, 0, len(state.Picks))
 	for _, pick := range state.Picks {
 		quote := pickQuotes[pick.Ticker]
-		currentPrice := strings.TrimSpace(quote.PreviousClose)
-		absoluteReturn, err := calculateReturnPct(pick.InitialPrice, currentPrice)
+		currentPrice, err := decimal.Parse(quote.PreviousClose)
 		if err != nil {
-			return err
+			return "", fmt.Errorf("invalid previous close for %s: %w", pick.Ticker, err)
 		}
-		vsBenchmark, err := subtractDecimalStrings(absoluteReturn, benchmarkReturn)
+		absoluteReturn, err := calculateReturnPct(pick.InitialPrice, currentPrice)
 		if err != nil {
-			return err
+			return "", err
 		}
+		vsBenchmark := absoluteReturn.Sub(benchmarkReturn)
 
 		metrics = append(metrics, db.NewCheckpointMetric{
 			PickID:            pick.PickID,
 			CurrentPrice:      currentPrice,
 			AbsoluteReturnPct: absoluteReturn,
 			VsBenchmarkPct:    vsBenchmark,
+			OpenPrice:         optionalDecimal(quote.Open),
+			HighPrice:         optionalDecimal(quote.High),
+			LowPrice:          optionalDecimal(quote.Low),
+			Volume:            optionalInt(quote.Volume),
 		})
+		prices = append(prices, dailyPrice(pick.Ticker, quote, checkpointDate, currentPrice))
+	}
+
+	return checkpointStatusComputed, s.persistCheckpoint(ctx, state, checkpointDate, &benchmarkPrice, &benchmarkReturn, metrics, prices, checkpointStatusComputed)
+}
+
+// dailyPrice converts a quote into a price history row. The quote's own
+// trading day is used when it parses, so a pick quoted on a different day
+// than the benchmark is not misfiled.
+func dailyPrice(ticker string, quote alphavantage.Quote, checkpointDate time.Time, closePrice decimal.Decimal) db.DailyPrice {
+	tradingDay := checkpointDate
+	if parsed, err := parseDate(quote.TradingDay); err == nil {
+		tradingDay = parsed
+	}
+	return db.DailyPrice{
+		Ticker:     ticker,
+		TradingDay: tradingDay,
+		Close:      closePrice,
+		Open:       optionalDecimal(quote.Open),
+		High:       optionalDecimal(quote.High),
+		Low:        optionalDecimal(quote.Low),
+		Volume:     optionalInt(quote.Volume),
+	}
+}
+
+// optionalDecimal parses an informational quote field, returning nil when it
+// is missing or malformed rather than failing the checkpoint.
+func optionalDecimal(value string) *decimal.Decimal {
+	parsed, err := decimal.Parse(strings.TrimSpace(value))
+	if err != nil {
+		return nil
 	}
+	return &parsed
+}
 
-	return s.persistCheckpoint(ctx, state, checkpointDate, &benchmarkPrice, &benchmarkReturn, metrics, checkpointStatusComputed)
+// optionalInt is optionalDecimal for integer fields such as volume.
+func optionalInt(value string) *int64 {
+	parsed, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+	if err != nil {
+		return nil
+	}
+	return &parsed
 }
 
-func (s *Steps) persistCheckpoint(ctx context.Context, state WeeklyPickState, checkpointDate time.Time, benchmarkPrice *string, benchmarkReturn *string, metrics []db.NewCheckpointMetric, status string) error {
+func (s *Steps) persistCheckpoint(ctx context.Context, state WeeklyPickState, checkpointDate time.Time, benchmarkPrice *decimal.Decimal, benchmarkReturn *decimal.Decimal, metrics []db.NewCheckpointMetric, prices []db.DailyPrice, status string) error {
 	if s.logger == nil {
 		s.logger = slog.Default()
 	}
@@ -511,6 +797,7 @@ func (s *Steps) persistCheckpoint(ctx context.Context, state WeeklyPickState, ch
 		BenchmarkPrice:     benchmarkPrice,
 		BenchmarkReturnPct: benchmarkReturn,
 		Metrics:            metrics,
+		Prices:             prices,
 	})
 	if err != nil {
 		if errors.Is(err, db.ErrCheckpointConflict) {
@@ -522,7 +809,43 @@ func (s *Steps) persistCheckpoint(ctx context.Context, state WeeklyPickState, ch
 	return nil
 }
 
+// recordRunOutcome counts a step outcome against the batch. Counters are
+// operational history only, so failing to record one never fails the step.
+func (s *Steps) recordRunOutcome(ctx context.Context, batchID, step, outcome string) {
+	if s.store == nil || batchID == "" {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+	defer cancel()
+	if err := s.store.RecordRunOutcome(ctx, batchID, step, outcome); err != nil {
+		s.logger.Warn("record run outcome failed", "batch_id", batchID, "step", step, "outcome", outcome, "error", err)
+	}
+}
+
+// markBatchFailed moves the batch to failed after an unrecoverable error, so
+// it stops showing as active. A failed update is only logged; the step error
+// is what the engine reports.
+func (s *Steps) markBatchFailed(ctx context.Context, batchID string, cause error) {
+	if s.store == nil || batchID == "" {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+	defer cancel()
+	if err := s.store.UpdateBatchStatus(ctx, batchID, batchStatusFailed); err != nil {
+		s.logger.Error("update batch status failed", "batch_id", batchID, "cause", cause, "error", err)
+		return
+	}
+	s.logger.Error("batch marked failed", "batch_id", batchID, "error", cause)
+}
+
+// fetchPickQuotes fetches the latest quote of each pick.
 func (s *Steps) fetchPickQuotes(ctx context.Context, picks []PickState) (map[string]alphavantage.Quote, error) {
+	return s.fetchQuotes(ctx, picks, s.alphaVantage.FetchPreviousClose)
+}
+
+// fetchQuotes fetches each distinct pick ticker with fetch, in parallel up to
+// the configured concurrency.
+func (s *Steps) fetchQuotes(ctx context.Context, picks []PickState, fetch quoteFunc) (map[string]alphavantage.Quote, error) {
 	tickers := make([]string, 0, len(picks))
 	seen := map[string]struct{}{}
 	for _, pick := range picks {
@@ -537,91 +860,78 @@ func (s *Steps) fetchPickQuotes(ctx context.Context, picks []PickState) (map[str
 		tickers = append(tickers, ticker)
 	}
 
-	type result struct {
-		ticker string
-		quote  alphavantage.Quote
-		err    error
+	concurrency := s.quoteConcurrency
+	if concurrency <= 0 {
+		concurrency = DefaultQuoteConcurrency
+	}
+	if s.quoteFanout == QuoteFanoutSequential {
+		concurrency = 1
 	}
 
-	results := make(chan result, len(tickers))
-	sem := make(chan struct{}, priceFanoutConcurrency)
+	// The first failure cancels the fetches still in flight; all goroutines
+	// finish before returning so none outlive the step.
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
 
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		firstErr error
+	)
+	quotes := make(map[string]alphavantage.Quote, len(tickers))
+	sem := make(chan struct{}, concurrency)
 	for _, ticker := range tickers {
-		sem <- struct{}{}
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+		wg.Add(1)
 		go func(symbol string) {
+			defer wg.Done()
 			defer func() { <-sem }()
-			quote, err := s.alphaVantage.FetchPreviousClose(ctx, symbol)
-			results <- result{ticker: symbol, quote: quote, err: err}
+			quote, err := fetch(ctx, symbol)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				if firstErr == nil {
+					firstErr = err
+					cancel()
+				}
+				return
+			}
+			quotes[symbol] = quote
 		}(ticker)
 	}
+	wg.Wait()
 
-	quotes := make(map[string]alphavantage.Quote, len(tickers))
-	for i := 0; i < len(tickers); i++ {
-		res := <-results
-		if res.err != nil {
-			return nil, res.err
-		}
-		quotes[res.ticker] = res.quote
-	}
-
-	return quotes, nil
-}
-
-func calculateReturnPct(initialValue, currentValue string) (string, error) {
-	initial, err := parsePositiveDecimal(initialValue, "initial")
-	if err != nil {
-		return "", err
-	}
-	current, err := parsePositiveDecimal(currentValue, "current")
-	if err != nil {
-		return "", err
+	if firstErr != nil {
+		return nil, firstErr
 	}
-
-	diff := new(big.Rat).Sub(current, initial)
-	diff.Mul(diff, big.NewRat(100, 1))
-	result := new(big.Rat).Quo(diff, initial)
-
-	return formatDecimal(result), nil
-}
-
-func subtractDecimalStrings(left, right string) (string, error) {
-	leftRat, err := parseDecimal(left)
-	if err != nil {
-		return "", fmt.Errorf("invalid decimal %q: %w", left, err)
-	}
-	rightRat, err := parseDecimal(right)
-	if err != nil {
-		return "", fmt.Errorf("invalid decimal %q: %w", right, err)
+	if err := ctx.Err(); err != nil {
+		return nil, err
 	}
-	result := new(big.Rat).Sub(leftRat, rightRat)
-	return formatDecimal(result), nil
+	return quotes, nil
 }
 
-func parseDecimal(value string) (*big.Rat, error) {
-	value = strings.TrimSpace(value)
-	if value == "" {
-		return nil, fmt.Errorf("value is required")
+// calculateReturnPct returns the percentage change from initial to current,
+// rounded to metricPrecisionScale fractional digits.
+func calculateReturnPct(initial, current decimal.Decimal) (decimal.Decimal, error) {
+	if initial.Sign() <= 0 {
+		return decimal.Decimal{}, fmt.Errorf("initial value must be positive")
 	}
-	rat, ok := new(big.Rat).SetString(value)
-	if !ok {
-		return nil, fmt.Errorf("invalid decimal %q", value)
+	if current.Sign() <= 0 {
+		return decimal.Decimal{}, fmt.Errorf("current value must be positive")
 	}
-	return rat, nil
-}
 
-func parsePositiveDecimal(value string, label string) (*big.Rat, error) {
-	rat, err := parseDecimal(value)
+	diff := current.Sub(initial).Mul(decimal.NewFromInt(100))
+	result, err := diff.Quo(initial)
 	if err != nil {
-		return nil, err
+		return decimal.Decimal{}, err
 	}
-	if rat.Sign() <= 0 {
-		return nil, fmt.Errorf("%s value must be positive", label)
-	}
-	return rat, nil
-}
-
-func formatDecimal(value *big.Rat) string {
-	return value.FloatString(metricPrecisionScale)
+	return result.Round(metricPrecisionScale), nil
 }
 
 func parseDateInLocation(value string, location *time.Location) (time.Time, error) {
@@ -0,0 +1,177 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/igor-kupczynski/alpha-monday/internal/integrations/alphavantage"
+	"github.com/igor-kupczynski/alpha-monday/internal/integrations/openai"
+)
+
+type fakeOpenAI struct {
+	requests []openai.PickRequest
+	// responses are returned in order; JNJ is returned once they run out.
+	responses [][]openai.Pick
+}
+
+func (f *fakeOpenAI) GeneratePicks(ctx context.Context, req openai.PickRequest) ([]openai.Pick, error) {
+	f.requests = append(f.requests, req)
+	if len(f.requests) <= len(f.responses) {
+		return f.responses[len(f.requests)-1], nil
+	}
+	return []openai.Pick{{Ticker: "JNJ", Action: openai.ActionBuy, Reasoning: "ok"}}, nil
+}
+
+func (f *fakeOpenAI) ConfigHash() string {
+	return "hash"
+}
+
+func TestGeneratePicksExcludesRecentPicks(t *testing.T) {
+	store := &fakeStore{recentTickers: []string{"AAPL", "MSFT"}, sectors: map[string]string{"AAPL": "Technology"}}
+	client := &fakeOpenAI{}
+	steps := NewSteps(store, client, nil, slog.New(slog.NewTextHandler(io.Discard, nil)), WithRecentPickWindow(4))
+	steps.clock = &fakeClock{now: time.Date(2026, 2, 2, 9, 0, 0, 0, time.UTC)}
+
+	output, err := steps.generatePicks(context.Background(), WeeklyPickInput{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := time.Date(2026, 1, 5, 9, 0, 0, 0, time.UTC); !store.recentSince.Equal(want) {
+		t.Fatalf("expected window start %s, got %s", want, store.recentSince)
+	}
+	if len(client.requests) != 1 || !reflect.DeepEqual(client.requests[0].ExcludeTickers, store.recentTickers) {
+		t.Fatalf("expected recent tickers excluded, got %+v", client.requests)
+	}
+	if !reflect.DeepEqual(client.requests[0].Sectors, store.sectors) {
+		t.Fatalf("expected stored sectors passed, got %v", client.requests[0].Sectors)
+	}
+	if output.ConfigHash != "hash" || output.RunDate != "2026-02-02" {
+		t.Fatalf("unexpected output %+v", output)
+	}
+
+	client.requests = nil
+	steps = NewSteps(store, client, nil, nil)
+	if _, err := steps.generatePicks(context.Background(), WeeklyPickInput{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(client.requests[0].ExcludeTickers) != 0 {
+		t.Fatalf("expected no exclusions without a window, got %v", client.requests[0].ExcludeTickers)
+	}
+}
+
+func TestGeneratePicksRunDateOverride(t *testing.T) {
+	steps := NewSteps(&fakeStore{}, &fakeOpenAI{}, nil, nil)
+	steps.clock = &fakeClock{now: time.Date(2026, 2, 2, 9, 0, 0, 0, time.UTC)}
+
+	output, err := steps.generatePicks(context.Background(), WeeklyPickInput{RunDate: "2026-01-26"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if output.RunDate != "2026-01-26" {
+		t.Fatalf("expected overridden run date, got %s", output.RunDate)
+	}
+
+	_, err = steps.generatePicks(context.Background(), WeeklyPickInput{RunDate: "26/01/2026"})
+	if !errors.Is(err, errUnrecoverable) {
+		t.Fatalf("expected unrecoverable error for invalid run date, got %v", err)
+	}
+}
+
+// quoteAlpha serves the configured previous closes and reports any other
+// symbol as missing, like Alpha Vantage does for untradeable tickers. Every
+// quoted symbol is recorded in fetched.
+type quoteAlpha struct {
+	closes  map[string]string
+	fetched []string
+}
+
+func (q *quoteAlpha) FetchPreviousClose(ctx context.Context, symbol string) (alphavantage.Quote, error) {
+	q.fetched = append(q.fetched, symbol)
+	price, ok := q.closes[symbol]
+	if !ok {
+		return alphavantage.Quote{Symbol: symbol}, nil
+	}
+	return alphavantage.Quote{Symbol: symbol, PreviousClose: price, TradingDay: "2026-01-30"}, nil
+}
+
+func (q *quoteAlpha) SnapshotPreviousCloses(ctx context.Context, benchmark string, picks []string) (map[string]alphavantage.Quote, error) {
+	quotes := map[string]alphavantage.Quote{}
+	var missing []error
+	for _, symbol := range append([]string{benchmark}, picks...) {
+		quote, _ := q.FetchPreviousClose(ctx, symbol)
+		if err := alphavantage.RequireQuote(quote); err != nil {
+			if symbol == benchmark {
+				return nil, err
+			}
+			missing = append(missing, err)
+			continue
+		}
+		quotes[symbol] = quote
+	}
+	return quotes, errors.Join(missing...)
+}
+
+func TestSnapshotInitialPricesReplacesUnquotablePicks(t *testing.T) {
+	client := &fakeOpenAI{responses: [][]openai.Pick{
+		{{Ticker: "AAPL", Action: openai.ActionBuy, Reasoning: "ok"}, {Ticker: "MSFT", Action: openai.ActionSell, Reasoning: "ok"}},
+	}}
+	alpha := &quoteAlpha{closes: map[string]string{"SPY": "480.00", "AAPL": "190.00", "MSFT": "400.00"}}
+	steps := NewSteps(&fakeStore{}, client, alpha, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	var reserved []int
+	steps.reserveQuotes = func(ctx context.Context, calls int) error {
+		reserved = append(reserved, calls)
+		return nil
+	}
+
+	output, err := steps.snapshotInitialPrices(context.Background(), GeneratePicksOutput{
+		RunDate:         "2026-02-02",
+		BenchmarkSymbol: "SPY",
+		Picks:           []PickDraft{{Ticker: "AAPL", Action: openai.ActionBuy, Reasoning: "ok"}, {Ticker: "DELISTED", Action: openai.ActionBuy, Reasoning: "ok"}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(output.Picks) != 2 || output.Picks[0].Ticker != "AAPL" || output.Picks[1].Ticker != "MSFT" || output.Picks[1].InitialPrice.String() != "400.00" {
+		t.Fatalf("expected DELISTED replaced by MSFT, got %+v", output.Picks)
+	}
+	if len(client.requests) != 1 || !reflect.DeepEqual(client.requests[0].ExcludeTickers, []string{"AAPL", "DELISTED"}) {
+		t.Fatalf("expected quoted and unquotable tickers excluded, got %+v", client.requests)
+	}
+	// Only the replacement is quoted again; the benchmark and AAPL are not.
+	if want := []string{"SPY", "AAPL", "DELISTED", "MSFT"}; !reflect.DeepEqual(alpha.fetched, want) {
+		t.Fatalf("expected quotes %v, got %v", want, alpha.fetched)
+	}
+	if !reflect.DeepEqual(reserved, []int{1}) {
+		t.Fatalf("expected one unit reserved for the replacement round, got %v", reserved)
+	}
+
+	// Without a usable replacement the step gives up after the bounded rounds.
+	client = &fakeOpenAI{responses: [][]openai.Pick{
+		{{Ticker: "GONE", Action: openai.ActionBuy, Reasoning: "ok"}},
+	}}
+	alpha = &quoteAlpha{closes: map[string]string{"SPY": "480.00"}}
+	steps = NewSteps(&fakeStore{}, client, alpha, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	_, err = steps.snapshotInitialPrices(context.Background(), GeneratePicksOutput{
+		RunDate:         "2026-02-02",
+		BenchmarkSymbol: "SPY",
+		Picks:           []PickDraft{{Ticker: "DELISTED", Action: openai.ActionBuy, Reasoning: "ok"}},
+	})
+	var missing *alphavantage.MissingQuoteError
+	if !errors.As(err, &missing) || missing.Symbol != "JNJ" {
+		t.Fatalf("expected missing quote error, got %v", err)
+	}
+	if len(client.requests) != quoteReplacementRounds {
+		t.Fatalf("expected %d replacement requests, got %d", quoteReplacementRounds, len(client.requests))
+	}
+	if !reflect.DeepEqual(client.requests[1].ExcludeTickers, []string{"DELISTED", "GONE"}) {
+		t.Fatalf("expected every unquotable ticker excluded, got %v", client.requests[1].ExcludeTickers)
+	}
+	if want := []string{"SPY", "DELISTED", "GONE", "JNJ"}; !reflect.DeepEqual(alpha.fetched, want) {
+		t.Fatalf("expected quotes %v, got %v", want, alpha.fetched)
+	}
+}
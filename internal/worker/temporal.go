@@ -0,0 +1,229 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"go.temporal.io/sdk/activity"
+	"go.temporal.io/sdk/client"
+	"go.temporal.io/sdk/temporal"
+	temporalworker "go.temporal.io/sdk/worker"
+	"go.temporal.io/sdk/workflow"
+)
+
+const (
+	temporalDailyCheckpointActivity = "daily_checkpoint"
+	temporalActivityTimeout         = 5 * time.Minute
+	temporalScheduleTimezone        = "America/New_York"
+)
+
+// temporalRetryPolicy mirrors the Hatchet step retries: a few attempts with
+// exponential backoff.
+var temporalRetryPolicy = &temporal.RetryPolicy{
+	InitialInterval:    time.Second,
+	BackoffCoefficient: 2,
+	MaximumInterval:    time.Minute,
+	MaximumAttempts:    3,
+}
+
+type TemporalConfig struct {
+	HostPort  string
+	Namespace string
+	TaskQueue string
+}
+
+// TemporalEngine runs the workflows on Temporal. Steps run as activities, the
+// daily loop is a workflow using durable timers and child workflows, and cron
+// workflows are registered as Temporal schedules.
+type TemporalEngine struct {
+	client  client.Client
+	config  TemporalConfig
+	limits  RateLimitConfig
+	logger  *slog.Logger
+	limiter *callLimiter
+	worker  temporalworker.Worker
+	steps   *Steps
+}
+
+func NewTemporalEngine(c client.Client, config TemporalConfig, limits RateLimitConfig, logger *slog.Logger) *TemporalEngine {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &TemporalEngine{
+		client:  c,
+		config:  config,
+		limits:  limits,
+		logger:  logger,
+		limiter: newCallLimiter(limits),
+	}
+}
+
+// Register registers workflows and activities with a worker on the task queue
+// and creates a schedule for every cron workflow. Existing schedules are kept.
+func (e *TemporalEngine) Register(steps *Steps) error {
+	if steps == nil {
+		return fmt.Errorf("steps are required")
+	}
+	if err := e.limits.Validate(); err != nil {
+		return err
+	}
+	e.steps = steps
+	steps.reserveQuotes = e.limiter.wait
+
+	w := temporalworker.New(e.client, e.config.TaskQueue, temporalworker.Options{})
+	e.registerWith(w)
+
+	for _, spec := range workflowSpecs(e.limits) {
+		if spec.Cron == "" {
+			continue
+		}
+		_, err := e.client.ScheduleClient().Create(context.Background(), client.ScheduleOptions{
+			ID: spec.ID,
+			Spec: client.ScheduleSpec{
+				CronExpressions: []string{spec.Cron},
+				TimeZoneName:    temporalScheduleTimezone,
+			},
+			Action: &client.ScheduleWorkflowAction{
+				ID:        spec.ID,
+				Workflow:  spec.ID,
+				TaskQueue: e.config.TaskQueue,
+			},
+		})
+		if err != nil && !errors.Is(err, temporal.ErrScheduleAlreadyRunning) {
+			return fmt.Errorf("create schedule %s: %w", spec.ID, err)
+		}
+	}
+
+	e.worker = w
+	return nil
+}
+
+// temporalRegistry is the registration subset shared by workers and the
+// workflow test environment.
+type temporalRegistry interface {
+	RegisterWorkflowWithOptions(w interface{}, options workflow.RegisterOptions)
+	RegisterActivityWithOptions(a interface{}, options activity.RegisterOptions)
+}
+
+func (e *TemporalEngine) registerWith(r temporalRegistry) {
+	r.RegisterWorkflowWithOptions(e.weeklyWorkflow, workflow.RegisterOptions{Name: WeeklyPickWorkflowID})
+	r.RegisterWorkflowWithOptions(e.dailyCheckpointWorkflow, workflow.RegisterOptions{Name: DailyCheckpointWorkflowID})
+	r.RegisterWorkflowWithOptions(e.verifyMetricsWorkflow, workflow.RegisterOptions{Name: VerifyMetricsWorkflowID})
+
+	r.RegisterActivityWithOptions(e.generatePicksActivity, activity.RegisterOptions{Name: StepGeneratePicksID})
+	r.RegisterActivityWithOptions(e.snapshotActivity, activity.RegisterOptions{Name: StepSnapshotPricesID})
+	r.RegisterActivityWithOptions(e.persistBatchActivity, activity.RegisterOptions{Name: StepPersistBatchID})
+	r.RegisterActivityWithOptions(e.dailyCheckpointActivity, activity.RegisterOptions{Name: temporalDailyCheckpointActivity})
+	r.RegisterActivityWithOptions(e.verifyMetricsActivity, activity.RegisterOptions{Name: StepVerifyMetricsID})
+}
+
+func (e *TemporalEngine) Start() (func() error, error) {
+	if e.worker == nil {
+		return nil, fmt.Errorf("workflows are not registered")
+	}
+	if err := e.worker.Start(); err != nil {
+		return nil, err
+	}
+	return func() error {
+		e.worker.Stop()
+		e.client.Close()
+		return nil
+	}, nil
+}
+
+func (e *TemporalEngine) weeklyWorkflow(ctx workflow.Context) (*DailyCheckpointLoopOutput, error) {
+	ctx = withTemporalActivityOptions(ctx)
+
+	var picks GeneratePicksOutput
+	if err := workflow.ExecuteActivity(ctx, StepGeneratePicksID).Get(ctx, &picks); err != nil {
+		return nil, err
+	}
+	var snapshot SnapshotOutput
+	if err := workflow.ExecuteActivity(ctx, StepSnapshotPricesID, picks).Get(ctx, &snapshot); err != nil {
+		return nil, err
+	}
+	var state WeeklyPickState
+	if err := workflow.ExecuteActivity(ctx, StepPersistBatchID, snapshot).Get(ctx, &state); err != nil {
+		return nil, err
+	}
+
+	// runDailyCheckpoints only does deterministic work besides the
+	// orchestrator calls, so it is safe to run as workflow code.
+	if err := e.steps.runDailyCheckpoints(context.Background(), temporalOrchestrator{ctx: ctx}, state); err != nil {
+		return nil, err
+	}
+	return &DailyCheckpointLoopOutput{Completed: true}, nil
+}
+
+func (e *TemporalEngine) dailyCheckpointWorkflow(ctx workflow.Context, input DailyCheckpointInput) (*DailyCheckpointResult, error) {
+	ctx = withTemporalActivityOptions(ctx)
+	var result DailyCheckpointResult
+	if err := workflow.ExecuteActivity(ctx, temporalDailyCheckpointActivity, input).Get(ctx, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (e *TemporalEngine) verifyMetricsWorkflow(ctx workflow.Context) (*VerifyMetricsOutput, error) {
+	ctx = withTemporalActivityOptions(ctx)
+	var output VerifyMetricsOutput
+	if err := workflow.ExecuteActivity(ctx, StepVerifyMetricsID, VerifyMetricsInput{}).Get(ctx, &output); err != nil {
+		return nil, err
+	}
+	return &output, nil
+}
+
+func (e *TemporalEngine) generatePicksActivity(ctx context.Context) (*GeneratePicksOutput, error) {
+	return e.steps.generatePicks(ctx, WeeklyPickInput{})
+}
+
+func (e *TemporalEngine) snapshotActivity(ctx context.Context, input GeneratePicksOutput) (*SnapshotOutput, error) {
+	if err := e.limiter.wait(ctx, e.limits.stepUnits(len(input.Picks))); err != nil {
+		return nil, err
+	}
+	return e.steps.snapshotInitialPrices(ctx, input)
+}
+
+func (e *TemporalEngine) persistBatchActivity(ctx context.Context, input SnapshotOutput) (*WeeklyPickState, error) {
+	return e.steps.persistBatch(ctx, input)
+}
+
+func (e *TemporalEngine) dailyCheckpointActivity(ctx context.Context, input DailyCheckpointInput) (*DailyCheckpointResult, error) {
+	if err := e.limiter.wait(ctx, e.limits.stepUnits(len(input.Picks))); err != nil {
+		return nil, err
+	}
+	return e.steps.runDailyCheckpointTask(ctx, input)
+}
+
+func (e *TemporalEngine) verifyMetricsActivity(ctx context.Context, input VerifyMetricsInput) (*VerifyMetricsOutput, error) {
+	return e.steps.verifyMetrics(ctx, input)
+}
+
+func withTemporalActivityOptions(ctx workflow.Context) workflow.Context {
+	return workflow.WithActivityOptions(ctx, workflow.ActivityOptions{
+		StartToCloseTimeout: temporalActivityTimeout,
+		RetryPolicy:         temporalRetryPolicy,
+	})
+}
+
+// temporalOrchestrator implements Orchestrator inside a Temporal workflow
+// with durable timers and child workflows. The context.Context arguments are
+// ignored; workflow code must use the workflow context.
+type temporalOrchestrator struct {
+	ctx workflow.Context
+}
+
+func (o temporalOrchestrator) SleepUntil(_ context.Context, target time.Time) error {
+	d := target.Sub(workflow.Now(o.ctx))
+	if d <= 0 {
+		return nil
+	}
+	return workflow.Sleep(o.ctx, d)
+}
+
+func (o temporalOrchestrator) RunChild(_ context.Context, workflowID string, input any) error {
+	return workflow.ExecuteChildWorkflow(o.ctx, workflowID, input).Get(o.ctx, nil)
+}
@@ -0,0 +1,70 @@
+package worker
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/igor-kupczynski/alpha-monday/internal/decimal"
+	"github.com/stretchr/testify/mock"
+	"go.temporal.io/sdk/testsuite"
+)
+
+func TestTemporalWeeklyWorkflowRunsDailyCheckpoints(t *testing.T) {
+	location, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatalf("load location: %v", err)
+	}
+
+	var suite testsuite.WorkflowTestSuite
+	env := suite.NewTestWorkflowEnvironment()
+	env.SetStartTime(time.Date(2026, 1, 5, 8, 0, 0, 0, location))
+
+	engine := NewTemporalEngine(nil, TemporalConfig{}, DefaultRateLimitConfig(), nil)
+	engine.steps = NewSteps(nil, nil, nil, nil)
+	engine.registerWith(env)
+
+	state := &WeeklyPickState{
+		BatchID:               "batch-123",
+		RunDate:               "2026-01-05",
+		BenchmarkSymbol:       "SPY",
+		BenchmarkInitialPrice: decimal.MustParse("95.00"),
+		Picks:                 []PickState{{PickID: "pick-1", Ticker: "AAPL", InitialPrice: decimal.MustParse("45.00")}},
+	}
+	env.OnActivity(StepGeneratePicksID, mock.Anything).Return(&GeneratePicksOutput{RunDate: state.RunDate}, nil)
+	env.OnActivity(StepSnapshotPricesID, mock.Anything, mock.Anything).Return(&SnapshotOutput{RunDate: state.RunDate}, nil)
+	env.OnActivity(StepPersistBatchID, mock.Anything, mock.Anything).Return(state, nil)
+
+	var daily []DailyCheckpointInput
+	env.OnActivity(temporalDailyCheckpointActivity, mock.Anything, mock.Anything).Return(
+		func(_ context.Context, input DailyCheckpointInput) (*DailyCheckpointResult, error) {
+			daily = append(daily, input)
+			return &DailyCheckpointResult{Status: "ok"}, nil
+		},
+	)
+
+	env.ExecuteWorkflow(WeeklyPickWorkflowID)
+	if !env.IsWorkflowCompleted() {
+		t.Fatalf("expected workflow to complete")
+	}
+	if err := env.GetWorkflowError(); err != nil {
+		t.Fatalf("workflow failed: %v", err)
+	}
+
+	targets := expectedDailyTargets(state.RunDate, location)
+	if len(daily) != len(targets) {
+		t.Fatalf("expected %d daily checkpoints, got %d", len(targets), len(daily))
+	}
+	for i, input := range daily {
+		scheduledAt, err := time.Parse(time.RFC3339, input.ScheduledAt)
+		if err != nil {
+			t.Fatalf("parse scheduled_at: %v", err)
+		}
+		if !scheduledAt.Equal(targets[i]) {
+			t.Fatalf("expected scheduled_at %s, got %s", targets[i], scheduledAt)
+		}
+		if input.BatchID != state.BatchID || input.MarkCompleted != (i == len(targets)-1) {
+			t.Fatalf("unexpected daily input %+v", input)
+		}
+	}
+}
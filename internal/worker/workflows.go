@@ -6,6 +6,8 @@ import (
 
 	"github.com/hatchet-dev/hatchet/pkg/client/types"
 	hatchet "github.com/hatchet-dev/hatchet/sdks/go"
+	"github.com/igor-kupczynski/alpha-monday/internal/decimal"
+	"github.com/igor-kupczynski/alpha-monday/internal/integrations/openai"
 )
 
 const (
@@ -15,29 +17,31 @@ const (
 	StepSnapshotPricesID           = "snapshot_initial_prices"
 	StepPersistBatchID             = "persist_batch"
 	StepDailyCheckpointLoopID      = "daily_checkpoint_loop"
+	VerifyMetricsWorkflowID        = "verify_metrics_v1"
+	StepVerifyMetricsID            = "verify_metrics"
+	verifyMetricsCronSchedule      = "30 10 * * *"
 	weeklyPickCronSchedule         = "0 9 * * 1"
 	alphaVantageRateLimitMinuteKey = "alpha_vantage_minute"
 	alphaVantageRateLimitDayKey    = "alpha_vantage_day"
-	alphaVantageRateLimitUnits     = 4
 	alphaVantageRateLimitMaxMinute = 5
 	alphaVantageRateLimitMaxDay    = 500
 )
 
 // WeeklyPickState is the workflow state stored by Hatchet for the weekly workflow.
 type WeeklyPickState struct {
-	BatchID               string      `json:"batch_id"`
-	RunDate               string      `json:"run_date"`
-	BenchmarkSymbol       string      `json:"benchmark_symbol"`
-	BenchmarkInitialPrice string      `json:"benchmark_initial_price"`
-	Picks                 []PickState `json:"picks"`
+	BatchID               string          `json:"batch_id"`
+	RunDate               string          `json:"run_date"`
+	BenchmarkSymbol       string          `json:"benchmark_symbol"`
+	BenchmarkInitialPrice decimal.Decimal `json:"benchmark_initial_price"`
+	Picks                 []PickState     `json:"picks"`
 }
 
 type PickState struct {
-	PickID       string `json:"pick_id"`
-	Ticker       string `json:"ticker"`
-	Action       string `json:"action"`
-	Reasoning    string `json:"reasoning"`
-	InitialPrice string `json:"initial_price"`
+	PickID       string          `json:"pick_id"`
+	Ticker       string          `json:"ticker"`
+	Action       string          `json:"action"`
+	Reasoning    string          `json:"reasoning"`
+	InitialPrice decimal.Decimal `json:"initial_price"`
 }
 
 type workflowSpec struct {
@@ -56,39 +60,55 @@ type stepSpec struct {
 type rateLimitSpec struct {
 	Key   string
 	Units int
+	// UnitsExpr is a CEL expression over the step input evaluated by Hatchet
+	// per run; it takes precedence over Units.
+	UnitsExpr string
 }
 
-func workflowSpecs() []workflowSpec {
+func workflowSpecs(limits RateLimitConfig) []workflowSpec {
 	return []workflowSpec{
-		weeklyWorkflowSpec(),
-		dailyCheckpointWorkflowSpec(),
+		weeklyWorkflowSpec(limits),
+		dailyCheckpointWorkflowSpec(limits),
+		verifyMetricsWorkflowSpec(),
 	}
 }
 
-func weeklyWorkflowSpec() workflowSpec {
+func weeklyWorkflowSpec(limits RateLimitConfig) workflowSpec {
 	return workflowSpec{
 		ID:   WeeklyPickWorkflowID,
 		Cron: weeklyPickCronSchedule,
 		Steps: []stepSpec{
 			{ID: StepGeneratePicksID},
-			{ID: StepSnapshotPricesID, RateLimits: alphaVantageRateLimitSpecs()},
+			// The snapshot step reads picks from the parent output, which CEL
+			// cannot see, so its units come from the fixed pick count.
+			{ID: StepSnapshotPricesID, RateLimits: alphaVantageRateLimitSpecs(limits.stepUnits(openai.PickCount), "")},
 			{ID: StepPersistBatchID},
 			{ID: StepDailyCheckpointLoopID, Durable: true},
 		},
 	}
 }
 
-func dailyCheckpointWorkflowSpec() workflowSpec {
+func dailyCheckpointWorkflowSpec(limits RateLimitConfig) workflowSpec {
 	return workflowSpec{
 		ID:         DailyCheckpointWorkflowID,
 		Standalone: true,
 		Steps: []stepSpec{
-			{ID: DailyCheckpointWorkflowID, RateLimits: alphaVantageRateLimitSpecs()},
+			{ID: DailyCheckpointWorkflowID, RateLimits: alphaVantageRateLimitSpecs(limits.stepUnits(openai.PickCount), limits.stepUnitsExpr("picks"))},
 		},
 	}
 }
 
-func BuildWorkflows(client *hatchet.Client, logger *slog.Logger, steps *Steps) ([]hatchet.WorkflowBase, error) {
+func verifyMetricsWorkflowSpec() workflowSpec {
+	return workflowSpec{
+		ID:   VerifyMetricsWorkflowID,
+		Cron: verifyMetricsCronSchedule,
+		Steps: []stepSpec{
+			{ID: StepVerifyMetricsID},
+		},
+	}
+}
+
+func BuildWorkflows(client *hatchet.Client, logger *slog.Logger, steps *Steps, limits RateLimitConfig) ([]hatchet.WorkflowBase, error) {
 	if client == nil {
 		return nil, fmt.Errorf("hatchet client is required")
 	}
@@ -96,10 +116,15 @@ func BuildWorkflows(client *hatchet.Client, logger *slog.Logger, steps *Steps) (
 		return nil, fmt.Errorf("steps are required")
 	}
 
+	if err := limits.Validate(); err != nil {
+		return nil, err
+	}
+
+	specs := workflowSpecs(limits)
 	handlers := stepHandlers(steps, logger)
-	workflows := make([]hatchet.WorkflowBase, 0, len(workflowSpecs()))
+	workflows := make([]hatchet.WorkflowBase, 0, len(specs))
 
-	for _, spec := range workflowSpecs() {
+	for _, spec := range specs {
 		if spec.Standalone {
 			if len(spec.Steps) != 1 {
 				return nil, fmt.Errorf("standalone workflow %q must define exactly one step", spec.ID)
@@ -162,21 +187,25 @@ func taskOptionsFromStep(step stepSpec, parent *hatchet.Task) []hatchet.TaskOpti
 	return opts
 }
 
-func alphaVantageRateLimitSpecs() []rateLimitSpec {
+func alphaVantageRateLimitSpecs(units int, unitsExpr string) []rateLimitSpec {
 	return []rateLimitSpec{
-		{Key: alphaVantageRateLimitMinuteKey, Units: alphaVantageRateLimitUnits},
-		{Key: alphaVantageRateLimitDayKey, Units: alphaVantageRateLimitUnits},
+		{Key: alphaVantageRateLimitMinuteKey, Units: units, UnitsExpr: unitsExpr},
+		{Key: alphaVantageRateLimitDayKey, Units: units, UnitsExpr: unitsExpr},
 	}
 }
 
 func rateLimitSpecsToTypes(specs []rateLimitSpec) []*types.RateLimit {
 	limits := make([]*types.RateLimit, 0, len(specs))
 	for _, spec := range specs {
-		units := spec.Units
-		limits = append(limits, &types.RateLimit{
-			Key:   spec.Key,
-			Units: &units,
-		})
+		limit := &types.RateLimit{Key: spec.Key}
+		if spec.UnitsExpr != "" {
+			expr := spec.UnitsExpr
+			limit.UnitsExpr = &expr
+		} else {
+			units := spec.Units
+			limit.Units = &units
+		}
+		limits = append(limits, limit)
 	}
 	return limits
 }
@@ -191,5 +220,6 @@ func stepHandlers(steps *Steps, logger *slog.Logger) map[string]any {
 		StepPersistBatchID:        withWorkflowLogging(logger, steps.PersistBatch),
 		StepDailyCheckpointLoopID: withDurableWorkflowLogging(logger, steps.DailyCheckpointLoop),
 		DailyCheckpointWorkflowID: withWorkflowLogging(logger, steps.DailyCheckpoint),
+		StepVerifyMetricsID:       withWorkflowLogging(logger, steps.VerifyMetrics),
 	}
 }
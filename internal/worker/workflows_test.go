@@ -5,7 +5,7 @@ import (
 )
 
 func TestWorkflowRegistrationIDs(t *testing.T) {
-	specs := workflowSpecs()
+	specs := workflowSpecs(DefaultRateLimitConfig())
 
 	weeklyFound := false
 	dailyFound := false
@@ -45,10 +45,48 @@ func TestWorkflowRateLimitsConfigured(t *testing.T) {
 	snapshotStep := findStepSpec(t, weekly, StepSnapshotPricesID)
 	dailyTask := findStepSpec(t, daily, DailyCheckpointWorkflowID)
 
-	assertRateLimit(t, snapshotStep, alphaVantageRateLimitMinuteKey, alphaVantageRateLimitUnits)
-	assertRateLimit(t, snapshotStep, alphaVantageRateLimitDayKey, alphaVantageRateLimitUnits)
-	assertRateLimit(t, dailyTask, alphaVantageRateLimitMinuteKey, alphaVantageRateLimitUnits)
-	assertRateLimit(t, dailyTask, alphaVantageRateLimitDayKey, alphaVantageRateLimitUnits)
+	// Three picks plus the benchmark.
+	assertRateLimit(t, snapshotStep, alphaVantageRateLimitMinuteKey, 4)
+	assertRateLimit(t, snapshotStep, alphaVantageRateLimitDayKey, 4)
+	assertRateLimit(t, dailyTask, alphaVantageRateLimitMinuteKey, 4)
+	assertRateLimit(t, dailyTask, alphaVantageRateLimitDayKey, 4)
+
+	for _, limit := range snapshotStep.RateLimits {
+		if limit.UnitsExpr != "" {
+			t.Fatalf("expected static units on snapshot step, got expr %q", limit.UnitsExpr)
+		}
+	}
+	for _, limit := range dailyTask.RateLimits {
+		if limit.UnitsExpr != "size(input.picks) + 1" {
+			t.Fatalf("expected units derived from input picks, got %q", limit.UnitsExpr)
+		}
+	}
+}
+
+func TestRateLimitSpecsToTypesPrefersExpr(t *testing.T) {
+	limits := rateLimitSpecsToTypes([]rateLimitSpec{
+		{Key: "static", Units: 4},
+		{Key: "dynamic", Units: 4, UnitsExpr: "size(input.picks) + 1"},
+	})
+	if limits[0].Units == nil || *limits[0].Units != 4 || limits[0].UnitsExpr != nil {
+		t.Fatalf("expected static units, got %+v", limits[0])
+	}
+	if limits[1].UnitsExpr == nil || *limits[1].UnitsExpr != "size(input.picks) + 1" || limits[1].Units != nil {
+		t.Fatalf("expected units expression, got %+v", limits[1])
+	}
+}
+
+func TestWorkflowRateLimitUnitsConfigurable(t *testing.T) {
+	limits := RateLimitConfig{PerMinute: 75, PerDay: 25, Units: 6}
+	for _, spec := range workflowSpecs(limits) {
+		for _, step := range spec.Steps {
+			for _, limit := range step.RateLimits {
+				if limit.Units != limits.Units || limit.UnitsExpr != "" {
+					t.Fatalf("expected %d units on %s/%s, got %+v", limits.Units, spec.ID, limit.Key, limit)
+				}
+			}
+		}
+	}
 }
 
 func TestWorkflowDurableLoopConfigured(t *testing.T) {
@@ -59,9 +97,17 @@ func TestWorkflowDurableLoopConfigured(t *testing.T) {
 	}
 }
 
+func TestVerifyMetricsWorkflowScheduled(t *testing.T) {
+	spec := findWorkflowSpec(t, VerifyMetricsWorkflowID)
+	if spec.Cron != verifyMetricsCronSchedule {
+		t.Fatalf("expected cron %q, got %q", verifyMetricsCronSchedule, spec.Cron)
+	}
+	findStepSpec(t, spec, StepVerifyMetricsID)
+}
+
 func findWorkflowSpec(t *testing.T, id string) workflowSpec {
 	t.Helper()
-	for _, spec := range workflowSpecs() {
+	for _, spec := range workflowSpecs(DefaultRateLimitConfig()) {
 		if spec.ID == id {
 			return spec
 		}
@@ -0,0 +1 @@
+ALTER TABLE checkpoints DROP COLUMN IF EXISTS created_at;
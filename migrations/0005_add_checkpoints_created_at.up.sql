@@ -0,0 +1,2 @@
+ALTER TABLE checkpoints
+  ADD COLUMN created_at timestamptz NOT NULL DEFAULT now();
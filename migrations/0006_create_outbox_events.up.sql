@@ -0,0 +1,16 @@
+CREATE TABLE outbox_events (
+  id uuid PRIMARY KEY,
+  created_at timestamptz NOT NULL DEFAULT now(),
+  event_type text NOT NULL,
+  batch_id uuid CONSTRAINT outbox_events_batch_fk REFERENCES batches(id),
+  payload jsonb NOT NULL,
+  attempts integer NOT NULL DEFAULT 0,
+  next_attempt_at timestamptz NOT NULL DEFAULT now(),
+  last_error text,
+  delivered_at timestamptz,
+  abandoned_at timestamptz
+);
+
+CREATE INDEX outbox_events_pending_idx ON outbox_events (next_attempt_at)
+  WHERE delivered_at IS NULL AND abandoned_at IS NULL;
+CREATE INDEX outbox_events_batch_id_idx ON outbox_events (batch_id);
@@ -0,0 +1,10 @@
+CREATE TABLE share_tokens (
+  id uuid PRIMARY KEY,
+  created_at timestamptz NOT NULL DEFAULT now(),
+  batch_id uuid NOT NULL CONSTRAINT share_tokens_batch_fk REFERENCES batches(id),
+  token_hash text NOT NULL CONSTRAINT share_tokens_token_hash_unique UNIQUE,
+  expires_at timestamptz NOT NULL,
+  revoked_at timestamptz
+);
+
+CREATE INDEX share_tokens_batch_id_idx ON share_tokens (batch_id);
@@ -0,0 +1,16 @@
+CREATE TABLE scheduled_jobs (
+  id uuid PRIMARY KEY,
+  created_at timestamptz NOT NULL DEFAULT now(),
+  task text NOT NULL,
+  dedupe_key text NOT NULL,
+  input jsonb NOT NULL,
+  run_at timestamptz NOT NULL,
+  attempts integer NOT NULL DEFAULT 0,
+  last_error text,
+  completed_at timestamptz,
+  abandoned_at timestamptz,
+  CONSTRAINT scheduled_jobs_task_dedupe_unique UNIQUE (task, dedupe_key)
+);
+
+CREATE INDEX scheduled_jobs_pending_idx ON scheduled_jobs (run_at)
+  WHERE completed_at IS NULL AND abandoned_at IS NULL;
@@ -0,0 +1,16 @@
+CREATE TABLE api_keys (
+  id uuid PRIMARY KEY,
+  created_at timestamptz NOT NULL DEFAULT now(),
+  name text NOT NULL,
+  key_hash text NOT NULL CONSTRAINT api_keys_key_hash_unique UNIQUE,
+  daily_quota integer NOT NULL CONSTRAINT api_keys_daily_quota_check CHECK (daily_quota > 0),
+  monthly_quota integer NOT NULL CONSTRAINT api_keys_monthly_quota_check CHECK (monthly_quota > 0),
+  revoked_at timestamptz
+);
+
+CREATE TABLE api_key_usage (
+  api_key_id uuid NOT NULL CONSTRAINT api_key_usage_api_key_fk REFERENCES api_keys(id),
+  usage_date date NOT NULL,
+  request_count integer NOT NULL DEFAULT 0,
+  CONSTRAINT api_key_usage_pkey PRIMARY KEY (api_key_id, usage_date)
+);
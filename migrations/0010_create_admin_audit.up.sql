@@ -0,0 +1,12 @@
+CREATE TABLE admin_audit (
+  id uuid PRIMARY KEY,
+  created_at timestamptz NOT NULL DEFAULT now(),
+  actor text NOT NULL,
+  action text NOT NULL,
+  target text,
+  params jsonb NOT NULL,
+  remote_addr text,
+  request_id text
+);
+
+CREATE INDEX admin_audit_created_at_idx ON admin_audit (created_at DESC);
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS batch_run_stats;
@@ -0,0 +1,9 @@
+CREATE TABLE batch_run_stats (
+  batch_id uuid NOT NULL CONSTRAINT batch_run_stats_batch_fk REFERENCES batches(id),
+  step text NOT NULL,
+  success_count integer NOT NULL DEFAULT 0,
+  skip_count integer NOT NULL DEFAULT 0,
+  failure_count integer NOT NULL DEFAULT 0,
+  updated_at timestamptz NOT NULL DEFAULT now(),
+  CONSTRAINT batch_run_stats_pkey PRIMARY KEY (batch_id, step)
+);
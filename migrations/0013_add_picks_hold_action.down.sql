@@ -0,0 +1,4 @@
+-- NOT VALID keeps existing HOLD picks readable while rejecting new ones.
+ALTER TABLE picks
+  DROP CONSTRAINT picks_action_check,
+  ADD CONSTRAINT picks_action_check CHECK (action IN ('BUY', 'SELL')) NOT VALID;
@@ -0,0 +1,3 @@
+ALTER TABLE picks
+  DROP CONSTRAINT picks_action_check,
+  ADD CONSTRAINT picks_action_check CHECK (action IN ('BUY', 'SELL', 'HOLD'));
@@ -0,0 +1,3 @@
+ALTER TABLE picks
+  DROP COLUMN IF EXISTS target_error_pct,
+  DROP COLUMN IF EXISTS target_price;
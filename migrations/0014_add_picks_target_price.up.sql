@@ -0,0 +1,3 @@
+ALTER TABLE picks
+  ADD COLUMN target_price numeric NULL CONSTRAINT picks_target_price_check CHECK (target_price > 0),
+  ADD COLUMN target_error_pct numeric NULL;
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS batches_config_hash_idx;
+
+ALTER TABLE batches
+  DROP COLUMN IF EXISTS config_hash;
@@ -0,0 +1,4 @@
+ALTER TABLE batches
+  ADD COLUMN config_hash text NULL;
+
+CREATE INDEX batches_config_hash_idx ON batches (config_hash);
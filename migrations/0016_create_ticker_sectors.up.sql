@@ -0,0 +1,5 @@
+CREATE TABLE ticker_sectors (
+  ticker text NOT NULL CONSTRAINT ticker_sectors_pkey PRIMARY KEY,
+  sector text NOT NULL,
+  updated_at timestamptz NOT NULL DEFAULT now()
+);
@@ -0,0 +1,5 @@
+ALTER TABLE pick_checkpoint_metrics
+  DROP COLUMN IF EXISTS volume,
+  DROP COLUMN IF EXISTS low_price,
+  DROP COLUMN IF EXISTS high_price,
+  DROP COLUMN IF EXISTS open_price;
@@ -0,0 +1,5 @@
+ALTER TABLE pick_checkpoint_metrics
+  ADD COLUMN open_price numeric NULL,
+  ADD COLUMN high_price numeric NULL,
+  ADD COLUMN low_price numeric NULL,
+  ADD COLUMN volume bigint NULL;
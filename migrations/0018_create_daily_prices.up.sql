@@ -0,0 +1,11 @@
+CREATE TABLE daily_prices (
+  ticker text NOT NULL,
+  trading_day date NOT NULL,
+  close_price numeric NOT NULL,
+  open_price numeric NULL,
+  high_price numeric NULL,
+  low_price numeric NULL,
+  volume bigint NULL,
+  updated_at timestamptz NOT NULL DEFAULT now(),
+  CONSTRAINT daily_prices_pkey PRIMARY KEY (ticker, trading_day)
+);
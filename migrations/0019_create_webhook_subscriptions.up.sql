@@ -0,0 +1,12 @@
+CREATE TABLE webhook_subscriptions (
+  id uuid PRIMARY KEY,
+  created_at timestamptz NOT NULL DEFAULT now(),
+  url text NOT NULL,
+  secret text NOT NULL,
+  event_types text[] NOT NULL,
+  revoked_at timestamptz,
+  CONSTRAINT webhook_subscriptions_event_types_check CHECK (
+    cardinality(event_types) > 0
+    AND event_types <@ ARRAY['batch_created', 'checkpoint_computed']::text[]
+  )
+);
@@ -0,0 +1,5 @@
+UPDATE batches SET status = 'failed' WHERE status = 'cancelled';
+
+ALTER TABLE batches
+  DROP CONSTRAINT batches_status_check,
+  ADD CONSTRAINT batches_status_check CHECK (status IN ('active', 'completed', 'failed', 'expired'));
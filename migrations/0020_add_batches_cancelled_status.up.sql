@@ -0,0 +1,3 @@
+ALTER TABLE batches
+  DROP CONSTRAINT batches_status_check,
+  ADD CONSTRAINT batches_status_check CHECK (status IN ('active', 'completed', 'failed', 'expired', 'cancelled'));
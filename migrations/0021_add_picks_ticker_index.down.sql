@@ -0,0 +1 @@
+DROP INDEX IF EXISTS picks_ticker_idx;
@@ -0,0 +1 @@
+CREATE INDEX picks_ticker_idx ON picks (ticker, batch_id);
@@ -0,0 +1,3 @@
+ALTER TABLE picks
+  DROP COLUMN IF EXISTS final_vs_benchmark_pct,
+  DROP COLUMN IF EXISTS final_absolute_return_pct;
@@ -0,0 +1,16 @@
+ALTER TABLE picks
+  ADD COLUMN final_absolute_return_pct numeric NULL,
+  ADD COLUMN final_vs_benchmark_pct numeric NULL;
+
+UPDATE picks p
+SET final_absolute_return_pct = f.absolute_return_pct,
+    final_vs_benchmark_pct = f.vs_benchmark_pct
+FROM (
+  SELECT DISTINCT ON (m.pick_id) m.pick_id, m.absolute_return_pct, m.vs_benchmark_pct
+  FROM pick_checkpoint_metrics m
+  JOIN checkpoints c ON c.id = m.checkpoint_id
+  JOIN batches b ON b.id = c.batch_id
+  WHERE b.status = 'completed'
+  ORDER BY m.pick_id, c.checkpoint_date DESC
+) f
+WHERE p.id = f.pick_id;
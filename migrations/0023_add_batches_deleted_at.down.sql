@@ -0,0 +1,2 @@
+ALTER TABLE batches
+  DROP COLUMN IF EXISTS deleted_at;
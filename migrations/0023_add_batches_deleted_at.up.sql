@@ -0,0 +1,2 @@
+ALTER TABLE batches
+  ADD COLUMN deleted_at timestamptz NULL;
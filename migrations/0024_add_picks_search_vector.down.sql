@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS picks_search_vector_idx;
+ALTER TABLE picks
+  DROP COLUMN IF EXISTS search_vector;
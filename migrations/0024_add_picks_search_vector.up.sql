@@ -0,0 +1,7 @@
+ALTER TABLE picks
+  ADD COLUMN search_vector tsvector GENERATED ALWAYS AS (
+    setweight(to_tsvector('simple', ticker), 'A') ||
+    setweight(to_tsvector('english', reasoning), 'B')
+  ) STORED;
+
+CREATE INDEX picks_search_vector_idx ON picks USING GIN (search_vector);
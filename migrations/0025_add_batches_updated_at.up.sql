@@ -0,0 +1,8 @@
+ALTER TABLE batches
+  ADD COLUMN updated_at timestamptz NULL;
+
+UPDATE batches SET updated_at = created_at;
+
+ALTER TABLE batches
+  ALTER COLUMN updated_at SET NOT NULL,
+  ALTER COLUMN updated_at SET DEFAULT now();
@@ -0,0 +1,6 @@
+CREATE TABLE outbox_deliveries (
+  event_id uuid NOT NULL CONSTRAINT outbox_deliveries_event_fk REFERENCES outbox_events(id) ON DELETE CASCADE,
+  target text NOT NULL,
+  delivered_at timestamptz NOT NULL DEFAULT now(),
+  PRIMARY KEY (event_id, target)
+);
@@ -0,0 +1,6 @@
+-- Fails while a deleted batch shares its run_date with a live one; delete
+-- one of the rows first.
+DROP INDEX IF EXISTS batches_run_date_unique;
+
+ALTER TABLE batches
+  ADD CONSTRAINT batches_run_date_unique UNIQUE (run_date);
@@ -0,0 +1,6 @@
+-- A soft-deleted batch no longer holds its run_date, so the week can be
+-- generated again. Restoring it fails while another live batch has the date.
+ALTER TABLE batches
+  DROP CONSTRAINT batches_run_date_unique;
+
+CREATE UNIQUE INDEX batches_run_date_unique ON batches (run_date) WHERE deleted_at IS NULL;